@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/Risuii/config"
+	"github.com/Risuii/db/migration"
+	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/internal/cli"
+)
+
+// admin runs the admin command in args and returns the exit code: 2 for a
+// bad command line, 1 when the command fails.
+func admin(cfg *config.Config, args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	defer db.Close()
+
+	// without the hub of a server, open streams do not see these changes;
+	// webhooks and the publisher still get them through the outbox
+	services, err := newServices(cfg, db, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	operator := os.Getenv("USER")
+	if operator == "" {
+		operator = "cli"
+	}
+
+	err = cli.Run(ctx, cli.Dependencies{
+		Validate:  validator.New(),
+		Cart:      services.cart,
+		Migrator:  migration.NewMigrator(db, constant.TableSchemaMigrations),
+		BatchSize: cfg.Cart.BatchMaxOperations,
+		Operator:  operator,
+		Out:       os.Stdout,
+	}, args)
+
+	switch {
+	case errors.Is(err, cli.ErrUsage):
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprint(os.Stderr, cli.Usage)
+		return 2
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
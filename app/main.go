@@ -1,46 +1,424 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
-	_ "github.com/joho/godotenv/autoload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/Risuii/config"
+	"github.com/Risuii/db/migration"
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/bodylimit"
+	"github.com/Risuii/helpers/cachecontrol"
+	"github.com/Risuii/helpers/compress"
 	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/helpers/cors"
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/ratelimit"
+	"github.com/Risuii/helpers/requestinfo"
+	"github.com/Risuii/helpers/secure"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/helpers/worker"
+	"github.com/Risuii/internal/apikey"
+	"github.com/Risuii/internal/audit"
 	"github.com/Risuii/internal/cart"
+	"github.com/Risuii/internal/health"
+	"github.com/Risuii/internal/outbox"
+	"github.com/Risuii/internal/routes"
+	"github.com/Risuii/internal/stream"
+	"github.com/Risuii/internal/webhook"
 )
 
 func main() {
-	cfg := config.New()
+	args := os.Args[1:]
 
-	db, err := sql.Open("mysql", cfg.Database.DSN)
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		cfg, err := config.Load(args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	cfg, rest, err := config.Parse(args)
 	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(rest) > 0 && rest[0] != "serve" {
+		os.Exit(admin(cfg, rest))
+	}
+
+	if err := run(cfg); err != nil {
 		log.Fatal(err)
 	}
+}
+
+func run(cfg *config.Config) error {
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.Health.Timeout)
+	if err := db.PingContext(pingCtx); err != nil {
+		log.Println("database is not reachable:", err)
+	}
+	cancel()
+
+	migrationVersion, err := migration.LatestVersion()
+	if err != nil {
+		return err
+	}
 
 	validator := validator.New()
 	router := mux.NewRouter()
+	if cfg.Features.RequestTracing {
+		router.Use(tracing.Middleware)
+	}
+	router.Use(requestinfo.Middleware(cfg.Server.TrustProxyHeaders))
+
+	// not the signal context: the workers keep relaying and delivering
+	// while requests drain, and only stop in workers.Shutdown
+	workers := worker.NewGroup(context.Background())
+
+	hub := stream.NewHub(stream.HubOptions{
+		BufferSize:  cfg.Stream.BufferSize,
+		HistorySize: cfg.Stream.HistorySize,
+	})
+
+	services, err := newServices(cfg, db, hub)
+	if err != nil {
+		return err
+	}
+
+	txManager := services.txManager
+	outboxRepo := services.outboxRepo
+	cartUseCase := services.cart
+	auditUseCase := audit.NewAuditUseCaseImpl(services.auditRepo, cartUseCase)
+
+	if cfg.Cart.PurgeInterval > 0 {
+		workers.Go("cart-purge", worker.Every("cart-purge", cfg.Cart.PurgeInterval, func(ctx context.Context) error {
+			purged, err := cartUseCase.PurgeDeleted(ctx)
+			if purged > 0 {
+				log.Printf("purged %d deleted cart lines", purged)
+			}
+
+			return err
+		}))
+	}
+
+	if cfg.Cart.AbandonInterval > 0 {
+		workers.Go("cart-abandon", worker.Every("cart-abandon", cfg.Cart.AbandonInterval, func(ctx context.Context) error {
+			_, err := cartUseCase.DetectAbandoned(auth.With(ctx, auth.Principal{Kind: auth.KindSystem, Subject: "cart-abandon"}))
+			return err
+		}))
+	}
+
+	webhookRepo := webhook.NewWebhookRepositoryImpl(db, constant.TableWebhooks, constant.TableWebhookDeliveries, cfg.Database.QueryTimeout)
+	webhookUseCase := webhook.NewWebhookUseCaseImpl(webhookRepo, txManager, webhook.Options{
+		Client:      &http.Client{Timeout: cfg.Webhooks.Timeout},
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		BackoffBase: cfg.Webhooks.BackoffBase,
+		BackoffMax:  cfg.Webhooks.BackoffMax,
+		BatchSize:   cfg.Webhooks.BatchSize,
+	})
+
+	if cfg.Webhooks.DeliveryInterval > 0 {
+		workers.Go("webhook-delivery", worker.Every("webhook-delivery", cfg.Webhooks.DeliveryInterval, func(ctx context.Context) error {
+			_, err := webhookUseCase.DeliverDue(ctx)
+			return err
+		}))
+	}
+
+	// registered webhooks always receive events; the configured publisher
+	// is an extra sink next to them
+	publishers := outbox.MultiPublisher{webhookUseCase}
+
+	publisher, err := newPublisher(cfg)
+	if err != nil {
+		return err
+	}
+
+	if publisher != nil {
+		if closer, ok := publisher.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		publishers = append(publishers, publisher)
+	}
+
+	// a claimed batch stays leased long enough for every message to time out
+	outboxLease := time.Duration(cfg.Outbox.BatchSize)*cfg.Outbox.WebhookTimeout + time.Minute
+	outboxUseCase := outbox.NewOutboxUseCaseImpl(outboxRepo, txManager, publishers, cfg.Outbox.BatchSize, outboxLease)
+	workers.Go("outbox-relay", worker.Every("outbox-relay", cfg.Outbox.RelayInterval, func(ctx context.Context) error {
+		_, err := outboxUseCase.Relay(ctx)
+		return err
+	}))
+
+	healthRepo := health.NewHealthRepositoryImpl(db, constant.TableSchemaMigrations)
+	healthUseCase := health.NewHealthUseCaseImpl(healthRepo, migrationVersion, cfg.Health.Timeout)
+
+	var draining atomic.Bool
+	healthUseCase.Register("shutdown", func(ctx context.Context) error {
+		if draining.Load() {
+			return errors.New("shutting down")
+		}
+
+		return nil
+	})
 
-	cartRepo := cart.NewCartRepositoryImpl(db, constant.TableCart)
-	cartUseCase := cart.NewCartUseCaseImpl(cartRepo)
+	apiKeyRepo := apikey.NewAPIKeyRepositoryImpl(db, constant.TableAPIKeys, cfg.Database.QueryTimeout)
+	apiKeyUseCase := apikey.NewAPIKeyUseCaseImpl(apiKeyRepo, txManager, apikey.Options{
+		UsageInterval: cfg.Auth.KeyUsageInterval,
+	})
 
-	cart.NewCartHandler(router, validator, cartUseCase)
+	authenticators := []auth.Authenticator{auth.APIKeys{Verifier: apiKeyUseCase}}
+	if cfg.Auth.JWTSecret != "" {
+		authenticators = append([]auth.Authenticator{auth.JWT{
+			Secret:   []byte(cfg.Auth.JWTSecret),
+			Issuer:   cfg.Auth.JWTIssuer,
+			Audience: cfg.Auth.JWTAudience,
+		}}, authenticators...)
+	}
+
+	// validated by the loader; empty leaves the zero time, announcing no sunset
+	legacySunset, _ := time.Parse(time.DateOnly, cfg.API.LegacySunset)
+
+	cachePolicies, err := cachecontrol.Parse(cfg.Cache.Policies)
+	if err != nil {
+		return fmt.Errorf("cache.policies: %w", err)
+	}
+
+	var (
+		rateLimiter ratelimit.Limiter
+		rateLimit   ratelimit.Options
+	)
+	if cfg.RateLimit.Enabled {
+		if cfg.RateLimit.Default != "" {
+			if rateLimit.Default, err = ratelimit.ParseQuota(cfg.RateLimit.Default); err != nil {
+				return fmt.Errorf("rateLimit.default: %w", err)
+			}
+		}
+
+		if rateLimit.Routes, err = ratelimit.Parse(cfg.RateLimit.Routes); err != nil {
+			return fmt.Errorf("rateLimit.routes: %w", err)
+		}
+
+		rateLimiter = ratelimit.NewMemory()
+	}
+
+	var compression *compress.Options
+	if cfg.Compression.Enabled {
+		compression = &compress.Options{MinBytes: cfg.Compression.MinBytes, Level: cfg.Compression.Level}
+	}
+
+	if err := routes.Register(router, routes.Dependencies{
+		Validate:  validator,
+		Cart:      cartUseCase,
+		Audit:     auditUseCase,
+		Hub:       hub,
+		Heartbeat: cfg.Stream.Heartbeat,
+		Webhook:   webhookUseCase,
+		Health:    healthUseCase,
+		APIKey:    apiKeyUseCase,
+
+		Authenticators: authenticators,
+		RequireAuth:    cfg.Auth.Required,
+
+		MaxBodyBytes: cfg.Server.MaxBodyBytes,
+		Legacy:       cfg.API.LegacyRoutes,
+		LegacySunset: legacySunset,
+
+		Compression:   compression,
+		CachePolicies: cachePolicies,
+
+		RateLimiter: rateLimiter,
+		RateLimit:   rateLimit,
+	}); err != nil {
+		return err
+	}
+
+	handler := secure.Handler(bodylimit.Handler(router, cfg.Server.MaxBodyBytes), secure.Options{
+		HSTSMaxAge:            cfg.Security.HSTSMaxAge,
+		HSTSIncludeSubdomains: cfg.Security.HSTSIncludeSubdomains,
+		FrameOptions:          cfg.Security.FrameOptions,
+		ReferrerPolicy:        cfg.Security.ReferrerPolicy,
+		TrustProxyHeaders:     cfg.Server.TrustProxyHeaders,
+	})
+
+	// preflight requests never match a route, so CORS wraps the router
+	// instead of being one of its middlewares
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		handler, err = cors.Handler(handler, cors.Options{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			ExposedHeaders:   cfg.CORS.ExposedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%s", cfg.App.Port),
-		Handler: router,
+		Addr:              fmt.Sprintf(":%d", cfg.App.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
 	}
 
-	port := os.Getenv("PORT")
+	// Shutdown waits for active requests, and streams only end when their
+	// subscription does
+	server.RegisterOnShutdown(hub.Close)
+
+	serverErr := make(chan error, 2)
+	go func() {
+		fmt.Println("SERVER ON")
+		fmt.Println("PORT :", cfg.App.Port)
+		if cfg.TLS.Enabled {
+			serverErr <- server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+			return
+		}
+
+		serverErr <- server.ListenAndServe()
+	}()
+
+	var grpcServer *grpc.Server
+	if cfg.App.GRPCPort > 0 {
+		opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(
+			requestinfo.UnaryServerInterceptor(),
+			auth.UnaryServerInterceptor(cart.GRPCRules(cfg.Auth.Required), authenticators...),
+		)}
+		if cfg.TLS.Enabled {
+			creds, err := credentials.NewServerTLSFromFile(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+			if err != nil {
+				return err
+			}
+
+			opts = append(opts, grpc.Creds(creds))
+		}
+
+		grpcServer = grpc.NewServer(opts...)
+		cart.NewCartGRPCServer(grpcServer, cartUseCase)
 
-	fmt.Println("SERVER ON")
-	fmt.Println("PORT :", port)
-	log.Fatal(server.ListenAndServe())
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.App.GRPCPort))
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			fmt.Println("GRPC PORT :", cfg.App.GRPCPort)
+			serverErr <- grpcServer.Serve(listener)
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+		log.Println("shutting down")
+	}
+
+	// report not ready to probes that still reach us while requests drain
+	draining.Store(true)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("server shutdown:", err)
+	}
+
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+
+	if err := workers.Shutdown(shutdownCtx); err != nil {
+		log.Println("workers shutdown:", err)
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Println("tracing shutdown:", err)
+	}
+
+	fmt.Println("SERVER OFF")
+
+	return nil
+}
+
+// stopGRPC lets in-flight calls finish, cutting them off when ctx ends.
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
+// newPublisher builds the configured outbox publisher, or nil when events
+// only go to registered webhooks.
+func newPublisher(cfg *config.Config) (outbox.Publisher, error) {
+	switch cfg.Outbox.Publisher {
+	case "stdout":
+		return outbox.NewWriterPublisher(os.Stdout), nil
+	case "file":
+		publisher, err := outbox.OpenFilePublisher(cfg.Outbox.FilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		return publisher, nil
+	case "webhook":
+		return outbox.NewWebhookPublisher(cfg.Outbox.WebhookURL, cfg.Outbox.WebhookTimeout), nil
+	default:
+		return nil, nil
+	}
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"database/sql"
+
+	"github.com/Risuii/config"
+	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/internal/audit"
+	"github.com/Risuii/internal/cart"
+	"github.com/Risuii/internal/outbox"
+)
+
+// services are the repositories and use cases the server and the admin
+// commands share, so both change a cart the same way.
+type services struct {
+	txManager  database.TxManager
+	auditRepo  audit.AuditRepository
+	outboxRepo outbox.OutboxRepository
+	cart       cart.CartUseCase
+}
+
+// newServices wires the services on db; notifier, when set, is handed the
+// events of every cart change.
+func newServices(cfg *config.Config, db *sql.DB, notifier cart.Notifier) (*services, error) {
+	isolation, err := database.ParseIsolation(cfg.Database.TxIsolation)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &services{
+		txManager:  database.NewTxManagerImpl(db, isolation, cfg.Database.TxMaxRetries, cfg.Database.TxRetryBackoff),
+		auditRepo:  audit.NewAuditRepositoryImpl(db, constant.TableCartEvents, cfg.Database.QueryTimeout),
+		outboxRepo: outbox.NewOutboxRepositoryImpl(db, constant.TableOutbox, cfg.Database.QueryTimeout),
+	}
+
+	cartRepo := cart.NewCartRepositoryImpl(db, constant.TableCart, cfg.Database.QueryTimeout)
+	accessRepo := audit.NewAccessRepositoryImpl(db, constant.TableCartAccess, cfg.Database.QueryTimeout)
+
+	s.cart = cart.NewCartUseCaseImpl(cartRepo, s.auditRepo, s.outboxRepo, s.txManager, cart.Options{
+		BatchMode:          cfg.Cart.BatchMode,
+		BatchMaxOperations: cfg.Cart.BatchMaxOperations,
+		RestoreWindow:      cfg.Cart.RestoreWindow,
+		DeletedRetention:   cfg.Cart.DeletedRetention,
+		AbandonAfter:       cfg.Cart.AbandonAfter,
+		Notifier:           notifier,
+		Access:             accessRepo,
+	})
+
+	return s, nil
+}
@@ -1,64 +1,279 @@
 package config
 
 import (
-	"fmt"
-	"log"
-	"net/url"
-	"os"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/go-sql-driver/mysql"
 )
 
+// Config is resolved from, in increasing priority: built-in defaults, a
+// YAML file, environment variables and command line flags. Every leaf field
+// carries its yaml key, env var and flag name; fields tagged secret are
+// redacted by Print.
 type Config struct {
 	App struct {
-		Port string
-	}
+		Port int `yaml:"port" env:"PORT" flag:"port" validate:"min=1,max=65535"`
+		// GRPCPort serves the gRPC API; 0 turns it off.
+		GRPCPort int `yaml:"grpcPort" env:"GRPC_PORT" flag:"grpc-port" validate:"min=0,max=65535"`
+	} `yaml:"app"`
+	Server struct {
+		ReadTimeout       time.Duration `yaml:"readTimeout" env:"SERVER_READ_TIMEOUT" flag:"server-read-timeout" validate:"gt=0"`
+		ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout" env:"SERVER_READ_HEADER_TIMEOUT" flag:"server-read-header-timeout" validate:"gt=0"`
+		WriteTimeout      time.Duration `yaml:"writeTimeout" env:"SERVER_WRITE_TIMEOUT" flag:"server-write-timeout" validate:"gt=0"`
+		IdleTimeout       time.Duration `yaml:"idleTimeout" env:"SERVER_IDLE_TIMEOUT" flag:"server-idle-timeout" validate:"gt=0"`
+		ShutdownTimeout   time.Duration `yaml:"shutdownTimeout" env:"SERVER_SHUTDOWN_TIMEOUT" flag:"server-shutdown-timeout" validate:"gt=0"`
+		MaxHeaderBytes    int           `yaml:"maxHeaderBytes" env:"SERVER_MAX_HEADER_BYTES" flag:"server-max-header-bytes" validate:"gt=0"`
+		MaxBodyBytes      int64         `yaml:"maxBodyBytes" env:"SERVER_MAX_BODY_BYTES" flag:"server-max-body-bytes" validate:"gt=0"`
+		// TrustProxyHeaders takes the client address from X-Forwarded-For;
+		// enable it only behind a proxy that sets the header.
+		TrustProxyHeaders bool `yaml:"trustProxyHeaders" env:"SERVER_TRUST_PROXY_HEADERS" flag:"server-trust-proxy-headers"`
+	} `yaml:"server"`
+	API struct {
+		// LegacyRoutes keeps serving the unversioned paths (/cart/items, ...)
+		// next to /v1, with Deprecation and Sunset headers.
+		LegacyRoutes bool `yaml:"legacyRoutes" env:"API_LEGACY_ROUTES" flag:"api-legacy-routes"`
+		// LegacySunset is the date, as YYYY-MM-DD, the unversioned paths
+		// are due to be removed; empty leaves it unannounced.
+		LegacySunset string `yaml:"legacySunset" env:"API_LEGACY_SUNSET" flag:"api-legacy-sunset" validate:"omitempty,datetime=2006-01-02"`
+	} `yaml:"api"`
+	Compression struct {
+		Enabled bool `yaml:"enabled" env:"COMPRESSION_ENABLED" flag:"compression"`
+		// MinBytes is the smallest response body worth compressing.
+		MinBytes int `yaml:"minBytes" env:"COMPRESSION_MIN_BYTES" flag:"compression-min-bytes" validate:"min=0"`
+		// Level is the compression level from 1 (fastest) to 9 (smallest),
+		// applied to every codec; -1 is the default of each codec.
+		Level int `yaml:"level" env:"COMPRESSION_LEVEL" flag:"compression-level" validate:"min=-1,max=9"`
+	} `yaml:"compression"`
+	Cache struct {
+		// Policies give the Cache-Control of routes as
+		// "METHOD /path=directives", the directives separated by spaces.
+		Policies []string `yaml:"policies" env:"CACHE_POLICIES" flag:"cache-policies"`
+	} `yaml:"cache"`
+	RateLimit struct {
+		Enabled bool `yaml:"enabled" env:"RATE_LIMIT_ENABLED" flag:"rate-limit"`
+		// Default is the quota, as limit/period ("300/1m"), every client
+		// shares across the routes not in Routes; empty leaves them
+		// unlimited.
+		Default string `yaml:"default" env:"RATE_LIMIT_DEFAULT" flag:"rate-limit-default"`
+		// Routes give routes a quota of their own as
+		// "METHOD /path=limit/period".
+		Routes []string `yaml:"routes" env:"RATE_LIMIT_ROUTES" flag:"rate-limit-routes"`
+	} `yaml:"rateLimit"`
+	CORS struct {
+		// AllowedOrigins may call the API from a browser, as
+		// "https://shop.example.com", "https://*.example.com" or "*"; empty
+		// turns CORS off.
+		AllowedOrigins   []string      `yaml:"allowedOrigins" env:"CORS_ALLOWED_ORIGINS" flag:"cors-allowed-origins"`
+		AllowedMethods   []string      `yaml:"allowedMethods" env:"CORS_ALLOWED_METHODS" flag:"cors-allowed-methods"`
+		AllowedHeaders   []string      `yaml:"allowedHeaders" env:"CORS_ALLOWED_HEADERS" flag:"cors-allowed-headers"`
+		ExposedHeaders   []string      `yaml:"exposedHeaders" env:"CORS_EXPOSED_HEADERS" flag:"cors-exposed-headers"`
+		AllowCredentials bool          `yaml:"allowCredentials" env:"CORS_ALLOW_CREDENTIALS" flag:"cors-allow-credentials"`
+		MaxAge           time.Duration `yaml:"maxAge" env:"CORS_MAX_AGE" flag:"cors-max-age" validate:"min=0"`
+	} `yaml:"cors"`
+	Security struct {
+		// HSTSMaxAge is sent in Strict-Transport-Security on HTTPS
+		// requests; 0 leaves the header out.
+		HSTSMaxAge            time.Duration `yaml:"hstsMaxAge" env:"SECURITY_HSTS_MAX_AGE" flag:"security-hsts-max-age" validate:"min=0"`
+		HSTSIncludeSubdomains bool          `yaml:"hstsIncludeSubdomains" env:"SECURITY_HSTS_INCLUDE_SUBDOMAINS" flag:"security-hsts-include-subdomains"`
+		FrameOptions          string        `yaml:"frameOptions" env:"SECURITY_FRAME_OPTIONS" flag:"security-frame-options" validate:"omitempty,oneof=DENY SAMEORIGIN"`
+		ReferrerPolicy        string        `yaml:"referrerPolicy" env:"SECURITY_REFERRER_POLICY" flag:"security-referrer-policy"`
+	} `yaml:"security"`
+	Auth struct {
+		// Required refuses API requests without a JWT or an API key; the
+		// administration routes always need one.
+		Required bool `yaml:"required" env:"AUTH_REQUIRED" flag:"auth-required"`
+		// JWTSecret verifies HS256 user tokens; empty only accepts API
+		// keys.
+		JWTSecret   string `yaml:"jwtSecret" env:"AUTH_JWT_SECRET" flag:"auth-jwt-secret" secret:"true"`
+		JWTIssuer   string `yaml:"jwtIssuer" env:"AUTH_JWT_ISSUER" flag:"auth-jwt-issuer"`
+		JWTAudience string `yaml:"jwtAudience" env:"AUTH_JWT_AUDIENCE" flag:"auth-jwt-audience"`
+		// KeyUsageInterval is how stale the recorded last use of an API
+		// key may get.
+		KeyUsageInterval time.Duration `yaml:"keyUsageInterval" env:"AUTH_KEY_USAGE_INTERVAL" flag:"auth-key-usage-interval" validate:"gt=0"`
+	} `yaml:"auth"`
+	TLS struct {
+		Enabled  bool   `yaml:"enabled" env:"TLS_ENABLED" flag:"tls"`
+		CertFile string `yaml:"certFile" env:"TLS_CERT_FILE" flag:"tls-cert-file" validate:"required_if=Enabled true"`
+		KeyFile  string `yaml:"keyFile" env:"TLS_KEY_FILE" flag:"tls-key-file" validate:"required_if=Enabled true"`
+	} `yaml:"tls"`
 	Database struct {
-		DSN string
-	}
+		Host            string        `yaml:"host" env:"DB_HOST" flag:"db-host" validate:"required"`
+		Port            int           `yaml:"port" env:"DB_PORT" flag:"db-port" validate:"min=1,max=65535"`
+		Username        string        `yaml:"username" env:"DB_USERNAME" flag:"db-username" validate:"required"`
+		Password        string        `yaml:"password" env:"DB_PASSWORD" flag:"db-password" secret:"true"`
+		Name            string        `yaml:"name" env:"DB_DATABASE_NAME" flag:"db-name" validate:"required"`
+		Location        string        `yaml:"location" env:"DB_LOCATION" flag:"db-location" validate:"required,timezone"`
+		MaxOpenConns    int           `yaml:"maxOpenConns" env:"DB_MAX_OPEN_CONNS" flag:"db-max-open-conns" validate:"min=0"`
+		MaxIdleConns    int           `yaml:"maxIdleConns" env:"DB_MAX_IDLE_CONNS" flag:"db-max-idle-conns" validate:"min=0"`
+		ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" env:"DB_CONN_MAX_LIFETIME" flag:"db-conn-max-lifetime" validate:"min=0"`
+		ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime" env:"DB_CONN_MAX_IDLE_TIME" flag:"db-conn-max-idle-time" validate:"min=0"`
+		QueryTimeout    time.Duration `yaml:"queryTimeout" env:"DB_QUERY_TIMEOUT" flag:"db-query-timeout" validate:"gt=0"`
+		TxIsolation     string        `yaml:"txIsolation" env:"DB_TX_ISOLATION" flag:"db-tx-isolation" validate:"omitempty,oneof=read-uncommitted read-committed repeatable-read serializable"`
+		TxMaxRetries    int           `yaml:"txMaxRetries" env:"DB_TX_MAX_RETRIES" flag:"db-tx-max-retries" validate:"min=0"`
+		TxRetryBackoff  time.Duration `yaml:"txRetryBackoff" env:"DB_TX_RETRY_BACKOFF" flag:"db-tx-retry-backoff" validate:"min=0"`
+
+		DSN string `yaml:"-"`
+	} `yaml:"database"`
+	Cart struct {
+		BatchMode          string        `yaml:"batchMode" env:"CART_BATCH_MODE" flag:"cart-batch-mode" validate:"oneof=atomic partial"`
+		BatchMaxOperations int           `yaml:"batchMaxOperations" env:"CART_BATCH_MAX_OPERATIONS" flag:"cart-batch-max-operations" validate:"min=1"`
+		RestoreWindow      time.Duration `yaml:"restoreWindow" env:"CART_RESTORE_WINDOW" flag:"cart-restore-window" validate:"min=0"`
+		DeletedRetention   time.Duration `yaml:"deletedRetention" env:"CART_DELETED_RETENTION" flag:"cart-deleted-retention" validate:"gt=0"`
+		PurgeInterval      time.Duration `yaml:"purgeInterval" env:"CART_PURGE_INTERVAL" flag:"cart-purge-interval" validate:"min=0"`
+		AbandonAfter       time.Duration `yaml:"abandonAfter" env:"CART_ABANDON_AFTER" flag:"cart-abandon-after" validate:"gt=0"`
+		AbandonInterval    time.Duration `yaml:"abandonInterval" env:"CART_ABANDON_INTERVAL" flag:"cart-abandon-interval" validate:"min=0"`
+	} `yaml:"cart"`
+	Outbox struct {
+		Publisher      string        `yaml:"publisher" env:"OUTBOX_PUBLISHER" flag:"outbox-publisher" validate:"oneof=stdout file webhook none"`
+		FilePath       string        `yaml:"filePath" env:"OUTBOX_FILE" flag:"outbox-file" validate:"required_if=Publisher file"`
+		WebhookURL     string        `yaml:"webhookUrl" env:"OUTBOX_WEBHOOK_URL" flag:"outbox-webhook-url" validate:"required_if=Publisher webhook,omitempty,url"`
+		WebhookTimeout time.Duration `yaml:"webhookTimeout" env:"OUTBOX_WEBHOOK_TIMEOUT" flag:"outbox-webhook-timeout" validate:"gt=0"`
+		RelayInterval  time.Duration `yaml:"relayInterval" env:"OUTBOX_RELAY_INTERVAL" flag:"outbox-relay-interval" validate:"gt=0"`
+		BatchSize      int           `yaml:"batchSize" env:"OUTBOX_BATCH_SIZE" flag:"outbox-batch-size" validate:"min=1"`
+	} `yaml:"outbox"`
+	Webhooks struct {
+		Timeout          time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT" flag:"webhooks-timeout" validate:"gt=0"`
+		MaxAttempts      int           `yaml:"maxAttempts" env:"WEBHOOKS_MAX_ATTEMPTS" flag:"webhooks-max-attempts" validate:"min=1"`
+		BackoffBase      time.Duration `yaml:"backoffBase" env:"WEBHOOKS_BACKOFF_BASE" flag:"webhooks-backoff-base" validate:"gt=0"`
+		BackoffMax       time.Duration `yaml:"backoffMax" env:"WEBHOOKS_BACKOFF_MAX" flag:"webhooks-backoff-max" validate:"gt=0"`
+		DeliveryInterval time.Duration `yaml:"deliveryInterval" env:"WEBHOOKS_DELIVERY_INTERVAL" flag:"webhooks-delivery-interval" validate:"min=0"`
+		BatchSize        int           `yaml:"batchSize" env:"WEBHOOKS_BATCH_SIZE" flag:"webhooks-batch-size" validate:"min=1"`
+	} `yaml:"webhooks"`
+	Stream struct {
+		Heartbeat   time.Duration `yaml:"heartbeat" env:"STREAM_HEARTBEAT" flag:"stream-heartbeat" validate:"gt=0"`
+		BufferSize  int           `yaml:"bufferSize" env:"STREAM_BUFFER_SIZE" flag:"stream-buffer-size" validate:"min=1"`
+		HistorySize int           `yaml:"historySize" env:"STREAM_HISTORY_SIZE" flag:"stream-history-size" validate:"min=1"`
+	} `yaml:"stream"`
+	Tracing struct {
+		ServiceName string  `yaml:"serviceName" env:"OTEL_SERVICE_NAME" flag:"tracing-service-name" validate:"required"`
+		Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" validate:"oneof=otlp stdout file none"`
+		Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" flag:"tracing-endpoint" validate:"omitempty,url"`
+		FilePath    string  `yaml:"filePath" env:"TRACING_FILE" flag:"tracing-file" validate:"required_if=Exporter file"`
+		SampleRatio float64 `yaml:"sampleRatio" env:"TRACING_SAMPLE_RATIO" flag:"tracing-sample-ratio" validate:"min=0,max=1"`
+	} `yaml:"tracing"`
+	Health struct {
+		Timeout time.Duration `yaml:"timeout" env:"HEALTH_CHECK_TIMEOUT" flag:"health-timeout" validate:"gt=0"`
+	} `yaml:"health"`
+	Features struct {
+		RequestTracing bool `yaml:"requestTracing" env:"FEATURE_REQUEST_TRACING" flag:"feature-request-tracing"`
+	} `yaml:"features"`
 }
 
-func New() *Config {
+// Default returns the configuration used when nothing overrides a key.
+func Default() *Config {
 	c := new(Config)
-	c.loadApp()
-	c.loadDatabase()
 
-	return c
-}
+	c.App.Port = 8080
+	c.App.GRPCPort = 9090
 
-func (c *Config) loadApp() *Config {
-	port := os.Getenv("PORT")
+	c.Server.ReadTimeout = 10 * time.Second
+	c.Server.ReadHeaderTimeout = 5 * time.Second
+	c.Server.WriteTimeout = 15 * time.Second
+	c.Server.IdleTimeout = 60 * time.Second
+	c.Server.ShutdownTimeout = 20 * time.Second
+	c.Server.MaxHeaderBytes = 1 << 20
+	c.Server.MaxBodyBytes = 1 << 20
 
-	c.App.Port = port
+	c.API.LegacyRoutes = true
 
-	return c
-}
+	c.Compression.Enabled = true
+	c.Compression.MinBytes = 1024
+	c.Compression.Level = -1
+
+	c.Cache.Policies = []string{
+		"GET /v1/cart/items=private no-cache",
+		"GET /v1/webhooks=private no-cache",
+		"GET /v1/webhooks/{id}=private no-cache",
+		"GET /v1/graphql/schema=public max-age=300",
+		"GET /admin/api-keys=no-store",
+		"GET /admin/carts=no-store",
+		"GET /admin/carts/{owner}=no-store",
+		"GET /openapi.json=public max-age=300",
+		"GET /docs=public max-age=300",
+	}
 
-func (c *Config) loadDatabase() *Config {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
+	c.RateLimit.Enabled = true
+	c.RateLimit.Default = "300/1m"
+	c.RateLimit.Routes = []string{
+		"POST /v1/cart/items=30/1m",
+		"POST /v1/cart/items:batch=10/1m",
+		"POST /v1/cart/checkout=10/1m",
+		"POST /v1/webhooks=10/1m",
 	}
 
-	// get Env Value
+	c.CORS.AllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+	c.CORS.AllowedHeaders = []string{"Accept", "Content-Type", "If-Match", "If-None-Match", "If-Modified-Since", "Last-Event-ID", "X-Request-Id", "Authorization", "X-API-Key"}
+	c.CORS.ExposedHeaders = []string{"ETag", "Last-Modified", "Deprecation", "Sunset", "Link", "X-Request-Id", "X-Trace-Id"}
+	c.CORS.MaxAge = 10 * time.Minute
 
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	username := os.Getenv("DB_USERNAME")
-	password := os.Getenv("DB_PASSWORD")
-	database := os.Getenv("DB_DATABASE_NAME")
+	c.Security.HSTSMaxAge = 365 * 24 * time.Hour
+	c.Security.HSTSIncludeSubdomains = true
+	c.Security.FrameOptions = "DENY"
+	c.Security.ReferrerPolicy = "no-referrer"
 
-	connVal := url.Values{}
-	// connVal.Add("parseTime", "1")
-	connVal.Add("loc", "Asia/Jakarta")
+	c.Auth.KeyUsageInterval = time.Minute
 
-	dbConnection := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", username, password, host, port, database)
+	c.Database.Port = 3306
+	c.Database.Location = "Asia/Jakarta"
+	c.Database.MaxOpenConns = 25
+	c.Database.MaxIdleConns = 25
+	c.Database.ConnMaxLifetime = 5 * time.Minute
+	c.Database.ConnMaxIdleTime = time.Minute
+	c.Database.QueryTimeout = 5 * time.Second
+	c.Database.TxIsolation = "repeatable-read"
+	c.Database.TxMaxRetries = 3
+	c.Database.TxRetryBackoff = 50 * time.Millisecond
 
-	dsn := fmt.Sprintf("%s?%s", dbConnection, connVal.Encode())
+	c.Cart.BatchMode = "atomic"
+	c.Cart.BatchMaxOperations = 100
+	c.Cart.RestoreWindow = 24 * time.Hour
+	c.Cart.DeletedRetention = 30 * 24 * time.Hour
+	c.Cart.PurgeInterval = time.Hour
+	c.Cart.AbandonAfter = 24 * time.Hour
+	c.Cart.AbandonInterval = 10 * time.Minute
 
-	c.Database.DSN = dsn
+	c.Outbox.Publisher = "none"
+	c.Outbox.WebhookTimeout = 5 * time.Second
+	c.Outbox.RelayInterval = time.Second
+	c.Outbox.BatchSize = 100
 
-	c.Database.DSN = dbConnection
+	c.Webhooks.Timeout = 5 * time.Second
+	c.Webhooks.MaxAttempts = 8
+	c.Webhooks.BackoffBase = 10 * time.Second
+	c.Webhooks.BackoffMax = time.Hour
+	c.Webhooks.DeliveryInterval = time.Second
+	c.Webhooks.BatchSize = 50
+
+	c.Stream.Heartbeat = 15 * time.Second
+	c.Stream.BufferSize = 16
+	c.Stream.HistorySize = 1000
+
+	c.Tracing.ServiceName = "haioo-cart"
+	c.Tracing.Exporter = "none"
+	c.Tracing.SampleRatio = 1
+
+	c.Health.Timeout = 2 * time.Second
+
+	c.Features.RequestTracing = true
 
 	return c
 }
+
+func (c *Config) buildDSN() {
+	dsn := mysql.NewConfig()
+	dsn.User = c.Database.Username
+	dsn.Passwd = c.Database.Password
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(c.Database.Host, strconv.Itoa(c.Database.Port))
+	dsn.DBName = c.Database.Name
+	dsn.ParseTime = true
+
+	if loc, err := time.LoadLocation(c.Database.Location); err == nil {
+		dsn.Loc = loc
+	}
+
+	c.Database.DSN = dsn.FormatDSN()
+}
@@ -0,0 +1,316 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile names the YAML file to load when -config is not given.
+const EnvConfigFile = "CONFIG_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is a leaf of Config together with its source names.
+type field struct {
+	path   string
+	env    string
+	flag   string
+	secret bool
+	value  reflect.Value
+}
+
+// Load resolves the configuration from defaults, the YAML file named by
+// -config or CONFIG_FILE, the environment (including an optional .env) and
+// the flags in args, then validates it. The problems of every layer and of
+// the validation are returned together.
+func Load(args []string) (*Config, error) {
+	c, _, err := Parse(args)
+
+	return c, err
+}
+
+// Parse is Load for a command line: the flags end at the first argument
+// that is not one, and the arguments from there on are returned.
+func Parse(args []string) (*Config, []string, error) {
+	c := Default()
+	fields := c.fields()
+
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := fs.String("config", "", "path to a YAML config file")
+	for _, f := range fields {
+		if f.flag != "" {
+			fs.Var(&flagValue{isBool: f.value.Kind() == reflect.Bool}, f.flag, f.path)
+		}
+	}
+
+	var problems []error
+
+	// the flags parsed before a bad one still apply
+	if err := fs.Parse(args); err != nil {
+		problems = append(problems, fmt.Errorf("flags: %w", err))
+	}
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		problems = append(problems, fmt.Errorf("loading .env: %w", err))
+	}
+
+	path := *configFile
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+
+	if path != "" {
+		if err := c.loadFile(path); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	for _, f := range fields {
+		if raw, ok := os.LookupEnv(f.env); ok && f.env != "" {
+			if err := setValue(f.value, raw); err != nil {
+				problems = append(problems, fmt.Errorf("%s: env %s: %w", f.path, f.env, err))
+			}
+		}
+	}
+
+	byFlag := map[string]field{}
+	for _, f := range fields {
+		byFlag[f.flag] = f
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		f, ok := byFlag[fl.Name]
+		if !ok {
+			return
+		}
+
+		if err := setValue(f.value, fl.Value.String()); err != nil {
+			problems = append(problems, fmt.Errorf("%s: flag -%s: %w", f.path, f.flag, err))
+		}
+	})
+
+	if err := c.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+
+	if len(problems) > 0 {
+		return nil, nil, errors.Join(problems...)
+	}
+
+	c.buildDSN()
+
+	return c, fs.Args(), nil
+}
+
+// New loads the configuration from the process arguments and exits on any
+// problem.
+func New() *Config {
+	c, err := Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	return c
+}
+
+func (c *Config) loadFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Validate checks every key and reports all problems at once.
+func (c *Config) Validate() error {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(sf reflect.StructField) string {
+		return strings.SplitN(sf.Tag.Get("yaml"), ",", 2)[0]
+	})
+
+	var problems []error
+
+	if err := validate.Struct(c); err != nil {
+		var fieldErrors validator.ValidationErrors
+		if !errors.As(err, &fieldErrors) {
+			return err
+		}
+
+		for _, fe := range fieldErrors {
+			problems = append(problems, fmt.Errorf("%s %s", strings.TrimPrefix(fe.Namespace(), "Config."), describe(fe)))
+		}
+	}
+
+	if c.Cart.DeletedRetention < c.Cart.RestoreWindow {
+		problems = append(problems, fmt.Errorf("cart.deletedRetention must not be shorter than cart.restoreWindow (%s)", c.Cart.RestoreWindow))
+	}
+
+	if c.App.GRPCPort == c.App.Port {
+		problems = append(problems, fmt.Errorf("app.grpcPort must differ from app.port (%d)", c.App.Port))
+	}
+
+	if c.Webhooks.BackoffMax < c.Webhooks.BackoffBase {
+		problems = append(problems, fmt.Errorf("webhooks.backoffMax must not be shorter than webhooks.backoffBase (%s)", c.Webhooks.BackoffBase))
+	}
+
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problems = append(problems, fmt.Errorf("database.maxIdleConns must not exceed database.maxOpenConns (%d)", c.Database.MaxOpenConns))
+	}
+
+	return errors.Join(problems...)
+}
+
+func describe(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		return fmt.Sprintf("is required when %s", strings.Replace(fe.Param(), " ", " is ", 1))
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", fe.Param())
+	case "timezone":
+		return "must be a valid time zone"
+	case "url":
+		return "must be a valid URL"
+	case "datetime":
+		return fmt.Sprintf("must be a date formatted as %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed %s validation", fe.Tag())
+	}
+}
+
+func (c *Config) fields() []field {
+	var fields []field
+	collect(reflect.ValueOf(c).Elem(), "", &fields)
+
+	return fields
+}
+
+func collect(v reflect.Value, prefix string, fields *[]field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.SplitN(sf.Tag.Get("yaml"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+			collect(v.Field(i), path, fields)
+			continue
+		}
+
+		*fields = append(*fields, field{
+			path:   path,
+			env:    sf.Tag.Get("env"),
+			flag:   sf.Tag.Get("flag"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+}
+
+// flagValue keeps the raw flag text so it can be applied after the file
+// and environment layers.
+type flagValue struct {
+	raw    string
+	isBool bool
+}
+
+func (fv *flagValue) String() string {
+	return fv.raw
+}
+
+func (fv *flagValue) Set(raw string) error {
+	fv.raw = raw
+	return nil
+}
+
+func (fv *flagValue) IsBoolFlag() bool {
+	return fv.isBool
+}
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "******"
+
+// Print writes the effective configuration as YAML with every secret
+// replaced, so the output is safe to paste into tickets.
+func Print(w io.Writer, c *Config) error {
+	clone := *c
+	for _, f := range clone.fields() {
+		if f.secret && f.value.Kind() == reflect.String && f.value.String() != "" {
+			f.value.SetString(redacted)
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&clone); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
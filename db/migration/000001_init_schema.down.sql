@@ -1 +1 @@
-DROP TABLE IF EXISTS ;
\ No newline at end of file
+DROP TABLE `Haioo`.`Cart`;
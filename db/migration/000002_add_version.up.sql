@@ -0,0 +1 @@
+ALTER TABLE `cart` ADD COLUMN `version` INT NOT NULL DEFAULT 1;
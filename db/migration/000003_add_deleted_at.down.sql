@@ -0,0 +1,2 @@
+DROP INDEX `idx_cart_deleted_at` ON `cart`;
+ALTER TABLE `cart` DROP COLUMN `deleted_at`;
@@ -0,0 +1,2 @@
+ALTER TABLE `cart` ADD COLUMN `deleted_at` DATETIME NULL DEFAULT NULL;
+CREATE INDEX `idx_cart_deleted_at` ON `cart` (`deleted_at`);
@@ -0,0 +1,14 @@
+CREATE TABLE `cart_events` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `cart_id` INT NOT NULL,
+    `kodeProduk` VARCHAR(255) NOT NULL,
+    `action` VARCHAR(32) NOT NULL,
+    `actor` VARCHAR(255) NOT NULL,
+    `request_id` VARCHAR(128) NOT NULL DEFAULT '',
+    `source_ip` VARCHAR(45) NOT NULL DEFAULT '',
+    `kuantitas_before` INT NOT NULL,
+    `kuantitas_after` INT NOT NULL,
+    `created_at` DATETIME(6) NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_cart_events_cart_id` (`cart_id`, `id`)
+);
@@ -0,0 +1,17 @@
+CREATE TABLE `outbox` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `event_id` CHAR(36) NOT NULL,
+    `event_type` VARCHAR(64) NOT NULL,
+    `version` INT NOT NULL,
+    `actor` VARCHAR(255) NOT NULL DEFAULT '',
+    `request_id` VARCHAR(128) NOT NULL DEFAULT '',
+    `payload` JSON NOT NULL,
+    `occurred_at` DATETIME(6) NOT NULL,
+    `published_at` DATETIME(6) NULL DEFAULT NULL,
+    `attempts` INT NOT NULL DEFAULT 0,
+    `last_error` TEXT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uq_outbox_event_id` (`event_id`),
+    INDEX `idx_outbox_unpublished` (`published_at`, `id`),
+    INDEX `idx_outbox_type_occurred` (`event_type`, `occurred_at`)
+);
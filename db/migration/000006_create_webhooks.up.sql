@@ -0,0 +1,29 @@
+CREATE TABLE `webhooks` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `url` VARCHAR(2048) NOT NULL,
+    `secret` VARCHAR(255) NOT NULL,
+    `event_types` VARCHAR(1024) NOT NULL DEFAULT '',
+    `active` BOOLEAN NOT NULL DEFAULT TRUE,
+    `created_at` DATETIME(6) NOT NULL,
+    `update_at` DATETIME(6) NOT NULL,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `webhook_deliveries` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `webhook_id` BIGINT NOT NULL,
+    `event_id` CHAR(36) NOT NULL,
+    `event_type` VARCHAR(64) NOT NULL,
+    `payload` JSON NOT NULL,
+    `status` VARCHAR(16) NOT NULL,
+    `attempts` INT NOT NULL DEFAULT 0,
+    `next_attempt_at` DATETIME(6) NOT NULL,
+    `last_status_code` INT NOT NULL DEFAULT 0,
+    `last_error` TEXT NULL,
+    `created_at` DATETIME(6) NOT NULL,
+    `delivered_at` DATETIME(6) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhook_deliveries_due` (`status`, `next_attempt_at`),
+    INDEX `idx_webhook_deliveries_webhook` (`webhook_id`, `id`),
+    CONSTRAINT `fk_webhook_deliveries_webhook` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+CREATE TABLE `api_keys` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `name` VARCHAR(100) NOT NULL,
+    `prefix` CHAR(8) NOT NULL,
+    `hash` CHAR(64) NOT NULL,
+    `scopes` VARCHAR(255) NOT NULL DEFAULT '',
+    `created_at` DATETIME(6) NOT NULL,
+    `update_at` DATETIME(6) NOT NULL,
+    `last_used_at` DATETIME(6) NULL DEFAULT NULL,
+    `revoked_at` DATETIME(6) NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_api_keys_prefix` (`prefix`)
+);
@@ -0,0 +1,3 @@
+DROP TABLE `cart_access`;
+DROP INDEX `idx_cart_owner` ON `cart`;
+ALTER TABLE `cart` DROP COLUMN `owner`;
@@ -0,0 +1,14 @@
+ALTER TABLE `cart` ADD COLUMN `owner` VARCHAR(255) NOT NULL DEFAULT 'anonymous';
+CREATE INDEX `idx_cart_owner` ON `cart` (`owner`, `kodeProduk`);
+CREATE TABLE `cart_access` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `actor` VARCHAR(255) NOT NULL,
+    `role` VARCHAR(32) NOT NULL,
+    `action` VARCHAR(32) NOT NULL,
+    `owner` VARCHAR(255) NOT NULL DEFAULT '',
+    `request_id` VARCHAR(128) NOT NULL DEFAULT '',
+    `source_ip` VARCHAR(45) NOT NULL DEFAULT '',
+    `created_at` DATETIME(6) NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_cart_access_owner` (`owner`, `id`)
+);
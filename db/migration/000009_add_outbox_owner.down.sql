@@ -0,0 +1,3 @@
+DROP INDEX `idx_outbox_type_owner_occurred` ON `outbox`;
+CREATE INDEX `idx_outbox_type_occurred` ON `outbox` (`event_type`, `occurred_at`);
+ALTER TABLE `outbox` DROP COLUMN `owner`;
@@ -0,0 +1,3 @@
+ALTER TABLE `outbox` ADD COLUMN `owner` VARCHAR(255) NOT NULL DEFAULT '';
+DROP INDEX `idx_outbox_type_occurred` ON `outbox`;
+CREATE INDEX `idx_outbox_type_owner_occurred` ON `outbox` (`event_type`, `owner`, `occurred_at`);
@@ -0,0 +1 @@
+DROP INDEX `uq_webhook_deliveries_event` ON `webhook_deliveries`;
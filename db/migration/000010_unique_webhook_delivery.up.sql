@@ -0,0 +1,2 @@
+DELETE `d` FROM `webhook_deliveries` `d` JOIN `webhook_deliveries` `k` ON `k`.`webhook_id` = `d`.`webhook_id` AND `k`.`event_id` = `d`.`event_id` AND `k`.`id` < `d`.`id`;
+CREATE UNIQUE INDEX `uq_webhook_deliveries_event` ON `webhook_deliveries` (`webhook_id`, `event_id`);
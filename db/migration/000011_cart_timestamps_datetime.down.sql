@@ -0,0 +1,3 @@
+ALTER TABLE `cart`
+    MODIFY `created_at` DATE NULL DEFAULT (now()),
+    MODIFY `update_at` DATE NULL DEFAULT (now());
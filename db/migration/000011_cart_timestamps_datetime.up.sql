@@ -0,0 +1,3 @@
+ALTER TABLE `cart`
+    MODIFY `created_at` DATETIME(6) NULL DEFAULT CURRENT_TIMESTAMP(6),
+    MODIFY `update_at` DATETIME(6) NULL DEFAULT CURRENT_TIMESTAMP(6);
@@ -0,0 +1 @@
+ALTER TABLE `outbox` ADD COLUMN `leased_until` DATETIME(6) NULL DEFAULT NULL;
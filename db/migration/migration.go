@@ -0,0 +1,17 @@
+package migration
+
+import "embed"
+
+//go:embed *.sql
+var Files embed.FS
+
+// LatestVersion returns the highest migration version shipped with the
+// binary, which is what a fully migrated database reports.
+func LatestVersion() (int64, error) {
+	versions, err := versions()
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+
+	return versions[len(versions)-1], nil
+}
@@ -0,0 +1,231 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrDirty is returned when an earlier migration failed half way; the
+// schema has to be repaired by hand before migrating again.
+var ErrDirty = errors.New("database is dirty")
+
+// Migrator applies the migrations shipped with the binary. It keeps the
+// version in the same one-row (version, dirty) table as golang-migrate, so
+// either can take over from the other.
+type Migrator struct {
+	DB    *sql.DB
+	Table string
+}
+
+func NewMigrator(db *sql.DB, table string) *Migrator {
+	return &Migrator{
+		DB:    db,
+		Table: table,
+	}
+}
+
+// Version returns the version the database is at, 0 before the first
+// migration.
+func (m *Migrator) Version(ctx context.Context) (version int64, dirty bool, err error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, false, err
+	}
+
+	query := fmt.Sprintf(`SELECT version, dirty FROM %s LIMIT 1`, m.Table)
+
+	err = m.DB.QueryRowContext(ctx, query).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+// Up applies every migration newer than the database and returns their
+// versions.
+func (m *Migrator) Up(ctx context.Context) ([]int64, error) {
+	current, err := m.clean(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := versions()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []int64
+	for _, version := range versions {
+		if version <= current {
+			continue
+		}
+
+		if err := m.apply(ctx, version, "up", version); err != nil {
+			return applied, err
+		}
+
+		applied = append(applied, version)
+	}
+
+	return applied, nil
+}
+
+// Down reverts the latest steps migrations and returns their versions.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int64, error) {
+	current, err := m.clean(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := versions()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []int64
+	for i := len(versions) - 1; i >= 0 && len(reverted) < steps; i-- {
+		if versions[i] > current {
+			continue
+		}
+
+		var previous int64
+		if i > 0 {
+			previous = versions[i-1]
+		}
+
+		if err := m.apply(ctx, versions[i], "down", previous); err != nil {
+			return reverted, err
+		}
+
+		reverted = append(reverted, versions[i])
+	}
+
+	return reverted, nil
+}
+
+// clean returns the current version, or ErrDirty.
+func (m *Migrator) clean(ctx context.Context) (int64, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return version, fmt.Errorf("%w at version %d", ErrDirty, version)
+	}
+
+	return version, nil
+}
+
+// apply runs the direction file of version, leaving the database at
+// target. The version is marked dirty while the statements run, as MySQL
+// cannot roll back schema changes.
+func (m *Migrator) apply(ctx context.Context, version int64, direction string, target int64) error {
+	file, err := fileOf(version, direction)
+	if err != nil {
+		return err
+	}
+
+	raw, err := fs.ReadFile(Files, file)
+	if err != nil {
+		return err
+	}
+
+	if err := m.setVersion(ctx, version, true); err != nil {
+		return err
+	}
+
+	for _, statement := range statements(string(raw)) {
+		if _, err := m.DB.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	return m.setVersion(ctx, target, false)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`, m.Table)
+
+	_, err := m.DB.ExecContext(ctx, query)
+
+	return err
+}
+
+// setVersion replaces the one row of the version table; version 0 leaves
+// it empty.
+func (m *Migrator) setVersion(ctx context.Context, version int64, dirty bool) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, m.Table)); err != nil {
+		return err
+	}
+
+	if version > 0 {
+		query := fmt.Sprintf(`INSERT INTO %s (version, dirty) VALUES (?, ?)`, m.Table)
+		if _, err := tx.ExecContext(ctx, query, version, dirty); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// versions lists the versions shipped with the binary, oldest first.
+func versions() ([]int64, error) {
+	files, err := fs.Glob(Files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]int64, 0, len(files))
+	for _, file := range files {
+		version, err := strconv.ParseInt(strings.SplitN(file, "_", 2)[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", file)
+		}
+
+		out = append(out, version)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+
+	return out, nil
+}
+
+func fileOf(version int64, direction string) (string, error) {
+	files, err := fs.Glob(Files, fmt.Sprintf("%06d_*.%s.sql", version, direction))
+	if err != nil {
+		return "", err
+	}
+
+	if len(files) != 1 {
+		return "", fmt.Errorf("no %s migration for version %d", direction, version)
+	}
+
+	return files[0], nil
+}
+
+// statements splits a migration file into the statements it holds; the
+// files keep semicolons out of their literals.
+func statements(raw string) []string {
+	var out []string
+	for _, statement := range strings.Split(raw, ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			out = append(out, statement)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// HeaderAPIKey carries an API key; "Authorization: Bearer <key>" works
+// too.
+const HeaderAPIKey = "X-API-Key"
+
+// KeyVerifier resolves an API key to the principal it was issued to.
+type KeyVerifier interface {
+	// VerifyKey returns ErrInvalidCredentials for an unknown or revoked
+	// key.
+	VerifyKey(ctx context.Context, key string) (Principal, error)
+}
+
+// APIKeys authenticates server-to-server clients by API key.
+type APIKeys struct {
+	Verifier KeyVerifier
+}
+
+func (a APIKeys) Authenticate(r *http.Request) (Principal, error) {
+	key := strings.TrimSpace(r.Header.Get(HeaderAPIKey))
+	if key == "" {
+		token, ok := bearer(r)
+		if !ok || strings.Contains(token, ".") {
+			return Principal{}, ErrNoCredentials
+		}
+		key = token
+	}
+
+	return a.Verifier.VerifyKey(r.Context(), key)
+}
@@ -0,0 +1,228 @@
+// Package auth identifies the caller of a request and checks the scopes of
+// the route it calls. Authenticators recognise one kind of credentials
+// each (user JWTs, API keys) and all of them yield a Principal, stored in
+// the request context and recorded as the requestinfo actor.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/helpers/requestinfo"
+	"github.com/Risuii/helpers/response"
+)
+
+const (
+	ScopeCartRead  = "cart:read"
+	ScopeCartWrite = "cart:write"
+	// ScopeCatalogAdmin manages the product catalog.
+	ScopeCatalogAdmin  = "catalog:admin"
+	ScopeWebhooksAdmin = "webhooks:admin"
+	ScopeKeysAdmin     = "keys:admin"
+	// ScopeCartsAdmin reaches the carts of other callers, as far as the
+	// role of the caller allows.
+	ScopeCartsAdmin = "carts:admin"
+
+	KindUser   = "user"
+	KindAPIKey = "apikey"
+	// KindOperator runs the admin commands of the binary; the subject is
+	// their login.
+	KindOperator = "operator"
+	// KindSystem is a background job of the service; the subject names
+	// the job.
+	KindSystem = "system"
+)
+
+// Scopes lists every scope a principal can be granted.
+var Scopes = []string{ScopeCartRead, ScopeCartWrite, ScopeCatalogAdmin, ScopeWebhooksAdmin, ScopeKeysAdmin, ScopeCartsAdmin}
+
+var (
+	// ErrNoCredentials is returned by an Authenticator for a request
+	// carrying none of the credentials it reads.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned for credentials that are
+	// malformed, unknown, expired or revoked.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	// Kind is KindUser, KindAPIKey, KindOperator or KindSystem.
+	Kind string
+	// Subject is the user id, or the id of the API key.
+	Subject string
+	Scopes  []string
+	// Role is the role claimed by a user token, if any.
+	Role string
+}
+
+// Actor names p in audit records and rate limit keys, as "user:42" or
+// "apikey:7".
+func (p Principal) Actor() string {
+	return p.Kind + ":" + p.Subject
+}
+
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+type contextKey struct{}
+
+// With stores p in ctx and records it as the actor of the request.
+func With(ctx context.Context, p Principal) context.Context {
+	return requestinfo.WithActor(context.WithValue(ctx, contextKey{}, p), p.Actor())
+}
+
+// From returns the principal stored in ctx, if the request was
+// authenticated.
+func From(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+
+	return p, ok
+}
+
+// Authenticator reads one kind of credentials from a request.
+type Authenticator interface {
+	// Authenticate returns ErrNoCredentials when r carries none of its
+	// kind and ErrInvalidCredentials when they are not accepted.
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// Middleware authenticates requests with the first authenticator that
+// finds credentials. Requests without any go on anonymous; invalid ones
+// are answered 401.
+func Middleware(authenticators ...Authenticator) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok, err := authenticate(r, authenticators)
+			if errors.Is(err, ErrInvalidCredentials) {
+				unauthorized(w, r)
+				return
+			}
+
+			if err != nil {
+				logger.Println(r.Context(), "authenticate:", err)
+				_ = response.Error(response.StatusInternalServerError, exception.ErrInternalServer).Write(w, r)
+				return
+			}
+
+			if ok {
+				r = r.WithContext(With(r.Context(), p))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authenticate returns the principal of the first authenticator that
+// finds credentials in r; ok is false when none does.
+func authenticate(r *http.Request, authenticators []Authenticator) (p Principal, ok bool, err error) {
+	for _, authenticator := range authenticators {
+		p, err = authenticator.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+
+		if err != nil {
+			return Principal{}, false, err
+		}
+
+		return p, true, nil
+	}
+
+	return Principal{}, false, nil
+}
+
+// Rule is what a route asks of its callers.
+type Rule struct {
+	Scope string
+	// Anonymous lets requests without credentials through; those with
+	// credentials still need Scope.
+	Anonymous bool
+}
+
+// Rules maps "METHOD /path" to the rule of that route. Paths are OpenAPI
+// templates, as in "DELETE /v1/cart/items".
+type Rules map[string]Rule
+
+// Require enforces the rule of the matched route: 401 without a
+// principal, unless the rule lets anonymous requests through, and 403
+// without its scope. Routes without a rule are open. prefix is tried in
+// front of a template missing from rules, for routes also mounted without
+// their version prefix.
+func Require(rules Rules, prefix string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rule, ok := rules.match(r, prefix)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p, authenticated := From(r.Context())
+			switch {
+			case !authenticated && rule.Anonymous:
+			case !authenticated:
+				unauthorized(w, r)
+				return
+			case !p.HasScope(rule.Scope):
+				_ = response.ErrorWithData(response.StatusForbiddend, exception.ErrForbidden, []string{rule.Scope}).Write(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (rules Rules) match(r *http.Request, prefix string) (Rule, bool) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return Rule{}, false
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return Rule{}, false
+	}
+
+	template = openapi.PathTemplate(template)
+	if rule, ok := rules[r.Method+" "+template]; ok {
+		return rule, true
+	}
+
+	rule, ok := rules[r.Method+" "+prefix+template]
+
+	return rule, ok
+}
+
+// bearer returns the token of an "Authorization: Bearer" header.
+func bearer(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="haioo"`)
+	_ = response.Error(response.StatusUnauthorized, exception.ErrUnauthorized).Write(w, r)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/logger"
+)
+
+// UnaryServerInterceptor is Middleware and Require for the gRPC server.
+// The credentials are read from the authorization and x-api-key metadata
+// by the same authenticators, and rules is keyed by the full method name,
+// as in "/cart.v1.CartService/GetItems". Methods without a rule are open.
+// Invalid or missing credentials are Unauthenticated, a missing scope
+// PermissionDenied.
+func UnaryServerInterceptor(rules Rules, authenticators ...Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		p, authenticated, err := authenticate(credentialsRequest(ctx), authenticators)
+		if errors.Is(err, ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, exception.ErrUnauthorized.Error())
+		}
+
+		if err != nil {
+			logger.Println(ctx, "authenticate:", err)
+			return nil, status.Error(codes.Internal, exception.ErrInternalServer.Error())
+		}
+
+		if authenticated {
+			ctx = With(ctx, p)
+		}
+
+		if rule, ok := rules[info.FullMethod]; ok {
+			switch {
+			case !authenticated && rule.Anonymous:
+			case !authenticated:
+				return nil, status.Error(codes.Unauthenticated, exception.ErrUnauthorized.Error())
+			case !p.HasScope(rule.Scope):
+				return nil, status.Error(codes.PermissionDenied, exception.ErrForbidden.Error())
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// credentialsRequest carries the credentials in the metadata of ctx as the
+// headers an Authenticator reads.
+func credentialsRequest(ctx context.Context) *http.Request {
+	r, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, name := range []string{"Authorization", HeaderAPIKey} {
+		if values := md.Get(name); len(values) > 0 {
+			r.Header.Set(name, values[0])
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,41 @@
+// Package bodylimit caps the size of request bodies. Bodies announced as
+// too large are refused before they are read; the others are read through
+// http.MaxBytesReader, and handlers map the error it returns to 413 with
+// DecodeError.
+package bodylimit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+)
+
+// Handler limits the bodies next reads to maxBytes.
+func Handler(next http.Handler, maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			res := response.ErrorWithData(response.StatusEntityTooLarge, exception.ErrEntityTooLarge,
+				fmt.Sprintf("body must not be larger than %d bytes", maxBytes))
+			_ = res.Write(w, r)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DecodeError is the response for a request body that could not be
+// decoded: 413 when it went over the limit, 422 otherwise.
+func DecodeError(err error) response.Response {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return response.Error(response.StatusEntityTooLarge, exception.ErrEntityTooLarge)
+	}
+
+	return response.Error(response.StatusUnprocessableEntity, exception.ErrUnprocessableEntity)
+}
@@ -0,0 +1,62 @@
+// Package cachecontrol sets the Cache-Control header of each route from a
+// configurable policy.
+package cachecontrol
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Risuii/helpers/openapi"
+)
+
+// Policies maps "METHOD /path" to the Cache-Control of that route. Paths
+// are OpenAPI templates, as in "GET /v1/webhooks/{id}".
+type Policies map[string]string
+
+// Parse reads policies written as "METHOD /path=directives", with the
+// directives separated by spaces ("GET /v1/cart/items=private no-cache")
+// so that a list of them survives comma-separated configuration.
+func Parse(entries []string) (Policies, error) {
+	policies := Policies{}
+
+	for _, entry := range entries {
+		route, directives, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		directives = strings.Join(strings.Fields(directives), ", ")
+
+		if !ok || !hasPath || !strings.HasPrefix(strings.TrimSpace(path), "/") || directives == "" {
+			return nil, fmt.Errorf("cache policy %q is not METHOD /path=directives", entry)
+		}
+
+		policies[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = directives
+	}
+
+	return policies, nil
+}
+
+// Middleware sets the policy of the matched route unless the handler sets
+// Cache-Control itself. prefix is put in front of the route template
+// before the lookup, for routes also mounted without their version prefix.
+func Middleware(policies Policies, prefix string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					method := r.Method
+					if method == http.MethodHead {
+						method = http.MethodGet
+					}
+
+					if policy, ok := policies[method+" "+prefix+openapi.PathTemplate(template)]; ok && w.Header().Get("Cache-Control") == "" {
+						w.Header().Set("Cache-Control", policy)
+					}
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
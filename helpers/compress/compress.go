@@ -0,0 +1,331 @@
+// Package compress encodes response bodies with the best codec the client
+// accepts. Bodies smaller than a threshold, streams (server-sent events,
+// WebSocket upgrades) and responses that already carry a Content-Encoding
+// are sent as they are.
+package compress
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/mux"
+)
+
+// Codec is one Content-Encoding.
+type Codec interface {
+	// Name is the Content-Encoding token, such as "gzip".
+	Name() string
+	NewWriter(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return "gzip" }
+
+func (gzipCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, level)
+}
+
+type deflateCodec struct{}
+
+func (deflateCodec) Name() string { return "deflate" }
+
+func (deflateCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return flate.NewWriter(w, level)
+}
+
+type brotliCodec struct{}
+
+func (brotliCodec) Name() string { return "br" }
+
+// NewWriter takes the levels of gzip; brotli goes up to 11, and -1 is its
+// default of 6.
+func (brotliCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level < brotli.BestSpeed || level > brotli.BestCompression {
+		level = brotli.DefaultCompression
+	}
+
+	return brotli.NewWriterLevel(w, level), nil
+}
+
+var (
+	Gzip    Codec = gzipCodec{}
+	Deflate Codec = deflateCodec{}
+	Brotli  Codec = brotliCodec{}
+)
+
+type Options struct {
+	// MinBytes is the smallest body worth compressing.
+	MinBytes int
+	// Level is passed to the codecs; -1 is their default.
+	Level int
+	// Codecs in order of preference when the client weighs them equally;
+	// nil means Brotli, Gzip then Deflate.
+	Codecs []Codec
+}
+
+// Middleware compresses the responses of next according to opts.
+func Middleware(opts Options) mux.MiddlewareFunc {
+	codecs := opts.Codecs
+	if codecs == nil {
+		codecs = []Codec{Brotli, Gzip, Deflate}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			codec := Negotiate(r.Header.Get("Accept-Encoding"), codecs)
+			if codec == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &writer{ResponseWriter: w, codec: codec, level: opts.Level, minBytes: opts.MinBytes}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// Negotiate returns the codec an Accept-Encoding header prefers, or nil
+// when the body should not be encoded.
+func Negotiate(header string, codecs []Codec) Codec {
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+
+		qualities[name] = quality
+	}
+
+	var (
+		best    Codec
+		quality float64
+	)
+	for _, codec := range codecs {
+		q, ok := qualities[codec.Name()]
+		if !ok {
+			q = qualities["*"]
+		}
+
+		if q > quality {
+			best, quality = codec, q
+		}
+	}
+
+	return best
+}
+
+// writer holds the body back until it knows whether to compress it: once
+// MinBytes are written, on Flush or when the handler returns.
+type writer struct {
+	http.ResponseWriter
+	codec    Codec
+	level    int
+	minBytes int
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (cw *writer) WriteHeader(status int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+
+	// informational responses go out as they are
+	if status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	cw.status = status
+	if !cw.eligible() {
+		cw.decide(false)
+	}
+}
+
+func (cw *writer) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if cw.worthIt() {
+			if err := cw.decide(true); err != nil {
+				return 0, err
+			}
+		}
+
+		return len(p), nil
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends what is held back, uncompressed if it is too small, and
+// flushes the codec so streamed bodies are not delayed.
+func (cw *writer) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.WriteHeader(http.StatusOK)
+		}
+		_ = cw.decide(cw.worthIt())
+	}
+
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+
+	_ = http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Hijack lets WebSocket upgrades through.
+func (cw *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+func (cw *writer) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *writer) close() {
+	if !cw.decided {
+		if cw.status == 0 {
+			// nothing was written; let net/http answer 200 with no body
+			return
+		}
+		_ = cw.decide(cw.worthIt())
+	}
+
+	if cw.encoder != nil {
+		_ = cw.encoder.Close()
+	}
+}
+
+// worthIt reports whether the body held back is big enough to compress.
+func (cw *writer) worthIt() bool {
+	return len(cw.buf) > 0 && len(cw.buf) >= cw.minBytes
+}
+
+// eligible reports whether the response, going by its status and headers,
+// may be compressed.
+func (cw *writer) eligible() bool {
+	if cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	return compressible(header.Get("Content-Type"))
+}
+
+// decide writes the header, compressing the body when compress is set and
+// the response is eligible, then sends what was held back.
+func (cw *writer) decide(compress bool) error {
+	cw.decided = true
+
+	if compress && cw.eligible() {
+		header := cw.Header()
+		header.Set("Content-Encoding", cw.codec.Name())
+		header.Del("Content-Length")
+
+		// net/http would sniff the compressed bytes
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", http.DetectContentType(cw.buf))
+		}
+
+		// the compressed bytes differ, so the validator can only be weak
+		if tag := header.Get("ETag"); tag != "" && !strings.HasPrefix(tag, "W/") {
+			header.Set("ETag", "W/"+tag)
+		}
+
+		encoder, err := cw.codec.NewWriter(cw.ResponseWriter, cw.level)
+		if err != nil {
+			return err
+		}
+		cw.encoder = encoder
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	if len(cw.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(cw.buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf)
+	}
+	cw.buf = nil
+
+	return err
+}
+
+// compressible leaves out streams and media that is compressed already.
+func compressible(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "image/") && mediaType != "image/svg+xml",
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"):
+		return false
+	case mediaType == "application/zip", mediaType == "application/gzip", mediaType == "application/zstd":
+		return false
+	}
+
+	return true
+}
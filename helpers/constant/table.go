@@ -1,5 +1,12 @@
 package constant
 
 const (
-	TableCart = "cart"
+	TableCart              = "cart"
+	TableCartEvents        = "cart_events"
+	TableOutbox            = "outbox"
+	TableSchemaMigrations  = "schema_migrations"
+	TableWebhooks          = "webhooks"
+	TableWebhookDeliveries = "webhook_deliveries"
+	TableAPIKeys           = "api_keys"
+	TableCartAccess        = "cart_access"
 )
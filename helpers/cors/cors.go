@@ -0,0 +1,159 @@
+// Package cors lets browsers on other origins call the API, answering
+// preflight requests and marking the responses of allowed origins with the
+// Access-Control-* headers.
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Options struct {
+	// AllowedOrigins are origins such as "https://shop.example.com"; a
+	// "*" label matches any subdomain ("https://*.example.com") and "*"
+	// alone matches every origin. Empty allows none.
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders are the request headers a preflight may ask for,
+	// matched case-insensitively.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials lets requests carry cookies and Authorization.
+	AllowCredentials bool
+	// MaxAge is how long a preflight may be cached; 0 leaves it to the
+	// browser.
+	MaxAge time.Duration
+}
+
+// Handler wraps next: preflight requests are answered here and never reach
+// it, other requests from allowed origins get the CORS headers. It fails
+// for credentials on every origin, which browsers refuse.
+func Handler(next http.Handler, opts Options) (http.Handler, error) {
+	for _, origin := range opts.AllowedOrigins {
+		if origin == "*" && opts.AllowCredentials {
+			return nil, errors.New(`cors: credentials cannot be allowed for origin "*"`)
+		}
+	}
+
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+
+	var maxAge string
+	if opts.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		header := w.Header()
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+		} else {
+			header.Add("Vary", "Origin")
+		}
+
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, everyOrigin := allowOrigin(opts.AllowedOrigins, origin)
+
+		if preflight {
+			// a refused preflight carries no Access-Control-* header,
+			// which is how browsers learn of the refusal
+			if allowed && contains(opts.AllowedMethods, r.Header.Get("Access-Control-Request-Method"), false) &&
+				allowHeaders(opts.AllowedHeaders, r.Header.Get("Access-Control-Request-Headers")) {
+				setOrigin(header, origin, everyOrigin, opts.AllowCredentials)
+				header.Set("Access-Control-Allow-Methods", methods)
+				if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+					header.Set("Access-Control-Allow-Headers", requested)
+				}
+				if maxAge != "" {
+					header.Set("Access-Control-Max-Age", maxAge)
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			setOrigin(header, origin, everyOrigin, opts.AllowCredentials)
+			if exposed != "" {
+				header.Set("Access-Control-Expose-Headers", exposed)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	}), nil
+}
+
+func setOrigin(header http.Header, origin string, everyOrigin, credentials bool) {
+	if everyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowOrigin reports whether origin is allowed and whether that is
+// because every origin is.
+func allowOrigin(allowed []string, origin string) (bool, bool) {
+	for _, pattern := range allowed {
+		if pattern == "*" {
+			return true, true
+		}
+
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		if !wildcard {
+			if strings.EqualFold(pattern, origin) {
+				return true, false
+			}
+			continue
+		}
+
+		// "https://*.example.com" needs at least one label in place of *
+		if len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+			strings.HasSuffix(strings.ToLower(origin), strings.ToLower(suffix)) &&
+			!strings.ContainsAny(origin[len(prefix):len(origin)-len(suffix)], "/:") {
+			return true, false
+		}
+	}
+
+	return false, false
+}
+
+// allowHeaders reports whether every header in the comma-separated
+// requested list is allowed.
+func allowHeaders(allowed []string, requested string) bool {
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !contains(allowed, name, true) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func contains(list []string, value string, fold bool) bool {
+	for _, item := range list {
+		if item == value || (fold && strings.EqualFold(item, value)) {
+			return true
+		}
+	}
+
+	return false
+}
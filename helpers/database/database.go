@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Risuii/config"
+)
+
+// Open creates the MySQL pool described by cfg. Like sql.Open it does not
+// connect; callers ping when they need to know the database is reachable.
+func Open(cfg *config.Config) (*sql.DB, error) {
+	db, err := sql.Open("mysql", cfg.Database.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+	return db, nil
+}
+
+// WithTimeout bounds a single query. The request deadline carried by ctx
+// wins when it is sooner; otherwise ceiling applies. A zero ceiling leaves
+// ctx untouched.
+func WithTimeout(ctx context.Context, ceiling time.Duration) (context.Context, context.CancelFunc) {
+	if ceiling <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= ceiling {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, ceiling)
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/tracing"
+)
+
+const (
+	errDeadlock        = 1213
+	errLockWaitTimeout = 1205
+)
+
+// Querier is satisfied by both *sql.DB and *sql.Tx, so repositories run
+// the same statements inside or outside a transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// Conn returns the transaction carried by ctx, or db when there is none.
+func Conn(ctx context.Context, db *sql.DB) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// InTransaction reports whether ctx carries a transaction.
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return ok
+}
+
+// Error wraps a driver error as exception.ErrInternalServer while keeping
+// the cause reachable, so the transaction manager can still recognise a
+// deadlock that a repository reported.
+func Error(err error) error {
+	return fmt.Errorf("%w: %w", exception.ErrInternalServer, err)
+}
+
+type (
+	// TxManager runs a unit of work in one transaction. Repositories pick the
+	// transaction up from the context passed to fn.
+	TxManager interface {
+		WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+		WithIsolation(level sql.IsolationLevel) TxManager
+	}
+
+	txManagerImpl struct {
+		DB         *sql.DB
+		isolation  sql.IsolationLevel
+		maxRetries int
+		backoff    time.Duration
+	}
+)
+
+func NewTxManagerImpl(db *sql.DB, isolation sql.IsolationLevel, maxRetries int, backoff time.Duration) TxManager {
+	return &txManagerImpl{
+		DB:         db,
+		isolation:  isolation,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+	}
+}
+
+// ParseIsolation maps a config value such as "read-committed" to its
+// sql.IsolationLevel. An empty value selects the driver default.
+func ParseIsolation(level string) (sql.IsolationLevel, error) {
+	switch level {
+	case "":
+		return sql.LevelDefault, nil
+	case "read-uncommitted":
+		return sql.LevelReadUncommitted, nil
+	case "read-committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable-read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("unknown isolation level %q", level)
+	}
+}
+
+func (tm *txManagerImpl) WithIsolation(level sql.IsolationLevel) TxManager {
+	clone := *tm
+	clone.isolation = level
+
+	return &clone
+}
+
+// WithinTransaction commits when fn returns nil and rolls back otherwise.
+// Deadlocks and lock wait timeouts restart fn in a fresh transaction, so fn
+// must not have side effects outside the database. A context that already
+// carries a transaction joins it instead of nesting.
+func (tm *txManagerImpl) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if InTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.Start(ctx, "db.transaction")
+	defer func() { tracing.End(span, err) }()
+
+	for attempt := 0; ; attempt++ {
+		err = tm.run(ctx, fn)
+		if err == nil || !retryable(err) || attempt >= tm.maxRetries {
+			return err
+		}
+
+		// exponential backoff with jitter so competing transactions spread out
+		wait := tm.backoff << attempt
+		if wait > 0 {
+			wait += time.Duration(rand.Int63n(int64(wait)))
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (tm *txManagerImpl) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := tm.DB.BeginTx(ctx, &sql.TxOptions{Isolation: tm.isolation})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func retryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+
+	return mysqlErr.Number == errDeadlock || mysqlErr.Number == errLockWaitTimeout
+}
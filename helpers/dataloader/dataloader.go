@@ -0,0 +1,136 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchFunc loads many keys at once. Keys missing from the result load as
+// the zero value.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader collects the keys passed to Load and fetches them with a single
+// BatchFunc call the first time any of the returned thunks runs. Results
+// are cached for the life of the loader, which is meant to be one request.
+//
+// With a wait, that first thunk holds the batch until wait has passed since
+// the first pending key was queued, so callers on other goroutines, such
+// as resolvers run in parallel, can add their keys to it.
+type Loader[K comparable, V any] struct {
+	batch BatchFunc[K, V]
+	wait  time.Duration
+
+	mu      sync.Mutex
+	pending []K
+	due     time.Time
+	cache   map[K]*result[V]
+	batches int
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func New[K comparable, V any](batch BatchFunc[K, V]) *Loader[K, V] {
+	return NewWithWait(batch, 0)
+}
+
+// NewWithWait returns a Loader whose batches collect keys for wait.
+func NewWithWait[K comparable, V any](batch BatchFunc[K, V], wait time.Duration) *Loader[K, V] {
+	return &Loader[K, V]{
+		batch: batch,
+		wait:  wait,
+		cache: map[K]*result[V]{},
+	}
+}
+
+// Load queues key and returns a thunk for its value.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	res, ok := l.cache[key]
+	if !ok {
+		res = &result[V]{done: make(chan struct{})}
+		l.cache[key] = res
+		if len(l.pending) == 0 {
+			l.due = time.Now().Add(l.wait)
+		}
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.dispatch(ctx)
+		<-res.done
+
+		return res.value, res.err
+	}
+}
+
+// Prime stores a value that is already known, so loading it is free.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+
+	res := &result[V]{done: make(chan struct{}), value: value}
+	close(res.done)
+	l.cache[key] = res
+}
+
+// Batches reports how many times the batch function has been called.
+func (l *Loader[K, V]) Batches() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.batches
+}
+
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	due := l.due
+	l.mu.Unlock()
+
+	if delay := time.Until(due); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	if len(keys) > 0 {
+		l.batches++
+	}
+	l.mu.Unlock()
+
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.batch(ctx, keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		res := l.cache[key]
+		res.value, res.err = values[key], err
+
+		// A failed batch is not cached, so a later Load can retry.
+		if err != nil {
+			delete(l.cache, key)
+		}
+
+		close(res.done)
+	}
+}
@@ -0,0 +1,42 @@
+// Package deprecation marks the responses of deprecated endpoints with the
+// Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers.
+package deprecation
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Policy struct {
+	// Since is when the endpoints were deprecated.
+	Since time.Time
+	// Sunset, when not zero, is when they stop being served.
+	Sunset time.Time
+	// Successor, when set, returns the path replacing the requested one.
+	Successor func(path string) string
+}
+
+// Middleware sets the headers of policy on every response before the
+// handler writes it.
+func Middleware(policy Policy) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+
+			header.Set("Deprecation", "@"+strconv.FormatInt(policy.Since.Unix(), 10))
+
+			if !policy.Sunset.IsZero() {
+				header.Set("Sunset", policy.Sunset.UTC().Format(http.TimeFormat))
+			}
+
+			if policy.Successor != nil {
+				header.Add("Link", "<"+policy.Successor(r.URL.Path)+`>; rel="successor-version"`)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
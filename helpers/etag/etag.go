@@ -0,0 +1,78 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// New returns a strong entity tag derived from parts, which should
+// describe the representation completely (for example id and version of
+// every row).
+func New(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	return `"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+}
+
+// Match reports whether an If-Match or If-None-Match header value accepts
+// tag. An empty header or "*" matches anything; weak tags compare by value.
+func Match(header, tag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ForMediaType returns the tag of the representation of tag in mediaType,
+// as in "3f9c-msgpack", so the same state sent as JSON, MessagePack or CSV
+// does not share one strong tag.
+func ForMediaType(tag, mediaType string) string {
+	name, _, _ := strings.Cut(mediaType, ";")
+	_, subtype, _ := strings.Cut(strings.ToLower(strings.TrimSpace(name)), "/")
+	if subtype == "" || !strings.HasSuffix(tag, `"`) {
+		return tag
+	}
+
+	return strings.TrimSuffix(tag, `"`) + "-" + subtype + `"`
+}
+
+// MatchState is Match for If-Match: the tag of any representation of tag,
+// and tag itself, match, since a write depends on the state the client
+// read rather than on how it was encoded.
+func MatchState(header, tag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		if state(candidate) == state(tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// state strips the weakness and the media type ForMediaType added.
+func state(tag string) string {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if value, _, ok := strings.Cut(tag, "-"); ok {
+		return value + `"`
+	}
+
+	return tag
+}
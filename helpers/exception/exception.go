@@ -10,4 +10,17 @@ var (
 	ErrUnauthorized        = fmt.Errorf("unauthorized")
 	ErrNotPremium          = fmt.Errorf("not premium user")
 	ErrUnprocessableEntity = fmt.Errorf("UnprocessableEntity")
+	ErrServiceUnavailable  = fmt.Errorf("service unavailable")
+	ErrVersionConflict     = fmt.Errorf("version conflict")
+	ErrPreconditionFailed  = fmt.Errorf("precondition failed")
+	ErrNotAcceptable       = fmt.Errorf("not acceptable")
+	ErrBatchFailed         = fmt.Errorf("batch failed")
+	ErrBatchTooLarge       = fmt.Errorf("too many operations")
+	ErrNamaRequired        = fmt.Errorf("nama is required")
+	ErrCartEmpty           = fmt.Errorf("cart is empty")
+	ErrEntityTooLarge      = fmt.Errorf("request entity too large")
+	ErrUnsupportedMedia    = fmt.Errorf("unsupported media type")
+	ErrTooManyRequests     = fmt.Errorf("too many requests")
+	ErrForbidden           = fmt.Errorf("forbidden")
+	ErrWebhookTarget       = fmt.Errorf("webhook url must resolve to a public address")
 )
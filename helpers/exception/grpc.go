@@ -0,0 +1,32 @@
+package exception
+
+import "google.golang.org/grpc/codes"
+
+// GRPCCode maps an error of this package to the gRPC status code closest
+// to the HTTP status the REST API answers with.
+func GRPCCode(err error) codes.Code {
+	switch err {
+	case nil:
+		return codes.OK
+	case ErrBadRequest, ErrNamaRequired, ErrBatchTooLarge, ErrUnprocessableEntity, ErrUnsupportedMedia:
+		return codes.InvalidArgument
+	case ErrNotFound:
+		return codes.NotFound
+	case ErrConflicted:
+		return codes.AlreadyExists
+	case ErrVersionConflict:
+		return codes.Aborted
+	case ErrPreconditionFailed, ErrCartEmpty, ErrBatchFailed:
+		return codes.FailedPrecondition
+	case ErrUnauthorized:
+		return codes.Unauthenticated
+	case ErrNotPremium, ErrForbidden:
+		return codes.PermissionDenied
+	case ErrEntityTooLarge, ErrTooManyRequests:
+		return codes.ResourceExhausted
+	case ErrServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
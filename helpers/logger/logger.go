@@ -0,0 +1,18 @@
+package logger
+
+import (
+	"context"
+	"log"
+
+	"github.com/Risuii/helpers/tracing"
+)
+
+// Println logs like log.Println, prefixed with the trace id carried by ctx
+// so a log line can be matched to its trace.
+func Println(ctx context.Context, v ...interface{}) {
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		v = append([]interface{}{"trace_id=" + traceID}, v...)
+	}
+
+	log.Println(v...)
+}
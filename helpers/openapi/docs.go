@@ -0,0 +1,18 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// DocsHandler serves a self-contained page that renders the document at
+// /openapi.json, so the docs work without reaching a CDN.
+func DocsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(docsPage)
+	}
+}
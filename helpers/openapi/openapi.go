@@ -0,0 +1,595 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Risuii/helpers/response"
+)
+
+const Version = "3.1.0"
+
+type (
+	Document struct {
+		OpenAPI    string               `json:"openapi"`
+		Info       Info                 `json:"info"`
+		Tags       []Tag                `json:"tags,omitempty"`
+		Paths      map[string]*PathItem `json:"paths"`
+		Components Components           `json:"components"`
+
+		names  map[reflect.Type]string
+		prefix string
+	}
+
+	Info struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+
+	Tag struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// PathItem maps a lower-case HTTP method to its operation.
+	PathItem map[string]*Operation
+
+	Operation struct {
+		Tags        []string    `json:"tags,omitempty"`
+		Summary     string      `json:"summary,omitempty"`
+		Description string      `json:"description,omitempty"`
+		OperationID string      `json:"operationId,omitempty"`
+		Parameters  []Parameter `json:"parameters,omitempty"`
+		RequestBody *Body       `json:"requestBody,omitempty"`
+		Responses   Responses   `json:"responses"`
+		Deprecated  bool        `json:"deprecated,omitempty"`
+		// Security lists the alternative ways of calling the operation.
+		Security []SecurityRequirement `json:"security,omitempty"`
+	}
+
+	// SecurityRequirement maps the name of a security scheme to the scopes
+	// the operation needs with it.
+	SecurityRequirement map[string][]string
+
+	SecurityScheme struct {
+		Type         string `json:"type"`
+		Description  string `json:"description,omitempty"`
+		Scheme       string `json:"scheme,omitempty"`
+		BearerFormat string `json:"bearerFormat,omitempty"`
+		Name         string `json:"name,omitempty"`
+		In           string `json:"in,omitempty"`
+	}
+
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	Body struct {
+		Description string               `json:"description,omitempty"`
+		Required    bool                 `json:"required,omitempty"`
+		Content     map[string]MediaType `json:"content"`
+	}
+
+	MediaType struct {
+		Schema *Schema `json:"schema,omitempty"`
+	}
+
+	// Responses maps a status code to its response.
+	Responses map[string]*Response
+
+	Response struct {
+		Description string               `json:"description"`
+		Headers     map[string]*Header   `json:"headers,omitempty"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+
+	Header struct {
+		Description string  `json:"description,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	Components struct {
+		Schemas         map[string]*Schema         `json:"schemas"`
+		SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	// Schema is the JSON Schema subset the generator emits.
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 interface{}        `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Enum                 []interface{}      `json:"enum,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		AllOf                []*Schema          `json:"allOf,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		MinLength            *int               `json:"minLength,omitempty"`
+		MaxLength            *int               `json:"maxLength,omitempty"`
+		MinItems             *int               `json:"minItems,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+	}
+)
+
+// New returns an empty document with the response envelope registered as
+// the "Response" schema.
+func New(info Info) *Document {
+	d := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+		},
+		names: map[reflect.Type]string{},
+	}
+
+	d.Components.Schemas["Response"] = &Schema{
+		Type:        "object",
+		Description: "Envelope of every REST response. traceId is only set on errors.",
+		Properties: map[string]*Schema{
+			"status": {
+				Type: "string",
+				Enum: []interface{}{
+					response.StatusOK,
+					response.StatusCreated,
+					response.StatusBadRequest,
+					response.StatusUnauthorized,
+					response.StatusForbiddend,
+					response.StatusNotFound,
+					response.StatusConflicted,
+					response.StatusNotAcceptable,
+					response.StatusPreconditionFailed,
+					response.StatusEntityTooLarge,
+					response.StatusUnsupportedMedia,
+					response.StatusUnprocessableEntity,
+					response.StatusTooManyRequests,
+					response.StatusInternalServerError,
+					response.StatusServiceUnavailable,
+				},
+			},
+			"data":    {},
+			"traceId": {Type: "string"},
+		},
+		Required: []string{"status", "data"},
+	}
+
+	return d
+}
+
+// Prefixed is a view of d that adds and looks up paths under prefix, for
+// routes mounted on a subrouter. Paths and components are shared with d.
+func (d *Document) Prefixed(prefix string) *Document {
+	view := *d
+	view.prefix = d.prefix + prefix
+
+	return &view
+}
+
+// Add documents method on path. path uses OpenAPI templates ("{id}").
+func (d *Document) Add(method, path string, op *Operation) {
+	path = d.prefix + path
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+
+	(*item)[strings.ToLower(method)] = op
+}
+
+// Operation returns what was documented for method on path, or nil.
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[d.prefix+path]
+	if !ok {
+		return nil
+	}
+
+	return (*item)[strings.ToLower(method)]
+}
+
+// Schema describes the Go value v. Structs become components, referenced
+// by name; the json tags give the property names and the validate tags
+// give required fields and constraints.
+func (d *Document) Schema(v interface{}) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+// Envelope is the Response envelope with data described by v.
+func (d *Document) Envelope(v interface{}) *Schema {
+	var data *Schema
+	if v == nil {
+		data = &Schema{Type: "null"}
+	} else {
+		data = d.Schema(v)
+	}
+
+	return &Schema{
+		AllOf: []*Schema{
+			{Ref: "#/components/schemas/Response"},
+			{Type: "object", Properties: map[string]*Schema{"data": data}},
+		},
+	}
+}
+
+// JSONBody is a required JSON request body shaped like v.
+func (d *Document) JSONBody(v interface{}) *Body {
+	return &Body{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: d.Schema(v)}},
+	}
+}
+
+// JSON is a response whose envelope carries v as data, served as JSON or,
+// when the client asks for it, MessagePack.
+func (d *Document) JSON(description string, v interface{}) *Response {
+	schema := d.Envelope(v)
+
+	return &Response{
+		Description: description,
+		Content: map[string]MediaType{
+			"application/json":    {Schema: schema},
+			"application/msgpack": {Schema: schema},
+		},
+	}
+}
+
+// Error is an error response: the envelope with a null data.
+func (d *Document) Error(description string) *Response {
+	return d.JSON(description, nil)
+}
+
+// WithHeader documents a response header and returns r.
+func (r *Response) WithHeader(name, description string) *Response {
+	if r.Headers == nil {
+		r.Headers = map[string]*Header{}
+	}
+
+	r.Headers[name] = &Header{Description: description, Schema: &Schema{Type: "string"}}
+
+	return r
+}
+
+// WithCSV documents that the list r carries can also be served as CSV,
+// one row per element under a header row, and returns r.
+func (r *Response) WithCSV() *Response {
+	r.Content["text/csv"] = MediaType{Schema: &Schema{
+		Type:        "string",
+		Description: "A header row of the element fields, then one row per element.",
+	}}
+
+	return r
+}
+
+// Conditional documents the validators of a GET: ETag and Last-Modified
+// on its 200, the If-None-Match and If-Modified-Since parameters and the
+// 304 they lead to. It returns op.
+func Conditional(op *Operation) *Operation {
+	op.Parameters = append(op.Parameters,
+		HeaderParam("If-None-Match", "ETag of a copy the client holds; answered with 304 while it is current."),
+		HeaderParam("If-Modified-Since", "Last-Modified of a copy the client holds; ignored with If-None-Match."),
+	)
+
+	if ok := op.Responses["200"]; ok != nil {
+		if _, set := ok.Headers["ETag"]; !set {
+			ok.WithHeader("ETag", "Validator of the representation.")
+		}
+		ok.WithHeader("Last-Modified", "Latest update_at of the returned rows.")
+	}
+
+	op.Responses["304"] = &Response{Description: "The copy the client holds is current."}
+
+	return op
+}
+
+// PathParam is a required path parameter.
+func PathParam(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: schema}
+}
+
+func QueryParam(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func HeaderParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Integer is an int64 schema; min, when given, is its minimum.
+func Integer(min ...float64) *Schema {
+	s := &Schema{Type: "integer", Format: "int64"}
+	if len(min) > 0 {
+		s.Minimum = &min[0]
+	}
+
+	return s
+}
+
+func String() *Schema {
+	return &Schema{Type: "string"}
+}
+
+// ValidationResponses documents what Middleware refuses on every
+// operation that takes parameters or a body: 400 listing the violations
+// and, with a body, 413 and 415. A 400 the operation already documents
+// keeps its description, and its content unless that is a plain error.
+func (d *Document) ValidationResponses() {
+	for _, item := range d.Paths {
+		for _, op := range *item {
+			if op.RequestBody == nil && !hasParameters(op) {
+				continue
+			}
+
+			refused := d.JSON("The request does not match this document; data lists the violations.", []Violation{})
+			if existing, ok := op.Responses["400"]; ok {
+				refused.Description = existing.Description + " Requests that do not match this document are refused too, in the envelope; data then lists the violations."
+				// a 400 with a body of its own keeps documenting it
+				if !reflect.DeepEqual(existing.Content, d.Error("").Content) {
+					refused.Content = existing.Content
+				}
+			}
+			op.Responses["400"] = refused
+
+			if op.RequestBody == nil {
+				continue
+			}
+
+			op.Responses["413"] = d.JSON("The body is larger than the server accepts.", []Violation{})
+			op.Responses["415"] = d.JSON("The Content-Type is not one this operation accepts.", []Violation{})
+		}
+	}
+}
+
+// NegotiationResponses documents 406 on every operation answering with
+// the envelope, for an Accept header none of its media types satisfies.
+func (d *Document) NegotiationResponses() {
+	for _, item := range d.Paths {
+		for _, op := range *item {
+			for code, res := range op.Responses {
+				if _, ok := res.Content["application/msgpack"]; ok && strings.HasPrefix(code, "2") {
+					op.Responses["406"] = d.JSON("No media type the operation serves is acceptable; data lists them.", []string{})
+					break
+				}
+			}
+		}
+	}
+}
+
+// RateLimitResponses documents on every operation the 429 of the rate
+// limiter and the headers it answers with.
+func (d *Document) RateLimitResponses() {
+	for _, item := range d.Paths {
+		for _, op := range *item {
+			if _, ok := op.Responses["429"]; ok {
+				continue
+			}
+
+			op.Responses["429"] = d.Error("The client used up its quota for the route.").
+				WithHeader("Retry-After", "Seconds until a request is allowed again.").
+				WithHeader("RateLimit-Limit", "Requests the quota allows per window.").
+				WithHeader("RateLimit-Remaining", "Requests left in the window.").
+				WithHeader("RateLimit-Reset", "Seconds until the quota is whole again.").
+				WithHeader("RateLimit-Policy", "The quota, as limit;w=window in seconds.")
+		}
+	}
+}
+
+func hasParameters(op *Operation) bool {
+	for _, param := range op.Parameters {
+		if param.In == "path" || param.In == "query" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Handler serves the document as JSON.
+func Handler(d *Document) http.HandlerFunc {
+	body, err := json.Marshal(d)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		s := d.schemaOf(t.Elem())
+
+		// a nil pointer is encoded as null
+		if name, ok := s.Type.(string); ok {
+			s.Type = []string{name, "null"}
+		}
+
+		return s
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return &Schema{Ref: "#/components/schemas/" + d.component(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// component registers struct t once and returns its name.
+func (d *Document) component(t reflect.Type) string {
+	if name, ok := d.names[t]; ok {
+		return name
+	}
+
+	name := componentName(t)
+	d.names[t] = name
+
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	d.Components.Schemas[name] = s
+	d.fields(t, s)
+
+	return name
+}
+
+// componentName is the type name, prefixed with its package unless the
+// name already starts with it: product.Product is "Product", batch.Result
+// is "BatchResult".
+func componentName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+
+	if pkg == "" || strings.HasPrefix(strings.ToLower(t.Name()), strings.ToLower(pkg)) {
+		return t.Name()
+	}
+
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name()
+}
+
+func (d *Document) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// like encoding/json, embedded structs are flattened even when the
+		// embedded type is unexported
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			d.fields(field.Type, s)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := d.schemaOf(field.Type)
+		if constrain(property, field.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+
+		s.Properties[name] = property
+	}
+}
+
+// constrain applies the validate rules that have a JSON Schema equivalent
+// and reports whether the field is required. Rules after "dive" apply to
+// the items of a slice.
+func constrain(s *Schema, rules string) (required bool) {
+	if rules == "" {
+		return false
+	}
+
+	target := s
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "required":
+			required = true
+			// like validator, required refuses the zero value
+			one := 1
+			switch target.Type {
+			case "array":
+				target.MinItems = &one
+			case "string":
+				target.MinLength = &one
+			}
+		case "dive":
+			if target.Items != nil {
+				target = target.Items
+			}
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				target.Enum = append(target.Enum, value)
+			}
+		case "url":
+			target.Format = "uri"
+		case "startswith":
+			target.Pattern = "^" + param
+		case "min", "max":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+
+			switch target.Type {
+			case "integer", "number":
+				if name == "min" {
+					target.Minimum = &n
+				} else {
+					target.Maximum = &n
+				}
+			case "string":
+				length := int(n)
+				if name == "min" {
+					target.MinLength = &length
+				} else {
+					target.MaxLength = &length
+				}
+			case "array":
+				items := int(n)
+				if name == "min" {
+					target.MinItems = &items
+				}
+			}
+		}
+	}
+
+	return required
+}
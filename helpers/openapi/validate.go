@@ -0,0 +1,511 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+)
+
+// maxViolations bounds how many problems one response reports.
+const maxViolations = 20
+
+// Violation is one way a request does not match the document.
+type Violation struct {
+	// In is "path", "query", "header" or "body".
+	In string `json:"in"`
+	// Field is the parameter name, or a JSON pointer into the body.
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Middleware validates every request against the operation documented
+// for its route before the handler runs: parameters, content type, body
+// size and the body itself. A request that does not match gets a 400 (or
+// 413, 415) listing the violations. Routes missing from doc pass through.
+func Middleware(doc *Document, maxBodyBytes int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := mux.CurrentRoute(r)
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			template, err := route.GetPathTemplate()
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			op := doc.Operation(r.Method, PathTemplate(template))
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			v := &validation{doc: doc}
+
+			v.parameters(op, r)
+
+			if op.RequestBody != nil {
+				status, body := v.body(op.RequestBody, w, r, maxBodyBytes)
+				if status != "" {
+					reject(w, r, status, v.violations)
+					return
+				}
+
+				if body != nil {
+					r.Body = io.NopCloser(bytes.NewReader(body))
+				}
+			}
+
+			if len(v.violations) > 0 {
+				reject(w, r, response.StatusBadRequest, v.violations)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// PathTemplate turns a mux template into an OpenAPI one by dropping the
+// patterns of its variables: "/cart/{id:[0-9]+}" becomes "/cart/{id}".
+func PathTemplate(template string) string {
+	var b strings.Builder
+
+	depth := 0
+	skipping := false
+	for _, c := range template {
+		switch {
+		case c == '{':
+			depth++
+			if depth == 1 {
+				skipping = false
+			}
+		case c == '}':
+			depth--
+			if depth == 0 {
+				skipping = false
+			}
+		case c == ':' && depth == 1:
+			skipping = true
+		}
+
+		if skipping && depth > 0 {
+			continue
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+func reject(w http.ResponseWriter, r *http.Request, status string, violations []Violation) {
+	var err error
+	switch status {
+	case response.StatusEntityTooLarge:
+		err = exception.ErrEntityTooLarge
+	case response.StatusUnsupportedMedia:
+		err = exception.ErrUnsupportedMedia
+	default:
+		err = exception.ErrBadRequest
+	}
+
+	response.ErrorWithData(status, err, violations).Write(w, r)
+}
+
+type validation struct {
+	doc        *Document
+	violations []Violation
+}
+
+func (v *validation) add(in, field, format string, args ...interface{}) {
+	if len(v.violations) >= maxViolations {
+		return
+	}
+
+	v.violations = append(v.violations, Violation{In: in, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validation) parameters(op *Operation, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	for _, param := range op.Parameters {
+		var (
+			raw     string
+			present bool
+		)
+
+		switch param.In {
+		case "path":
+			raw, present = vars[param.Name]
+		case "query":
+			present = query.Has(param.Name)
+			raw = query.Get(param.Name)
+		case "header":
+			raw = r.Header.Get(param.Name)
+			present = raw != ""
+		default:
+			continue
+		}
+
+		if !present {
+			if param.Required {
+				v.add(param.In, param.Name, "is required")
+			}
+			continue
+		}
+
+		value, ok := parseParameter(raw, param.Schema)
+		if !ok {
+			v.add(param.In, param.Name, "must be %s", typeName(param.Schema))
+			continue
+		}
+
+		v.value(param.In, param.Name, param.Schema, value, 0)
+	}
+}
+
+// parseParameter reads a parameter as the JSON value its schema expects.
+func parseParameter(raw string, schema *Schema) (interface{}, bool) {
+	if schema == nil {
+		return raw, true
+	}
+
+	switch firstType(schema) {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(raw), true
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		return b, err == nil
+	default:
+		return raw, true
+	}
+}
+
+// body checks the content type and size and validates the JSON body. It
+// returns the status to reject with, if the request cannot go on, and
+// the body it read so the handler can read it again.
+func (v *validation) body(spec *Body, w http.ResponseWriter, r *http.Request, maxBodyBytes int64) (string, []byte) {
+	if maxBodyBytes > 0 && r.ContentLength > maxBodyBytes {
+		v.add("body", "", "must not be larger than %d bytes", maxBodyBytes)
+		return response.StatusEntityTooLarge, nil
+	}
+
+	reader := r.Body
+	if maxBodyBytes > 0 {
+		reader = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			v.add("body", "", "must not be larger than %d bytes", tooLarge.Limit)
+			return response.StatusEntityTooLarge, nil
+		}
+
+		v.add("body", "", "could not be read")
+		return response.StatusBadRequest, nil
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if spec.Required {
+			v.add("body", "", "is required")
+		}
+		return "", body
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	content, ok := spec.Content[mediaType]
+	if !ok {
+		var supported []string
+		for name := range spec.Content {
+			supported = append(supported, name)
+		}
+		sort.Strings(supported)
+
+		v.add("header", "Content-Type", "must be one of %s", strings.Join(supported, ", "))
+		return response.StatusUnsupportedMedia, nil
+	}
+
+	if mediaType != "application/json" || content.Schema == nil {
+		return "", body
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		v.add("body", "", "is not valid JSON: %s", err)
+		return response.StatusBadRequest, nil
+	}
+
+	if decoder.More() {
+		v.add("body", "", "has data after the JSON value")
+		return response.StatusBadRequest, nil
+	}
+
+	v.value("body", "", content.Schema, value, 0)
+
+	return "", body
+}
+
+// maxDepth stops runaway recursion through self-referencing schemas.
+const maxDepth = 32
+
+func (v *validation) value(in, field string, schema *Schema, value interface{}, depth int) {
+	if schema == nil || depth > maxDepth {
+		return
+	}
+
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		v.value(in, field, v.doc.Components.Schemas[name], value, depth+1)
+		return
+	}
+
+	for _, part := range schema.AllOf {
+		v.value(in, field, part, value, depth+1)
+	}
+
+	if types := schemaTypes(schema); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if isType(value, t) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			v.add(in, field, "must be %s", typeName(schema))
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			v.add(in, field, "must be one of %s", enumList(schema.Enum))
+			return
+		}
+	}
+
+	switch value := value.(type) {
+	case json.Number:
+		n, _ := value.Float64()
+		if schema.Minimum != nil && n < *schema.Minimum {
+			v.add(in, field, "must be at least %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			v.add(in, field, "must be at most %v", *schema.Maximum)
+		}
+		if firstType(schema) == "integer" {
+			limit := float64(math.MaxInt64)
+			if schema.Format == "int32" {
+				limit = math.MaxInt32
+			}
+			if math.Abs(n) > limit {
+				v.add(in, field, "is out of range")
+			}
+		}
+
+	case string:
+		length := len([]rune(value))
+		if schema.MinLength != nil && length < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				v.add(in, field, "must not be empty")
+			} else {
+				v.add(in, field, "must be at least %d characters", *schema.MinLength)
+			}
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			v.add(in, field, "must be at most %d characters", *schema.MaxLength)
+		}
+		if schema.Pattern != "" && !matchPattern(schema.Pattern, value) {
+			v.add(in, field, "must match %s", schema.Pattern)
+		}
+		switch schema.Format {
+		case "uri":
+			if u, err := url.ParseRequestURI(value); err != nil || u.Scheme == "" || u.Host == "" {
+				v.add(in, field, "must be an absolute URI")
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				v.add(in, field, "must be an RFC 3339 date-time")
+			}
+		}
+
+	case []interface{}:
+		if schema.MinItems != nil && len(value) < *schema.MinItems {
+			v.add(in, field, "must have at least %d items", *schema.MinItems)
+		}
+		for i, item := range value {
+			v.value(in, field+"/"+strconv.Itoa(i), schema.Items, item, depth+1)
+		}
+
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := value[name]; !ok {
+				v.add(in, field+"/"+pointerEscape(name), "is required")
+			}
+		}
+
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			pointer := field + "/" + pointerEscape(name)
+
+			if property, ok := schema.Properties[name]; ok {
+				v.value(in, pointer, property, value[name], depth+1)
+				continue
+			}
+
+			if schema.AdditionalProperties != nil {
+				v.value(in, pointer, schema.AdditionalProperties, value[name], depth+1)
+				continue
+			}
+
+			// allOf parts describe the properties of the whole value
+			if len(schema.AllOf) > 0 || schema.Properties == nil {
+				continue
+			}
+
+			v.add(in, pointer, "is not a known field")
+		}
+	}
+}
+
+func schemaTypes(schema *Schema) []string {
+	switch t := schema.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+
+	return nil
+}
+
+func firstType(schema *Schema) string {
+	if types := schemaTypes(schema); len(types) > 0 {
+		return types[0]
+	}
+
+	return ""
+}
+
+func typeName(schema *Schema) string {
+	names := schemaTypes(schema)
+	for i, name := range names {
+		switch name {
+		case "integer", "array", "object":
+			names[i] = "an " + name
+		case "null":
+			names[i] = "null"
+		default:
+			names[i] = "a " + name
+		}
+	}
+
+	return strings.Join(names, " or ")
+}
+
+func isType(value interface{}, t string) bool {
+	switch t {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+
+	return true
+}
+
+func enumList(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprint(value)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func pointerEscape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+var patterns sync.Map
+
+func matchPattern(pattern, value string) bool {
+	compiled, ok := patterns.Load(pattern)
+	if !ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return true
+		}
+		compiled, _ = patterns.LoadOrStore(pattern, re)
+	}
+
+	return compiled.(*regexp.Regexp).MatchString(value)
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Memory drops the buckets that are full again,
+// which are no different from missing ones.
+const sweepInterval = time.Minute
+
+// Memory keeps the buckets of one process. Replicas each count their own
+// requests, so behind a load balancer a client gets the quota once per
+// replica.
+type Memory struct {
+	// Now returns the current time; nil is time.Now.
+	Now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket holds its whole quota again.
+	full time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]*bucket{}}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, quota Quota) (Decision, error) {
+	now := time.Now()
+	if m.Now != nil {
+		now = m.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		for k, b := range m.buckets {
+			if !now.Before(b.full) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	rate := quota.rate()
+	limit := float64(quota.Limit)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit, updated: now}
+		m.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(limit, b.tokens+elapsed*rate)
+	}
+	b.updated = now
+
+	var decision Decision
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = duration((1 - b.tokens) / rate)
+	}
+
+	decision.Remaining = int(b.tokens)
+	decision.Reset = duration((limit - b.tokens) / rate)
+	b.full = now.Add(decision.Reset)
+
+	return decision, nil
+}
+
+func duration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,199 @@
+// Package ratelimit limits how often each client may call each route with
+// token buckets. A bucket holds up to Quota.Limit tokens and refills at
+// Limit per Period; every request takes one. The buckets live behind
+// Limiter so they can be kept in memory or in a store shared by replicas.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/helpers/requestinfo"
+	"github.com/Risuii/helpers/response"
+)
+
+// Quota allows Limit requests per Period, all of them at once when the
+// bucket is full.
+type Quota struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseQuota reads a quota written as "limit/period", such as "30/1m".
+func ParseQuota(s string) (Quota, error) {
+	limit, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	if !ok || err != nil || n < 1 {
+		return Quota{}, fmt.Errorf("quota %q is not limit/period", s)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Quota{}, fmt.Errorf("quota %q is not limit/period", s)
+	}
+
+	return Quota{Limit: n, Period: d}, nil
+}
+
+func (q Quota) String() string {
+	return strconv.Itoa(q.Limit) + "/" + q.Period.String()
+}
+
+// rate is the number of tokens added per second.
+func (q Quota) rate() float64 {
+	return float64(q.Limit) / q.Period.Seconds()
+}
+
+// Decision is the state of a bucket after a request took from it.
+type Decision struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is, for a refused request, the time until a token is
+	// available.
+	RetryAfter time.Duration
+}
+
+// Limiter keeps the buckets.
+type Limiter interface {
+	// Take takes a token from the bucket of key, created full with quota
+	// on first use.
+	Take(ctx context.Context, key string, quota Quota) (Decision, error)
+}
+
+// Quotas maps "METHOD /path" to the quota of that route. Paths are
+// OpenAPI templates, as in "POST /v1/cart/items".
+type Quotas map[string]Quota
+
+// Parse reads quotas written as "METHOD /path=limit/period", such as
+// "POST /v1/cart/items=30/1m".
+func Parse(entries []string) (Quotas, error) {
+	quotas := Quotas{}
+
+	for _, entry := range entries {
+		route, value, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		path = strings.TrimSpace(path)
+
+		if !ok || !hasPath || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("rate limit %q is not METHOD /path=limit/period", entry)
+		}
+
+		quota, err := ParseQuota(value)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %q: %w", entry, err)
+		}
+
+		quotas[strings.ToUpper(method)+" "+path] = quota
+	}
+
+	return quotas, nil
+}
+
+type Options struct {
+	// Default is the quota of the routes not in Routes, shared by all of
+	// them; a zero Limit leaves them unlimited.
+	Default Quota
+	// Routes have a bucket of their own.
+	Routes Quotas
+	// Prefix is tried in front of a route template missing from Routes,
+	// so that routes also mounted without their version prefix share the
+	// quota and the bucket of the versioned ones.
+	Prefix string
+	// Key names the client a request comes from; nil is ClientKey.
+	Key func(r *http.Request) string
+}
+
+// ClientKey names the client by the authenticated actor, user or API key,
+// falling back to the source IP for anonymous requests.
+func ClientKey(r *http.Request) string {
+	info := requestinfo.From(r.Context())
+	if info.Actor != requestinfo.ActorAnonymous {
+		return "actor:" + info.Actor
+	}
+
+	return "ip:" + info.SourceIP
+}
+
+// Middleware takes a token for every request on a matched route and
+// answers 429 when there is none. Responses carry RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy, refusals
+// Retry-After too. When the limiter fails the request is let through.
+func Middleware(limiter Limiter, opts Options) mux.MiddlewareFunc {
+	key := opts.Key
+	if key == nil {
+		key = ClientKey
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bucket, quota, ok := opts.route(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision, err := limiter.Take(r.Context(), key(r)+"|"+bucket, quota)
+			if err != nil {
+				logger.Println(r.Context(), "rate limit:", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(quota.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			header.Set("RateLimit-Reset", seconds(decision.Reset))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", quota.Limit, seconds(quota.Period)))
+
+			if !decision.Allowed {
+				header.Set("Retry-After", seconds(decision.RetryAfter))
+
+				res := response.Error(response.StatusTooManyRequests, exception.ErrTooManyRequests)
+				_ = res.Write(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// route returns the bucket and quota of the route r matched.
+func (opts Options) route(r *http.Request) (string, Quota, bool) {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			template = openapi.PathTemplate(template)
+
+			for _, name := range []string{r.Method + " " + template, r.Method + " " + opts.Prefix + template} {
+				if quota, ok := opts.Routes[name]; ok {
+					return name, quota, true
+				}
+			}
+		}
+	}
+
+	if opts.Default.Limit < 1 {
+		return "", Quota{}, false
+	}
+
+	return "*", opts.Default, true
+}
+
+// seconds rounds d up to whole seconds, as the headers count them.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
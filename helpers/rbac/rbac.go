@@ -0,0 +1,135 @@
+// Package rbac decides what a caller may do with a cart. Customers act on
+// their own cart; support and admin act on any cart, chosen with WithCart.
+// The use cases ask Authorize before touching a cart, and the cart
+// repository scopes its queries to Cart.
+package rbac
+
+import (
+	"context"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/requestinfo"
+)
+
+type (
+	Role   string
+	Action string
+)
+
+const (
+	RoleCustomer Role = "customer"
+	RoleSupport  Role = "support"
+	RoleAdmin    Role = "admin"
+
+	ActionViewCart   Action = "cart.view"
+	ActionEditCart   Action = "cart.edit"
+	ActionListCarts  Action = "carts.list"
+	ActionExpireCart Action = "cart.expire"
+
+	// CartAnonymous owns the lines of requests without credentials; it is
+	// the single cart the API served before carts had owners.
+	CartAnonymous = requestinfo.ActorAnonymous
+)
+
+// reach is how far a role may take an action.
+type reach int
+
+const (
+	reachNone reach = iota
+	reachOwn
+	reachAny
+)
+
+var policy = map[Role]map[Action]reach{
+	RoleCustomer: {
+		ActionViewCart: reachOwn,
+		ActionEditCart: reachOwn,
+	},
+	RoleSupport: {
+		ActionViewCart:  reachAny,
+		ActionEditCart:  reachAny,
+		ActionListCarts: reachAny,
+	},
+	RoleAdmin: {
+		ActionViewCart:   reachAny,
+		ActionEditCart:   reachAny,
+		ActionListCarts:  reachAny,
+		ActionExpireCart: reachAny,
+	},
+}
+
+// RoleOf returns the role of the caller. Users get the role of their
+// token, customer when it names none; API keys are trusted services,
+// already bounded by their scopes, and act as admin, as do operators and
+// the background jobs of the service.
+// Anonymous callers are customers.
+func RoleOf(ctx context.Context) Role {
+	p, ok := auth.From(ctx)
+	if !ok {
+		return RoleCustomer
+	}
+
+	if p.Kind == auth.KindAPIKey || p.Kind == auth.KindOperator || p.Kind == auth.KindSystem {
+		return RoleAdmin
+	}
+
+	if _, known := policy[Role(p.Role)]; known {
+		return Role(p.Role)
+	}
+
+	return RoleCustomer
+}
+
+// Own returns the owner of the caller's own cart.
+func Own(ctx context.Context) string {
+	if p, ok := auth.From(ctx); ok {
+		return p.Actor()
+	}
+
+	return CartAnonymous
+}
+
+type cartKey struct{}
+
+// WithCart selects the cart of owner for the rest of the request, for
+// support and admin acting on a customer's cart.
+func WithCart(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, cartKey{}, owner)
+}
+
+// Cart returns the owner of the cart the request acts on: the one
+// selected with WithCart, else the caller's own.
+func Cart(ctx context.Context) string {
+	if owner, ok := ctx.Value(cartKey{}).(string); ok {
+		return owner
+	}
+
+	return Own(ctx)
+}
+
+// Foreign reports whether the request acts on a cart other than the
+// caller's own.
+func Foreign(ctx context.Context) bool {
+	return Cart(ctx) != Own(ctx)
+}
+
+// Authorize checks action against the cart the request acts on.
+func Authorize(ctx context.Context, action Action) error {
+	return AuthorizeCart(ctx, action, Cart(ctx))
+}
+
+// AuthorizeCart checks action against the cart of owner, returning
+// exception.ErrForbidden when the role of the caller does not reach it.
+func AuthorizeCart(ctx context.Context, action Action, owner string) error {
+	switch policy[RoleOf(ctx)][action] {
+	case reachAny:
+		return nil
+	case reachOwn:
+		if owner == Own(ctx) {
+			return nil
+		}
+	}
+
+	return exception.ErrForbidden
+}
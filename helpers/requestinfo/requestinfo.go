@@ -0,0 +1,156 @@
+package requestinfo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	HeaderRequestID = "X-Request-Id"
+
+	// ActorAnonymous is recorded when no caller has been authenticated.
+	ActorAnonymous = "anonymous"
+	// ActorSystem is recorded for changes made by background jobs.
+	ActorSystem = "system"
+
+	maxRequestIDLength = 128
+)
+
+// Info describes who made a request and from where.
+type Info struct {
+	RequestID string
+	Actor     string
+	SourceIP  string
+}
+
+type contextKey struct{}
+
+// With stores info in ctx.
+func With(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// From returns the info stored in ctx. Outside a request the actor is
+// anonymous and the other fields are empty.
+func From(ctx context.Context) Info {
+	info, ok := ctx.Value(contextKey{}).(Info)
+	if !ok || info.Actor == "" {
+		info.Actor = ActorAnonymous
+	}
+
+	return info
+}
+
+// WithActor records the authenticated caller, keeping the rest of the info.
+func WithActor(ctx context.Context, actor string) context.Context {
+	info := From(ctx)
+	info.Actor = actor
+
+	return With(ctx, info)
+}
+
+// Middleware attaches Info to every request. An incoming X-Request-Id is
+// kept when it looks sane, otherwise a new one is generated; either way it
+// is echoed on the response. With trustProxy the left-most X-Forwarded-For
+// address is taken as the source IP.
+func Middleware(trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(HeaderRequestID)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+
+			w.Header().Set(HeaderRequestID, requestID)
+
+			ctx := With(r.Context(), Info{
+				RequestID: requestID,
+				Actor:     ActorAnonymous,
+				SourceIP:  sourceIP(r, trustProxy),
+			})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UnaryServerInterceptor is Middleware for the gRPC server. The request id
+// is read from the x-request-id metadata and sent back as a header.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var requestID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(HeaderRequestID); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(HeaderRequestID, requestID))
+
+		var source string
+		if p, ok := peer.FromContext(ctx); ok {
+			source = p.Addr.String()
+			if host, _, err := net.SplitHostPort(source); err == nil {
+				source = host
+			}
+		}
+
+		return handler(With(ctx, Info{
+			RequestID: requestID,
+			Actor:     ActorAnonymous,
+			SourceIP:  source,
+		}), req)
+	}
+}
+
+func sourceIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			if ip := strings.TrimSpace(strings.SplitN(forwarded, ",", 2)[0]); net.ParseIP(ip) != nil {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
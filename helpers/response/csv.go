@@ -0,0 +1,244 @@
+package response
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CSVEncoder writes the data of a successful list response as CSV: a
+// header row of the json names of the element fields, then one row per
+// element. Nested values are written as JSON. Anything else, errors
+// included, is ErrUnencodable.
+type CSVEncoder struct{}
+
+func (CSVEncoder) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (CSVEncoder) Encode(w io.Writer, res *ResponseImpl) error {
+	if res.err != nil || res.Data == nil {
+		return ErrUnencodable
+	}
+
+	list := reflect.ValueOf(res.Data)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return ErrUnencodable
+	}
+
+	columns, row := csvLayout(list)
+
+	out := csv.NewWriter(w)
+	if len(columns) > 0 {
+		if err := out.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < list.Len(); i++ {
+		record, err := row(list.Index(i))
+		if err != nil {
+			return err
+		}
+
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+
+	return out.Error()
+}
+
+// csvLayout returns the header of list and a function writing one element
+// as a row under it.
+func csvLayout(list reflect.Value) ([]string, func(reflect.Value) ([]string, error)) {
+	elem := list.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	switch {
+	case elem.Kind() == reflect.Struct && elem != reflect.TypeOf(time.Time{}):
+		fields := csvFields(elem, nil)
+
+		columns := make([]string, len(fields))
+		for i, f := range fields {
+			columns[i] = f.name
+		}
+
+		return columns, func(v reflect.Value) ([]string, error) {
+			record := make([]string, len(fields))
+
+			v = indirect(v)
+			if !v.IsValid() {
+				return record, nil
+			}
+
+			for i, f := range fields {
+				field, ok := fieldByIndex(v, f.index)
+				if !ok {
+					continue
+				}
+
+				cell, err := csvCell(field)
+				if err != nil {
+					return nil, err
+				}
+				record[i] = cell
+			}
+
+			return record, nil
+		}
+
+	case elem.Kind() == reflect.Map && elem.Key().Kind() == reflect.String:
+		seen := map[string]bool{}
+		for i := 0; i < list.Len(); i++ {
+			m := indirect(list.Index(i))
+			if !m.IsValid() {
+				continue
+			}
+
+			for _, key := range m.MapKeys() {
+				seen[key.String()] = true
+			}
+		}
+
+		columns := make([]string, 0, len(seen))
+		for key := range seen {
+			columns = append(columns, key)
+		}
+		sort.Strings(columns)
+
+		return columns, func(v reflect.Value) ([]string, error) {
+			record := make([]string, len(columns))
+
+			v = indirect(v)
+			if !v.IsValid() {
+				return record, nil
+			}
+
+			for i, column := range columns {
+				value := v.MapIndex(reflect.ValueOf(column).Convert(v.Type().Key()))
+				if !value.IsValid() {
+					continue
+				}
+
+				cell, err := csvCell(value)
+				if err != nil {
+					return nil, err
+				}
+				record[i] = cell
+			}
+
+			return record, nil
+		}
+	}
+
+	return []string{"value"}, func(v reflect.Value) ([]string, error) {
+		cell, err := csvCell(v)
+		return []string{cell}, err
+	}
+}
+
+type csvField struct {
+	name  string
+	index []int
+}
+
+// csvFields lists the fields of t the way encoding/json names them,
+// flattening embedded structs.
+func csvFields(t reflect.Type, index []int) []csvField {
+	var fields []csvField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		at := append(append([]int{}, index...), i)
+
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, csvFields(embedded, at)...)
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, csvField{name: name, index: at})
+	}
+
+	return fields
+}
+
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		v = indirect(v)
+		if !v.IsValid() {
+			return reflect.Value{}, false
+		}
+
+		v = v.Field(i)
+	}
+
+	return v, true
+}
+
+// indirect follows pointers and interfaces; a nil one gives the zero Value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func csvCell(v reflect.Value) (string, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return "", nil
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface()), nil
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,297 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Risuii/helpers/etag"
+	"github.com/Risuii/helpers/exception"
+)
+
+// ErrUnencodable is returned by an Encoder for a response that has no
+// representation in its media type, such as an object as CSV.
+var ErrUnencodable = errors.New("response has no representation in this media type")
+
+// Encoder writes a response in one media type.
+type Encoder interface {
+	// ContentType is the Content-Type the encoder writes; its media type
+	// is what Accept is matched against.
+	ContentType() string
+	Encode(w io.Writer, res *ResponseImpl) error
+}
+
+// Registry is an ordered set of encoders, one per media type. The first
+// one is used when the client has no preference.
+type Registry struct {
+	mu       sync.RWMutex
+	encoders []Encoder
+}
+
+// Encoders is the registry Write negotiates with.
+var Encoders = NewRegistry(JSONEncoder{}, MessagePackEncoder{}, CSVEncoder{})
+
+func NewRegistry(encoders ...Encoder) *Registry {
+	reg := &Registry{}
+	for _, encoder := range encoders {
+		reg.Register(encoder)
+	}
+
+	return reg
+}
+
+// Register adds encoder, replacing the one registered for the same media
+// type.
+func (reg *Registry) Register(encoder Encoder) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for i, existing := range reg.encoders {
+		if mediaType(existing) == mediaType(encoder) {
+			reg.encoders[i] = encoder
+			return
+		}
+	}
+
+	reg.encoders = append(reg.encoders, encoder)
+}
+
+// MediaTypes lists the registered media types in order.
+func (reg *Registry) MediaTypes() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	types := make([]string, len(reg.encoders))
+	for i, encoder := range reg.encoders {
+		types[i] = mediaType(encoder)
+	}
+
+	return types
+}
+
+// Negotiate orders the encoders acceptable to an Accept header, most
+// preferred first. An empty header accepts every encoder.
+func (reg *Registry) Negotiate(accept string) []Encoder {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	ranges := parseAccept(accept)
+
+	type candidate struct {
+		encoder Encoder
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, encoder := range reg.encoders {
+		quality := 1.0
+		if ranges != nil {
+			quality = qualityOf(mediaType(encoder), ranges)
+		}
+
+		if quality > 0 {
+			candidates = append(candidates, candidate{encoder, quality})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	encoders := make([]Encoder, len(candidates))
+	for i, c := range candidates {
+		encoders[i] = c.encoder
+	}
+
+	return encoders
+}
+
+// Write encodes res with the most preferred encoder able to represent it.
+// The ETag of res is made specific to the media type chosen.
+func (reg *Registry) Write(w http.ResponseWriter, req *http.Request, res *ResponseImpl) error {
+	w.Header().Add("Vary", "Accept")
+	res.prepare(w)
+
+	encoders := reg.Negotiate(req.Header.Get("Accept"))
+	if res.err != nil {
+		// errors are always representable, by the default encoder at least
+		encoders = append(encoders, reg.fallback())
+	}
+
+	var buf bytes.Buffer
+	for _, encoder := range encoders {
+		buf.Reset()
+
+		err := encoder.Encode(&buf, res)
+		if errors.Is(err, ErrUnencodable) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if tag := w.Header().Get("ETag"); tag != "" {
+			w.Header().Set("ETag", etag.ForMediaType(tag, encoder.ContentType()))
+		}
+
+		if notModified(req, res, w.Header()) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+
+		w.Header().Set("Content-Type", encoder.ContentType())
+		w.WriteHeader(res.getStatusCode(res.Status))
+
+		_, err = w.Write(buf.Bytes())
+		return err
+	}
+
+	notAcceptable := ErrorWithData(StatusNotAcceptable, exception.ErrNotAcceptable, reg.MediaTypes())
+
+	return notAcceptable.JSON(w)
+}
+
+// notModified reports whether a successful GET can be answered with 304.
+// If-None-Match is compared with the ETag; only without it is
+// If-Modified-Since compared with Last-Modified, which has a resolution
+// of a second and misses removed rows.
+func notModified(req *http.Request, res *ResponseImpl, header http.Header) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	if res.err != nil || res.getStatusCode(res.Status) != http.StatusOK {
+		return false
+	}
+
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		tag := header.Get("ETag")
+		return tag != "" && etag.Match(match, tag)
+	}
+
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	modified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	return !modified.After(since)
+}
+
+func (reg *Registry) fallback() Encoder {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	if len(reg.encoders) == 0 {
+		return JSONEncoder{}
+	}
+
+	return reg.encoders[0]
+}
+
+func mediaType(encoder Encoder) string {
+	name, _, _ := strings.Cut(encoder.ContentType(), ";")
+
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+type mediaRange struct {
+	typ, subtype string
+	quality      float64
+}
+
+// parseAccept reads the media ranges of an Accept header, skipping the
+// malformed ones. It returns nil for an empty header.
+func parseAccept(accept string) []mediaRange {
+	if strings.TrimSpace(accept) == "" {
+		return nil
+	}
+
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		name, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		typ, subtype, ok := strings.Cut(name, "/")
+		if !ok || (typ == "*" && subtype != "*") {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, quality: quality})
+	}
+
+	return ranges
+}
+
+// qualityOf is the quality the most specific range matching name gives it.
+func qualityOf(name string, ranges []mediaRange) float64 {
+	typ, subtype, _ := strings.Cut(name, "/")
+
+	quality, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > specificity {
+			quality, specificity = r.quality, s
+		}
+	}
+
+	return quality
+}
+
+// JSONEncoder writes the whole envelope as JSON.
+type JSONEncoder struct{}
+
+func (JSONEncoder) ContentType() string { return "application/json" }
+
+func (JSONEncoder) Encode(w io.Writer, res *ResponseImpl) error {
+	return json.NewEncoder(w).Encode(res)
+}
+
+// MessagePackEncoder writes the whole envelope as MessagePack, with the
+// same field names as JSON. Integers use the smallest MessagePack int that
+// holds them and times are MessagePack timestamps.
+type MessagePackEncoder struct{}
+
+func (MessagePackEncoder) ContentType() string { return "application/msgpack" }
+
+func (MessagePackEncoder) Encode(w io.Writer, res *ResponseImpl) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+
+	return encoder.Encode(res)
+}
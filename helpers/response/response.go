@@ -3,17 +3,25 @@ package response
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/Risuii/helpers/etag"
+	"github.com/Risuii/helpers/tracing"
 )
 
 type Response interface {
 	Err() (err error)
+	SetHeader(key, value string) Response
+	Header() http.Header
 	JSON(w http.ResponseWriter) (err error)
+	Write(w http.ResponseWriter, r *http.Request) (err error)
 }
 
 type ResponseImpl struct {
-	err    error
-	Status string      `json:"status"`
-	Data   interface{} `json:"data"`
+	err     error
+	header  http.Header
+	Status  string      `json:"status"`
+	Data    interface{} `json:"data"`
+	TraceID string      `json:"traceId,omitempty"`
 }
 
 func Success(status string, data interface{}) (resp Response) {
@@ -32,6 +40,14 @@ func Error(status string, err error) (resp Response) {
 	}
 }
 
+func ErrorWithData(status string, err error, data interface{}) (resp Response) {
+	return &ResponseImpl{
+		err:    err,
+		Status: status,
+		Data:   data,
+	}
+}
+
 func (r *ResponseImpl) getStatusCode(status string) (statusCode int) {
 	switch status {
 	case StatusOK:
@@ -48,10 +64,22 @@ func (r *ResponseImpl) getStatusCode(status string) (statusCode int) {
 		return http.StatusNotFound
 	case StatusConflicted:
 		return http.StatusConflict
+	case StatusNotAcceptable:
+		return http.StatusNotAcceptable
+	case StatusPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case StatusEntityTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case StatusUnsupportedMedia:
+		return http.StatusUnsupportedMediaType
 	case StatusUnprocessableEntity:
 		return http.StatusUnprocessableEntity
+	case StatusTooManyRequests:
+		return http.StatusTooManyRequests
 	case StatusInternalServerError:
 		return http.StatusInternalServerError
+	case StatusServiceUnavailable:
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
@@ -61,10 +89,55 @@ func (r *ResponseImpl) Err() (err error) {
 	return r.err
 }
 
+// SetHeader adds a header, such as an ETag, written alongside the body.
+func (r *ResponseImpl) SetHeader(key, value string) Response {
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+
+	r.header.Set(key, value)
+
+	return r
+}
+
+// Header returns the headers set with SetHeader.
+func (r *ResponseImpl) Header() http.Header {
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+
+	return r.header
+}
+
 func (r *ResponseImpl) JSON(w http.ResponseWriter) error {
-	statusCode := r.getStatusCode(r.Status)
+	r.prepare(w)
+
+	if tag := w.Header().Get("ETag"); tag != "" {
+		w.Header().Set("ETag", etag.ForMediaType(tag, "application/json"))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+	w.WriteHeader(r.getStatusCode(r.Status))
 
 	return json.NewEncoder(w).Encode(r)
 }
+
+// Write encodes the response in the media type the Accept header of req
+// prefers among Encoders, answering 406 when a successful response has no
+// acceptable representation. Errors fall back to JSON instead, so the
+// client still learns what went wrong.
+func (r *ResponseImpl) Write(w http.ResponseWriter, req *http.Request) error {
+	return Encoders.Write(w, req, r)
+}
+
+// prepare copies the headers set with SetHeader to w and, for errors,
+// takes the trace ID from the tracing middleware.
+func (r *ResponseImpl) prepare(w http.ResponseWriter) {
+	for key, values := range r.header {
+		w.Header()[key] = values
+	}
+
+	if r.err != nil {
+		r.TraceID = w.Header().Get(tracing.HeaderTraceID)
+	}
+}
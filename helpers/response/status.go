@@ -8,6 +8,12 @@ const (
 	StatusForbiddend          = "FORBIDDEN"
 	StatusNotFound            = "NOT_FOUND"
 	StatusConflicted          = "CONFLICTED"
+	StatusNotAcceptable       = "NOT_ACCEPTABLE"
+	StatusPreconditionFailed  = "PRECONDITION_FAILED"
+	StatusEntityTooLarge      = "REQUEST_ENTITY_TOO_LARGE"
+	StatusUnsupportedMedia    = "UNSUPPORTED_MEDIA_TYPE"
 	StatusUnprocessableEntity = "UNPROCESSABLE_ENTITY"
+	StatusTooManyRequests     = "TOO_MANY_REQUESTS"
 	StatusInternalServerError = "INTERNAL_SERVER_ERROR"
+	StatusServiceUnavailable  = "SERVICE_UNAVAILABLE"
 )
@@ -0,0 +1,72 @@
+// Package secure sets the security headers every response carries:
+// X-Content-Type-Options, X-Frame-Options, Referrer-Policy and, over HTTPS,
+// Strict-Transport-Security.
+package secure
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Options struct {
+	// HSTSMaxAge is the max-age of Strict-Transport-Security; 0 leaves
+	// the header out.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	// FrameOptions is DENY or SAMEORIGIN; empty leaves the header out.
+	FrameOptions string
+	// ReferrerPolicy is empty to leave the header out.
+	ReferrerPolicy string
+	// TrustProxyHeaders takes X-Forwarded-Proto as the scheme the client
+	// used, for TLS terminated by a proxy.
+	TrustProxyHeaders bool
+}
+
+// Handler sets the headers of opts before next writes the response;
+// next may still override them.
+func Handler(next http.Handler, opts Options) http.Handler {
+	var hsts string
+	if opts.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(opts.HSTSMaxAge.Seconds()))
+		if opts.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("X-Content-Type-Options", "nosniff")
+
+		if opts.FrameOptions != "" {
+			header.Set("X-Frame-Options", opts.FrameOptions)
+		}
+
+		if opts.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", opts.ReferrerPolicy)
+		}
+
+		// browsers ignore the header over plain HTTP (RFC 6797 section 8.1)
+		if hsts != "" && https(r, opts.TrustProxyHeaders) {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func https(r *http.Request, trustProxyHeaders bool) bool {
+	if r.TLS != nil {
+		return true
+	}
+
+	if !trustProxyHeaders {
+		return false
+	}
+
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
@@ -0,0 +1,156 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Risuii/config"
+)
+
+const (
+	instrumentationName = "github.com/Risuii"
+
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterNone   = "none"
+)
+
+// New installs the global tracer provider and the W3C trace context
+// propagator. The returned function flushes pending spans and must be
+// called before the process exits.
+func New(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.Tracing.ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	}
+
+	var closer io.Closer
+
+	switch cfg.Tracing.Exporter {
+	case ExporterOTLP:
+		var clientOpts []otlptracehttp.Option
+		if cfg.Tracing.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpointURL(cfg.Tracing.Endpoint))
+		}
+
+		exporter, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterFile:
+		file, err := os.OpenFile(cfg.Tracing.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		closer = file
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterNone, "":
+		// spans are still created so trace ids reach logs and error bodies
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Tracing.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+
+		return err
+	}, nil
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start opens an internal span, used by the use case layer.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartQuery opens a client span describing a single SQL statement.
+func StartQuery(ctx context.Context, operation, table, query string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemMySQL,
+		semconv.DBOperationName(operation),
+		semconv.DBCollectionName(table),
+	}
+
+	if query != "" {
+		attrs = append(attrs, semconv.DBQueryText(query))
+	}
+
+	return Tracer().Start(
+		ctx,
+		fmt.Sprintf("%s %s", operation, table),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// End records err on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// TraceID returns the hex trace id carried by ctx, or an empty string.
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+
+	return spanContext.TraceID().String()
+}
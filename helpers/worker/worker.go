@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Group runs background workers until it is shut down. Every worker gets a
+// context that is cancelled when Shutdown is called.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewGroup(ctx context.Context) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &Group{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Go starts fn in its own goroutine. fn should return once its context is
+// done; an error other than the cancellation itself is logged.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		if err := fn(g.ctx); err != nil && err != context.Canceled {
+			log.Printf("worker %s stopped: %v", name, err)
+		}
+	}()
+}
+
+// Every returns a worker that calls fn once per interval until its context
+// is done. An error from fn is logged and the next tick runs as usual.
+func Every(name string, interval time.Duration, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				if err := fn(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("worker %s: %v", name, err)
+				}
+			}
+		}
+	}
+}
+
+// Shutdown cancels every worker and waits for them to return, giving up
+// when ctx is done.
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,94 @@
+package apikey
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/Risuii/helpers/bodylimit"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/models/apikey"
+)
+
+type APIKeyHandler struct {
+	Validate *validator.Validate
+	UseCase  APIKeyUseCase
+}
+
+func NewAPIKeyHandler(router *mux.Router, validate *validator.Validate, usecase APIKeyUseCase) {
+	handler := APIKeyHandler{
+		Validate: validate,
+		UseCase:  usecase,
+	}
+
+	api := router.PathPrefix("/api-keys").Subrouter()
+
+	api.HandleFunc("", handler.Create).Methods(http.MethodPost)
+	api.HandleFunc("", handler.List).Methods(http.MethodGet)
+	api.HandleFunc("/{id:[0-9]+}/rotate", handler.Rotate).Methods(http.MethodPost)
+	api.HandleFunc("/{id:[0-9]+}", handler.Revoke).Methods(http.MethodDelete)
+}
+
+func (handler *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var res response.Response
+	var userInput apikey.Input
+
+	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
+		res = bodylimit.DecodeError(err)
+		res.Write(w, r)
+		return
+	}
+
+	if err := handler.Validate.StructCtx(r.Context(), userInput); err != nil {
+		res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+		res.Write(w, r)
+		return
+	}
+
+	res = handler.UseCase.Create(r.Context(), userInput)
+
+	res.Write(w, r)
+}
+
+func (handler *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	res := handler.UseCase.List(r.Context())
+
+	res.Write(w, r)
+}
+
+func (handler *APIKeyHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	res := handler.UseCase.Rotate(r.Context(), id)
+
+	res.Write(w, r)
+}
+
+func (handler *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	res := handler.UseCase.Revoke(r.Context(), id)
+
+	res.Write(w, r)
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		res := response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+		res.Write(w, r)
+		return 0, false
+	}
+
+	return id, true
+}
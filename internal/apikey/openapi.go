@@ -0,0 +1,65 @@
+package apikey
+
+import (
+	"net/http"
+
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/models/apikey"
+)
+
+// OpenAPI documents the routes of APIKeyHandler.
+func OpenAPI(doc *openapi.Document) {
+	id := openapi.PathParam("id", "Id of the API key.", openapi.Integer())
+
+	doc.Add(http.MethodPost, "/api-keys", &openapi.Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Issue an API key",
+		Description: "The whole key is only returned here; send it as X-API-Key or as a bearer token.",
+		OperationID: "createAPIKey",
+		RequestBody: doc.JSONBody(apikey.Input{}),
+		Responses: openapi.Responses{
+			"201": doc.JSON("The key, with its whole value.", apikey.APIKey{}),
+			"400": doc.Error("The input is invalid."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/api-keys", &openapi.Operation{
+		Tags:        []string{"admin"},
+		Summary:     "List API keys",
+		OperationID: "listAPIKeys",
+		Responses: openapi.Responses{
+			"200": doc.JSON("Every key, revoked ones included, without their values.", []apikey.APIKey{}).WithCSV(),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/api-keys/{id}/rotate", &openapi.Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Rotate an API key",
+		Description: "Issues a new value with the same name and scopes; the old value stops working at once.",
+		OperationID: "rotateAPIKey",
+		Parameters:  []openapi.Parameter{id},
+		Responses: openapi.Responses{
+			"200": doc.JSON("The key, with its new whole value.", apikey.APIKey{}),
+			"400": doc.Error("The id is invalid."),
+			"404": doc.Error("No such key."),
+			"409": doc.Error("The key is revoked."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodDelete, "/api-keys/{id}", &openapi.Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Revoke an API key",
+		Description: "Revoking a revoked key changes nothing.",
+		OperationID: "revokeAPIKey",
+		Parameters:  []openapi.Parameter{id},
+		Responses: openapi.Responses{
+			"200": doc.JSON("The revoked key.", apikey.APIKey{}),
+			"400": doc.Error("The id is invalid."),
+			"404": doc.Error("No such key."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+}
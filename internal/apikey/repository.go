@@ -0,0 +1,188 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/apikey"
+)
+
+type (
+	APIKeyRepository interface {
+		Create(ctx context.Context, params apikey.APIKey) (int64, error)
+		FindAll(ctx context.Context) ([]apikey.APIKey, error)
+		FindByID(ctx context.Context, id int64) (apikey.APIKey, error)
+		FindByPrefix(ctx context.Context, prefix string) (apikey.APIKey, error)
+		// Rotate replaces the prefix and hash of a key that is not revoked.
+		Rotate(ctx context.Context, params apikey.APIKey) error
+		Revoke(ctx context.Context, id int64, at time.Time) error
+		Touch(ctx context.Context, id int64, at time.Time) error
+	}
+
+	apiKeyRepositoryImpl struct {
+		DB           *sql.DB
+		tableName    string
+		queryTimeout time.Duration
+	}
+)
+
+func NewAPIKeyRepositoryImpl(db *sql.DB, tableName string, queryTimeout time.Duration) APIKeyRepository {
+	return &apiKeyRepositoryImpl{
+		DB:           db,
+		tableName:    tableName,
+		queryTimeout: queryTimeout,
+	}
+}
+
+const apiKeyColumns = `id, name, prefix, hash, scopes, created_at, update_at, last_used_at, revoked_at`
+
+func (ar *apiKeyRepositoryImpl) Create(ctx context.Context, params apikey.APIKey) (ID int64, err error) {
+	query := fmt.Sprintf(`INSERT INTO %s (name, prefix, hash, scopes, created_at, update_at) VALUES (?, ?, ?, ?, ?, ?)`, ar.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "INSERT", ar.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, ar.queryTimeout)
+	defer cancel()
+
+	result, err := database.Conn(ctx, ar.DB).ExecContext(ctx, query, params.Name, params.Prefix, params.Hash, strings.Join(params.Scopes, ","), params.CreatedAt, params.UpdateAt)
+	if err != nil {
+		logger.Println(ctx, err)
+		return ID, database.Error(err)
+	}
+
+	return result.LastInsertId()
+}
+
+func (ar *apiKeyRepositoryImpl) FindAll(ctx context.Context) (keys []apikey.APIKey, err error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s ORDER BY id`, apiKeyColumns, ar.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", ar.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, ar.queryTimeout)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, ar.DB).QueryContext(ctx, query)
+	if err != nil {
+		logger.Println(ctx, err)
+		return keys, database.Error(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			logger.Println(ctx, err)
+			return keys, database.Error(err)
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+func (ar *apiKeyRepositoryImpl) FindByID(ctx context.Context, id int64) (apikey.APIKey, error) {
+	return ar.findBy(ctx, "id", id)
+}
+
+func (ar *apiKeyRepositoryImpl) FindByPrefix(ctx context.Context, prefix string) (apikey.APIKey, error) {
+	return ar.findBy(ctx, "prefix", prefix)
+}
+
+func (ar *apiKeyRepositoryImpl) findBy(ctx context.Context, column string, value interface{}) (data apikey.APIKey, err error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s = ?`, apiKeyColumns, ar.tableName, column)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", ar.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, ar.queryTimeout)
+	defer cancel()
+
+	data, err = scanAPIKey(database.Conn(ctx, ar.DB).QueryRowContext(ctx, query, value))
+	if err == sql.ErrNoRows {
+		return data, exception.ErrNotFound
+	}
+
+	if err != nil {
+		logger.Println(ctx, err)
+		return data, database.Error(err)
+	}
+
+	return data, nil
+}
+
+func (ar *apiKeyRepositoryImpl) Rotate(ctx context.Context, params apikey.APIKey) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET prefix = ?, hash = ?, update_at = ? WHERE id = ? AND revoked_at IS NULL`, ar.tableName)
+
+	return ar.update(ctx, query, params.Prefix, params.Hash, params.UpdateAt, params.ID)
+}
+
+func (ar *apiKeyRepositoryImpl) Revoke(ctx context.Context, id int64, at time.Time) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET revoked_at = ?, update_at = ? WHERE id = ? AND revoked_at IS NULL`, ar.tableName)
+
+	return ar.update(ctx, query, at, at, id)
+}
+
+// Touch records a use of the key. It leaves update_at alone, so using a
+// key does not change the validators of the key list.
+func (ar *apiKeyRepositoryImpl) Touch(ctx context.Context, id int64, at time.Time) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET last_used_at = ? WHERE id = ?`, ar.tableName)
+
+	return ar.update(ctx, query, at, id)
+}
+
+func (ar *apiKeyRepositoryImpl) update(ctx context.Context, query string, args ...interface{}) (err error) {
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", ar.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, ar.queryTimeout)
+	defer cancel()
+
+	result, err := database.Conn(ctx, ar.DB).ExecContext(ctx, query, args...)
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected < 1 {
+		return exception.ErrNotFound
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row scanner) (k apikey.APIKey, err error) {
+	var scopes string
+
+	err = row.Scan(
+		&k.ID,
+		&k.Name,
+		&k.Prefix,
+		&k.Hash,
+		&scopes,
+		&k.CreatedAt,
+		&k.UpdateAt,
+		&k.LastUsedAt,
+		&k.RevokedAt,
+	)
+
+	if scopes != "" {
+		k.Scopes = strings.Split(scopes, ",")
+	}
+
+	return k, err
+}
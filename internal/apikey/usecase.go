@@ -0,0 +1,269 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/apikey"
+)
+
+const (
+	// DefaultUsageInterval is how stale last_used_at may get, so that a
+	// busy key is not written on every request.
+	DefaultUsageInterval = time.Minute
+
+	prefixBytes = 4
+	secretBytes = 24
+)
+
+type (
+	APIKeyUseCase interface {
+		Create(ctx context.Context, params apikey.Input) response.Response
+		List(ctx context.Context) response.Response
+		Rotate(ctx context.Context, id int64) response.Response
+		Revoke(ctx context.Context, id int64) response.Response
+		// VerifyKey makes the use case an auth.KeyVerifier.
+		VerifyKey(ctx context.Context, key string) (auth.Principal, error)
+	}
+
+	// Options tunes the use case; zero values fall back to the defaults.
+	Options struct {
+		UsageInterval time.Duration
+	}
+
+	apiKeyUseCaseImpl struct {
+		repo APIKeyRepository
+		tx   database.TxManager
+		opts Options
+	}
+)
+
+func NewAPIKeyUseCaseImpl(repo APIKeyRepository, tx database.TxManager, opts Options) APIKeyUseCase {
+	if opts.UsageInterval <= 0 {
+		opts.UsageInterval = DefaultUsageInterval
+	}
+
+	return &apiKeyUseCaseImpl{
+		repo: repo,
+		tx:   tx,
+		opts: opts,
+	}
+}
+
+// Create issues a key. The whole key is only returned here.
+func (au *apiKeyUseCaseImpl) Create(ctx context.Context, params apikey.Input) response.Response {
+	ctx, span := tracing.Start(ctx, "APIKeyUseCase.Create")
+	defer span.End()
+
+	now := time.Now()
+	data := apikey.APIKey{
+		Name:      params.Name,
+		Scopes:    params.Scopes,
+		CreatedAt: now,
+		UpdateAt:  now,
+	}
+
+	if err := issue(&data); err != nil {
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+
+	ID, err := au.repo.Create(ctx, data)
+	if err != nil {
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+
+	data.ID = ID
+
+	return response.Success(response.StatusCreated, data)
+}
+
+func (au *apiKeyUseCaseImpl) List(ctx context.Context) response.Response {
+	ctx, span := tracing.Start(ctx, "APIKeyUseCase.List")
+	defer span.End()
+
+	data, err := au.repo.FindAll(ctx)
+	if err != nil {
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+
+	if data == nil {
+		data = []apikey.APIKey{}
+	}
+
+	return response.Success(response.StatusOK, data)
+}
+
+// Rotate gives a key a new prefix and secret, keeping its name and
+// scopes; the old key stops working at once. Revoked keys cannot be
+// rotated.
+func (au *apiKeyUseCaseImpl) Rotate(ctx context.Context, id int64) response.Response {
+	ctx, span := tracing.Start(ctx, "APIKeyUseCase.Rotate")
+	defer span.End()
+
+	var data apikey.APIKey
+
+	err := au.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := au.repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if current.Revoked() {
+			return exception.ErrConflicted
+		}
+
+		data = current
+		data.UpdateAt = time.Now()
+		if err := issue(&data); err != nil {
+			return err
+		}
+
+		return au.repo.Rotate(ctx, data)
+	})
+
+	if err != nil {
+		return failure(err)
+	}
+
+	return response.Success(response.StatusOK, data)
+}
+
+// Revoke stops a key from authenticating. Revoking it again changes
+// nothing.
+func (au *apiKeyUseCaseImpl) Revoke(ctx context.Context, id int64) response.Response {
+	ctx, span := tracing.Start(ctx, "APIKeyUseCase.Revoke")
+	defer span.End()
+
+	var data apikey.APIKey
+
+	err := au.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := au.repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		data = current
+		if data.Revoked() {
+			return nil
+		}
+
+		now := time.Now()
+		data.RevokedAt = &now
+		data.UpdateAt = now
+
+		return au.repo.Revoke(ctx, id, now)
+	})
+
+	if err != nil {
+		return failure(err)
+	}
+
+	return response.Success(response.StatusOK, data)
+}
+
+// VerifyKey resolves a whole key to its principal and records its use.
+func (au *apiKeyUseCaseImpl) VerifyKey(ctx context.Context, key string) (auth.Principal, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyUseCase.VerifyKey")
+	defer span.End()
+
+	prefix, secret, ok := parse(key)
+	if !ok {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+
+	data, err := au.repo.FindByPrefix(ctx, prefix)
+	if err == exception.ErrNotFound {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	if data.Revoked() || subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(data.Hash)) != 1 {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if data.LastUsedAt == nil || now.Sub(*data.LastUsedAt) >= au.opts.UsageInterval {
+		// a lost usage timestamp is no reason to refuse the request
+		if err := au.repo.Touch(ctx, data.ID, now); err != nil {
+			logger.Println(ctx, "api key usage:", err)
+		}
+	}
+
+	// the prefix changes on rotation, the id does not
+	return auth.Principal{
+		Kind:    auth.KindAPIKey,
+		Subject: strconv.FormatInt(data.ID, 10),
+		Scopes:  data.Scopes,
+	}, nil
+}
+
+// issue sets a new prefix, key and hash on data.
+func issue(data *apikey.APIKey) error {
+	prefix, err := randomHex(prefixBytes)
+	if err != nil {
+		return err
+	}
+
+	secret, err := randomHex(secretBytes)
+	if err != nil {
+		return err
+	}
+
+	data.Prefix = prefix
+	data.Key = apikey.KeyPrefix + "_" + prefix + "_" + secret
+	data.Hash = hash(secret)
+
+	return nil
+}
+
+// parse splits "hk_<prefix>_<secret>".
+func parse(key string) (string, string, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apikey.KeyPrefix || len(parts[1]) != 2*prefixBytes || parts[2] == "" {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
+
+// hash is enough for secrets of this length; a slow hash only helps
+// against guessing low-entropy ones.
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func failure(err error) response.Response {
+	switch err {
+	case exception.ErrNotFound:
+		return response.Error(response.StatusNotFound, exception.ErrNotFound)
+	case exception.ErrConflicted:
+		return response.Error(response.StatusConflicted, exception.ErrConflicted)
+	default:
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/cartevent"
+)
+
+type (
+	// AccessRepository keeps the record of support and admin reaching
+	// carts that are not theirs.
+	AccessRepository interface {
+		Append(ctx context.Context, access cartevent.Access) error
+	}
+
+	accessRepositoryImpl struct {
+		DB           *sql.DB
+		tableName    string
+		queryTimeout time.Duration
+	}
+)
+
+func NewAccessRepositoryImpl(db *sql.DB, tableName string, queryTimeout time.Duration) AccessRepository {
+	return &accessRepositoryImpl{
+		DB:           db,
+		tableName:    tableName,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// Append inserts access. Called inside a unit of work it joins that
+// transaction.
+func (ar *accessRepositoryImpl) Append(ctx context.Context, access cartevent.Access) (err error) {
+	query := fmt.Sprintf(`INSERT INTO %s (actor, role, action, owner, request_id, source_ip, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`, ar.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "INSERT", ar.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, ar.queryTimeout)
+	defer cancel()
+
+	if _, err = database.Conn(ctx, ar.DB).ExecContext(ctx, query, access.Actor, access.Role, access.Action, access.Owner, access.RequestID, access.SourceIP, access.CreatedAt); err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	return nil
+}
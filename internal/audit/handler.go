@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+)
+
+type AuditHandler struct {
+	UseCase AuditUseCase
+}
+
+func NewAuditHandler(router *mux.Router, usecase AuditUseCase) {
+	handler := AuditHandler{
+		UseCase: usecase,
+	}
+
+	router.HandleFunc("/cart/{id:[0-9]+}/history", handler.History).Methods(http.MethodGet)
+}
+
+func (handler *AuditHandler) History(w http.ResponseWriter, r *http.Request) {
+	var res response.Response
+
+	cartID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+		res.Write(w, r)
+		return
+	}
+
+	page, pageSize := 1, 0
+	query := r.URL.Query()
+
+	if raw := query.Get("page"); raw != "" {
+		if page, err = strconv.Atoi(raw); err != nil {
+			res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+			res.Write(w, r)
+			return
+		}
+	}
+
+	if raw := query.Get("pageSize"); raw != "" {
+		if pageSize, err = strconv.Atoi(raw); err != nil {
+			res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+			res.Write(w, r)
+			return
+		}
+	}
+
+	res = handler.UseCase.History(r.Context(), cartID, page, pageSize)
+
+	res.Write(w, r)
+}
@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/models/cartevent"
+)
+
+// OpenAPI documents the routes of AuditHandler.
+func OpenAPI(doc *openapi.Document) {
+	doc.Add(http.MethodGet, "/cart/{id}/history", &openapi.Operation{
+		Tags:        []string{"cart"},
+		Summary:     "History of a cart line",
+		Description: "Every recorded change of the line, newest first.",
+		OperationID: "history",
+		Parameters: []openapi.Parameter{
+			openapi.PathParam("id", "Id of the cart line.", openapi.Integer()),
+			openapi.QueryParam("page", "1-based page, default 1.", openapi.Integer(1)),
+			openapi.QueryParam("pageSize", "Events per page, default 20, at most 100.", openapi.Integer(1)),
+		},
+		Responses: openapi.Responses{
+			"200": doc.JSON("One page of events.", cartevent.History{}),
+			"400": doc.Error("The page or pageSize is out of range."),
+			"404": doc.Error("The line has no history."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+}
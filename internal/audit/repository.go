@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/cartevent"
+)
+
+type (
+	AuditRepository interface {
+		Append(ctx context.Context, events ...cartevent.Event) error
+		FindByCartID(ctx context.Context, cartID int64, limit, offset int) ([]cartevent.Event, error)
+		CountByCartID(ctx context.Context, cartID int64) (int64, error)
+	}
+
+	auditRepositoryImpl struct {
+		DB           *sql.DB
+		tableName    string
+		queryTimeout time.Duration
+	}
+)
+
+func NewAuditRepositoryImpl(db *sql.DB, tableName string, queryTimeout time.Duration) AuditRepository {
+	return &auditRepositoryImpl{
+		DB:           db,
+		tableName:    tableName,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// Append inserts events with one statement. Called inside a unit of work it
+// joins that transaction, so the events commit or roll back with the change
+// they describe.
+func (ar *auditRepositoryImpl) Append(ctx context.Context, events ...cartevent.Event) (err error) {
+	if len(events) == 0 {
+		return nil
+	}
+
+	values := strings.TrimSuffix(strings.Repeat("(?,?,?,?,?,?,?,?,?),", len(events)), ",")
+	query := fmt.Sprintf(`INSERT INTO %s (cart_id, kodeProduk, action, actor, request_id, source_ip, kuantitas_before, kuantitas_after, created_at) VALUES %s`, ar.tableName, values)
+
+	ctx, span := tracing.StartQuery(ctx, "INSERT", ar.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, ar.queryTimeout)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(events)*9)
+	for _, e := range events {
+		args = append(args, e.CartID, e.KodeProduk, e.Action, e.Actor, e.RequestID, e.SourceIP, e.KuantitasBefore, e.KuantitasAfter, e.CreatedAt)
+	}
+
+	if _, err = database.Conn(ctx, ar.DB).ExecContext(ctx, query, args...); err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+// FindByCartID returns a page of the line's events, newest first.
+func (ar *auditRepositoryImpl) FindByCartID(ctx context.Context, cartID int64, limit, offset int) (events []cartevent.Event, err error) {
+	query := fmt.Sprintf(`SELECT id, cart_id, kodeProduk, action, actor, request_id, source_ip, kuantitas_before, kuantitas_after, created_at FROM %s WHERE cart_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`, ar.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", ar.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, ar.queryTimeout)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, ar.DB).QueryContext(ctx, query, cartID, limit, offset)
+	if err != nil {
+		logger.Println(ctx, err)
+		return events, database.Error(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var e cartevent.Event
+		if err := rows.Scan(
+			&e.ID,
+			&e.CartID,
+			&e.KodeProduk,
+			&e.Action,
+			&e.Actor,
+			&e.RequestID,
+			&e.SourceIP,
+			&e.KuantitasBefore,
+			&e.KuantitasAfter,
+			&e.CreatedAt,
+		); err != nil {
+			logger.Println(ctx, err)
+			return events, database.Error(err)
+		}
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+func (ar *auditRepositoryImpl) CountByCartID(ctx context.Context, cartID int64) (total int64, err error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE cart_id = ?`, ar.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", ar.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, ar.queryTimeout)
+	defer cancel()
+
+	if err = database.Conn(ctx, ar.DB).QueryRowContext(ctx, query, cartID).Scan(&total); err != nil {
+		logger.Println(ctx, err)
+		return 0, database.Error(err)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/cartevent"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type (
+	AuditUseCase interface {
+		History(ctx context.Context, cartID int64, page, pageSize int) response.Response
+	}
+
+	// Lines decides who may see a cart line; the cart use case is one.
+	Lines interface {
+		// AuthorizeLine returns exception.ErrNotFound unless the caller
+		// may view the cart holding line cartID.
+		AuthorizeLine(ctx context.Context, cartID int64) error
+	}
+
+	auditUseCaseImpl struct {
+		repo  AuditRepository
+		lines Lines
+	}
+)
+
+func NewAuditUseCaseImpl(repo AuditRepository, lines Lines) AuditUseCase {
+	return &auditUseCaseImpl{
+		repo:  repo,
+		lines: lines,
+	}
+}
+
+// History returns one page of a cart line's events, newest first. page
+// starts at 1; a zero pageSize means DefaultPageSize. Lines of carts the
+// caller may not view are not found.
+func (au *auditUseCaseImpl) History(ctx context.Context, cartID int64, page, pageSize int) response.Response {
+	ctx, span := tracing.Start(ctx, "AuditUseCase.History")
+	defer span.End()
+
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+
+	if page < 1 || pageSize < 1 || pageSize > MaxPageSize {
+		return response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+	}
+
+	err := au.lines.AuthorizeLine(ctx, cartID)
+	if err == exception.ErrNotFound {
+		return response.Error(response.StatusNotFound, exception.ErrNotFound)
+	}
+
+	if err != nil {
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+
+	total, err := au.repo.CountByCartID(ctx, cartID)
+	if err != nil {
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+
+	if total == 0 {
+		return response.Error(response.StatusNotFound, exception.ErrNotFound)
+	}
+
+	events, err := au.repo.FindByCartID(ctx, cartID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+
+	if events == nil {
+		events = []cartevent.Event{}
+	}
+
+	return response.Success(response.StatusOK, cartevent.History{
+		Events:   events,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
@@ -0,0 +1,109 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/Risuii/helpers/bodylimit"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/helpers/response"
+	v1 "github.com/Risuii/internal/cart/v1"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/filter"
+)
+
+// CartAdminHandler lets support and admin find any cart and act on it
+// as its owner would.
+type CartAdminHandler struct {
+	Validate *validator.Validate
+	UseCase  CartUseCase
+}
+
+func NewCartAdminHandler(router *mux.Router, validate *validator.Validate, usecase CartUseCase) {
+	handler := CartAdminHandler{
+		Validate: validate,
+		UseCase:  usecase,
+	}
+
+	api := router.PathPrefix("/carts").Subrouter()
+
+	api.HandleFunc("", handler.ListCarts).Methods(http.MethodGet)
+	api.HandleFunc("/{owner}", handler.GetCart).Methods(http.MethodGet)
+	api.HandleFunc("/{owner}/items:batch", handler.BatchItems).Methods(http.MethodPost)
+	api.HandleFunc("/{owner}/expire", handler.Expire).Methods(http.MethodPost)
+}
+
+func (handler *CartAdminHandler) ListCarts(w http.ResponseWriter, r *http.Request) {
+	var res response.Response
+	var err error
+
+	query := r.URL.Query()
+	params := cartsummary.Filter{Q: query.Get("q"), Page: 1}
+
+	if raw := query.Get("page"); raw != "" {
+		if params.Page, err = strconv.Atoi(raw); err != nil {
+			res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+			res.Write(w, r)
+			return
+		}
+	}
+
+	if raw := query.Get("pageSize"); raw != "" {
+		if params.PageSize, err = strconv.Atoi(raw); err != nil {
+			res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+			res.Write(w, r)
+			return
+		}
+	}
+
+	res = handler.UseCase.ListCarts(r.Context(), params)
+
+	res.Write(w, r)
+}
+
+func (handler *CartAdminHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	res := handler.UseCase.GetItems(ownerContext(r), filter.Filter{})
+
+	v1.Present(res).Write(w, r)
+}
+
+func (handler *CartAdminHandler) BatchItems(w http.ResponseWriter, r *http.Request) {
+	var res response.Response
+	var userInput v1.BatchRequest
+
+	ctx := ownerContext(r)
+
+	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
+		res = bodylimit.DecodeError(err)
+		res.Write(w, r)
+		return
+	}
+
+	err := handler.Validate.StructCtx(ctx, userInput)
+	if err != nil {
+		res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+		res.Write(w, r)
+		return
+	}
+
+	res = handler.UseCase.BatchItems(ctx, userInput.Batch(), r.Header.Get("If-Match"))
+
+	v1.Present(res).Write(w, r)
+}
+
+func (handler *CartAdminHandler) Expire(w http.ResponseWriter, r *http.Request) {
+	res := handler.UseCase.Expire(ownerContext(r))
+
+	v1.Present(res).Write(w, r)
+}
+
+// ownerContext selects the cart named by the path for the use case.
+func ownerContext(r *http.Request) context.Context {
+	return rbac.WithCart(r.Context(), mux.Vars(r)["owner"])
+}
@@ -0,0 +1,207 @@
+package cart
+
+import (
+	"time"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/cartevent"
+	"github.com/Risuii/models/product"
+)
+
+type (
+	// batchLine is the state of one cart line while a batch is planned.
+	batchLine struct {
+		item     product.Product
+		original int64
+		stored   bool
+		removed  bool
+		dirty    bool
+	}
+
+	// batchPlan replays batch operations in memory so the result can be
+	// written with one statement per kind of change.
+	batchPlan struct {
+		lines map[string]*batchLine
+		order []string
+	}
+)
+
+func newBatchPlan(existing []product.Product) *batchPlan {
+	plan := &batchPlan{
+		lines: make(map[string]*batchLine, len(existing)),
+	}
+
+	for _, item := range existing {
+		plan.lines[item.KodeProduk] = &batchLine{item: item, original: item.Kuantitas, stored: true}
+		plan.order = append(plan.order, item.KodeProduk)
+	}
+
+	return plan
+}
+
+// apply runs every operation in order. A failing operation leaves the plan
+// untouched and is reported in its result.
+func (p *batchPlan) apply(ops []batch.Operation) (results []batch.Result, failed bool) {
+	results = make([]batch.Result, 0, len(ops))
+
+	for i, op := range ops {
+		result := batch.Result{
+			Index:      i,
+			Op:         op.Op,
+			KodeProduk: op.KodeProduk,
+			Status:     response.StatusOK,
+		}
+
+		if status, err := p.applyOne(op); err != nil {
+			result.Status = status
+			result.Error = err.Error()
+			failed = true
+		}
+
+		results = append(results, result)
+	}
+
+	return results, failed
+}
+
+func (p *batchPlan) applyOne(op batch.Operation) (string, error) {
+	line, ok := p.lines[op.KodeProduk]
+	present := ok && !line.removed
+
+	switch op.Op {
+	case batch.OpAdd:
+		switch {
+		case present:
+			line.item.Kuantitas += op.Kuantitas
+		case ok:
+			line.removed = false
+			line.item.Kuantitas = op.Kuantitas
+			if op.Nama != "" {
+				line.item.Nama = op.Nama
+			}
+		default:
+			if op.Nama == "" {
+				return response.StatusBadRequest, exception.ErrNamaRequired
+			}
+
+			line = &batchLine{item: product.Product{
+				Nama:       op.Nama,
+				KodeProduk: op.KodeProduk,
+				Kuantitas:  op.Kuantitas,
+			}}
+			p.lines[op.KodeProduk] = line
+			p.order = append(p.order, op.KodeProduk)
+		}
+	case batch.OpSet:
+		if !present {
+			return response.StatusNotFound, exception.ErrNotFound
+		}
+
+		if op.Kuantitas == 0 {
+			line.removed = true
+		}
+
+		line.item.Kuantitas = op.Kuantitas
+	case batch.OpRemove:
+		if !present {
+			return response.StatusNotFound, exception.ErrNotFound
+		}
+
+		line.removed = true
+	default:
+		return response.StatusBadRequest, exception.ErrBadRequest
+	}
+
+	line.dirty = true
+
+	return response.StatusOK, nil
+}
+
+// changes splits the plan into rows to insert, rows to update and ids to
+// delete.
+func (p *batchPlan) changes(now time.Time) (inserts, updates []product.Product, deletes []int64) {
+	for _, kodeProduk := range p.order {
+		line := p.lines[kodeProduk]
+
+		switch {
+		case line.stored && line.removed:
+			deletes = append(deletes, line.item.ID)
+		case line.stored && line.dirty:
+			item := line.item
+			item.UpdateAt = now
+			updates = append(updates, item)
+		case !line.stored && !line.removed:
+			item := line.item
+			item.CreatedAt = now
+			inserts = append(inserts, item)
+		}
+	}
+
+	return inserts, updates, deletes
+}
+
+// events describes the written changes for the audit trail. current maps
+// the kept lines, as re-read after the writes, by kodeProduk so inserted
+// lines carry their new id.
+func (p *batchPlan) events(current map[string]product.Product) []cartevent.Event {
+	var events []cartevent.Event
+
+	for _, kodeProduk := range p.order {
+		line := p.lines[kodeProduk]
+
+		switch {
+		case line.stored && line.removed:
+			events = append(events, cartevent.Event{
+				CartID:          line.item.ID,
+				KodeProduk:      kodeProduk,
+				Action:          cartevent.ActionDelete,
+				KuantitasBefore: line.original,
+			})
+		case line.stored && line.dirty:
+			events = append(events, cartevent.Event{
+				CartID:          line.item.ID,
+				KodeProduk:      kodeProduk,
+				Action:          cartevent.ActionUpdateKuantitas,
+				KuantitasBefore: line.original,
+				KuantitasAfter:  line.item.Kuantitas,
+			})
+		case !line.stored && !line.removed:
+			events = append(events, cartevent.Event{
+				CartID:         current[kodeProduk].ID,
+				KodeProduk:     kodeProduk,
+				Action:         cartevent.ActionAdd,
+				KuantitasAfter: line.item.Kuantitas,
+			})
+		}
+	}
+
+	return events
+}
+
+// kept lists the kodeProduk of every line that survives the batch.
+func (p *batchPlan) kept() []string {
+	var kodeProduks []string
+	for _, kodeProduk := range p.order {
+		if !p.lines[kodeProduk].removed {
+			kodeProduks = append(kodeProduks, kodeProduk)
+		}
+	}
+
+	return kodeProduks
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,450 @@
+package cart
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/dataloader"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/filter"
+	"github.com/Risuii/models/product"
+)
+
+// SchemaSDL is the GraphQL schema of the cart.
+//
+//go:embed schema.graphql
+var SchemaSDL string
+
+// productsWait is how long a product lookup waits for the lookups of the
+// sibling fields, which graphql-go resolves in parallel, to batch with.
+const productsWait = 5 * time.Millisecond
+
+// CartGraphQLHandler serves the cart schema at /graphql on the same use
+// case as CartHandler.
+type CartGraphQLHandler struct {
+	Schema  *graphql.Schema
+	UseCase CartUseCase
+}
+
+// GraphQLRequest is the body of POST /graphql.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+func NewCartGraphQLHandler(router *mux.Router, usecase CartUseCase) error {
+	schema, err := NewCartSchema(usecase)
+	if err != nil {
+		return err
+	}
+
+	handler := CartGraphQLHandler{
+		Schema:  schema,
+		UseCase: usecase,
+	}
+
+	router.HandleFunc("/graphql", handler.Serve).Methods(http.MethodGet, http.MethodPost)
+	router.HandleFunc("/graphql/schema", handler.SDL).Methods(http.MethodGet)
+
+	return nil
+}
+
+// NewCartSchema parses SchemaSDL; every resolver goes through usecase.
+func NewCartSchema(usecase CartUseCase) (*graphql.Schema, error) {
+	return graphql.ParseSchema(SchemaSDL, &rootResolver{usecase: usecase}, graphql.UseStringDescriptions())
+}
+
+// Serve runs a query or mutation. Requests that cannot be executed get a
+// 400 with only "errors"; executed ones get a 200 even when some fields
+// failed. Mutations are only accepted over POST, and need cart:write
+// from an authenticated caller.
+func (handler *CartGraphQLHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	var req GraphQLRequest
+
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				writeGraphQLErrors(w, http.StatusBadRequest, "Variables are invalid JSON.")
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeGraphQLErrors(w, http.StatusRequestEntityTooLarge, "Body is too large.")
+			return
+		}
+
+		writeGraphQLErrors(w, http.StatusBadRequest, "Body is not a valid GraphQL request.")
+		return
+	}
+
+	guard := &mutationGuard{}
+
+	// the route only asks for cart:read, which is not enough to mutate
+	if r.Method == http.MethodGet {
+		guard.status, guard.message = http.StatusMethodNotAllowed, "Mutations are only accepted over POST."
+	} else if p, ok := auth.From(r.Context()); ok && !p.HasScope(auth.ScopeCartWrite) {
+		guard.status, guard.message = http.StatusForbidden, "Mutations need the "+auth.ScopeCartWrite+" scope."
+	}
+
+	ctx := context.WithValue(WithLoaders(r.Context(), handler.UseCase), mutationGuardKey{}, guard)
+
+	result := handler.Schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	switch {
+	case guard.refused:
+		if guard.status == http.StatusMethodNotAllowed {
+			w.Header().Set("Allow", http.MethodPost)
+		}
+
+		writeGraphQLErrors(w, guard.status, guard.message)
+	case len(result.Data) == 0:
+		// parsing or validation failed, nothing ran
+		writeGraphQL(w, http.StatusBadRequest, &graphql.Response{Errors: result.Errors})
+	default:
+		writeGraphQL(w, http.StatusOK, result)
+	}
+}
+
+// SDL serves the schema for tooling that does not use introspection.
+func (handler *CartGraphQLHandler) SDL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(SchemaSDL))
+}
+
+func writeGraphQL(w http.ResponseWriter, status int, result *graphql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(result)
+}
+
+func writeGraphQLErrors(w http.ResponseWriter, status int, message string) {
+	writeGraphQL(w, status, &graphql.Response{Errors: []*gqlerrors.QueryError{{Message: message}}})
+}
+
+// mutationGuard refuses every mutation of a request that may not mutate.
+// Serve then answers with its status rather than the result; mutations
+// run one after the other, so none of them has run by then.
+type mutationGuard struct {
+	status  int
+	message string
+	refused bool
+}
+
+type mutationGuardKey struct{}
+
+func allowMutation(ctx context.Context) error {
+	guard, ok := ctx.Value(mutationGuardKey{}).(*mutationGuard)
+	if !ok || guard.status == 0 {
+		return nil
+	}
+
+	guard.refused = true
+
+	return errors.New(guard.message)
+}
+
+// Loaders batch the lookups resolvers make during one request.
+type Loaders struct {
+	Products *dataloader.Loader[string, *product.Product]
+}
+
+type loadersKey struct{}
+
+// WithLoaders attaches fresh loaders backed by usecase to ctx.
+func WithLoaders(ctx context.Context, usecase CartUseCase) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &Loaders{
+		Products: dataloader.NewWithWait(func(ctx context.Context, kodeProduks []string) (map[string]*product.Product, error) {
+			res := usecase.FindItems(ctx, kodeProduks)
+			if err := res.Err(); err != nil {
+				return nil, graphQLError(res)
+			}
+
+			items, _ := res.(*response.ResponseImpl).Data.([]product.Product)
+
+			found := make(map[string]*product.Product, len(items))
+			for i := range items {
+				found[items[i].KodeProduk] = &items[i]
+			}
+
+			return found, nil
+		}, productsWait),
+	})
+}
+
+func loadersFrom(ctx context.Context, usecase CartUseCase) *Loaders {
+	if loaders, ok := ctx.Value(loadersKey{}).(*Loaders); ok {
+		return loaders
+	}
+
+	return WithLoaders(ctx, usecase).Value(loadersKey{}).(*Loaders)
+}
+
+// rootResolver resolves the fields of Query and Mutation.
+type rootResolver struct {
+	usecase CartUseCase
+}
+
+func (r *rootResolver) Cart(ctx context.Context) (*cartResolver, error) {
+	res := r.usecase.GetItems(ctx, filter.Filter{})
+
+	cart := &cartResolver{usecase: r.usecase, etag: res.Header().Get("ETag")}
+
+	switch err := res.Err(); err {
+	case nil:
+		cart.lines, _ = res.(*response.ResponseImpl).Data.([]product.Product)
+	case exception.ErrNotFound:
+	default:
+		return nil, graphQLError(res)
+	}
+
+	// the lines already carry their products
+	loaders := loadersFrom(ctx, r.usecase)
+	for i := range cart.lines {
+		loaders.Products.Prime(cart.lines[i].KodeProduk, &cart.lines[i])
+	}
+
+	return cart, nil
+}
+
+func (r *rootResolver) Product(ctx context.Context, args struct{ KodeProduk string }) (*productResolver, error) {
+	item, err := loadersFrom(ctx, r.usecase).Products.Load(ctx, args.KodeProduk)()
+	if err != nil || item == nil {
+		return nil, err
+	}
+
+	return &productResolver{item: item}, nil
+}
+
+func (r *rootResolver) AddItem(ctx context.Context, args struct {
+	Nama       string
+	KodeProduk string
+	Kuantitas  int32
+	IfMatch    *string
+}) (*lineResolver, error) {
+	if err := allowMutation(ctx); err != nil {
+		return nil, err
+	}
+
+	if args.Nama == "" {
+		return nil, codedError{status: response.StatusBadRequest, err: exception.ErrNamaRequired}
+	}
+
+	res := r.usecase.AddItems(ctx, product.Product{
+		Nama:       args.Nama,
+		KodeProduk: args.KodeProduk,
+		Kuantitas:  int64(args.Kuantitas),
+	}, stringArg(args.IfMatch))
+
+	if err := res.Err(); err != nil {
+		return nil, graphQLError(res)
+	}
+
+	item := res.(*response.ResponseImpl).Data.(product.Product)
+
+	return &lineResolver{usecase: r.usecase, line: &item}, nil
+}
+
+func (r *rootResolver) SetQuantity(ctx context.Context, args struct {
+	KodeProduk string
+	Kuantitas  int32
+	IfMatch    *string
+}) (*lineResolver, error) {
+	if err := allowMutation(ctx); err != nil {
+		return nil, err
+	}
+
+	if args.Kuantitas < 0 {
+		return nil, codedError{status: response.StatusBadRequest, err: exception.ErrBadRequest}
+	}
+
+	res := r.usecase.BatchItems(ctx, batch.Batch{
+		Mode: batch.ModeAtomic,
+		Operations: []batch.Operation{{
+			Op:         batch.OpSet,
+			KodeProduk: args.KodeProduk,
+			Kuantitas:  int64(args.Kuantitas),
+		}},
+	}, stringArg(args.IfMatch))
+
+	results, _ := res.(*response.ResponseImpl).Data.([]batch.Result)
+
+	if err := res.Err(); err != nil {
+		// report why the single operation failed rather than the batch
+		if err == exception.ErrBatchFailed && len(results) == 1 {
+			return nil, codedError{status: results[0].Status, err: errors.New(results[0].Error)}
+		}
+
+		return nil, graphQLError(res)
+	}
+
+	if len(results) == 1 && results[0].Item != nil {
+		return &lineResolver{usecase: r.usecase, line: results[0].Item}, nil
+	}
+
+	return nil, nil
+}
+
+func (r *rootResolver) RemoveItem(ctx context.Context, args struct {
+	KodeProduk string
+	IfMatch    *string
+}) (bool, error) {
+	if err := allowMutation(ctx); err != nil {
+		return false, err
+	}
+
+	res := r.usecase.DeleteItems(ctx, args.KodeProduk, stringArg(args.IfMatch))
+	if err := res.Err(); err != nil {
+		return false, graphQLError(res)
+	}
+
+	return true, nil
+}
+
+// cartResolver resolves the Cart type.
+type cartResolver struct {
+	usecase CartUseCase
+	lines   []product.Product
+	etag    string
+}
+
+func (c *cartResolver) Etag() *string {
+	if c.etag == "" {
+		return nil
+	}
+
+	return &c.etag
+}
+
+func (c *cartResolver) Lines() []*lineResolver {
+	lines := make([]*lineResolver, len(c.lines))
+	for i := range c.lines {
+		lines[i] = &lineResolver{usecase: c.usecase, line: &c.lines[i]}
+	}
+
+	return lines
+}
+
+func (c *cartResolver) LineCount() int32 {
+	return int32(len(c.lines))
+}
+
+func (c *cartResolver) TotalKuantitas() int32 {
+	var total int64
+	for _, line := range c.lines {
+		total += line.Kuantitas
+	}
+
+	return int32(total)
+}
+
+// lineResolver resolves the CartLine type.
+type lineResolver struct {
+	usecase CartUseCase
+	line    *product.Product
+}
+
+func (l *lineResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(l.line.ID, 10))
+}
+
+func (l *lineResolver) KodeProduk() string {
+	return l.line.KodeProduk
+}
+
+func (l *lineResolver) Kuantitas() int32 {
+	return int32(l.line.Kuantitas)
+}
+
+func (l *lineResolver) Version() int32 {
+	return int32(l.line.Version)
+}
+
+func (l *lineResolver) CreatedAt() *string {
+	return timestamp(l.line.CreatedAt)
+}
+
+func (l *lineResolver) UpdateAt() *string {
+	return timestamp(l.line.UpdateAt)
+}
+
+func (l *lineResolver) Product(ctx context.Context) (*productResolver, error) {
+	item, err := loadersFrom(ctx, l.usecase).Products.Load(ctx, l.line.KodeProduk)()
+	if err != nil {
+		return nil, err
+	}
+
+	if item == nil {
+		return nil, codedError{status: response.StatusNotFound, err: exception.ErrNotFound}
+	}
+
+	return &productResolver{item: item}, nil
+}
+
+// productResolver resolves the Product type.
+type productResolver struct {
+	item *product.Product
+}
+
+func (p *productResolver) KodeProduk() string {
+	return p.item.KodeProduk
+}
+
+func (p *productResolver) Nama() string {
+	return p.item.Nama
+}
+
+// codedError carries the response status as the "code" extension, so
+// clients can tell a NOT_FOUND from a PRECONDITION_FAILED.
+type codedError struct {
+	status string
+	err    error
+}
+
+func (e codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e codedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.status}
+}
+
+func graphQLError(res response.Response) error {
+	return codedError{status: res.(*response.ResponseImpl).Status, err: res.Err()}
+}
+
+func stringArg(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+func timestamp(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+
+	formatted := t.Format(time.RFC3339Nano)
+
+	return &formatted
+}
@@ -0,0 +1,209 @@
+package cart
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/filter"
+	"github.com/Risuii/models/product"
+	cartpb "github.com/Risuii/proto/cart"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// CartGRPCServer serves the cart over gRPC on the same use case as
+// CartHandler.
+type CartGRPCServer struct {
+	cartpb.UnimplementedCartServiceServer
+
+	UseCase CartUseCase
+}
+
+// GRPCRules gives the scope of every method of the cart service, for
+// auth.UnaryServerInterceptor. Like the cart routes, the methods let calls
+// without credentials through unless required is set.
+func GRPCRules(required bool) auth.Rules {
+	return auth.Rules{
+		cartpb.CartService_AddItems_FullMethodName:       {Scope: auth.ScopeCartWrite, Anonymous: !required},
+		cartpb.CartService_GetItems_FullMethodName:       {Scope: auth.ScopeCartRead, Anonymous: !required},
+		cartpb.CartService_DeleteItems_FullMethodName:    {Scope: auth.ScopeCartWrite, Anonymous: !required},
+		cartpb.CartService_UpdateQuantity_FullMethodName: {Scope: auth.ScopeCartWrite, Anonymous: !required},
+	}
+}
+
+func NewCartGRPCServer(server *grpc.Server, usecase CartUseCase) {
+	cartpb.RegisterCartServiceServer(server, &CartGRPCServer{
+		UseCase: usecase,
+	})
+}
+
+func (s *CartGRPCServer) AddItems(ctx context.Context, req *cartpb.AddItemsRequest) (*cartpb.AddItemsResponse, error) {
+	if req.GetNama() == "" {
+		return nil, status.Error(codes.InvalidArgument, exception.ErrNamaRequired.Error())
+	}
+
+	res := s.UseCase.AddItems(ctx, product.Product{
+		Nama:       req.GetNama(),
+		KodeProduk: req.GetKodeProduk(),
+		Kuantitas:  req.GetKuantitas(),
+		Version:    req.GetVersion(),
+	}, req.GetIfMatch())
+
+	if err := res.Err(); err != nil {
+		return nil, grpcError(err)
+	}
+
+	body := res.(*response.ResponseImpl)
+	item := body.Data.(product.Product)
+
+	return &cartpb.AddItemsResponse{
+		Item:    toItem(item),
+		Created: body.Status == response.StatusCreated,
+	}, nil
+}
+
+// GetItems pages through the lines the use case returns. An empty cart is
+// an empty page rather than NotFound.
+func (s *CartGRPCServer) GetItems(ctx context.Context, req *cartpb.GetItemsRequest) (*cartpb.GetItemsResponse, error) {
+	page, pageSize := int(req.GetPage()), int(req.GetPageSize())
+	if page == 0 {
+		page = 1
+	}
+
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+
+	if page < 1 || pageSize < 1 || pageSize > MaxPageSize {
+		return nil, status.Error(codes.InvalidArgument, exception.ErrBadRequest.Error())
+	}
+
+	res := s.UseCase.GetItems(ctx, filter.Filter{
+		Nama:      req.GetNama(),
+		Kuantitas: req.GetKuantitas(),
+	})
+
+	var data []product.Product
+
+	switch err := res.Err(); err {
+	case nil:
+		data, _ = res.(*response.ResponseImpl).Data.([]product.Product)
+	case exception.ErrNotFound:
+	default:
+		return nil, grpcError(err)
+	}
+
+	resp := &cartpb.GetItemsResponse{
+		Items:    []*cartpb.Item{},
+		Page:     int32(page),
+		PageSize: int32(pageSize),
+		Total:    int64(len(data)),
+		Etag:     res.Header().Get("ETag"),
+	}
+
+	for i := (page - 1) * pageSize; i < len(data) && i < page*pageSize; i++ {
+		resp.Items = append(resp.Items, toItem(data[i]))
+	}
+
+	if resp.Etag != "" {
+		grpc.SetHeader(ctx, metadata.Pairs("etag", resp.Etag))
+	}
+
+	return resp, nil
+}
+
+func (s *CartGRPCServer) DeleteItems(ctx context.Context, req *cartpb.DeleteItemsRequest) (*cartpb.DeleteItemsResponse, error) {
+	if req.GetKodeProduk() == "" {
+		return nil, status.Error(codes.InvalidArgument, exception.ErrBadRequest.Error())
+	}
+
+	res := s.UseCase.DeleteItems(ctx, req.GetKodeProduk(), req.GetIfMatch())
+	if err := res.Err(); err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &cartpb.DeleteItemsResponse{}, nil
+}
+
+// UpdateQuantity is a one-operation batch, so it follows the same rules as
+// a "set" in POST /cart/items:batch.
+func (s *CartGRPCServer) UpdateQuantity(ctx context.Context, req *cartpb.UpdateQuantityRequest) (*cartpb.UpdateQuantityResponse, error) {
+	if req.GetKodeProduk() == "" || req.GetKuantitas() < 0 {
+		return nil, status.Error(codes.InvalidArgument, exception.ErrBadRequest.Error())
+	}
+
+	res := s.UseCase.BatchItems(ctx, batch.Batch{
+		Mode: batch.ModeAtomic,
+		Operations: []batch.Operation{{
+			Op:         batch.OpSet,
+			KodeProduk: req.GetKodeProduk(),
+			Kuantitas:  req.GetKuantitas(),
+		}},
+	}, req.GetIfMatch())
+
+	results, _ := res.(*response.ResponseImpl).Data.([]batch.Result)
+
+	if err := res.Err(); err != nil {
+		// report why the single operation failed rather than the batch
+		if err == exception.ErrBatchFailed && len(results) == 1 {
+			return nil, status.Error(resultCode(results[0].Status), results[0].Error)
+		}
+
+		return nil, grpcError(err)
+	}
+
+	resp := &cartpb.UpdateQuantityResponse{}
+	if len(results) == 1 && results[0].Item != nil {
+		resp.Item = toItem(*results[0].Item)
+	}
+
+	return resp, nil
+}
+
+func toItem(item product.Product) *cartpb.Item {
+	out := &cartpb.Item{
+		Id:         item.ID,
+		Nama:       item.Nama,
+		KodeProduk: item.KodeProduk,
+		Kuantitas:  item.Kuantitas,
+		Version:    item.Version,
+	}
+
+	if !item.CreatedAt.IsZero() {
+		out.CreatedAt = timestamppb.New(item.CreatedAt)
+	}
+
+	if !item.UpdateAt.IsZero() {
+		out.UpdateAt = timestamppb.New(item.UpdateAt)
+	}
+
+	return out
+}
+
+func grpcError(err error) error {
+	return status.Error(exception.GRPCCode(err), err.Error())
+}
+
+// resultCode maps the status of a batch result.
+func resultCode(s string) codes.Code {
+	switch s {
+	case response.StatusBadRequest:
+		return codes.InvalidArgument
+	case response.StatusNotFound:
+		return codes.NotFound
+	default:
+		return codes.Internal
+	}
+}
@@ -7,10 +7,10 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 
+	"github.com/Risuii/helpers/bodylimit"
 	"github.com/Risuii/helpers/exception"
 	"github.com/Risuii/helpers/response"
-	"github.com/Risuii/models/filter"
-	"github.com/Risuii/models/product"
+	v1 "github.com/Risuii/internal/cart/v1"
 )
 
 type CartHandler struct {
@@ -29,62 +29,110 @@ func NewCartHandler(router *mux.Router, validate *validator.Validate, usecase Ca
 	api.HandleFunc("/items", handler.AddItems).Methods(http.MethodPost)
 	api.HandleFunc("/items", handler.GetItems).Methods(http.MethodGet)
 	api.HandleFunc("/items", handler.DeleteItems).Methods(http.MethodDelete)
+	api.HandleFunc("/items:batch", handler.BatchItems).Methods(http.MethodPost)
+	api.HandleFunc("/items/{kodeProduk}/restore", handler.RestoreItems).Methods(http.MethodPost)
+	api.HandleFunc("/checkout", handler.Checkout).Methods(http.MethodPost)
 }
 
 func (handler *CartHandler) AddItems(w http.ResponseWriter, r *http.Request) {
 	var res response.Response
-	var userInput product.Product
+	var userInput v1.AddItemRequest
 
 	ctx := r.Context()
 
 	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
-		res = response.Error(response.StatusUnprocessableEntity, exception.ErrUnprocessableEntity)
-		res.JSON(w)
+		res = bodylimit.DecodeError(err)
+		res.Write(w, r)
 		return
 	}
 
 	err := handler.Validate.StructCtx(ctx, userInput)
 	if err != nil {
 		res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
-		res.JSON(w)
+		res.Write(w, r)
 		return
 	}
 
-	res = handler.UseCase.AddItems(ctx, userInput)
+	res = handler.UseCase.AddItems(ctx, userInput.Product(), r.Header.Get("If-Match"))
 
-	res.JSON(w)
+	v1.Present(res).Write(w, r)
 }
 
 func (handler *CartHandler) GetItems(w http.ResponseWriter, r *http.Request) {
 	var res response.Response
-	var userInput filter.Filter
+	var userInput v1.FilterRequest
 
 	ctx := r.Context()
 
 	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
-		res = response.Error(response.StatusUnprocessableEntity, exception.ErrUnprocessableEntity)
-		res.JSON(w)
+		res = bodylimit.DecodeError(err)
+		res.Write(w, r)
 		return
 	}
 
-	res = handler.UseCase.GetItems(ctx, userInput)
+	res = handler.UseCase.GetItems(ctx, userInput.Filter())
 
-	res.JSON(w)
+	v1.Present(res).Write(w, r)
 }
 
 func (handler *CartHandler) DeleteItems(w http.ResponseWriter, r *http.Request) {
 	var res response.Response
-	var userInput product.Product
+	var userInput v1.DeleteItemRequest
 
 	ctx := r.Context()
 
 	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
-		res = response.Error(response.StatusUnprocessableEntity, exception.ErrUnprocessableEntity)
-		res.JSON(w)
+		res = bodylimit.DecodeError(err)
+		res.Write(w, r)
 		return
 	}
 
-	res = handler.UseCase.DeleteItems(ctx, userInput.KodeProduk)
+	err := handler.Validate.StructCtx(ctx, userInput)
+	if err != nil {
+		res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+		res.Write(w, r)
+		return
+	}
+
+	res = handler.UseCase.DeleteItems(ctx, userInput.KodeProduk, r.Header.Get("If-Match"))
+
+	res.Write(w, r)
+}
+
+func (handler *CartHandler) BatchItems(w http.ResponseWriter, r *http.Request) {
+	var res response.Response
+	var userInput v1.BatchRequest
+
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
+		res = bodylimit.DecodeError(err)
+		res.Write(w, r)
+		return
+	}
+
+	err := handler.Validate.StructCtx(ctx, userInput)
+	if err != nil {
+		res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+		res.Write(w, r)
+		return
+	}
+
+	res = handler.UseCase.BatchItems(ctx, userInput.Batch(), r.Header.Get("If-Match"))
+
+	v1.Present(res).Write(w, r)
+}
+
+func (handler *CartHandler) RestoreItems(w http.ResponseWriter, r *http.Request) {
+	kodeProduk := mux.Vars(r)["kodeProduk"]
+
+	res := handler.UseCase.RestoreItems(r.Context(), kodeProduk, r.Header.Get("If-Match"))
+
+	v1.Present(res).Write(w, r)
+}
+
+func (handler *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	res := handler.UseCase.Checkout(r.Context(), r.Header.Get("If-Match"))
 
-	res.JSON(w)
+	res.Write(w, r)
 }
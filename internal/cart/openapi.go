@@ -0,0 +1,220 @@
+package cart
+
+import (
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"github.com/Risuii/helpers/openapi"
+	v1 "github.com/Risuii/internal/cart/v1"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/event"
+)
+
+// OpenAPI documents the routes of CartHandler and CartGraphQLHandler.
+func OpenAPI(doc *openapi.Document) {
+	ifMatch := openapi.HeaderParam("If-Match", "ETag of the cart from GET /cart/items; the change is refused with 412 when the cart has changed since.")
+
+	doc.Add(http.MethodPost, "/cart/items", &openapi.Operation{
+		Tags:        []string{"cart"},
+		Summary:     "Add a product to the cart",
+		Description: "Adds kuantitas to the line for kodeProduk, creating it when needed. A non-zero version makes the update conditional on the line version.",
+		OperationID: "addItems",
+		Parameters:  []openapi.Parameter{ifMatch},
+		RequestBody: doc.JSONBody(v1.AddItemRequest{}),
+		Responses: openapi.Responses{
+			"200": doc.JSON("The existing line was updated.", v1.Item{}),
+			"201": doc.JSON("A new line was created.", v1.Item{}),
+			"400": doc.Error("nama is missing."),
+			"409": doc.Error("The line version does not match."),
+			"412": doc.Error("If-Match does not match the cart."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/cart/items", openapi.Conditional(&openapi.Operation{
+		Tags:        []string{"cart"},
+		Summary:     "List the cart",
+		Description: "The body filters the lines by Nama and Kuantitas; send {} for every line.",
+		OperationID: "getItems",
+		RequestBody: doc.JSONBody(v1.FilterRequest{}),
+		Responses: openapi.Responses{
+			"200": doc.JSON("The matching lines.", []v1.Item{}).WithCSV().WithHeader("ETag", "Version of the whole cart, for If-Match."),
+			"404": doc.Error("No line matches."),
+			"500": doc.Error("Unexpected error."),
+		},
+	}))
+
+	doc.Add(http.MethodDelete, "/cart/items", &openapi.Operation{
+		Tags:        []string{"cart"},
+		Summary:     "Remove a product from the cart",
+		Description: "The line can be restored within the restore window.",
+		OperationID: "deleteItems",
+		Parameters:  []openapi.Parameter{ifMatch},
+		RequestBody: doc.JSONBody(v1.DeleteItemRequest{}),
+		Responses: openapi.Responses{
+			"200": doc.JSON("The line was removed.", ""),
+			"404": doc.Error("The cart has no line for kodeProduk."),
+			"412": doc.Error("If-Match does not match the cart."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/cart/items:batch", &openapi.Operation{
+		Tags:        []string{"cart"},
+		Summary:     "Apply several changes at once",
+		Description: "In atomic mode every operation is applied or none is; in partial mode the failures are reported next to the applied operations.",
+		OperationID: "batchItems",
+		Parameters:  []openapi.Parameter{ifMatch},
+		RequestBody: doc.JSONBody(v1.BatchRequest{}),
+		Responses: openapi.Responses{
+			"200": doc.JSON("The result of every operation.", []v1.BatchResult{}),
+			"400": doc.Error("The batch is invalid or has too many operations."),
+			"412": doc.Error("If-Match does not match the cart."),
+			"422": doc.JSON("An atomic batch failed; data holds the result of every operation.", []v1.BatchResult{}),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/cart/items/{kodeProduk}/restore", &openapi.Operation{
+		Tags:        []string{"cart"},
+		Summary:     "Restore a removed product",
+		OperationID: "restoreItems",
+		Parameters: []openapi.Parameter{
+			openapi.PathParam("kodeProduk", "Product code of the removed line.", openapi.String()),
+			ifMatch,
+		},
+		Responses: openapi.Responses{
+			"200": doc.JSON("The restored line.", v1.Item{}),
+			"404": doc.Error("No removed line for kodeProduk within the restore window."),
+			"409": doc.Error("The cart already has an active line for kodeProduk."),
+			"412": doc.Error("If-Match does not match the cart."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/cart/checkout", &openapi.Operation{
+		Tags:        []string{"cart"},
+		Summary:     "Check the cart out",
+		Description: "Empties the cart and publishes cart.CartCheckedOut.",
+		OperationID: "checkout",
+		Parameters:  []openapi.Parameter{ifMatch},
+		Responses: openapi.Responses{
+			"200": doc.JSON("The checked out lines.", event.CartCheckedOut{}),
+			"412": doc.Error("If-Match does not match the cart."),
+			"422": doc.Error("The cart is empty."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	graphQLResult := map[string]openapi.MediaType{"application/json": {Schema: doc.Schema(graphql.Response{})}}
+	graphQLErrors := map[string]openapi.MediaType{"application/json": {Schema: &openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{"errors": doc.Schema([]gqlerrors.QueryError{})},
+		Required:   []string{"errors"},
+	}}}
+
+	doc.Add(http.MethodPost, "/graphql", &openapi.Operation{
+		Tags:        []string{"graphql"},
+		Summary:     "Run a GraphQL query or mutation",
+		Description: "The schema is served at /graphql/schema. Field errors come back with a 200 next to the data.",
+		OperationID: "graphql",
+		RequestBody: doc.JSONBody(GraphQLRequest{}),
+		Responses: openapi.Responses{
+			"200": {Description: "The operation ran.", Content: graphQLResult},
+			"400": {Description: "The request is not a valid operation for the schema.", Content: graphQLErrors},
+		},
+	})
+
+	doc.Add(http.MethodGet, "/graphql", &openapi.Operation{
+		Tags:        []string{"graphql"},
+		Summary:     "Run a GraphQL query",
+		Description: "Mutations are refused over GET.",
+		OperationID: "graphqlQuery",
+		Parameters: []openapi.Parameter{
+			{Name: "query", In: "query", Required: true, Schema: openapi.String()},
+			openapi.QueryParam("operationName", "", openapi.String()),
+			openapi.QueryParam("variables", "Variables as a JSON object.", openapi.String()),
+		},
+		Responses: openapi.Responses{
+			"200": {Description: "The operation ran.", Content: graphQLResult},
+			"400": {Description: "The request is not a valid operation for the schema.", Content: graphQLErrors},
+			"405": {Description: "The operation is a mutation.", Content: graphQLErrors},
+		},
+	})
+
+	doc.Add(http.MethodGet, "/graphql/schema", &openapi.Operation{
+		Tags:        []string{"graphql"},
+		Summary:     "The GraphQL schema in SDL",
+		OperationID: "graphqlSchema",
+		Responses: openapi.Responses{
+			"200": {Description: "The schema.", Content: map[string]openapi.MediaType{"text/plain": {Schema: openapi.String()}}},
+		},
+	})
+}
+
+// AdminOpenAPI documents the routes of CartAdminHandler.
+func AdminOpenAPI(doc *openapi.Document) {
+	owner := openapi.PathParam("owner", "Owner of the cart, as kind:subject, or anonymous.", openapi.String())
+	ifMatch := openapi.HeaderParam("If-Match", "ETag of the cart from GET /carts/{owner}; the change is refused with 412 when the cart has changed since.")
+
+	doc.Add(http.MethodGet, "/carts", &openapi.Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Search carts",
+		Description: "Non-empty carts by owner, for support and admin. Every listing is recorded.",
+		OperationID: "listCarts",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("q", "Matches carts whose owner, or any of whose lines by kodeProduk or nama, contains it.", openapi.String()),
+			openapi.QueryParam("page", "1-based page, default 1.", openapi.Integer(1)),
+			openapi.QueryParam("pageSize", "Carts per page, default 20, at most 100.", openapi.Integer(1)),
+		},
+		Responses: openapi.Responses{
+			"200": doc.JSON("One page of carts.", cartsummary.List{}),
+			"400": doc.Error("The page or pageSize is out of range."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/carts/{owner}", openapi.Conditional(&openapi.Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Inspect a cart",
+		Description: "Looking at a cart other than one's own is recorded.",
+		OperationID: "getCart",
+		Parameters:  []openapi.Parameter{owner},
+		Responses: openapi.Responses{
+			"200": doc.JSON("The lines of the cart.", []v1.Item{}).WithCSV().WithHeader("ETag", "Version of the whole cart, for If-Match."),
+			"404": doc.Error("The cart is empty."),
+			"500": doc.Error("Unexpected error."),
+		},
+	}))
+
+	doc.Add(http.MethodPost, "/carts/{owner}/items:batch", &openapi.Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Adjust the lines of a cart",
+		Description: "Works as POST /cart/items:batch on the cart of owner; the changes are audited under the caller.",
+		OperationID: "adminBatchItems",
+		Parameters:  []openapi.Parameter{owner, ifMatch},
+		RequestBody: doc.JSONBody(v1.BatchRequest{}),
+		Responses: openapi.Responses{
+			"200": doc.JSON("The result of every operation.", []v1.BatchResult{}),
+			"400": doc.Error("The batch is invalid or has too many operations."),
+			"412": doc.Error("If-Match does not match the cart."),
+			"422": doc.JSON("An atomic batch failed; data holds the result of every operation.", []v1.BatchResult{}),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/carts/{owner}/expire", &openapi.Operation{
+		Tags:        []string{"admin"},
+		Summary:     "Force-expire a cart",
+		Description: "Admin only. Removes every line, publishing cart.ItemRemoved for each; the lines can be restored within the restore window.",
+		OperationID: "expireCart",
+		Parameters:  []openapi.Parameter{owner},
+		Responses: openapi.Responses{
+			"200": doc.JSON("The removed lines.", []v1.Item{}),
+			"422": doc.Error("The cart is empty."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+}
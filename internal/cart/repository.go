@@ -4,42 +4,78 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"strings"
+	"time"
 
+	"github.com/Risuii/helpers/database"
 	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/catalog"
 	"github.com/Risuii/models/filter"
 	"github.com/Risuii/models/product"
 )
 
 type (
+	// CartRepository reads and adds the lines of the cart the request acts
+	// on (rbac.Cart). Lines are written by the ids those reads returned.
 	CartRepository interface {
 		Add(ctx context.Context, params product.Product) (int64, error)
 		UpdateKuantitas(ctx context.Context, id int64, params product.Product) error
+		UpdateKuantitasIfVersion(ctx context.Context, id int64, version int64, params product.Product) error
 		FindByKodeProduk(ctx context.Context, kodeProduk string) (product.Product, error)
 		FindByFilter(ctx context.Context, params filter.Filter) ([]product.Product, error)
-		FindAll() ([]product.Product, error)
+		FindAll(ctx context.Context) ([]product.Product, error)
 		Delete(ctx context.Context, id int64) error
+		FindByKodeProduks(ctx context.Context, kodeProduks []string) ([]product.Product, error)
+		AddBatch(ctx context.Context, items []product.Product) error
+		UpdateKuantitasBatch(ctx context.Context, items []product.Product) error
+		DeleteBatch(ctx context.Context, ids []int64) error
+		FindDeletedByKodeProduk(ctx context.Context, kodeProduk string, since time.Time) (product.Product, error)
+		Restore(ctx context.Context, id int64) error
+		Purge(ctx context.Context, before time.Time, limit int) (int64, error)
+		// FindOwner returns the owner of line id, removed or not.
+		FindOwner(ctx context.Context, id int64) (string, error)
+		// FindCarts and CountCarts see every cart.
+		FindCarts(ctx context.Context, params cartsummary.Filter) ([]cartsummary.Summary, error)
+		CountCarts(ctx context.Context, q string) (int64, error)
+		// FindCatalog returns the distinct kodeProduk and nama pairs of the
+		// live lines of every cart.
+		FindCatalog(ctx context.Context) ([]catalog.Entry, error)
 	}
 
 	cartRepositoryImpl struct {
-		DB        *sql.DB
-		tableName string
+		DB           *sql.DB
+		tableName    string
+		queryTimeout time.Duration
 	}
 )
 
-func NewCartRepositoryImpl(db *sql.DB, tableName string) CartRepository {
+// NewCartRepositoryImpl builds the MySQL cart repository. queryTimeout caps
+// every statement unless the caller's context expires sooner.
+func NewCartRepositoryImpl(db *sql.DB, tableName string, queryTimeout time.Duration) CartRepository {
 	return &cartRepositoryImpl{
-		DB:        db,
-		tableName: tableName,
+		DB:           db,
+		tableName:    tableName,
+		queryTimeout: queryTimeout,
 	}
 }
 
-func (cr *cartRepositoryImpl) Add(ctx context.Context, params product.Product) (int64, error) {
-	query := fmt.Sprintf(`INSERT INTO %s (nama, kodeProduk, kuantitas, created_at) VALUES (?,?,?,?)`, cr.tableName)
-	stmt, err := cr.DB.PrepareContext(ctx, query)
+func (cr *cartRepositoryImpl) Add(ctx context.Context, params product.Product) (ID int64, err error) {
+	query := fmt.Sprintf(`INSERT INTO %s (nama, kodeProduk, kuantitas, created_at, owner) VALUES (?,?,?,?,?)`, cr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "INSERT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	stmt, err := database.Conn(ctx, cr.DB).PrepareContext(ctx, query)
 	if err != nil {
-		log.Println(err)
-		return 0, exception.ErrInternalServer
+		logger.Println(ctx, err)
+		return 0, database.Error(err)
 	}
 
 	defer stmt.Close()
@@ -50,24 +86,32 @@ func (cr *cartRepositoryImpl) Add(ctx context.Context, params product.Product) (
 		params.KodeProduk,
 		params.Kuantitas,
 		params.CreatedAt,
+		rbac.Cart(ctx),
 	)
 
 	if err != nil {
-		log.Println(err)
-		return 0, exception.ErrInternalServer
+		logger.Println(ctx, err)
+		return 0, database.Error(err)
 	}
 
-	ID, _ := result.LastInsertId()
+	ID, _ = result.LastInsertId()
 
 	return ID, nil
 }
 
-func (cr *cartRepositoryImpl) UpdateKuantitas(ctx context.Context, id int64, params product.Product) error {
-	query := fmt.Sprintf(`UPDATE %s SET kuantitas = ? WHERE id = %d`, cr.tableName, id)
-	stmt, err := cr.DB.PrepareContext(ctx, query)
+func (cr *cartRepositoryImpl) UpdateKuantitas(ctx context.Context, id int64, params product.Product) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET kuantitas = ?, update_at = ?, version = version + 1 WHERE id = %d`, cr.tableName, id)
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	stmt, err := database.Conn(ctx, cr.DB).PrepareContext(ctx, query)
 	if err != nil {
-		log.Println(err)
-		return exception.ErrInternalServer
+		logger.Println(ctx, err)
+		return database.Error(err)
 	}
 
 	defer stmt.Close()
@@ -75,11 +119,12 @@ func (cr *cartRepositoryImpl) UpdateKuantitas(ctx context.Context, id int64, par
 	result, err := stmt.ExecContext(
 		ctx,
 		params.Kuantitas,
+		params.UpdateAt,
 	)
 
 	if err != nil {
-		log.Println(err)
-		return exception.ErrInternalServer
+		logger.Println(ctx, err)
+		return database.Error(err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
@@ -90,19 +135,70 @@ func (cr *cartRepositoryImpl) UpdateKuantitas(ctx context.Context, id int64, par
 	return nil
 }
 
-func (cr *cartRepositoryImpl) FindByKodeProduk(ctx context.Context, kodeProduk string) (product.Product, error) {
-	var product product.Product
+// UpdateKuantitasIfVersion only writes when the row is still at version,
+// returning exception.ErrVersionConflict when another writer got there
+// first.
+func (cr *cartRepositoryImpl) UpdateKuantitasIfVersion(ctx context.Context, id int64, version int64, params product.Product) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET kuantitas = ?, update_at = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`, cr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	stmt, err := database.Conn(ctx, cr.DB).PrepareContext(ctx, query)
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(
+		ctx,
+		params.Kuantitas,
+		params.UpdateAt,
+		id,
+		version,
+	)
+
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected < 1 {
+		return exception.ErrVersionConflict
+	}
+
+	return nil
+}
+
+func (cr *cartRepositoryImpl) FindByKodeProduk(ctx context.Context, kodeProduk string) (product product.Product, err error) {
+	query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kodeProduk = ? AND deleted_at IS NULL AND owner = ?`, cr.tableName)
+
+	// lock the row so a find-then-write unit of work cannot lose an update
+	if database.InTransaction(ctx) {
+		query += ` FOR UPDATE`
+	}
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
 
-	query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE kodeProduk = ?`, cr.tableName)
-	stmt, err := cr.DB.PrepareContext(ctx, query)
+	stmt, err := database.Conn(ctx, cr.DB).PrepareContext(ctx, query)
 	if err != nil {
-		log.Println(err)
-		return product, exception.ErrInternalServer
+		logger.Println(ctx, err)
+		return product, database.Error(err)
 	}
 
 	defer stmt.Close()
 
-	rows := stmt.QueryRowContext(ctx, kodeProduk)
+	rows := stmt.QueryRowContext(ctx, kodeProduk, rbac.Cart(ctx))
 
 	err = rows.Scan(
 		&product.ID,
@@ -111,23 +207,34 @@ func (cr *cartRepositoryImpl) FindByKodeProduk(ctx context.Context, kodeProduk s
 		&product.Kuantitas,
 		&product.CreatedAt,
 		&product.UpdateAt,
+		&product.Version,
 	)
 
-	if err != nil {
-		log.Println(err)
+	if err == sql.ErrNoRows {
 		return product, exception.ErrNotFound
 	}
 
+	if err != nil {
+		logger.Println(ctx, err)
+		return product, database.Error(err)
+	}
+
 	return product, nil
 }
 
-func (cr *cartRepositoryImpl) FindAll() ([]product.Product, error) {
-	var products []product.Product
+func (cr *cartRepositoryImpl) FindAll(ctx context.Context) (products []product.Product, err error) {
+	query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE deleted_at IS NULL AND owner = ?`, cr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
 
-	rows, err := cr.DB.Query(fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s`, cr.tableName))
+	rows, err := database.Conn(ctx, cr.DB).QueryContext(ctx, query, rbac.Cart(ctx))
 	if err != nil {
-		log.Println(err)
-		return products, exception.ErrInternalServer
+		logger.Println(ctx, err)
+		return products, database.Error(err)
 	}
 
 	defer rows.Close()
@@ -141,33 +248,50 @@ func (cr *cartRepositoryImpl) FindAll() ([]product.Product, error) {
 			&c.Kuantitas,
 			&c.CreatedAt,
 			&c.UpdateAt,
+			&c.Version,
 		); err != nil {
-			log.Println(err)
-			return products, exception.ErrNotFound
+			logger.Println(ctx, err)
+			return products, database.Error(err)
 		}
 		products = append(products, c)
 	}
 
+	if err := rows.Err(); err != nil {
+		logger.Println(ctx, err)
+		return products, database.Error(err)
+	}
+
 	return products, nil
 }
 
-func (cr *cartRepositoryImpl) Delete(ctx context.Context, id int64) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE id = %d`, cr.tableName, id)
-	stmt, err := cr.DB.PrepareContext(ctx, query)
+// Delete soft-deletes the line; it stays restorable until it is purged.
+func (cr *cartRepositoryImpl) Delete(ctx context.Context, id int64) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = ?, update_at = ?, version = version + 1 WHERE id = %d AND deleted_at IS NULL`, cr.tableName, id)
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	stmt, err := database.Conn(ctx, cr.DB).PrepareContext(ctx, query)
 	if err != nil {
-		log.Println(err)
-		return exception.ErrInternalServer
+		logger.Println(ctx, err)
+		return database.Error(err)
 	}
 
 	defer stmt.Close()
 
+	now := time.Now()
 	result, err := stmt.ExecContext(
 		ctx,
+		now,
+		now,
 	)
 
 	if err != nil {
-		log.Println(err)
-		return exception.ErrInternalServer
+		logger.Println(ctx, err)
+		return database.Error(err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
@@ -178,16 +302,20 @@ func (cr *cartRepositoryImpl) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (cr *cartRepositoryImpl) FindByFilter(ctx context.Context, params filter.Filter) ([]product.Product, error) {
-	var products []product.Product
+func (cr *cartRepositoryImpl) FindByFilter(ctx context.Context, params filter.Filter) (products []product.Product, err error) {
+	ctx, span := tracing.StartQuery(ctx, "SELECT", cr.tableName, "")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
 
 	if params.Nama != "" && params.Kuantitas != 0 {
 		var products []product.Product
 
-		rows, err := cr.DB.Query(fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE nama = '%s' AND kuantitas = '%d'`, cr.tableName, params.Nama, params.Kuantitas))
+		rows, err := database.Conn(ctx, cr.DB).QueryContext(ctx, fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE nama = ? AND kuantitas = ? AND deleted_at IS NULL AND owner = ?`, cr.tableName), params.Nama, params.Kuantitas, rbac.Cart(ctx))
 		if err != nil {
-			log.Println(err)
-			return products, exception.ErrInternalServer
+			logger.Println(ctx, err)
+			return products, database.Error(err)
 		}
 
 		defer rows.Close()
@@ -201,13 +329,19 @@ func (cr *cartRepositoryImpl) FindByFilter(ctx context.Context, params filter.Fi
 				&c.Kuantitas,
 				&c.CreatedAt,
 				&c.UpdateAt,
+				&c.Version,
 			); err != nil {
-				log.Println(err)
-				return products, exception.ErrNotFound
+				logger.Println(ctx, err)
+				return products, database.Error(err)
 			}
 			products = append(products, c)
 		}
 
+		if err := rows.Err(); err != nil {
+			logger.Println(ctx, err)
+			return products, database.Error(err)
+		}
+
 		if products == nil {
 			return products, exception.ErrNotFound
 		}
@@ -216,10 +350,10 @@ func (cr *cartRepositoryImpl) FindByFilter(ctx context.Context, params filter.Fi
 	} else if params.Nama != "" && params.Kuantitas == 0 {
 		var products []product.Product
 
-		rows, err := cr.DB.Query(fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE nama = '%s'`, cr.tableName, params.Nama))
+		rows, err := database.Conn(ctx, cr.DB).QueryContext(ctx, fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE nama = ? AND deleted_at IS NULL AND owner = ?`, cr.tableName), params.Nama, rbac.Cart(ctx))
 		if err != nil {
-			log.Println(err)
-			return products, exception.ErrInternalServer
+			logger.Println(ctx, err)
+			return products, database.Error(err)
 		}
 
 		defer rows.Close()
@@ -233,13 +367,19 @@ func (cr *cartRepositoryImpl) FindByFilter(ctx context.Context, params filter.Fi
 				&c.Kuantitas,
 				&c.CreatedAt,
 				&c.UpdateAt,
+				&c.Version,
 			); err != nil {
-				log.Println(err)
-				return products, exception.ErrNotFound
+				logger.Println(ctx, err)
+				return products, database.Error(err)
 			}
 			products = append(products, c)
 		}
 
+		if err := rows.Err(); err != nil {
+			logger.Println(ctx, err)
+			return products, database.Error(err)
+		}
+
 		if products == nil {
 			return products, exception.ErrNotFound
 		}
@@ -248,10 +388,10 @@ func (cr *cartRepositoryImpl) FindByFilter(ctx context.Context, params filter.Fi
 	} else if params.Nama == "" && params.Kuantitas != 0 {
 		var products []product.Product
 
-		rows, err := cr.DB.Query(fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE kuantitas = '%d'`, cr.tableName, params.Kuantitas))
+		rows, err := database.Conn(ctx, cr.DB).QueryContext(ctx, fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kuantitas = ? AND deleted_at IS NULL AND owner = ?`, cr.tableName), params.Kuantitas, rbac.Cart(ctx))
 		if err != nil {
-			log.Println(err)
-			return products, exception.ErrInternalServer
+			logger.Println(ctx, err)
+			return products, database.Error(err)
 		}
 
 		defer rows.Close()
@@ -265,13 +405,19 @@ func (cr *cartRepositoryImpl) FindByFilter(ctx context.Context, params filter.Fi
 				&c.Kuantitas,
 				&c.CreatedAt,
 				&c.UpdateAt,
+				&c.Version,
 			); err != nil {
-				log.Println(err)
-				return products, exception.ErrNotFound
+				logger.Println(ctx, err)
+				return products, database.Error(err)
 			}
 			products = append(products, c)
 		}
 
+		if err := rows.Err(); err != nil {
+			logger.Println(ctx, err)
+			return products, database.Error(err)
+		}
+
 		if products == nil {
 			return products, exception.ErrNotFound
 		}
@@ -281,3 +427,403 @@ func (cr *cartRepositoryImpl) FindByFilter(ctx context.Context, params filter.Fi
 
 	return products, nil
 }
+
+// FindByKodeProduks loads every line whose kodeProduk is listed, in one
+// statement.
+func (cr *cartRepositoryImpl) FindByKodeProduks(ctx context.Context, kodeProduks []string) (products []product.Product, err error) {
+	if len(kodeProduks) == 0 {
+		return products, nil
+	}
+
+	query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kodeProduk IN (%s) AND deleted_at IS NULL AND owner = ?`, cr.tableName, placeholders(len(kodeProduks)))
+	if database.InTransaction(ctx) {
+		query += ` FOR UPDATE`
+	}
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(kodeProduks)+1)
+	for _, kodeProduk := range kodeProduks {
+		args = append(args, kodeProduk)
+	}
+	args = append(args, rbac.Cart(ctx))
+
+	rows, err := database.Conn(ctx, cr.DB).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Println(ctx, err)
+		return products, database.Error(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var c product.Product
+		if err := rows.Scan(
+			&c.ID,
+			&c.Nama,
+			&c.KodeProduk,
+			&c.Kuantitas,
+			&c.CreatedAt,
+			&c.UpdateAt,
+			&c.Version,
+		); err != nil {
+			logger.Println(ctx, err)
+			return products, database.Error(err)
+		}
+		products = append(products, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Println(ctx, err)
+		return products, database.Error(err)
+	}
+
+	return products, nil
+}
+
+// AddBatch inserts every item with a single multi-row INSERT.
+func (cr *cartRepositoryImpl) AddBatch(ctx context.Context, items []product.Product) (err error) {
+	if len(items) == 0 {
+		return nil
+	}
+
+	values := strings.TrimSuffix(strings.Repeat("(?,?,?,?,?,?),", len(items)), ",")
+	query := fmt.Sprintf(`INSERT INTO %s (nama, kodeProduk, kuantitas, created_at, version, owner) VALUES %s`, cr.tableName, values)
+
+	ctx, span := tracing.StartQuery(ctx, "INSERT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	owner := rbac.Cart(ctx)
+
+	args := make([]interface{}, 0, len(items)*6)
+	for _, item := range items {
+		args = append(args, item.Nama, item.KodeProduk, item.Kuantitas, item.CreatedAt, 1, owner)
+	}
+
+	if _, err = database.Conn(ctx, cr.DB).ExecContext(ctx, query, args...); err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+// UpdateKuantitasBatch sets each item's kuantitas by id in one UPDATE and
+// bumps the version of every touched row.
+func (cr *cartRepositoryImpl) UpdateKuantitasBatch(ctx context.Context, items []product.Product) (err error) {
+	if len(items) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE %s SET kuantitas = CASE id %s END, update_at = ?, version = version + 1 WHERE id IN (%s) AND deleted_at IS NULL`,
+		cr.tableName,
+		strings.TrimSuffix(strings.Repeat("WHEN ? THEN ? ", len(items)), " "),
+		placeholders(len(items)),
+	)
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(items)*3+1)
+	for _, item := range items {
+		args = append(args, item.ID, item.Kuantitas)
+	}
+
+	args = append(args, items[0].UpdateAt)
+	for _, item := range items {
+		args = append(args, item.ID)
+	}
+
+	result, err := database.Conn(ctx, cr.DB).ExecContext(ctx, query, args...)
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected < int64(len(items)) {
+		return exception.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteBatch soft-deletes every listed id in one UPDATE.
+func (cr *cartRepositoryImpl) DeleteBatch(ctx context.Context, ids []int64) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = ?, update_at = ?, version = version + 1 WHERE id IN (%s) AND deleted_at IS NULL`, cr.tableName, placeholders(len(ids)))
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	now := time.Now()
+	args := make([]interface{}, 0, len(ids)+2)
+	args = append(args, now, now)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	result, err := database.Conn(ctx, cr.DB).ExecContext(ctx, query, args...)
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected < int64(len(ids)) {
+		return exception.ErrNotFound
+	}
+
+	return nil
+}
+
+// FindDeletedByKodeProduk returns the most recently deleted line for
+// kodeProduk that was deleted at or after since.
+func (cr *cartRepositoryImpl) FindDeletedByKodeProduk(ctx context.Context, kodeProduk string, since time.Time) (product product.Product, err error) {
+	query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version, deleted_at FROM %s WHERE kodeProduk = ? AND deleted_at >= ? AND owner = ? ORDER BY deleted_at DESC LIMIT 1`, cr.tableName)
+
+	if database.InTransaction(ctx) {
+		query += ` FOR UPDATE`
+	}
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	stmt, err := database.Conn(ctx, cr.DB).PrepareContext(ctx, query)
+	if err != nil {
+		logger.Println(ctx, err)
+		return product, database.Error(err)
+	}
+
+	defer stmt.Close()
+
+	rows := stmt.QueryRowContext(ctx, kodeProduk, since, rbac.Cart(ctx))
+
+	err = rows.Scan(
+		&product.ID,
+		&product.Nama,
+		&product.KodeProduk,
+		&product.Kuantitas,
+		&product.CreatedAt,
+		&product.UpdateAt,
+		&product.Version,
+		&product.DeletedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return product, exception.ErrNotFound
+	}
+
+	if err != nil {
+		logger.Println(ctx, err)
+		return product, database.Error(err)
+	}
+
+	return product, nil
+}
+
+// Restore clears the deletion mark of a soft-deleted line.
+func (cr *cartRepositoryImpl) Restore(ctx context.Context, id int64) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL, update_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL`, cr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	stmt, err := database.Conn(ctx, cr.DB).PrepareContext(ctx, query)
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, time.Now(), id)
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected < 1 {
+		return exception.ErrNotFound
+	}
+
+	return nil
+}
+
+// Purge hard-deletes up to limit lines that were soft-deleted before the
+// given time and reports how many were removed.
+func (cr *cartRepositoryImpl) Purge(ctx context.Context, before time.Time, limit int) (purged int64, err error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE deleted_at IS NOT NULL AND deleted_at < ? LIMIT ?`, cr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "DELETE", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	result, err := database.Conn(ctx, cr.DB).ExecContext(ctx, query, before, limit)
+	if err != nil {
+		logger.Println(ctx, err)
+		return 0, database.Error(err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (cr *cartRepositoryImpl) FindOwner(ctx context.Context, id int64) (owner string, err error) {
+	query := fmt.Sprintf(`SELECT owner FROM %s WHERE id = ?`, cr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	err = database.Conn(ctx, cr.DB).QueryRowContext(ctx, query, id).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return owner, exception.ErrNotFound
+	}
+
+	if err != nil {
+		logger.Println(ctx, err)
+		return owner, database.Error(err)
+	}
+
+	return owner, nil
+}
+
+// FindCarts returns a page of the non-empty carts matching params, by
+// owner.
+func (cr *cartRepositoryImpl) FindCarts(ctx context.Context, params cartsummary.Filter) (carts []cartsummary.Summary, err error) {
+	where, args := cr.searchCarts(params.Q)
+	query := fmt.Sprintf(`SELECT owner, COUNT(*), COALESCE(SUM(kuantitas), 0), MAX(COALESCE(update_at, created_at)) FROM %s WHERE %s GROUP BY owner ORDER BY owner LIMIT ? OFFSET ?`, cr.tableName, where)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	args = append(args, params.PageSize, (params.Page-1)*params.PageSize)
+
+	rows, err := database.Conn(ctx, cr.DB).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Println(ctx, err)
+		return carts, database.Error(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var c cartsummary.Summary
+		var lastActivity sql.NullTime
+		if err := rows.Scan(&c.Owner, &c.Lines, &c.TotalKuantitas, &lastActivity); err != nil {
+			logger.Println(ctx, err)
+			return carts, database.Error(err)
+		}
+		c.LastActivityAt = lastActivity.Time
+		carts = append(carts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Println(ctx, err)
+		return carts, database.Error(err)
+	}
+
+	return carts, nil
+}
+
+func (cr *cartRepositoryImpl) CountCarts(ctx context.Context, q string) (total int64, err error) {
+	where, args := cr.searchCarts(q)
+	query := fmt.Sprintf(`SELECT COUNT(DISTINCT owner) FROM %s WHERE %s`, cr.tableName, where)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	if err = database.Conn(ctx, cr.DB).QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		logger.Println(ctx, err)
+		return 0, database.Error(err)
+	}
+
+	return total, nil
+}
+
+// FindCatalog returns the products of the live lines of every cart, by
+// kodeProduk.
+func (cr *cartRepositoryImpl) FindCatalog(ctx context.Context) (entries []catalog.Entry, err error) {
+	query := fmt.Sprintf(`SELECT DISTINCT kodeProduk, COALESCE(nama, '') FROM %s WHERE deleted_at IS NULL ORDER BY kodeProduk, 2`, cr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", cr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, cr.queryTimeout)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, cr.DB).QueryContext(ctx, query)
+	if err != nil {
+		logger.Println(ctx, err)
+		return entries, database.Error(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var e catalog.Entry
+		if err := rows.Scan(&e.KodeProduk, &e.Nama); err != nil {
+			logger.Println(ctx, err)
+			return entries, database.Error(err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Println(ctx, err)
+		return entries, database.Error(err)
+	}
+
+	return entries, nil
+}
+
+// searchCarts selects the live lines of the carts matching q: those whose
+// owner contains q, or holding a line whose kodeProduk or nama does.
+func (cr *cartRepositoryImpl) searchCarts(q string) (string, []interface{}) {
+	if q == "" {
+		return `deleted_at IS NULL`, nil
+	}
+
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q) + "%"
+	where := fmt.Sprintf(`deleted_at IS NULL AND owner IN (SELECT owner FROM %s WHERE deleted_at IS NULL AND (owner LIKE ? OR kodeProduk LIKE ? OR nama LIKE ?))`, cr.tableName)
+
+	return where, []interface{}{pattern, pattern, pattern}
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -2,70 +2,212 @@ package cart
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
 	"time"
 
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/etag"
 	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/helpers/requestinfo"
 	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/internal/audit"
+	"github.com/Risuii/internal/outbox"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/cartevent"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/catalog"
+	"github.com/Risuii/models/event"
 	"github.com/Risuii/models/filter"
 	"github.com/Risuii/models/product"
 )
 
 type (
 	CartUseCase interface {
-		AddItems(ctx context.Context, params product.Product) response.Response
+		AddItems(ctx context.Context, params product.Product, ifMatch string) response.Response
 		GetItems(ctx context.Context, params filter.Filter) response.Response
-		DeleteItems(ctx context.Context, kodeProduk string) response.Response
+		FindItems(ctx context.Context, kodeProduks []string) response.Response
+		DeleteItems(ctx context.Context, kodeProduk string, ifMatch string) response.Response
+		BatchItems(ctx context.Context, params batch.Batch, ifMatch string) response.Response
+		RestoreItems(ctx context.Context, kodeProduk string, ifMatch string) response.Response
+		PurgeDeleted(ctx context.Context) (int64, error)
+		Checkout(ctx context.Context, ifMatch string) response.Response
+		DetectAbandoned(ctx context.Context) (int, error)
+		AuthorizeLine(ctx context.Context, cartID int64) error
+		ListCarts(ctx context.Context, params cartsummary.Filter) response.Response
+		Catalog(ctx context.Context) response.Response
+		Expire(ctx context.Context) response.Response
+	}
+
+	// Options tunes the use case; zero values fall back to the defaults.
+	Options struct {
+		BatchMode          string
+		BatchMaxOperations int
+		// RestoreWindow is how long a removed line can still be restored.
+		RestoreWindow time.Duration
+		// DeletedRetention is how long a removed line is kept before
+		// PurgeDeleted hard-deletes it.
+		DeletedRetention time.Duration
+		// AbandonAfter is how long a non-empty cart can stay untouched
+		// before DetectAbandoned reports it.
+		AbandonAfter time.Duration
+		// Notifier, when set, is handed the domain events of every change
+		// once its transaction has committed.
+		Notifier Notifier
+		// Access, when set, records support and admin reaching carts
+		// other than their own.
+		Access audit.AccessRepository
+	}
+
+	// Notifier receives committed domain events, e.g. to push them to
+	// connected clients. It must not block.
+	Notifier interface {
+		Notify(envelopes ...event.Envelope)
 	}
 
 	cartUseCaseImpl struct {
-		repo CartRepository
+		repo   CartRepository
+		events audit.AuditRepository
+		outbox outbox.OutboxRepository
+		tx     database.TxManager
+		opts   Options
 	}
 )
 
-func NewCartUseCaseImpl(repo CartRepository) CartUseCase {
+func NewCartUseCaseImpl(repo CartRepository, events audit.AuditRepository, outboxRepo outbox.OutboxRepository, tx database.TxManager, opts Options) CartUseCase {
+	if opts.BatchMode == "" {
+		opts.BatchMode = batch.ModeAtomic
+	}
+
+	if opts.BatchMaxOperations <= 0 {
+		opts.BatchMaxOperations = 100
+	}
+
+	if opts.RestoreWindow <= 0 {
+		opts.RestoreWindow = 24 * time.Hour
+	}
+
+	if opts.DeletedRetention <= 0 {
+		opts.DeletedRetention = 30 * 24 * time.Hour
+	}
+
+	if opts.AbandonAfter <= 0 {
+		opts.AbandonAfter = 24 * time.Hour
+	}
+
 	return &cartUseCaseImpl{
-		repo: repo,
+		repo:   repo,
+		events: events,
+		outbox: outboxRepo,
+		tx:     tx,
+		opts:   opts,
 	}
 }
 
-func (cu *cartUseCaseImpl) AddItems(ctx context.Context, params product.Product) response.Response {
-	data, err := cu.repo.FindByKodeProduk(ctx, params.KodeProduk)
-	if err == nil {
-		data = product.Product{
-			ID:         data.ID,
-			Nama:       data.Nama,
-			KodeProduk: data.KodeProduk,
-			Kuantitas:  data.Kuantitas + params.Kuantitas,
-			UpdateAt:   time.Now(),
+// AddItems adds params to the cart, or raises the quantity of an existing
+// line. ifMatch, when set, must match the current cart ETag; a non-zero
+// params.Version must match the line being raised.
+func (cu *cartUseCaseImpl) AddItems(ctx context.Context, params product.Product, ifMatch string) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.AddItems")
+	defer span.End()
+
+	if err := cu.authorize(ctx, rbac.ActionEditCart); err != nil {
+		return failure(err)
+	}
+
+	var res response.Response
+
+	err := cu.within(ctx, func(ctx context.Context) error {
+		if err := cu.checkPrecondition(ctx, ifMatch); err != nil {
+			return err
+		}
+
+		data, err := cu.repo.FindByKodeProduk(ctx, params.KodeProduk)
+		if err != nil && !errors.Is(err, exception.ErrNotFound) {
+			return err
 		}
 
-		err := cu.repo.UpdateKuantitas(ctx, data.ID, data)
+		if err == nil {
+			version := data.Version
+			if params.Version != 0 {
+				version = params.Version
+			}
+
+			data = product.Product{
+				ID:         data.ID,
+				Nama:       data.Nama,
+				KodeProduk: data.KodeProduk,
+				Kuantitas:  data.Kuantitas + params.Kuantitas,
+				CreatedAt:  data.CreatedAt,
+				UpdateAt:   time.Now(),
+				Version:    version + 1,
+			}
+
+			if err := cu.repo.UpdateKuantitasIfVersion(ctx, data.ID, version, data); err != nil {
+				return err
+			}
+
+			if err := cu.record(ctx, cartevent.Event{
+				CartID:          data.ID,
+				KodeProduk:      data.KodeProduk,
+				Action:          cartevent.ActionUpdateKuantitas,
+				KuantitasBefore: data.Kuantitas - params.Kuantitas,
+				KuantitasAfter:  data.Kuantitas,
+			}); err != nil {
+				return err
+			}
+
+			res = response.Success(response.StatusOK, data)
+			return nil
+		}
+
+		item := product.Product{
+			ID:         params.ID,
+			Nama:       params.Nama,
+			KodeProduk: params.KodeProduk,
+			Kuantitas:  params.Kuantitas,
+			CreatedAt:  time.Now(),
+			Version:    1,
+		}
+
+		ID, err := cu.repo.Add(ctx, item)
 		if err != nil {
-			return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+			return err
 		}
 
-		return response.Success(response.StatusOK, data)
-	}
+		item.ID = ID
 
-	item := product.Product{
-		ID:         params.ID,
-		Nama:       params.Nama,
-		KodeProduk: params.KodeProduk,
-		Kuantitas:  params.Kuantitas,
-		CreatedAt:  time.Now(),
-	}
+		if err := cu.record(ctx, cartevent.Event{
+			CartID:         item.ID,
+			KodeProduk:     item.KodeProduk,
+			Action:         cartevent.ActionAdd,
+			KuantitasAfter: item.Kuantitas,
+		}); err != nil {
+			return err
+		}
+
+		res = response.Success(response.StatusCreated, item)
+		return nil
+	})
 
-	ID, err := cu.repo.Add(ctx, item)
 	if err != nil {
-		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+		return failure(err)
 	}
 
-	item.ID = ID
-
-	return response.Success(response.StatusCreated, item)
+	return res
 }
 
 func (cu *cartUseCaseImpl) GetItems(ctx context.Context, params filter.Filter) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.GetItems")
+	defer span.End()
+
+	if err := cu.authorize(ctx, rbac.ActionViewCart); err != nil {
+		return failure(err)
+	}
 
 	if params.Nama != "" || params.Kuantitas != 0 {
 		data, err := cu.repo.FindByFilter(ctx, params)
@@ -78,10 +220,17 @@ func (cu *cartUseCaseImpl) GetItems(ctx context.Context, params filter.Filter) r
 			return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
 		}
 
-		return response.Success(response.StatusOK, data)
+		// If-Match is checked against the whole cart, so that is the ETag
+		// a filtered view hands out too
+		all, err := cu.repo.FindAll(ctx)
+		if err != nil && err != exception.ErrNotFound {
+			return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+		}
+
+		return cartResponse(data, cartETag(all))
 	}
 
-	data, err := cu.repo.FindAll()
+	data, err := cu.repo.FindAll(ctx)
 
 	if err == exception.ErrNotFound {
 		return response.Error(response.StatusNotFound, exception.ErrNotFound)
@@ -91,24 +240,794 @@ func (cu *cartUseCaseImpl) GetItems(ctx context.Context, params filter.Filter) r
 		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
 	}
 
-	return response.Success(response.StatusOK, data)
+	return cartResponse(data, cartETag(data))
 }
 
-func (cu *cartUseCaseImpl) DeleteItems(ctx context.Context, kodeProduk string) response.Response {
-	user, err := cu.repo.FindByKodeProduk(ctx, kodeProduk)
-	if err == exception.ErrNotFound {
-		return response.Error(response.StatusNotFound, exception.ErrNotFound)
+// FindItems returns the lines for the listed kodeProduks in one query.
+// Unknown codes are simply absent, so the result may be empty.
+func (cu *cartUseCaseImpl) FindItems(ctx context.Context, kodeProduks []string) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.FindItems")
+	defer span.End()
+
+	if err := cu.authorize(ctx, rbac.ActionViewCart); err != nil {
+		return failure(err)
 	}
 
+	data, err := cu.repo.FindByKodeProduks(ctx, kodeProduks)
 	if err != nil {
 		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
 	}
 
-	if err := cu.repo.Delete(ctx, user.ID); err != nil {
-		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	if data == nil {
+		data = []product.Product{}
+	}
+
+	return response.Success(response.StatusOK, data)
+}
+
+// DeleteItems removes the line for kodeProduk. ifMatch, when set, must
+// match the current cart ETag.
+func (cu *cartUseCaseImpl) DeleteItems(ctx context.Context, kodeProduk string, ifMatch string) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.DeleteItems")
+	defer span.End()
+
+	if err := cu.authorize(ctx, rbac.ActionEditCart); err != nil {
+		return failure(err)
+	}
+
+	err := cu.within(ctx, func(ctx context.Context) error {
+		if err := cu.checkPrecondition(ctx, ifMatch); err != nil {
+			return err
+		}
+
+		user, err := cu.repo.FindByKodeProduk(ctx, kodeProduk)
+		if err != nil {
+			return err
+		}
+
+		if err := cu.repo.Delete(ctx, user.ID); err != nil {
+			return err
+		}
+
+		return cu.record(ctx, cartevent.Event{
+			CartID:          user.ID,
+			KodeProduk:      user.KodeProduk,
+			Action:          cartevent.ActionDelete,
+			KuantitasBefore: user.Kuantitas,
+		})
+	})
+
+	if err != nil {
+		return failure(err)
 	}
 
 	msg := "Success Delete Data"
 
 	return response.Success(response.StatusOK, msg)
 }
+
+// BatchItems applies a list of add, set and remove operations to the cart.
+// Existing lines are read with one query and the resulting inserts, updates
+// and deletes are each written with one statement. In atomic mode any
+// failing operation rejects the whole batch; in partial mode the failing
+// operations are reported and the rest are applied. params.Mode overrides
+// the configured mode.
+func (cu *cartUseCaseImpl) BatchItems(ctx context.Context, params batch.Batch, ifMatch string) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.BatchItems")
+	defer span.End()
+
+	if err := cu.authorize(ctx, rbac.ActionEditCart); err != nil {
+		return failure(err)
+	}
+
+	if len(params.Operations) > cu.opts.BatchMaxOperations {
+		return response.Error(response.StatusBadRequest, exception.ErrBatchTooLarge)
+	}
+
+	mode := params.Mode
+	if mode == "" {
+		mode = cu.opts.BatchMode
+	}
+
+	var results []batch.Result
+	var failed bool
+
+	err := cu.within(ctx, func(ctx context.Context) error {
+		if err := cu.checkPrecondition(ctx, ifMatch); err != nil {
+			return err
+		}
+
+		kodeProduks := make([]string, 0, len(params.Operations))
+		for _, op := range params.Operations {
+			kodeProduks = append(kodeProduks, op.KodeProduk)
+		}
+
+		existing, err := cu.repo.FindByKodeProduks(ctx, unique(kodeProduks))
+		if err != nil {
+			return err
+		}
+
+		plan := newBatchPlan(existing)
+		results, failed = plan.apply(params.Operations)
+
+		if failed && mode == batch.ModeAtomic {
+			for i := range results {
+				if results[i].Error == "" {
+					results[i].Status = batch.StatusAborted
+				}
+			}
+
+			return nil
+		}
+
+		now := time.Now()
+		inserts, updates, deletes := plan.changes(now)
+
+		if err := cu.repo.DeleteBatch(ctx, deletes); err != nil {
+			return err
+		}
+
+		if err := cu.repo.UpdateKuantitasBatch(ctx, updates); err != nil {
+			return err
+		}
+
+		if err := cu.repo.AddBatch(ctx, inserts); err != nil {
+			return err
+		}
+
+		current, err := cu.repo.FindByKodeProduks(ctx, plan.kept())
+		if err != nil {
+			return err
+		}
+
+		byKode := make(map[string]product.Product, len(current))
+		for _, item := range current {
+			byKode[item.KodeProduk] = item
+		}
+
+		if err := cu.record(ctx, plan.events(byKode)...); err != nil {
+			return err
+		}
+
+		for i := range results {
+			if results[i].Error != "" || results[i].Op == batch.OpRemove {
+				continue
+			}
+
+			if item, ok := byKode[results[i].KodeProduk]; ok {
+				results[i].Item = &item
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return failure(err)
+	}
+
+	if failed && mode == batch.ModeAtomic {
+		return response.ErrorWithData(response.StatusUnprocessableEntity, exception.ErrBatchFailed, results)
+	}
+
+	return response.Success(response.StatusOK, results)
+}
+
+// RestoreItems brings back the most recently removed line for kodeProduk
+// if it was removed within the restore window. A line that is already in
+// the cart again is reported as a conflict.
+func (cu *cartUseCaseImpl) RestoreItems(ctx context.Context, kodeProduk string, ifMatch string) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.RestoreItems")
+	defer span.End()
+
+	if err := cu.authorize(ctx, rbac.ActionEditCart); err != nil {
+		return failure(err)
+	}
+
+	var data product.Product
+
+	err := cu.within(ctx, func(ctx context.Context) error {
+		if err := cu.checkPrecondition(ctx, ifMatch); err != nil {
+			return err
+		}
+
+		switch _, err := cu.repo.FindByKodeProduk(ctx, kodeProduk); {
+		case err == nil:
+			return exception.ErrConflicted
+		case !errors.Is(err, exception.ErrNotFound):
+			return err
+		}
+
+		deleted, err := cu.repo.FindDeletedByKodeProduk(ctx, kodeProduk, time.Now().Add(-cu.opts.RestoreWindow))
+		if err != nil {
+			return err
+		}
+
+		if err := cu.repo.Restore(ctx, deleted.ID); err != nil {
+			return err
+		}
+
+		if err := cu.record(ctx, cartevent.Event{
+			CartID:         deleted.ID,
+			KodeProduk:     deleted.KodeProduk,
+			Action:         cartevent.ActionRestore,
+			KuantitasAfter: deleted.Kuantitas,
+		}); err != nil {
+			return err
+		}
+
+		data = deleted
+		data.DeletedAt = nil
+		data.UpdateAt = time.Now()
+		data.Version++
+
+		return nil
+	})
+
+	if err != nil {
+		return failure(err)
+	}
+
+	return response.Success(response.StatusOK, data)
+}
+
+// purgeBatchSize bounds each purge statement so it never holds locks on a
+// large part of the table.
+const purgeBatchSize = 500
+
+// PurgeDeleted hard-deletes lines removed longer ago than the retention
+// period and reports how many were purged.
+func (cu *cartUseCaseImpl) PurgeDeleted(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CartUseCase.PurgeDeleted")
+	defer span.End()
+
+	before := time.Now().Add(-cu.opts.DeletedRetention)
+
+	var total int64
+	for {
+		purged, err := cu.repo.Purge(ctx, before, purgeBatchSize)
+		total += purged
+
+		if err != nil {
+			return total, err
+		}
+
+		if purged < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// Checkout empties the cart and publishes CartCheckedOut with the lines
+// it held. ifMatch, when set, must match the current cart ETag.
+func (cu *cartUseCaseImpl) Checkout(ctx context.Context, ifMatch string) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.Checkout")
+	defer span.End()
+
+	if err := cu.authorize(ctx, rbac.ActionEditCart); err != nil {
+		return failure(err)
+	}
+
+	var checkedOut event.CartCheckedOut
+
+	err := cu.within(ctx, func(ctx context.Context) error {
+		if err := cu.checkPrecondition(ctx, ifMatch); err != nil {
+			return err
+		}
+
+		data, err := cu.repo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(data) == 0 {
+			return exception.ErrCartEmpty
+		}
+
+		ids := make([]int64, 0, len(data))
+		audits := make([]cartevent.Event, 0, len(data))
+		checkedOut = event.CartCheckedOut{Items: lines(data)}
+
+		for _, item := range data {
+			ids = append(ids, item.ID)
+			audits = append(audits, cartevent.Event{
+				CartID:          item.ID,
+				KodeProduk:      item.KodeProduk,
+				Action:          cartevent.ActionCheckout,
+				KuantitasBefore: item.Kuantitas,
+			})
+			checkedOut.TotalKuantitas += item.Kuantitas
+		}
+
+		if err := cu.repo.DeleteBatch(ctx, ids); err != nil {
+			return err
+		}
+
+		if err := cu.record(ctx, audits...); err != nil {
+			return err
+		}
+
+		return cu.publish(ctx, event.TypeCartCheckedOut, checkedOut)
+	})
+
+	if err != nil {
+		return failure(err)
+	}
+
+	return response.Success(response.StatusOK, checkedOut)
+}
+
+// AuthorizeLine reports line cartID as not found unless the caller may
+// view the cart holding it.
+func (cu *cartUseCaseImpl) AuthorizeLine(ctx context.Context, cartID int64) error {
+	ctx, span := tracing.Start(ctx, "CartUseCase.AuthorizeLine")
+	defer span.End()
+
+	owner, err := cu.repo.FindOwner(ctx, cartID)
+	if err != nil {
+		return err
+	}
+
+	if err := rbac.AuthorizeCart(ctx, rbac.ActionViewCart, owner); err != nil {
+		return exception.ErrNotFound
+	}
+
+	if owner == rbac.Own(ctx) {
+		return nil
+	}
+
+	return cu.access(ctx, rbac.ActionViewCart, owner)
+}
+
+// ListCarts returns one page of the carts matching params, for support and
+// admin. page starts at 1; a zero PageSize means DefaultPageSize.
+func (cu *cartUseCaseImpl) ListCarts(ctx context.Context, params cartsummary.Filter) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.ListCarts")
+	defer span.End()
+
+	if params.PageSize == 0 {
+		params.PageSize = DefaultPageSize
+	}
+
+	if params.Page < 1 || params.PageSize < 1 || params.PageSize > MaxPageSize {
+		return response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+	}
+
+	if err := cu.authorize(ctx, rbac.ActionListCarts); err != nil {
+		return failure(err)
+	}
+
+	total, err := cu.repo.CountCarts(ctx, params.Q)
+	if err != nil {
+		return failure(err)
+	}
+
+	carts, err := cu.repo.FindCarts(ctx, params)
+	if err != nil {
+		return failure(err)
+	}
+
+	if carts == nil {
+		carts = []cartsummary.Summary{}
+	}
+
+	return response.Success(response.StatusOK, cartsummary.List{
+		Carts:    carts,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		Total:    total,
+	})
+}
+
+// Catalog returns the products held in any cart, for support and admin:
+// each distinct kodeProduk and nama of the live lines.
+func (cu *cartUseCaseImpl) Catalog(ctx context.Context) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.Catalog")
+	defer span.End()
+
+	if err := cu.authorize(ctx, rbac.ActionListCarts); err != nil {
+		return failure(err)
+	}
+
+	entries, err := cu.repo.FindCatalog(ctx)
+	if err != nil {
+		return failure(err)
+	}
+
+	if entries == nil {
+		entries = []catalog.Entry{}
+	}
+
+	return response.Success(response.StatusOK, entries)
+}
+
+// Expire removes every line of the cart the request acts on, as an admin
+// giving up on it, and returns the removed lines. Each line is published
+// as ItemRemoved.
+func (cu *cartUseCaseImpl) Expire(ctx context.Context) response.Response {
+	ctx, span := tracing.Start(ctx, "CartUseCase.Expire")
+	defer span.End()
+
+	if err := cu.authorize(ctx, rbac.ActionExpireCart); err != nil {
+		return failure(err)
+	}
+
+	var data []product.Product
+
+	err := cu.within(ctx, func(ctx context.Context) error {
+		var err error
+
+		data, err = cu.repo.FindAll(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(data) == 0 {
+			return exception.ErrCartEmpty
+		}
+
+		ids := make([]int64, 0, len(data))
+		audits := make([]cartevent.Event, 0, len(data))
+
+		for _, item := range data {
+			ids = append(ids, item.ID)
+			audits = append(audits, cartevent.Event{
+				CartID:          item.ID,
+				KodeProduk:      item.KodeProduk,
+				Action:          cartevent.ActionExpire,
+				KuantitasBefore: item.Kuantitas,
+			})
+		}
+
+		if err := cu.repo.DeleteBatch(ctx, ids); err != nil {
+			return err
+		}
+
+		return cu.record(ctx, audits...)
+	})
+
+	if err != nil {
+		return failure(err)
+	}
+
+	return response.Success(response.StatusOK, data)
+}
+
+// abandonPageSize is how many carts DetectAbandoned reads at a time.
+const abandonPageSize = 100
+
+// DetectAbandoned publishes CartAbandoned once for every non-empty cart
+// that has not changed for AbandonAfter, and returns how many it reported.
+// A cart that fails is skipped and its error returned with the others.
+func (cu *cartUseCaseImpl) DetectAbandoned(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "CartUseCase.DetectAbandoned")
+	defer span.End()
+
+	var (
+		reported int
+		errs     []error
+	)
+
+	for page := 1; ; page++ {
+		carts, err := cu.repo.FindCarts(ctx, cartsummary.Filter{Page: page, PageSize: abandonPageSize})
+		if err != nil {
+			return reported, errors.Join(append(errs, err)...)
+		}
+
+		for _, c := range carts {
+			if time.Since(c.LastActivityAt) < cu.opts.AbandonAfter {
+				continue
+			}
+
+			abandoned, err := cu.detectAbandoned(rbac.WithCart(ctx, c.Owner))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cart %s: %w", c.Owner, err))
+				continue
+			}
+
+			if abandoned {
+				reported++
+			}
+		}
+
+		if len(carts) < abandonPageSize {
+			return reported, errors.Join(errs...)
+		}
+	}
+}
+
+// detectAbandoned reports the cart the request acts on if it is
+// abandoned and has not been reported since its last change.
+func (cu *cartUseCaseImpl) detectAbandoned(ctx context.Context) (bool, error) {
+	var abandoned bool
+
+	err := cu.within(ctx, func(ctx context.Context) error {
+		data, err := cu.repo.FindAll(ctx)
+		if err != nil || len(data) == 0 {
+			return err
+		}
+
+		var lastActivity time.Time
+		for _, item := range data {
+			if item.CreatedAt.After(lastActivity) {
+				lastActivity = item.CreatedAt
+			}
+
+			if item.UpdateAt.After(lastActivity) {
+				lastActivity = item.UpdateAt
+			}
+		}
+
+		if time.Since(lastActivity) < cu.opts.AbandonAfter {
+			return nil
+		}
+
+		// one event per cart and period of inactivity
+		reported, err := cu.outbox.LastOccurredAt(ctx, event.TypeCartAbandoned, rbac.Cart(ctx))
+		if err != nil {
+			return err
+		}
+
+		if !reported.Before(lastActivity) {
+			return nil
+		}
+
+		abandoned = true
+
+		return cu.publish(ctx, event.TypeCartAbandoned, event.CartAbandoned{
+			Items:          lines(data),
+			LastActivityAt: lastActivity,
+		})
+	})
+
+	return abandoned, err
+}
+
+// authorize checks action against the cart the request acts on, and
+// records the access when the caller reaches beyond their own cart.
+func (cu *cartUseCaseImpl) authorize(ctx context.Context, action rbac.Action) error {
+	if err := rbac.Authorize(ctx, action); err != nil {
+		return err
+	}
+
+	switch {
+	case action == rbac.ActionListCarts:
+		return cu.access(ctx, action, "")
+	case rbac.Foreign(ctx):
+		return cu.access(ctx, action, rbac.Cart(ctx))
+	default:
+		return nil
+	}
+}
+
+// access records the caller taking action on the cart of owner; an empty
+// owner stands for all carts.
+func (cu *cartUseCaseImpl) access(ctx context.Context, action rbac.Action, owner string) error {
+	if cu.opts.Access == nil {
+		return nil
+	}
+
+	info := requestinfo.From(ctx)
+
+	return cu.opts.Access.Append(ctx, cartevent.Access{
+		Actor:     info.Actor,
+		Role:      string(rbac.RoleOf(ctx)),
+		Action:    string(action),
+		Owner:     owner,
+		RequestID: info.RequestID,
+		SourceIP:  info.SourceIP,
+		CreatedAt: time.Now(),
+	})
+}
+
+// pendingKey carries the domain events written by the running unit of work.
+type pendingKey struct{}
+
+// within runs fn in a transaction and notifies the domain events it wrote
+// once the transaction has committed.
+func (cu *cartUseCaseImpl) within(ctx context.Context, fn func(ctx context.Context) error) error {
+	var pending []event.Envelope
+
+	err := cu.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// a retried transaction starts over
+		pending = pending[:0]
+
+		return fn(context.WithValue(ctx, pendingKey{}, &pending))
+	})
+
+	if err == nil && cu.opts.Notifier != nil && len(pending) > 0 {
+		cu.opts.Notifier.Notify(pending...)
+	}
+
+	return err
+}
+
+// appendOutbox writes envelopes to the outbox and keeps them for the
+// notifier.
+func (cu *cartUseCaseImpl) appendOutbox(ctx context.Context, envelopes ...event.Envelope) error {
+	if err := cu.outbox.Append(ctx, envelopes...); err != nil {
+		return err
+	}
+
+	if pending, ok := ctx.Value(pendingKey{}).(*[]event.Envelope); ok {
+		*pending = append(*pending, envelopes...)
+	}
+
+	return nil
+}
+
+// record appends audit events for the current request, together with the
+// matching domain events for the outbox. It must run inside the unit of
+// work that made the change.
+func (cu *cartUseCaseImpl) record(ctx context.Context, events ...cartevent.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	info := requestinfo.From(ctx)
+	now := time.Now()
+
+	envelopes := make([]event.Envelope, 0, len(events))
+
+	for i := range events {
+		events[i].Actor = info.Actor
+		events[i].RequestID = info.RequestID
+		events[i].SourceIP = info.SourceIP
+		events[i].CreatedAt = now
+
+		eventType, data := domainEvent(events[i])
+		if eventType == "" {
+			continue
+		}
+
+		envelope, err := newEnvelope(ctx, eventType, data, now)
+		if err != nil {
+			return err
+		}
+
+		envelopes = append(envelopes, envelope)
+	}
+
+	if err := cu.events.Append(ctx, events...); err != nil {
+		return err
+	}
+
+	return cu.appendOutbox(ctx, envelopes...)
+}
+
+// publish appends a single domain event to the outbox.
+func (cu *cartUseCaseImpl) publish(ctx context.Context, eventType string, data interface{}) error {
+	envelope, err := newEnvelope(ctx, eventType, data, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return cu.appendOutbox(ctx, envelope)
+}
+
+func newEnvelope(ctx context.Context, eventType string, data interface{}, now time.Time) (event.Envelope, error) {
+	envelope, err := event.New(eventType, data, now)
+	if err != nil {
+		return envelope, err
+	}
+
+	info := requestinfo.From(ctx)
+	envelope.Actor = info.Actor
+	envelope.RequestID = info.RequestID
+	envelope.Owner = rbac.Cart(ctx)
+
+	return envelope, nil
+}
+
+// domainEvent maps an audit event to the domain event other services
+// consume. Checkout lines map to nothing; the checkout itself is one
+// CartCheckedOut event.
+func domainEvent(e cartevent.Event) (string, interface{}) {
+	switch e.Action {
+	case cartevent.ActionAdd, cartevent.ActionRestore:
+		return event.TypeItemAdded, event.ItemAdded{
+			CartID:     e.CartID,
+			KodeProduk: e.KodeProduk,
+			Kuantitas:  e.KuantitasAfter,
+		}
+	case cartevent.ActionUpdateKuantitas:
+		return event.TypeQuantityChanged, event.QuantityChanged{
+			CartID:          e.CartID,
+			KodeProduk:      e.KodeProduk,
+			KuantitasBefore: e.KuantitasBefore,
+			KuantitasAfter:  e.KuantitasAfter,
+		}
+	case cartevent.ActionDelete, cartevent.ActionExpire:
+		return event.TypeItemRemoved, event.ItemRemoved{
+			CartID:     e.CartID,
+			KodeProduk: e.KodeProduk,
+			Kuantitas:  e.KuantitasBefore,
+		}
+	default:
+		return "", nil
+	}
+}
+
+func lines(data []product.Product) []event.Line {
+	items := make([]event.Line, 0, len(data))
+	for _, item := range data {
+		items = append(items, event.Line{
+			CartID:     item.ID,
+			KodeProduk: item.KodeProduk,
+			Nama:       item.Nama,
+			Kuantitas:  item.Kuantitas,
+		})
+	}
+
+	return items
+}
+
+// checkPrecondition compares an If-Match value against the ETag of the
+// whole cart. It runs inside the caller's transaction.
+func (cu *cartUseCaseImpl) checkPrecondition(ctx context.Context, ifMatch string) error {
+	if ifMatch == "" {
+		return nil
+	}
+
+	data, err := cu.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !etag.MatchState(ifMatch, cartETag(data)) {
+		return exception.ErrPreconditionFailed
+	}
+
+	return nil
+}
+
+// cartResponse carries the lines with their validators: tag, the ETag of
+// the whole cart, and, as Last-Modified, the latest update_at among them.
+func cartResponse(data []product.Product, tag string) response.Response {
+	res := response.Success(response.StatusOK, data).SetHeader("ETag", tag)
+
+	var modified time.Time
+	for _, item := range data {
+		if item.UpdateAt.After(modified) {
+			modified = item.UpdateAt
+		}
+	}
+
+	if !modified.IsZero() {
+		res.SetHeader("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	return res
+}
+
+// cartETag identifies a set of cart lines by their ids and versions, so it
+// changes whenever any line is written, added or removed.
+func cartETag(data []product.Product) string {
+	parts := make([]string, 0, len(data))
+	for _, item := range data {
+		parts = append(parts, fmt.Sprintf("%d:%d", item.ID, item.Version))
+	}
+
+	sort.Strings(parts)
+
+	return etag.New(parts...)
+}
+
+func failure(err error) response.Response {
+	switch err {
+	case exception.ErrNotFound:
+		return response.Error(response.StatusNotFound, exception.ErrNotFound)
+	case exception.ErrConflicted:
+		return response.Error(response.StatusConflicted, exception.ErrConflicted)
+	case exception.ErrVersionConflict:
+		return response.Error(response.StatusConflicted, exception.ErrVersionConflict)
+	case exception.ErrCartEmpty:
+		return response.Error(response.StatusUnprocessableEntity, exception.ErrCartEmpty)
+	case exception.ErrPreconditionFailed:
+		return response.Error(response.StatusPreconditionFailed, exception.ErrPreconditionFailed)
+	case exception.ErrForbidden:
+		return response.Error(response.StatusForbiddend, exception.ErrForbidden)
+	default:
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+}
@@ -0,0 +1,157 @@
+// Package v1 is the JSON contract of the /v1 cart routes. Handlers decode
+// requests into these types and map the models they get back onto them,
+// so a change to product.Product or batch.Batch does not reach clients.
+package v1
+
+import (
+	"time"
+
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/filter"
+	"github.com/Risuii/models/product"
+)
+
+type AddItemRequest struct {
+	Nama       string `json:"nama" validate:"required"`
+	KodeProduk string `json:"kodeProduk"`
+	Kuantitas  int64  `json:"kuantitas"`
+	// Version, when not zero, makes the update conditional on the line
+	// still being at that version.
+	Version int64 `json:"version"`
+}
+
+func (req AddItemRequest) Product() product.Product {
+	return product.Product{
+		Nama:       req.Nama,
+		KodeProduk: req.KodeProduk,
+		Kuantitas:  req.Kuantitas,
+		Version:    req.Version,
+	}
+}
+
+type FilterRequest struct {
+	Nama      string `json:"nama"`
+	Kuantitas int64  `json:"kuantitas"`
+}
+
+func (req FilterRequest) Filter() filter.Filter {
+	return filter.Filter{
+		Nama:      req.Nama,
+		Kuantitas: req.Kuantitas,
+	}
+}
+
+type DeleteItemRequest struct {
+	KodeProduk string `json:"kodeProduk" validate:"required"`
+}
+
+type BatchOperation struct {
+	Op         string `json:"op" validate:"required,oneof=add set remove"`
+	KodeProduk string `json:"kodeProduk" validate:"required"`
+	Nama       string `json:"nama"`
+	Kuantitas  int64  `json:"kuantitas" validate:"min=0"`
+}
+
+type BatchRequest struct {
+	Mode       string           `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,dive"`
+}
+
+func (req BatchRequest) Batch() batch.Batch {
+	operations := make([]batch.Operation, len(req.Operations))
+	for i, op := range req.Operations {
+		operations[i] = batch.Operation{
+			Op:         op.Op,
+			KodeProduk: op.KodeProduk,
+			Nama:       op.Nama,
+			Kuantitas:  op.Kuantitas,
+		}
+	}
+
+	return batch.Batch{Mode: req.Mode, Operations: operations}
+}
+
+// Item is a cart line.
+type Item struct {
+	ID         int64      `json:"id"`
+	Nama       string     `json:"nama"`
+	KodeProduk string     `json:"kodeProduk"`
+	Kuantitas  int64      `json:"kuantitas"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdateAt   time.Time  `json:"update_at"`
+	Version    int64      `json:"version"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+func NewItem(p product.Product) Item {
+	return Item{
+		ID:         p.ID,
+		Nama:       p.Nama,
+		KodeProduk: p.KodeProduk,
+		Kuantitas:  p.Kuantitas,
+		CreatedAt:  p.CreatedAt,
+		UpdateAt:   p.UpdateAt,
+		Version:    p.Version,
+		DeletedAt:  p.DeletedAt,
+	}
+}
+
+func NewItems(products []product.Product) []Item {
+	items := make([]Item, len(products))
+	for i, p := range products {
+		items[i] = NewItem(p)
+	}
+
+	return items
+}
+
+// BatchResult is the outcome of one operation of a batch.
+type BatchResult struct {
+	Index      int    `json:"index"`
+	Op         string `json:"op"`
+	KodeProduk string `json:"kodeProduk"`
+	Status     string `json:"status"`
+	Item       *Item  `json:"item,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func NewBatchResults(results []batch.Result) []BatchResult {
+	out := make([]BatchResult, len(results))
+	for i, result := range results {
+		out[i] = BatchResult{
+			Index:      result.Index,
+			Op:         result.Op,
+			KodeProduk: result.KodeProduk,
+			Status:     result.Status,
+			Error:      result.Error,
+		}
+
+		if result.Item != nil {
+			item := NewItem(*result.Item)
+			out[i].Item = &item
+		}
+	}
+
+	return out
+}
+
+// Present replaces the models res carries with their v1 shape; any other
+// data is left as it is.
+func Present(res response.Response) response.Response {
+	impl, ok := res.(*response.ResponseImpl)
+	if !ok {
+		return res
+	}
+
+	switch data := impl.Data.(type) {
+	case product.Product:
+		impl.Data = NewItem(data)
+	case []product.Product:
+		impl.Data = NewItems(data)
+	case []batch.Result:
+		impl.Data = NewBatchResults(data)
+	}
+
+	return res
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/helpers/response"
+	v1 "github.com/Risuii/internal/cart/v1"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/catalog"
+)
+
+// DefaultBatchSize is the BatchSize of a zero Dependencies.
+const DefaultBatchSize = 100
+
+func exportCatalog(ctx context.Context, deps Dependencies, args []string) error {
+	cmd := newCommand("catalog export", OutputCSV)
+
+	if err := noArguments(cmd, args); err != nil {
+		return err
+	}
+
+	data, err := result(deps.Cart.Catalog(ctx))
+	if err != nil {
+		return err
+	}
+
+	entries, _ := data.([]catalog.Entry)
+	if len(entries) == 0 && *cmd.output == OutputTable {
+		return nil
+	}
+
+	return cmd.print(deps.Out, entries)
+}
+
+// importCatalog sets every entry of a file in a cart, in atomic batches
+// of at most deps.BatchSize operations. The results of the batches sent
+// are printed even when one fails; the batches before it stay applied.
+func importCatalog(ctx context.Context, deps Dependencies, args []string) error {
+	cmd := newCommand("catalog import")
+	owner := cmd.flags.String("owner", rbac.CartAnonymous, "cart to import into")
+	file := cmd.flags.String("file", "", "catalog to import, as catalog export writes it")
+	format := cmd.flags.String("format", "", "csv or json, by default the extension of -file")
+	kuantitas := cmd.flags.Int64("kuantitas", 1, "kuantitas of every imported line")
+
+	if err := noArguments(cmd, args); err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return usageError("catalog import: missing -file")
+	}
+
+	if *kuantitas < 1 {
+		return usageError("catalog import: -kuantitas must be at least 1")
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	if *format != OutputCSV && *format != OutputJSON {
+		return usageError("catalog import: -format must be %s or %s", OutputCSV, OutputJSON)
+	}
+
+	entries, err := readCatalog(*file, *format)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return usageError("catalog import: %s has no entries", *file)
+	}
+
+	for i, entry := range entries {
+		if err := deps.Validate.StructCtx(ctx, entry); err != nil {
+			return usageError("catalog import: entry %d: %v", i+1, err)
+		}
+	}
+
+	size := deps.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	ctx = rbac.WithCart(ctx, *owner)
+	results := []v1.BatchResult{}
+
+	for start := 0; start < len(entries); start += size {
+		end := start + size
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		lines := v1.BatchRequest{Mode: batch.ModeAtomic}
+		for _, entry := range entries[start:end] {
+			lines.Operations = append(lines.Operations, v1.BatchOperation{
+				Op:         batch.OpSet,
+				KodeProduk: entry.KodeProduk,
+				Nama:       entry.Nama,
+				Kuantitas:  *kuantitas,
+			})
+		}
+
+		res := v1.Present(deps.Cart.BatchItems(ctx, lines.Batch(), ""))
+
+		// the indexes of a batch count from its own first operation
+		if impl, ok := res.(*response.ResponseImpl); ok {
+			chunk, _ := impl.Data.([]v1.BatchResult)
+			for _, r := range chunk {
+				r.Index += start
+				results = append(results, r)
+			}
+		}
+
+		if err = res.Err(); err != nil {
+			break
+		}
+	}
+
+	if printErr := cmd.print(deps.Out, results); printErr != nil && err == nil {
+		err = printErr
+	}
+
+	return err
+}
+
+// readCatalog reads the entries of file: CSV with a kodeProduk and a nama
+// column named in its header, or a JSON array.
+func readCatalog(file, format string) ([]catalog.Entry, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var entries []catalog.Entry
+
+	if format == OutputJSON {
+		if err := json.NewDecoder(f).Decode(&entries); err != nil {
+			return nil, usageError("catalog import: %s: %v", file, err)
+		}
+
+		return entries, nil
+	}
+
+	reader := csv.NewReader(f)
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, usageError("catalog import: %s: %v", file, err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	kode, hasKode := columns["kodeProduk"]
+	nama, hasNama := columns["nama"]
+	if !hasKode || !hasNama {
+		return nil, usageError("catalog import: %s: the header needs the kodeProduk and nama columns", file)
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return nil, usageError("catalog import: %s: %v", file, err)
+		}
+
+		entries = append(entries, catalog.Entry{KodeProduk: record[kode], Nama: record[nama]})
+	}
+}
@@ -0,0 +1,215 @@
+// Package cli holds the admin commands of the binary. They run on the same
+// use cases as the HTTP routes, as an operator with the admin role, so
+// their changes are authorized, audited and published like any other.
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/cart"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+)
+
+// ErrUsage is returned for a command line that names no known command or
+// has invalid flags.
+var ErrUsage = errors.New("usage")
+
+// Usage describes the commands, for the help output of the binary.
+const Usage = `usage: app [config flags] [command] [flags]
+
+commands:
+  serve                        run the HTTP and gRPC servers (the default)
+  migrate [up|down|version]    apply, revert (-steps n) or show the schema migrations
+  carts list                   search carts (-q, -page, -page-size)
+  carts show <owner>           print the lines of a cart
+  carts clear <owner>          remove every line of a cart
+  seed                         set sample lines in a cart (-owner, -file)
+  purge-expired                hard-delete lines removed longer than cart.deletedRetention ago
+  catalog export               print the products held in any cart (-o csv for a file to import)
+  catalog import               set the products of a CSV or JSON file in a cart
+                               (-file, -format, -owner, -kuantitas)
+
+Every command but serve prints a table, or JSON with -o json.
+`
+
+type (
+	// Dependencies are what the commands run on, wired like the server.
+	Dependencies struct {
+		Validate *validator.Validate
+		Cart     cart.CartUseCase
+		Migrator Migrator
+		// BatchSize caps the operations catalog import sends in one batch;
+		// it should not exceed cart.batchMaxOperations. Zero means 100.
+		BatchSize int
+		// Operator names whoever runs the commands in the audit records.
+		Operator string
+		Out      io.Writer
+	}
+
+	// Migrator applies the schema migrations shipped with the binary.
+	Migrator interface {
+		Up(ctx context.Context) ([]int64, error)
+		Down(ctx context.Context, steps int) ([]int64, error)
+		Version(ctx context.Context) (int64, bool, error)
+	}
+)
+
+// Run runs the command named by args.
+func Run(ctx context.Context, deps Dependencies, args []string) error {
+	if len(args) == 0 {
+		return usageError("missing command")
+	}
+
+	ctx = auth.With(ctx, auth.Principal{Kind: auth.KindOperator, Subject: deps.Operator})
+
+	switch args[0] {
+	case "migrate":
+		return migrate(ctx, deps, args[1:])
+	case "carts":
+		if len(args) < 2 {
+			return usageError("carts: missing list, show or clear")
+		}
+
+		switch args[1] {
+		case "list":
+			return listCarts(ctx, deps, args[2:])
+		case "show":
+			return showCart(ctx, deps, args[2:])
+		case "clear":
+			return clearCart(ctx, deps, args[2:])
+		}
+
+		return usageError("carts: unknown command %q", args[1])
+	case "seed":
+		return seed(ctx, deps, args[1:])
+	case "purge-expired":
+		return purgeExpired(ctx, deps, args[1:])
+	case "catalog":
+		if len(args) < 2 {
+			return usageError("catalog: missing export or import")
+		}
+
+		switch args[1] {
+		case "export":
+			return exportCatalog(ctx, deps, args[2:])
+		case "import":
+			return importCatalog(ctx, deps, args[2:])
+		}
+
+		return usageError("catalog: unknown command %q", args[1])
+	}
+
+	return usageError("unknown command %q", args[0])
+}
+
+func usageError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUsage, fmt.Sprintf(format, args...))
+}
+
+// command is the flags of one command, -o among them.
+type command struct {
+	name    string
+	flags   *flag.FlagSet
+	output  *string
+	outputs []string
+}
+
+func newCommand(name string, outputs ...string) *command {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	outputs = append([]string{OutputTable, OutputJSON}, outputs...)
+
+	return &command{
+		name:    name,
+		flags:   fs,
+		output:  fs.String("o", OutputTable, "output format, "+strings.Join(outputs, ", ")),
+		outputs: outputs,
+	}
+}
+
+// parse parses args, letting flags come after the positional arguments
+// too, and returns the positional ones.
+func (c *command) parse(args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := c.flags.Parse(args); err != nil {
+			return nil, usageError("%s: %v", c.name, err)
+		}
+
+		if c.flags.NArg() == 0 {
+			break
+		}
+
+		positional = append(positional, c.flags.Arg(0))
+		args = c.flags.Args()[1:]
+	}
+
+	for _, output := range c.outputs {
+		if *c.output == output {
+			return positional, nil
+		}
+	}
+
+	return nil, usageError("%s: -o must be one of %s", c.name, strings.Join(c.outputs, ", "))
+}
+
+// print writes data as indented JSON, or as CSV or a table with a column
+// per field the way the API names them.
+func (c *command) print(w io.Writer, data interface{}) error {
+	if *c.output == OutputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(data)
+	}
+
+	list := reflect.ValueOf(data)
+	if list.Kind() != reflect.Slice {
+		list = reflect.Append(reflect.MakeSlice(reflect.SliceOf(list.Type()), 0, 1), list)
+	}
+
+	// the CSV of the API has the columns of the table
+	var buf bytes.Buffer
+	res := response.Success(response.StatusOK, list.Interface()).(*response.ResponseImpl)
+	if err := (response.CSVEncoder{}).Encode(&buf, res); err != nil {
+		return err
+	}
+
+	if *c.output == OutputCSV {
+		_, err := buf.WriteTo(w)
+		return err
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, record := range records {
+		fmt.Fprintln(table, strings.Join(record, "\t"))
+	}
+
+	return table.Flush()
+}
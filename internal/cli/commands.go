@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/helpers/response"
+	v1 "github.com/Risuii/internal/cart/v1"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/filter"
+)
+
+// SampleLines is what seed sets in a cart without -file.
+var SampleLines = v1.BatchRequest{
+	Mode: batch.ModeAtomic,
+	Operations: []v1.BatchOperation{
+		{Op: batch.OpSet, KodeProduk: "KOPI-01", Nama: "Kopi Susu", Kuantitas: 2},
+		{Op: batch.OpSet, KodeProduk: "ROTI-01", Nama: "Roti Bakar", Kuantitas: 1},
+		{Op: batch.OpSet, KodeProduk: "TEH-01", Nama: "Teh Manis", Kuantitas: 3},
+	},
+}
+
+type (
+	migration struct {
+		Version   int64  `json:"version"`
+		Direction string `json:"direction"`
+	}
+
+	migrationVersion struct {
+		Version int64 `json:"version"`
+		Dirty   bool  `json:"dirty"`
+	}
+
+	purged struct {
+		Purged int64 `json:"purged"`
+	}
+)
+
+func migrate(ctx context.Context, deps Dependencies, args []string) error {
+	cmd := newCommand("migrate")
+	steps := cmd.flags.Int("steps", 1, "migrations to revert with down")
+
+	positional, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+
+	action := "up"
+	if len(positional) > 0 {
+		action = positional[0]
+	}
+
+	if len(positional) > 1 {
+		return usageError("migrate: unexpected %q", positional[1])
+	}
+
+	var applied []int64
+	direction := action
+
+	switch action {
+	case "up":
+		applied, err = deps.Migrator.Up(ctx)
+	case "down":
+		if *steps < 1 {
+			return usageError("migrate: -steps must be at least 1")
+		}
+
+		applied, err = deps.Migrator.Down(ctx, *steps)
+	case "version":
+		version, dirty, err := deps.Migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		return cmd.print(deps.Out, migrationVersion{Version: version, Dirty: dirty})
+	default:
+		return usageError("migrate: unknown action %q", action)
+	}
+
+	// what was applied before a failure is still reported
+	rows := make([]migration, len(applied))
+	for i, version := range applied {
+		rows[i] = migration{Version: version, Direction: direction}
+	}
+
+	if printErr := cmd.print(deps.Out, rows); printErr != nil && err == nil {
+		err = printErr
+	}
+
+	return err
+}
+
+func listCarts(ctx context.Context, deps Dependencies, args []string) error {
+	cmd := newCommand("carts list")
+	params := cartsummary.Filter{}
+	cmd.flags.StringVar(&params.Q, "q", "", "owner, kodeProduk or nama to search for")
+	cmd.flags.IntVar(&params.Page, "page", 1, "page to print")
+	cmd.flags.IntVar(&params.PageSize, "page-size", 0, "carts per page")
+
+	if err := noArguments(cmd, args); err != nil {
+		return err
+	}
+
+	data, err := result(deps.Cart.ListCarts(ctx, params))
+	if err != nil {
+		return err
+	}
+
+	list, _ := data.(cartsummary.List)
+	if *cmd.output == OutputJSON {
+		return cmd.print(deps.Out, list)
+	}
+
+	if len(list.Carts) == 0 {
+		return nil
+	}
+
+	return cmd.print(deps.Out, list.Carts)
+}
+
+func showCart(ctx context.Context, deps Dependencies, args []string) error {
+	cmd := newCommand("carts show")
+
+	owner, err := ownerArgument(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	res := deps.Cart.GetItems(rbac.WithCart(ctx, owner), filter.Filter{})
+	if errors.Is(res.Err(), exception.ErrNotFound) {
+		return cmd.print(deps.Out, []v1.Item{})
+	}
+
+	return printResult(cmd, deps, res)
+}
+
+func clearCart(ctx context.Context, deps Dependencies, args []string) error {
+	cmd := newCommand("carts clear")
+
+	owner, err := ownerArgument(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	return printResult(cmd, deps, deps.Cart.Expire(rbac.WithCart(ctx, owner)))
+}
+
+func seed(ctx context.Context, deps Dependencies, args []string) error {
+	cmd := newCommand("seed")
+	owner := cmd.flags.String("owner", rbac.CartAnonymous, "cart to seed")
+	file := cmd.flags.String("file", "", "JSON batch request to apply instead of SampleLines")
+
+	if err := noArguments(cmd, args); err != nil {
+		return err
+	}
+
+	lines := SampleLines
+	if *file != "" {
+		raw, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+
+		lines = v1.BatchRequest{}
+		if err := json.Unmarshal(raw, &lines); err != nil {
+			return usageError("seed: %s: %v", *file, err)
+		}
+	}
+
+	if err := deps.Validate.StructCtx(ctx, lines); err != nil {
+		return usageError("seed: %v", err)
+	}
+
+	return printResult(cmd, deps, deps.Cart.BatchItems(rbac.WithCart(ctx, *owner), lines.Batch(), ""))
+}
+
+func purgeExpired(ctx context.Context, deps Dependencies, args []string) error {
+	cmd := newCommand("purge-expired")
+
+	if err := noArguments(cmd, args); err != nil {
+		return err
+	}
+
+	total, err := deps.Cart.PurgeDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cmd.print(deps.Out, purged{Purged: total})
+}
+
+func noArguments(cmd *command, args []string) error {
+	positional, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) > 0 {
+		return usageError("%s: unexpected %q", cmd.name, positional[0])
+	}
+
+	return nil
+}
+
+func ownerArgument(cmd *command, args []string) (string, error) {
+	positional, err := cmd.parse(args)
+	if err != nil {
+		return "", err
+	}
+
+	if len(positional) != 1 {
+		return "", usageError("%s: needs exactly one owner", cmd.name)
+	}
+
+	return positional[0], nil
+}
+
+// result returns the data of res, or its error.
+func result(res response.Response) (interface{}, error) {
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+
+	impl, ok := res.(*response.ResponseImpl)
+	if !ok {
+		return nil, nil
+	}
+
+	return impl.Data, nil
+}
+
+// printResult prints the data of res the way the API presents it.
+func printResult(cmd *command, deps Dependencies, res response.Response) error {
+	data, err := result(v1.Present(res))
+	if err != nil {
+		return err
+	}
+
+	return cmd.print(deps.Out, data)
+}
@@ -0,0 +1,32 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type HealthHandler struct {
+	UseCase HealthUseCase
+}
+
+func NewHealthHandler(router *mux.Router, usecase HealthUseCase) {
+	handler := HealthHandler{
+		UseCase: usecase,
+	}
+
+	router.HandleFunc("/healthz", handler.Liveness).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", handler.Readiness).Methods(http.MethodGet)
+}
+
+func (handler *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	res := handler.UseCase.Liveness(r.Context())
+
+	res.Write(w, r)
+}
+
+func (handler *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	res := handler.UseCase.Readiness(r.Context())
+
+	res.Write(w, r)
+}
@@ -0,0 +1,31 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/models/health"
+)
+
+// OpenAPI documents the routes of HealthHandler.
+func OpenAPI(doc *openapi.Document) {
+	doc.Add(http.MethodGet, "/healthz", &openapi.Operation{
+		Tags:        []string{"health"},
+		Summary:     "Liveness",
+		OperationID: "liveness",
+		Responses: openapi.Responses{
+			"200": doc.JSON("The process is running.", health.Report{}),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/readyz", &openapi.Operation{
+		Tags:        []string{"health"},
+		Summary:     "Readiness",
+		Description: "Checks the dependencies; not ready while shutting down.",
+		OperationID: "readiness",
+		Responses: openapi.Responses{
+			"200": doc.JSON("Every check passed.", health.Report{}),
+			"503": doc.JSON("A check failed.", health.Report{}),
+		},
+	})
+}
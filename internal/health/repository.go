@@ -0,0 +1,56 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/tracing"
+)
+
+type (
+	HealthRepository interface {
+		Ping(ctx context.Context) error
+		MigrationVersion(ctx context.Context) (int64, bool, error)
+	}
+
+	healthRepositoryImpl struct {
+		DB        *sql.DB
+		tableName string
+	}
+)
+
+func NewHealthRepositoryImpl(db *sql.DB, tableName string) HealthRepository {
+	return &healthRepositoryImpl{
+		DB:        db,
+		tableName: tableName,
+	}
+}
+
+func (hr *healthRepositoryImpl) Ping(ctx context.Context) (err error) {
+	ctx, span := tracing.StartQuery(ctx, "PING", "", "")
+	defer func() { tracing.End(span, err) }()
+
+	if err = hr.DB.PingContext(ctx); err != nil {
+		logger.Println(ctx, err)
+		return err
+	}
+
+	return nil
+}
+
+func (hr *healthRepositoryImpl) MigrationVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	query := fmt.Sprintf(`SELECT version, dirty FROM %s LIMIT 1`, hr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", hr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	err = hr.DB.QueryRowContext(ctx, query).Scan(&version, &dirty)
+	if err != nil {
+		logger.Println(ctx, err)
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
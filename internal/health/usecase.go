@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/models/health"
+)
+
+// Check reports whether a dependency is usable. It must honor ctx, which
+// carries the readiness timeout.
+type Check func(ctx context.Context) error
+
+type (
+	HealthUseCase interface {
+		Register(name string, check Check)
+		Liveness(ctx context.Context) response.Response
+		Readiness(ctx context.Context) response.Response
+	}
+
+	healthUseCaseImpl struct {
+		repo             HealthRepository
+		migrationVersion int64
+		timeout          time.Duration
+
+		mu     sync.RWMutex
+		names  []string
+		checks map[string]Check
+	}
+)
+
+func NewHealthUseCaseImpl(repo HealthRepository, migrationVersion int64, timeout time.Duration) HealthUseCase {
+	hu := &healthUseCaseImpl{
+		repo:             repo,
+		migrationVersion: migrationVersion,
+		timeout:          timeout,
+		checks:           map[string]Check{},
+	}
+
+	hu.Register("database", hu.checkDatabase)
+	hu.Register("migration", hu.checkMigration)
+
+	return hu
+}
+
+// Register adds a subsystem check to the readiness report. Registering a
+// name twice replaces the previous check.
+func (hu *healthUseCaseImpl) Register(name string, check Check) {
+	hu.mu.Lock()
+	defer hu.mu.Unlock()
+
+	if _, ok := hu.checks[name]; !ok {
+		hu.names = append(hu.names, name)
+	}
+
+	hu.checks[name] = check
+}
+
+func (hu *healthUseCaseImpl) Liveness(ctx context.Context) response.Response {
+	return response.Success(response.StatusOK, health.Report{
+		Status: health.StatusUp,
+		Checks: []health.Check{},
+	})
+}
+
+func (hu *healthUseCaseImpl) Readiness(ctx context.Context) response.Response {
+	hu.mu.RLock()
+	names := append([]string(nil), hu.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = hu.checks[name]
+	}
+	hu.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, hu.timeout)
+	defer cancel()
+
+	report := health.Report{
+		Status: health.StatusUp,
+		Checks: make([]health.Check, len(names)),
+	}
+
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			report.Checks[i] = run(ctx, names[i], checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Status != health.StatusUp {
+			report.Status = health.StatusDown
+			return response.ErrorWithData(response.StatusServiceUnavailable, exception.ErrServiceUnavailable, report)
+		}
+	}
+
+	return response.Success(response.StatusOK, report)
+}
+
+func (hu *healthUseCaseImpl) checkDatabase(ctx context.Context) error {
+	return hu.repo.Ping(ctx)
+}
+
+func (hu *healthUseCaseImpl) checkMigration(ctx context.Context) error {
+	version, dirty, err := hu.repo.MigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+
+	if version != hu.migrationVersion {
+		return fmt.Errorf("database at migration %d, expected %d", version, hu.migrationVersion)
+	}
+
+	return nil
+}
+
+func run(ctx context.Context, name string, check Check) health.Check {
+	start := time.Now()
+
+	result := make(chan error, 1)
+	go func() { result <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	status := health.Check{
+		Name:      name,
+		Status:    health.StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		status.Status = health.StatusDown
+		status.Error = err.Error()
+	}
+
+	return status
+}
@@ -0,0 +1,132 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Risuii/models/event"
+)
+
+const (
+	HeaderEventID   = "X-Event-Id"
+	HeaderEventType = "X-Event-Type"
+)
+
+type (
+	// Publisher delivers one envelope. Delivery is at least once, so
+	// consumers deduplicate on the envelope id.
+	Publisher interface {
+		Publish(ctx context.Context, envelope event.Envelope) error
+	}
+
+	// WriterPublisher writes every envelope as one JSON line.
+	WriterPublisher struct {
+		mu     sync.Mutex
+		w      io.Writer
+		closer io.Closer
+	}
+
+	// MultiPublisher hands every envelope to each publisher in turn and
+	// fails on the first error, so the message is retried for all of them;
+	// publishers that keep what they are handed, like the webhook registry,
+	// must ignore an envelope they already have.
+	MultiPublisher []Publisher
+
+	// WebhookPublisher POSTs every envelope to a URL and treats any non-2xx
+	// answer as a failed delivery.
+	WebhookPublisher struct {
+		URL    string
+		Client *http.Client
+	}
+)
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// OpenFilePublisher appends envelopes to the file at path, creating it if
+// needed.
+func OpenFilePublisher(path string) (*WriterPublisher, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WriterPublisher{w: file, closer: file}, nil
+}
+
+func (wp *WriterPublisher) Publish(ctx context.Context, envelope event.Envelope) error {
+	line, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	_, err = wp.w.Write(append(line, '\n'))
+
+	return err
+}
+
+func (wp *WriterPublisher) Close() error {
+	if wp.closer == nil {
+		return nil
+	}
+
+	return wp.closer.Close()
+}
+
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{
+		URL:    url,
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (wp *WebhookPublisher) Publish(ctx context.Context, envelope event.Envelope) error {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wp.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, envelope.ID)
+	req.Header.Set(HeaderEventType, envelope.Type)
+
+	resp, err := wp.Client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (mp MultiPublisher) Publish(ctx context.Context, envelope event.Envelope) error {
+	for _, publisher := range mp {
+		if err := publisher.Publish(ctx, envelope); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
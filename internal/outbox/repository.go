@@ -0,0 +1,211 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/event"
+)
+
+type (
+	OutboxRepository interface {
+		Append(ctx context.Context, envelopes ...event.Envelope) error
+		FindUnpublished(ctx context.Context, now time.Time, limit int) ([]event.Message, error)
+		LeaseMessages(ctx context.Context, ids []int64, until time.Time) error
+		MarkPublished(ctx context.Context, ids []int64, publishedAt time.Time) error
+		MarkFailed(ctx context.Context, id int64, reason string) error
+		LastOccurredAt(ctx context.Context, eventType, owner string) (time.Time, error)
+	}
+
+	outboxRepositoryImpl struct {
+		DB           *sql.DB
+		tableName    string
+		queryTimeout time.Duration
+	}
+)
+
+func NewOutboxRepositoryImpl(db *sql.DB, tableName string, queryTimeout time.Duration) OutboxRepository {
+	return &outboxRepositoryImpl{
+		DB:           db,
+		tableName:    tableName,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// Append stores envelopes with one statement. It must run in the same
+// unit of work as the change the envelopes describe.
+func (or *outboxRepositoryImpl) Append(ctx context.Context, envelopes ...event.Envelope) (err error) {
+	if len(envelopes) == 0 {
+		return nil
+	}
+
+	values := strings.TrimSuffix(strings.Repeat("(?,?,?,?,?,?,?,?),", len(envelopes)), ",")
+	query := fmt.Sprintf(`INSERT INTO %s (event_id, event_type, version, actor, request_id, owner, payload, occurred_at) VALUES %s`, or.tableName, values)
+
+	ctx, span := tracing.StartQuery(ctx, "INSERT", or.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, or.queryTimeout)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(envelopes)*8)
+	for _, e := range envelopes {
+		args = append(args, e.ID, e.Type, e.Version, e.Actor, e.RequestID, e.Owner, string(e.Data), e.OccurredAt)
+	}
+
+	if _, err = database.Conn(ctx, or.DB).ExecContext(ctx, query, args...); err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+// FindUnpublished returns the oldest unpublished messages not leased to a
+// relay at now. Inside a transaction the rows are locked and rows locked
+// by another relay are skipped, so several instances can relay side by
+// side.
+func (or *outboxRepositoryImpl) FindUnpublished(ctx context.Context, now time.Time, limit int) (messages []event.Message, err error) {
+	query := fmt.Sprintf(`SELECT id, event_id, event_type, version, actor, request_id, owner, payload, occurred_at, attempts FROM %s WHERE published_at IS NULL AND (leased_until IS NULL OR leased_until <= ?) ORDER BY id LIMIT ?`, or.tableName)
+	if database.InTransaction(ctx) {
+		query += ` FOR UPDATE SKIP LOCKED`
+	}
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", or.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, or.queryTimeout)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, or.DB).QueryContext(ctx, query, now, limit)
+	if err != nil {
+		logger.Println(ctx, err)
+		return messages, database.Error(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var m event.Message
+		var payload []byte
+		if err := rows.Scan(
+			&m.ID,
+			&m.Envelope.ID,
+			&m.Envelope.Type,
+			&m.Envelope.Version,
+			&m.Envelope.Actor,
+			&m.Envelope.RequestID,
+			&m.Envelope.Owner,
+			&payload,
+			&m.Envelope.OccurredAt,
+			&m.Attempts,
+		); err != nil {
+			logger.Println(ctx, err)
+			return messages, database.Error(err)
+		}
+
+		m.Envelope.Data = payload
+		messages = append(messages, m)
+	}
+
+	return messages, nil
+}
+
+// LeaseMessages keeps messages ids from other relays until until; a
+// lease that has already ended hands them back.
+func (or *outboxRepositoryImpl) LeaseMessages(ctx context.Context, ids []int64, until time.Time) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET leased_until = ? WHERE id IN (%s)`, or.tableName, strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","))
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", or.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, or.queryTimeout)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, until)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	if _, err = database.Conn(ctx, or.DB).ExecContext(ctx, query, args...); err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+func (or *outboxRepositoryImpl) MarkPublished(ctx context.Context, ids []int64, publishedAt time.Time) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET published_at = ?, attempts = attempts + 1, last_error = NULL WHERE id IN (%s)`, or.tableName, strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","))
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", or.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, or.queryTimeout)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, publishedAt)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	if _, err = database.Conn(ctx, or.DB).ExecContext(ctx, query, args...); err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+func (or *outboxRepositoryImpl) MarkFailed(ctx context.Context, id int64, reason string) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET attempts = attempts + 1, last_error = ?, leased_until = NULL WHERE id = ?`, or.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", or.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, or.queryTimeout)
+	defer cancel()
+
+	if _, err = database.Conn(ctx, or.DB).ExecContext(ctx, query, reason, id); err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+// LastOccurredAt returns when the latest event of eventType about the cart
+// of owner happened, or the zero time if there is none.
+func (or *outboxRepositoryImpl) LastOccurredAt(ctx context.Context, eventType, owner string) (last time.Time, err error) {
+	query := fmt.Sprintf(`SELECT MAX(occurred_at) FROM %s WHERE event_type = ? AND owner = ?`, or.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", or.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, or.queryTimeout)
+	defer cancel()
+
+	var occurredAt sql.NullTime
+	if err = database.Conn(ctx, or.DB).QueryRowContext(ctx, query, eventType, owner).Scan(&occurredAt); err != nil {
+		logger.Println(ctx, err)
+		return last, database.Error(err)
+	}
+
+	return occurredAt.Time, nil
+}
@@ -0,0 +1,134 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/event"
+)
+
+type (
+	OutboxUseCase interface {
+		Relay(ctx context.Context) (int, error)
+	}
+
+	outboxUseCaseImpl struct {
+		repo      OutboxRepository
+		tx        database.TxManager
+		publisher Publisher
+		batchSize int
+		lease     time.Duration
+	}
+)
+
+// NewOutboxUseCaseImpl relays batches of batchSize messages, each kept
+// from other relays for lease while it is being published.
+func NewOutboxUseCaseImpl(repo OutboxRepository, tx database.TxManager, publisher Publisher, batchSize int, lease time.Duration) OutboxUseCase {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	if lease <= 0 {
+		lease = time.Minute
+	}
+
+	return &outboxUseCaseImpl{
+		repo:      repo,
+		tx:        tx,
+		publisher: publisher,
+		batchSize: batchSize,
+		lease:     lease,
+	}
+}
+
+// Relay publishes one batch of pending messages in outbox order and
+// reports how many were delivered. It stops at the first failed delivery,
+// recording the error on that message, so later events never overtake an
+// earlier one. The batch is claimed in a short transaction and published
+// outside of it, so a retried transaction never publishes twice; messages
+// whose outcome is lost, e.g. to a crash, are published again once their
+// lease ends.
+func (ou *outboxUseCaseImpl) Relay(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "OutboxUseCase.Relay")
+	defer span.End()
+
+	messages, err := ou.claim(ctx)
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+
+	var (
+		published []int64
+		failed    *event.Message
+		reason    string
+	)
+
+	for i, message := range messages {
+		if err := ou.publisher.Publish(ctx, message.Envelope); err != nil {
+			logger.Println(ctx, "publishing", message.Envelope.ID, err)
+			failed, reason = &messages[i], err.Error()
+			break
+		}
+
+		published = append(published, message.ID)
+	}
+
+	err = ou.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := ou.repo.MarkPublished(ctx, published, time.Now()); err != nil {
+			return err
+		}
+
+		if failed == nil {
+			return nil
+		}
+
+		if err := ou.repo.MarkFailed(ctx, failed.ID, reason); err != nil {
+			return err
+		}
+
+		// the messages after the failed one were never tried; hand them
+		// back at once instead of waiting for their lease to end
+		var unsent []int64
+		for _, message := range messages[len(published)+1:] {
+			unsent = append(unsent, message.ID)
+		}
+
+		return ou.repo.LeaseMessages(ctx, unsent, time.Now())
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(published), nil
+}
+
+// claim leases a batch of pending messages to this relay and returns them.
+func (ou *outboxUseCaseImpl) claim(ctx context.Context) ([]event.Message, error) {
+	var messages []event.Message
+
+	err := ou.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		messages, err = ou.repo.FindUnpublished(ctx, time.Now(), ou.batchSize)
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+
+		return ou.repo.LeaseMessages(ctx, ids, time.Now().Add(ou.lease))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,346 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/cachecontrol"
+	"github.com/Risuii/helpers/compress"
+	"github.com/Risuii/helpers/deprecation"
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/helpers/ratelimit"
+	"github.com/Risuii/internal/apikey"
+	"github.com/Risuii/internal/audit"
+	"github.com/Risuii/internal/cart"
+	"github.com/Risuii/internal/health"
+	"github.com/Risuii/internal/stream"
+	"github.com/Risuii/internal/webhook"
+)
+
+const (
+	PathSpec = "/openapi.json"
+	PathDocs = "/docs"
+
+	// PrefixV1 is where version 1 of the API is mounted.
+	PrefixV1 = "/v1"
+	// PrefixAdmin is where the administration routes are mounted; they
+	// are not versioned with the API.
+	PrefixAdmin = "/admin"
+)
+
+// LegacyDeprecated is when the unversioned paths were deprecated in favour
+// of PrefixV1.
+var LegacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// Dependencies are what the HTTP routes are served from.
+type Dependencies struct {
+	Validate  *validator.Validate
+	Cart      cart.CartUseCase
+	Audit     audit.AuditUseCase
+	Hub       *stream.Hub
+	Heartbeat time.Duration
+	Webhook   webhook.WebhookUseCase
+	Health    health.HealthUseCase
+	APIKey    apikey.APIKeyUseCase
+	// Authenticators identify the callers, tried in order.
+	Authenticators []auth.Authenticator
+	// RequireAuth refuses API requests without credentials. Without it
+	// they go through as before authentication existed, while requests
+	// with credentials still need the scope of the route.
+	RequireAuth bool
+	// MaxBodyBytes is the largest request body accepted; 0 means no limit.
+	MaxBodyBytes int64
+	// Legacy also serves the API at its unversioned paths, answering with
+	// deprecation headers and LegacySunset, when set, as the Sunset.
+	Legacy       bool
+	LegacySunset time.Time
+	// Compression, when set, compresses the responses.
+	Compression *compress.Options
+	// CachePolicies give the Cache-Control of routes, by their /v1 path
+	// for the unversioned ones too.
+	CachePolicies cachecontrol.Policies
+	// RateLimiter, when set, limits the requests of each client by
+	// RateLimit; the unversioned paths share the quotas of /v1.
+	RateLimiter ratelimit.Limiter
+	RateLimit   ratelimit.Options
+}
+
+// Register mounts every HTTP route on router: the API under PrefixV1,
+// and again at the unversioned paths when deps.Legacy is set, next to the
+// probes, the administration routes under PrefixAdmin, the OpenAPI
+// document describing them all and its docs page. Requests are
+// authenticated and checked against Rules, rate limited, then validated
+// against the document before they reach a handler.
+func Register(router *mux.Router, deps Dependencies) error {
+	spec := Spec(deps.Legacy)
+	router.Use(
+		auth.Middleware(deps.Authenticators...),
+		auth.Require(Rules(deps.RequireAuth), PrefixV1),
+	)
+	if deps.RateLimiter != nil {
+		opts := deps.RateLimit
+		opts.Prefix = PrefixV1
+		router.Use(ratelimit.Middleware(deps.RateLimiter, opts))
+	}
+	if deps.Compression != nil {
+		router.Use(compress.Middleware(*deps.Compression))
+	}
+	router.Use(cachecontrol.Middleware(deps.CachePolicies, ""))
+	router.Use(openapi.Middleware(spec, deps.MaxBodyBytes))
+
+	health.NewHealthHandler(router, deps.Health)
+
+	router.HandleFunc(PathSpec, openapi.Handler(spec)).Methods(http.MethodGet)
+	router.HandleFunc(PathDocs, openapi.DocsHandler()).Methods(http.MethodGet)
+
+	admin := router.PathPrefix(PrefixAdmin).Subrouter()
+	apikey.NewAPIKeyHandler(admin, deps.Validate, deps.APIKey)
+	cart.NewCartAdminHandler(admin, deps.Validate, deps.Cart)
+
+	if err := mount(router.PathPrefix(PrefixV1).Subrouter(), deps); err != nil {
+		return err
+	}
+
+	if deps.Legacy {
+		legacy := router.NewRoute().Subrouter()
+		legacy.Use(
+			deprecation.Middleware(deprecation.Policy{
+				Since:  LegacyDeprecated,
+				Sunset: deps.LegacySunset,
+				Successor: func(path string) string {
+					return PrefixV1 + path
+				},
+			}),
+			cachecontrol.Middleware(deps.CachePolicies, PrefixV1),
+		)
+
+		if err := mount(legacy, deps); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mount registers the versioned API routes on router.
+func mount(router *mux.Router, deps Dependencies) error {
+	cart.NewCartHandler(router, deps.Validate, deps.Cart)
+	if err := cart.NewCartGraphQLHandler(router, deps.Cart); err != nil {
+		return err
+	}
+	audit.NewAuditHandler(router, deps.Audit)
+	stream.NewStreamHandler(router, deps.Hub, deps.Heartbeat)
+	webhook.NewWebhookHandler(router, deps.Validate, deps.Webhook)
+
+	return nil
+}
+
+// Rules gives the scope of every API and administration route. The API
+// lets requests without credentials through unless required is set; the
+// webhook and administration routes never do. Routes without a rule, the probes and
+// the docs, are open.
+func Rules(required bool) auth.Rules {
+	rules := auth.Rules{}
+
+	api := func(scope string, routes ...string) {
+		for _, route := range routes {
+			method, path, _ := strings.Cut(route, " ")
+			rules[method+" "+PrefixV1+path] = auth.Rule{Scope: scope, Anonymous: !required}
+		}
+	}
+
+	api(auth.ScopeCartRead,
+		"GET /cart/items",
+		"GET /cart/{id}/history",
+		"GET /cart/stream",
+		// mutations also need cart:write, checked by the GraphQL handler
+		"GET /graphql",
+		"POST /graphql",
+		"GET /graphql/schema",
+	)
+	api(auth.ScopeCartWrite,
+		"POST /cart/items",
+		"DELETE /cart/items",
+		"POST /cart/items:batch",
+		"POST /cart/items/{kodeProduk}/restore",
+		"POST /cart/checkout",
+	)
+	// webhooks receive the events of every cart, so like the
+	// administration routes they always need credentials
+	private := func(scope string, routes ...string) {
+		for _, route := range routes {
+			method, path, _ := strings.Cut(route, " ")
+			rules[method+" "+PrefixV1+path] = auth.Rule{Scope: scope}
+		}
+	}
+
+	private(auth.ScopeWebhooksAdmin,
+		"POST /webhooks",
+		"GET /webhooks",
+		"GET /webhooks/{id}",
+		"PUT /webhooks/{id}",
+		"DELETE /webhooks/{id}",
+		"GET /webhooks/{id}/deliveries",
+		"POST /webhooks/{id}/deliveries/{deliveryId}/redeliver",
+	)
+
+	admin := func(scope string, routes ...string) {
+		for _, route := range routes {
+			method, path, _ := strings.Cut(route, " ")
+			rules[method+" "+PrefixAdmin+path] = auth.Rule{Scope: scope}
+		}
+	}
+
+	admin(auth.ScopeKeysAdmin,
+		"POST /api-keys",
+		"GET /api-keys",
+		"POST /api-keys/{id}/rotate",
+		"DELETE /api-keys/{id}",
+	)
+	// the role of the caller further limits what they may do with a cart
+	admin(auth.ScopeCartsAdmin,
+		"GET /carts",
+		"GET /carts/{owner}",
+		"POST /carts/{owner}/items:batch",
+		"POST /carts/{owner}/expire",
+	)
+
+	return rules
+}
+
+// Spec documents every route Register mounts, with the unversioned paths
+// as deprecated operations when legacy is set. Each package describes its
+// own routes next to its handler.
+func Spec(legacy bool) *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:       "Haioo Cart API",
+		Version:     "1.0.0",
+		Description: "Every REST response is wrapped in the Response envelope; errors carry a null data unless stated otherwise.",
+	})
+
+	doc.Tags = []openapi.Tag{
+		{Name: "cart", Description: "The shopping cart."},
+		{Name: "graphql", Description: "The cart over GraphQL."},
+		{Name: "webhooks", Description: "Subscriptions to cart events."},
+		{Name: "admin", Description: "Administration; needs credentials."},
+		{Name: "health", Description: "Liveness and readiness probes."},
+		{Name: "docs", Description: "This document."},
+	}
+
+	v1 := doc.Prefixed(PrefixV1)
+	cart.OpenAPI(v1)
+	audit.OpenAPI(v1)
+	stream.OpenAPI(v1)
+	webhook.OpenAPI(v1)
+
+	admin := doc.Prefixed(PrefixAdmin)
+	apikey.OpenAPI(admin)
+	cart.AdminOpenAPI(admin)
+	health.OpenAPI(doc)
+	secure(doc, Rules(false))
+	doc.ValidationResponses()
+	doc.NegotiationResponses()
+
+	if legacy {
+		deprecate(doc)
+	}
+
+	doc.Add(http.MethodGet, PathSpec, &openapi.Operation{
+		Tags:        []string{"docs"},
+		Summary:     "This OpenAPI document",
+		OperationID: "openapi",
+		Responses: openapi.Responses{
+			"200": {Description: "The document.", Content: map[string]openapi.MediaType{"application/json": {}}},
+		},
+	})
+
+	doc.Add(http.MethodGet, PathDocs, &openapi.Operation{
+		Tags:        []string{"docs"},
+		Summary:     "Browsable API documentation",
+		OperationID: "docs",
+		Responses: openapi.Responses{
+			"200": {Description: "An HTML page rendering " + PathSpec + ".", Content: map[string]openapi.MediaType{"text/html": {Schema: openapi.String()}}},
+		},
+	})
+
+	doc.RateLimitResponses()
+
+	return doc
+}
+
+// secure documents the credentials each rule asks for, as a JWT or an API
+// key carrying its scope, and the 401 and 403 that enforce it.
+func secure(doc *openapi.Document, rules auth.Rules) {
+	doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		"bearer": {
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+			Description:  "A user token, its scopes space-separated in the scope claim. API keys are accepted as bearer tokens too.",
+		},
+		"apiKey": {
+			Type:        "apiKey",
+			In:          "header",
+			Name:        auth.HeaderAPIKey,
+			Description: "An API key issued at " + PrefixAdmin + "/api-keys.",
+		},
+	}
+
+	for route, rule := range rules {
+		method, path, _ := strings.Cut(route, " ")
+		op := doc.Operation(method, path)
+		if op == nil {
+			continue
+		}
+
+		op.Security = []openapi.SecurityRequirement{
+			{"bearer": {rule.Scope}},
+			{"apiKey": {rule.Scope}},
+		}
+		op.Responses["401"] = doc.Error("The credentials are missing or invalid.").
+			WithHeader("WWW-Authenticate", "The scheme to authenticate with.")
+		op.Responses["403"] = doc.Error("The credentials lack the scope of the route; data names it.")
+	}
+}
+
+// deprecate documents every PrefixV1 operation again at its unversioned
+// path, marked deprecated and answering with the deprecation headers.
+func deprecate(doc *openapi.Document) {
+	versioned := map[string]*openapi.PathItem{}
+	for path, item := range doc.Paths {
+		if strings.HasPrefix(path, PrefixV1+"/") {
+			versioned[path] = item
+		}
+	}
+
+	for path, item := range versioned {
+		for method, op := range *item {
+			old := *op
+			old.Deprecated = true
+			old.OperationID = "legacy" + strings.ToUpper(op.OperationID[:1]) + op.OperationID[1:]
+			old.Description = strings.TrimSpace(op.Description + " Deprecated: use " + path + " instead.")
+
+			old.Responses = openapi.Responses{}
+			for code, res := range op.Responses {
+				copied := *res
+				copied.Headers = nil
+				for name, header := range res.Headers {
+					copied.WithHeader(name, header.Description)
+				}
+
+				copied.WithHeader("Deprecation", "When this path was deprecated, as @ followed by a Unix time.")
+				copied.WithHeader("Sunset", "When this path stops being served, if that has been decided.")
+				copied.WithHeader("Link", "The path replacing this one, with rel=\"successor-version\".")
+
+				old.Responses[code] = &copied
+			}
+
+			doc.Add(method, strings.TrimPrefix(path, PrefixV1), &old)
+		}
+	}
+}
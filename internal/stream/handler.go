@@ -0,0 +1,194 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/models/event"
+)
+
+const (
+	HeaderLastEventID = "Last-Event-ID"
+
+	// Control messages sent next to the cart events.
+	EventHeartbeat = "stream.heartbeat"
+	EventReset     = "stream.reset"
+	EventOverflow  = "stream.overflow"
+
+	// retryMillis is how long an EventSource waits before reconnecting.
+	retryMillis = 3000
+)
+
+type StreamHandler struct {
+	Hub       *Hub
+	Heartbeat time.Duration
+}
+
+func NewStreamHandler(router *mux.Router, hub *Hub, heartbeat time.Duration) {
+	handler := StreamHandler{
+		Hub:       hub,
+		Heartbeat: heartbeat,
+	}
+
+	router.HandleFunc("/cart/stream", handler.Stream).Methods(http.MethodGet)
+}
+
+// Stream sends the changes of the cart of the caller as server-sent
+// events, or over a WebSocket when the client asks for an upgrade. A
+// client resumes with the Last-Event-ID header (or the lastEventId query
+// parameter); when that event is too old it gets a stream.reset and should
+// reload the cart.
+func (handler *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	// the owner is fixed when the stream opens, like the cart of any other
+	// request
+	owner := rbac.Cart(r.Context())
+
+	lastEventID := r.Header.Get(HeaderLastEventID)
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		server := websocket.Server{Handler: func(ws *websocket.Conn) {
+			handler.serveWebSocket(ws, owner, lastEventID)
+		}}
+		server.ServeHTTP(w, r)
+		return
+	}
+
+	handler.serveEvents(w, r, owner, lastEventID)
+}
+
+func (handler *StreamHandler) serveEvents(w http.ResponseWriter, r *http.Request, owner, lastEventID string) {
+	rc := http.NewResponseController(w)
+
+	// the stream outlives the server's write timeout
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(id, name string, data []byte) error {
+		if id != "" {
+			if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+			return err
+		}
+
+		return rc.Flush()
+	}
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", retryMillis); err != nil {
+		return
+	}
+
+	handler.run(r.Context(), owner, lastEventID, func(envelope event.Envelope) error {
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return err
+		}
+
+		return send(envelope.ID, envelope.Type, data)
+	}, func(name string) error {
+		if name == EventHeartbeat {
+			// a comment keeps proxies from closing an idle stream
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return err
+			}
+
+			return rc.Flush()
+		}
+
+		return send("", name, []byte("{}"))
+	})
+}
+
+func (handler *StreamHandler) serveWebSocket(ws *websocket.Conn, owner, lastEventID string) {
+	defer ws.Close()
+
+	ws.SetDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(ws.Request().Context())
+	defer cancel()
+
+	// the client only talks to close the connection
+	go func() {
+		io.Copy(io.Discard, ws)
+		cancel()
+	}()
+
+	handler.run(ctx, owner, lastEventID, func(envelope event.Envelope) error {
+		return websocket.JSON.Send(ws, envelope)
+	}, func(name string) error {
+		return websocket.JSON.Send(ws, map[string]string{"type": name})
+	})
+}
+
+// run replays missed events and then forwards live ones until ctx ends,
+// the hub drops the subscription or a write fails.
+func (handler *StreamHandler) run(ctx context.Context, owner, lastEventID string, send func(event.Envelope) error, control func(string) error) {
+	sub, replay, resumed := handler.Hub.Subscribe(owner, lastEventID)
+	defer handler.Hub.Unsubscribe(sub)
+
+	if !resumed {
+		if err := control(EventReset); err != nil {
+			return
+		}
+	}
+
+	for _, envelope := range replay {
+		if err := send(envelope); err != nil {
+			return
+		}
+	}
+
+	if err := control(EventHeartbeat); err != nil {
+		return
+	}
+
+	heartbeat := handler.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := control(EventHeartbeat); err != nil {
+				return
+			}
+		case envelope, ok := <-sub.C:
+			if !ok {
+				if sub.Overflowed() {
+					control(EventOverflow)
+				}
+				return
+			}
+
+			if err := send(envelope); err != nil {
+				return
+			}
+		}
+	}
+}
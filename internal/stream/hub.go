@@ -0,0 +1,192 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/Risuii/models/event"
+)
+
+type (
+	// HubOptions tunes the hub; zero values fall back to the defaults.
+	HubOptions struct {
+		// BufferSize is how many events may wait for one slow connection
+		// before it is dropped.
+		BufferSize int
+		// HistorySize is how many recent events are kept to resume streams
+		// from a Last-Event-ID.
+		HistorySize int
+	}
+
+	// Hub fans committed cart events out to the connections watching a
+	// cart, keyed by the owner of the cart. It lives in one process;
+	// connections on other instances only see the changes made there.
+	Hub struct {
+		mu      sync.Mutex
+		opts    HubOptions
+		subs    map[string]map[*Subscription]struct{}
+		history []event.Envelope
+		next    int
+		closed  bool
+	}
+
+	// Subscription receives the events of one cart on C. C is closed when
+	// the subscription ends; Overflowed tells whether the hub dropped it
+	// because the connection could not keep up.
+	Subscription struct {
+		C <-chan event.Envelope
+
+		ch         chan event.Envelope
+		owner      string
+		overflowed bool
+	}
+)
+
+func NewHub(opts HubOptions) *Hub {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 16
+	}
+
+	if opts.HistorySize <= 0 {
+		opts.HistorySize = 1000
+	}
+
+	return &Hub{
+		opts:    opts,
+		subs:    map[string]map[*Subscription]struct{}{},
+		history: make([]event.Envelope, 0, opts.HistorySize),
+	}
+}
+
+// Notify publishes envelopes to the connections watching the cart of
+// their owner. A connection whose buffer is full is dropped rather than
+// blocking the caller; its client resumes from history when it
+// reconnects.
+func (h *Hub) Notify(envelopes ...event.Envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, envelope := range envelopes {
+		h.remember(envelope)
+
+		for sub := range h.subs[envelope.Owner] {
+			select {
+			case sub.ch <- envelope:
+			default:
+				sub.overflowed = true
+				h.remove(sub)
+			}
+		}
+	}
+}
+
+// Subscribe watches the cart of owner. When lastEventID is set, the events
+// of the cart published after it are returned for replay; resumed is false
+// when that event is no longer in history and the client has to reload
+// the cart instead.
+func (h *Hub) Subscribe(owner, lastEventID string) (sub *Subscription, replay []event.Envelope, resumed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan event.Envelope, h.opts.BufferSize)
+	sub = &Subscription{C: ch, ch: ch, owner: owner}
+
+	// a hub that is shutting down ends new streams at once
+	if h.closed {
+		close(ch)
+		return sub, nil, true
+	}
+
+	if h.subs[owner] == nil {
+		h.subs[owner] = map[*Subscription]struct{}{}
+	}
+	h.subs[owner][sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+
+	for _, envelope := range h.ordered() {
+		if envelope.ID == lastEventID {
+			resumed = true
+			replay = replay[:0]
+			continue
+		}
+
+		if resumed && envelope.Owner == owner {
+			replay = append(replay, envelope)
+		}
+	}
+
+	return sub, replay, resumed
+}
+
+// Unsubscribe ends sub. It is safe to call more than once.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub)
+}
+
+// Close ends every subscription and every later one, so open streams
+// return and the server can shut down. It is safe to call more than once.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribers reports how many connections watch the cart of owner.
+func (h *Hub) Subscribers(owner string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs[owner])
+}
+
+// Overflowed reports whether the hub dropped the subscription because its
+// buffer was full. It is only meaningful once C is closed.
+func (s *Subscription) Overflowed() bool {
+	return s.overflowed
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	subs, ok := h.subs[sub.owner]
+	if !ok {
+		return
+	}
+
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	close(sub.ch)
+
+	if len(subs) == 0 {
+		delete(h.subs, sub.owner)
+	}
+}
+
+// remember keeps envelope in the history ring.
+func (h *Hub) remember(envelope event.Envelope) {
+	if len(h.history) < h.opts.HistorySize {
+		h.history = append(h.history, envelope)
+		return
+	}
+
+	h.history[h.next] = envelope
+	h.next = (h.next + 1) % h.opts.HistorySize
+}
+
+// ordered returns the history oldest first.
+func (h *Hub) ordered() []event.Envelope {
+	return append(append([]event.Envelope{}, h.history[h.next:]...), h.history[:h.next]...)
+}
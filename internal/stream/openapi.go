@@ -0,0 +1,26 @@
+package stream
+
+import (
+	"net/http"
+
+	"github.com/Risuii/helpers/openapi"
+)
+
+// OpenAPI documents the routes of StreamHandler.
+func OpenAPI(doc *openapi.Document) {
+	doc.Add(http.MethodGet, "/cart/stream", &openapi.Operation{
+		Tags:    []string{"cart"},
+		Summary: "Follow the changes of the cart of the caller",
+		Description: "Server-sent events carrying the event envelope as data, or the same envelopes as JSON messages over a WebSocket " +
+			"when the request asks for an upgrade. Control events are " + EventHeartbeat + ", " + EventReset + " and " + EventOverflow + ".",
+		OperationID: "stream",
+		Parameters: []openapi.Parameter{
+			openapi.HeaderParam(HeaderLastEventID, "Resume after this event."),
+			openapi.QueryParam("lastEventId", "Same as the Last-Event-ID header, for clients that cannot set it.", openapi.String()),
+		},
+		Responses: openapi.Responses{
+			"101": {Description: "Switched to a WebSocket."},
+			"200": {Description: "The event stream.", Content: map[string]openapi.MediaType{"text/event-stream": {Schema: openapi.String()}}},
+		},
+	})
+}
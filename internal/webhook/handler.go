@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/Risuii/helpers/bodylimit"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/models/webhook"
+)
+
+type WebhookHandler struct {
+	Validate *validator.Validate
+	UseCase  WebhookUseCase
+}
+
+func NewWebhookHandler(router *mux.Router, validate *validator.Validate, usecase WebhookUseCase) {
+	handler := WebhookHandler{
+		Validate: validate,
+		UseCase:  usecase,
+	}
+
+	api := router.PathPrefix("/webhooks").Subrouter()
+
+	api.HandleFunc("", handler.Create).Methods(http.MethodPost)
+	api.HandleFunc("", handler.List).Methods(http.MethodGet)
+	api.HandleFunc("/{id:[0-9]+}", handler.Get).Methods(http.MethodGet)
+	api.HandleFunc("/{id:[0-9]+}", handler.Update).Methods(http.MethodPut)
+	api.HandleFunc("/{id:[0-9]+}", handler.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/{id:[0-9]+}/deliveries", handler.Deliveries).Methods(http.MethodGet)
+	api.HandleFunc("/{id:[0-9]+}/deliveries/{deliveryId:[0-9]+}/redeliver", handler.Redeliver).Methods(http.MethodPost)
+}
+
+func (handler *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userInput, ok := handler.input(w, r)
+	if !ok {
+		return
+	}
+
+	res := handler.UseCase.Create(r.Context(), userInput)
+
+	res.Write(w, r)
+}
+
+func (handler *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	res := handler.UseCase.List(r.Context())
+
+	res.Write(w, r)
+}
+
+func (handler *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	res := handler.UseCase.Get(r.Context(), id)
+
+	res.Write(w, r)
+}
+
+func (handler *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	userInput, ok := handler.input(w, r)
+	if !ok {
+		return
+	}
+
+	res := handler.UseCase.Update(r.Context(), id, userInput)
+
+	res.Write(w, r)
+}
+
+func (handler *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	res := handler.UseCase.Delete(r.Context(), id)
+
+	res.Write(w, r)
+}
+
+func (handler *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	var res response.Response
+
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var err error
+	page, pageSize := 1, 0
+	query := r.URL.Query()
+
+	if raw := query.Get("page"); raw != "" {
+		if page, err = strconv.Atoi(raw); err != nil {
+			res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+			res.Write(w, r)
+			return
+		}
+	}
+
+	if raw := query.Get("pageSize"); raw != "" {
+		if pageSize, err = strconv.Atoi(raw); err != nil {
+			res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+			res.Write(w, r)
+			return
+		}
+	}
+
+	res = handler.UseCase.Deliveries(r.Context(), id, page, pageSize)
+
+	res.Write(w, r)
+}
+
+func (handler *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	deliveryID, ok := pathID(w, r, "deliveryId")
+	if !ok {
+		return
+	}
+
+	res := handler.UseCase.Redeliver(r.Context(), id, deliveryID)
+
+	res.Write(w, r)
+}
+
+func (handler *WebhookHandler) input(w http.ResponseWriter, r *http.Request) (webhook.Input, bool) {
+	var res response.Response
+	var userInput webhook.Input
+
+	if err := json.NewDecoder(r.Body).Decode(&userInput); err != nil {
+		res = bodylimit.DecodeError(err)
+		res.Write(w, r)
+		return userInput, false
+	}
+
+	if err := handler.Validate.StructCtx(r.Context(), userInput); err != nil {
+		res = response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+		res.Write(w, r)
+		return userInput, false
+	}
+
+	return userInput, true
+}
+
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil {
+		res := response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+		res.Write(w, r)
+		return 0, false
+	}
+
+	return id, true
+}
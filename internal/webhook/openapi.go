@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/models/webhook"
+)
+
+// OpenAPI documents the routes of WebhookHandler.
+func OpenAPI(doc *openapi.Document) {
+	id := openapi.PathParam("id", "Id of the webhook.", openapi.Integer())
+
+	doc.Add(http.MethodPost, "/webhooks", &openapi.Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Subscribe to events",
+		Description: "The secret is only returned here; a missing secret is generated.",
+		OperationID: "createWebhook",
+		RequestBody: doc.JSONBody(webhook.Input{}),
+		Responses: openapi.Responses{
+			"201": doc.JSON("The subscription, with its secret.", webhook.Webhook{}),
+			"400": doc.Error("The input is invalid."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/webhooks", openapi.Conditional(&openapi.Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "List subscriptions",
+		OperationID: "listWebhooks",
+		Responses: openapi.Responses{
+			"200": doc.JSON("Every subscription, without secrets.", []webhook.Webhook{}).WithCSV(),
+			"500": doc.Error("Unexpected error."),
+		},
+	}))
+
+	doc.Add(http.MethodGet, "/webhooks/{id}", openapi.Conditional(&openapi.Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Get a subscription",
+		OperationID: "getWebhook",
+		Parameters:  []openapi.Parameter{id},
+		Responses: openapi.Responses{
+			"200": doc.JSON("The subscription, without its secret.", webhook.Webhook{}),
+			"400": doc.Error("The id is invalid."),
+			"404": doc.Error("No such webhook."),
+			"500": doc.Error("Unexpected error."),
+		},
+	}))
+
+	doc.Add(http.MethodPut, "/webhooks/{id}", &openapi.Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Replace a subscription",
+		OperationID: "updateWebhook",
+		Parameters:  []openapi.Parameter{id},
+		RequestBody: doc.JSONBody(webhook.Input{}),
+		Responses: openapi.Responses{
+			"200": doc.JSON("The subscription, without its secret.", webhook.Webhook{}),
+			"400": doc.Error("The id or the input is invalid."),
+			"404": doc.Error("No such webhook."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodDelete, "/webhooks/{id}", &openapi.Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Unsubscribe",
+		OperationID: "deleteWebhook",
+		Parameters:  []openapi.Parameter{id},
+		Responses: openapi.Responses{
+			"200": doc.JSON("The webhook and its deliveries were deleted.", ""),
+			"400": doc.Error("The id is invalid."),
+			"404": doc.Error("No such webhook."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/webhooks/{id}/deliveries", &openapi.Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Delivery log of a subscription",
+		OperationID: "webhookDeliveries",
+		Parameters: []openapi.Parameter{
+			id,
+			openapi.QueryParam("page", "1-based page, default 1.", openapi.Integer(1)),
+			openapi.QueryParam("pageSize", "Deliveries per page, default 20, at most 100.", openapi.Integer(1)),
+		},
+		Responses: openapi.Responses{
+			"200": doc.JSON("One page of deliveries, newest first.", webhook.DeliveryLog{}),
+			"400": doc.Error("The id, page or pageSize is invalid."),
+			"404": doc.Error("No such webhook."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/webhooks/{id}/deliveries/{deliveryId}/redeliver", &openapi.Operation{
+		Tags:        []string{"webhooks"},
+		Summary:     "Send a delivery again",
+		Description: "Queues the delivery for an immediate attempt with a fresh attempt count.",
+		OperationID: "redeliverWebhook",
+		Parameters: []openapi.Parameter{
+			id,
+			openapi.PathParam("deliveryId", "Id of the delivery.", openapi.Integer()),
+		},
+		Responses: openapi.Responses{
+			"200": doc.JSON("The queued delivery.", webhook.Delivery{}),
+			"400": doc.Error("An id is invalid."),
+			"404": doc.Error("No such webhook or delivery."),
+			"500": doc.Error("Unexpected error."),
+		},
+	})
+}
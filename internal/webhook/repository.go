@@ -0,0 +1,390 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/webhook"
+)
+
+type (
+	WebhookRepository interface {
+		Create(ctx context.Context, params webhook.Webhook) (int64, error)
+		Update(ctx context.Context, params webhook.Webhook) error
+		Delete(ctx context.Context, id int64) error
+		FindByID(ctx context.Context, id int64) (webhook.Webhook, error)
+		FindAll(ctx context.Context) ([]webhook.Webhook, error)
+		AddDeliveries(ctx context.Context, deliveries ...webhook.Delivery) error
+		FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]webhook.Delivery, error)
+		LeaseDeliveries(ctx context.Context, ids []int64, until time.Time) error
+		UpdateDelivery(ctx context.Context, delivery webhook.Delivery) error
+		FindDeliveryByID(ctx context.Context, webhookID, id int64) (webhook.Delivery, error)
+		FindDeliveries(ctx context.Context, webhookID int64, limit, offset int) ([]webhook.Delivery, error)
+		CountDeliveries(ctx context.Context, webhookID int64) (int64, error)
+	}
+
+	webhookRepositoryImpl struct {
+		DB              *sql.DB
+		tableName       string
+		deliveriesTable string
+		queryTimeout    time.Duration
+	}
+)
+
+func NewWebhookRepositoryImpl(db *sql.DB, tableName, deliveriesTable string, queryTimeout time.Duration) WebhookRepository {
+	return &webhookRepositoryImpl{
+		DB:              db,
+		tableName:       tableName,
+		deliveriesTable: deliveriesTable,
+		queryTimeout:    queryTimeout,
+	}
+}
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at`
+
+func (wr *webhookRepositoryImpl) Create(ctx context.Context, params webhook.Webhook) (ID int64, err error) {
+	query := fmt.Sprintf(`INSERT INTO %s (url, secret, event_types, active, created_at, update_at) VALUES (?, ?, ?, ?, ?, ?)`, wr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "INSERT", wr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	result, err := database.Conn(ctx, wr.DB).ExecContext(ctx, query, params.URL, params.Secret, strings.Join(params.EventTypes, ","), params.Active, params.CreatedAt, params.UpdateAt)
+	if err != nil {
+		logger.Println(ctx, err)
+		return ID, database.Error(err)
+	}
+
+	return result.LastInsertId()
+}
+
+func (wr *webhookRepositoryImpl) Update(ctx context.Context, params webhook.Webhook) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET url = ?, secret = ?, event_types = ?, active = ?, update_at = ? WHERE id = ?`, wr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", wr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	result, err := database.Conn(ctx, wr.DB).ExecContext(ctx, query, params.URL, params.Secret, strings.Join(params.EventTypes, ","), params.Active, params.UpdateAt, params.ID)
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected < 1 {
+		return exception.ErrNotFound
+	}
+
+	return nil
+}
+
+func (wr *webhookRepositoryImpl) Delete(ctx context.Context, id int64) (err error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, wr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "DELETE", wr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	result, err := database.Conn(ctx, wr.DB).ExecContext(ctx, query, id)
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected < 1 {
+		return exception.ErrNotFound
+	}
+
+	return nil
+}
+
+func (wr *webhookRepositoryImpl) FindByID(ctx context.Context, id int64) (data webhook.Webhook, err error) {
+	query := fmt.Sprintf(`SELECT id, url, secret, event_types, active, created_at, update_at FROM %s WHERE id = ?`, wr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", wr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	data, err = scanWebhook(database.Conn(ctx, wr.DB).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return data, exception.ErrNotFound
+	}
+
+	if err != nil {
+		logger.Println(ctx, err)
+		return data, database.Error(err)
+	}
+
+	return data, nil
+}
+
+func (wr *webhookRepositoryImpl) FindAll(ctx context.Context) (webhooks []webhook.Webhook, err error) {
+	query := fmt.Sprintf(`SELECT id, url, secret, event_types, active, created_at, update_at FROM %s ORDER BY id`, wr.tableName)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", wr.tableName, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, wr.DB).QueryContext(ctx, query)
+	if err != nil {
+		logger.Println(ctx, err)
+		return webhooks, database.Error(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			logger.Println(ctx, err)
+			return webhooks, database.Error(err)
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, nil
+}
+
+// AddDeliveries queues deliveries with one statement. A delivery of an
+// event already queued for its webhook is left as it is.
+func (wr *webhookRepositoryImpl) AddDeliveries(ctx context.Context, deliveries ...webhook.Delivery) (err error) {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	values := strings.TrimSuffix(strings.Repeat("(?,?,?,?,?,?,?,?),", len(deliveries)), ",")
+	query := fmt.Sprintf(`INSERT INTO %s (webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at) VALUES %s ON DUPLICATE KEY UPDATE id = id`, wr.deliveriesTable, values)
+
+	ctx, span := tracing.StartQuery(ctx, "INSERT", wr.deliveriesTable, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(deliveries)*8)
+	for _, d := range deliveries {
+		args = append(args, d.WebhookID, d.EventID, d.EventType, string(d.Payload), d.Status, d.Attempts, d.NextAttemptAt, d.CreatedAt)
+	}
+
+	if _, err = database.Conn(ctx, wr.DB).ExecContext(ctx, query, args...); err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+// FindDueDeliveries returns pending deliveries whose next attempt is due.
+// Inside a transaction they are locked, skipping rows another sender holds.
+func (wr *webhookRepositoryImpl) FindDueDeliveries(ctx context.Context, now time.Time, limit int) (deliveries []webhook.Delivery, err error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?`, deliveryColumns, wr.deliveriesTable)
+	if database.InTransaction(ctx) {
+		query += ` FOR UPDATE SKIP LOCKED`
+	}
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", wr.deliveriesTable, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	return wr.queryDeliveries(ctx, query, webhook.DeliveryPending, now, limit)
+}
+
+// LeaseDeliveries pushes the next attempt of deliveries ids to until, so
+// senders looking for due deliveries pass them by until then.
+func (wr *webhookRepositoryImpl) LeaseDeliveries(ctx context.Context, ids []int64, until time.Time) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET next_attempt_at = ? WHERE id IN (%s)`, wr.deliveriesTable, strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","))
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", wr.deliveriesTable, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, until)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	if _, err = database.Conn(ctx, wr.DB).ExecContext(ctx, query, args...); err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	return nil
+}
+
+func (wr *webhookRepositoryImpl) UpdateDelivery(ctx context.Context, delivery webhook.Delivery) (err error) {
+	query := fmt.Sprintf(`UPDATE %s SET status = ?, attempts = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?, delivered_at = ? WHERE id = ?`, wr.deliveriesTable)
+
+	ctx, span := tracing.StartQuery(ctx, "UPDATE", wr.deliveriesTable, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	result, err := database.Conn(ctx, wr.DB).ExecContext(
+		ctx,
+		query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DeliveredAt,
+		delivery.ID,
+	)
+	if err != nil {
+		logger.Println(ctx, err)
+		return database.Error(err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected < 1 {
+		return exception.ErrNotFound
+	}
+
+	return nil
+}
+
+func (wr *webhookRepositoryImpl) FindDeliveryByID(ctx context.Context, webhookID, id int64) (delivery webhook.Delivery, err error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE webhook_id = ? AND id = ?`, deliveryColumns, wr.deliveriesTable)
+	if database.InTransaction(ctx) {
+		query += ` FOR UPDATE`
+	}
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", wr.deliveriesTable, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	deliveries, err := wr.queryDeliveries(ctx, query, webhookID, id)
+	if err != nil {
+		return delivery, err
+	}
+
+	if len(deliveries) == 0 {
+		return delivery, exception.ErrNotFound
+	}
+
+	return deliveries[0], nil
+}
+
+// FindDeliveries returns a page of a webhook's deliveries, newest first.
+func (wr *webhookRepositoryImpl) FindDeliveries(ctx context.Context, webhookID int64, limit, offset int) (deliveries []webhook.Delivery, err error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE webhook_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`, deliveryColumns, wr.deliveriesTable)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", wr.deliveriesTable, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	return wr.queryDeliveries(ctx, query, webhookID, limit, offset)
+}
+
+func (wr *webhookRepositoryImpl) CountDeliveries(ctx context.Context, webhookID int64) (total int64, err error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE webhook_id = ?`, wr.deliveriesTable)
+
+	ctx, span := tracing.StartQuery(ctx, "SELECT", wr.deliveriesTable, query)
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := database.WithTimeout(ctx, wr.queryTimeout)
+	defer cancel()
+
+	if err = database.Conn(ctx, wr.DB).QueryRowContext(ctx, query, webhookID).Scan(&total); err != nil {
+		logger.Println(ctx, err)
+		return 0, database.Error(err)
+	}
+
+	return total, nil
+}
+
+func (wr *webhookRepositoryImpl) queryDeliveries(ctx context.Context, query string, args ...interface{}) (deliveries []webhook.Delivery, err error) {
+	rows, err := database.Conn(ctx, wr.DB).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Println(ctx, err)
+		return deliveries, database.Error(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var d webhook.Delivery
+		var payload []byte
+		var lastError sql.NullString
+		if err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.EventID,
+			&d.EventType,
+			&payload,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.LastStatusCode,
+			&lastError,
+			&d.CreatedAt,
+			&d.DeliveredAt,
+		); err != nil {
+			logger.Println(ctx, err)
+			return deliveries, database.Error(err)
+		}
+
+		d.Payload = payload
+		d.LastError = lastError.String
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhook(row scanner) (w webhook.Webhook, err error) {
+	var eventTypes string
+
+	err = row.Scan(
+		&w.ID,
+		&w.URL,
+		&w.Secret,
+		&eventTypes,
+		&w.Active,
+		&w.CreatedAt,
+		&w.UpdateAt,
+	)
+
+	if eventTypes != "" {
+		w.EventTypes = strings.Split(eventTypes, ",")
+	}
+
+	return w, err
+}
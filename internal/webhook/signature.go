@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Risuii/models/event"
+)
+
+const (
+	HeaderSignature  = "X-Webhook-Signature"
+	HeaderDeliveryID = "X-Webhook-Delivery-Id"
+	HeaderEventID    = "X-Event-Id"
+	HeaderEventType  = "X-Event-Type"
+)
+
+// Sign returns the X-Webhook-Signature value for body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed by secret>".
+// Binding the timestamp lets receivers reject replays.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+
+	return fmt.Sprintf("t=%s,v1=%s", t, signature(secret, t, body))
+}
+
+// Verify checks a signature header against body and rejects it when the
+// timestamp is further than tolerance from now. A zero tolerance skips
+// the timestamp check.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t, v1 string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			v1 = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || v1 == "" {
+		return fmt.Errorf("malformed signature header")
+	}
+
+	if tolerance > 0 {
+		if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("signature timestamp outside tolerance")
+		}
+	}
+
+	if !hmac.Equal([]byte(v1), []byte(signature(secret, t, body))) {
+		return fmt.Errorf("signature mismatch")
+	}
+
+	return nil
+}
+
+func signature(secret, t string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// envelopeJSON is the body sent to receivers.
+func envelopeJSON(envelope event.Envelope) ([]byte, error) {
+	return json.Marshal(envelope)
+}
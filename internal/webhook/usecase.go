@@ -0,0 +1,578 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/etag"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/logger"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/helpers/tracing"
+	"github.com/Risuii/models/event"
+	"github.com/Risuii/models/webhook"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	// maxErrorLength bounds what is kept of a receiver's error answer.
+	maxErrorLength = 1024
+)
+
+type (
+	WebhookUseCase interface {
+		Create(ctx context.Context, params webhook.Input) response.Response
+		List(ctx context.Context) response.Response
+		Get(ctx context.Context, id int64) response.Response
+		Update(ctx context.Context, id int64, params webhook.Input) response.Response
+		Delete(ctx context.Context, id int64) response.Response
+		Deliveries(ctx context.Context, id int64, page, pageSize int) response.Response
+		Redeliver(ctx context.Context, id, deliveryID int64) response.Response
+		Publish(ctx context.Context, envelope event.Envelope) error
+		DeliverDue(ctx context.Context) (int, error)
+	}
+
+	// Options tunes delivery; zero values fall back to the defaults.
+	Options struct {
+		Client      *http.Client
+		MaxAttempts int
+		BackoffBase time.Duration
+		BackoffMax  time.Duration
+		BatchSize   int
+		// Lease is how long a claimed batch is kept from other senders; it
+		// must outlast sending the whole batch.
+		Lease time.Duration
+		// Resolver looks up the host of a subscription URL when it is
+		// created or updated; net.DefaultResolver when nil.
+		Resolver Resolver
+	}
+
+	Resolver interface {
+		LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	}
+
+	webhookUseCaseImpl struct {
+		repo WebhookRepository
+		tx   database.TxManager
+		opts Options
+	}
+)
+
+func NewWebhookUseCaseImpl(repo WebhookRepository, tx database.TxManager, opts Options) WebhookUseCase {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+
+	if opts.BackoffBase <= 0 {
+		opts.BackoffBase = 10 * time.Second
+	}
+
+	if opts.BackoffMax <= 0 {
+		opts.BackoffMax = time.Hour
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+
+	if opts.Resolver == nil {
+		opts.Resolver = net.DefaultResolver
+	}
+
+	if opts.Lease <= 0 {
+		opts.Lease = time.Hour
+		if opts.Client.Timeout > 0 {
+			opts.Lease = time.Duration(opts.BatchSize)*opts.Client.Timeout + time.Minute
+		}
+	}
+
+	return &webhookUseCaseImpl{
+		repo: repo,
+		tx:   tx,
+		opts: opts,
+	}
+}
+
+// Create registers a subscription. The secret is only returned here.
+func (wu *webhookUseCaseImpl) Create(ctx context.Context, params webhook.Input) response.Response {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Create")
+	defer span.End()
+
+	if err := wu.checkTarget(ctx, params.URL); err != nil {
+		return failure(err)
+	}
+
+	now := time.Now()
+	data := webhook.Webhook{
+		URL:        params.URL,
+		Secret:     params.Secret,
+		EventTypes: params.EventTypes,
+		Active:     params.Active == nil || *params.Active,
+		CreatedAt:  now,
+		UpdateAt:   now,
+	}
+
+	if data.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+		}
+
+		data.Secret = secret
+	}
+
+	ID, err := wu.repo.Create(ctx, data)
+	if err != nil {
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+
+	data.ID = ID
+
+	return response.Success(response.StatusCreated, data)
+}
+
+func (wu *webhookUseCaseImpl) List(ctx context.Context) response.Response {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.List")
+	defer span.End()
+
+	data, err := wu.repo.FindAll(ctx)
+	if err != nil {
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+
+	for i := range data {
+		data[i].Secret = ""
+	}
+
+	if data == nil {
+		data = []webhook.Webhook{}
+	}
+
+	return withValidators(response.Success(response.StatusOK, data), data...)
+}
+
+func (wu *webhookUseCaseImpl) Get(ctx context.Context, id int64) response.Response {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Get")
+	defer span.End()
+
+	data, err := wu.repo.FindByID(ctx, id)
+	if err != nil {
+		return failure(err)
+	}
+
+	data.Secret = ""
+
+	return withValidators(response.Success(response.StatusOK, data), data)
+}
+
+// Update replaces a subscription. An empty secret keeps the current one.
+func (wu *webhookUseCaseImpl) Update(ctx context.Context, id int64, params webhook.Input) response.Response {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Update")
+	defer span.End()
+
+	if err := wu.checkTarget(ctx, params.URL); err != nil {
+		return failure(err)
+	}
+
+	var data webhook.Webhook
+
+	err := wu.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := wu.repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		data = current
+		data.URL = params.URL
+		data.EventTypes = params.EventTypes
+		data.UpdateAt = time.Now()
+
+		if params.Secret != "" {
+			data.Secret = params.Secret
+		}
+
+		if params.Active != nil {
+			data.Active = *params.Active
+		}
+
+		return wu.repo.Update(ctx, data)
+	})
+
+	if err != nil {
+		return failure(err)
+	}
+
+	data.Secret = ""
+
+	return withValidators(response.Success(response.StatusOK, data), data)
+}
+
+func (wu *webhookUseCaseImpl) Delete(ctx context.Context, id int64) response.Response {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Delete")
+	defer span.End()
+
+	if err := wu.repo.Delete(ctx, id); err != nil {
+		return failure(err)
+	}
+
+	msg := "Success Delete Data"
+
+	return response.Success(response.StatusOK, msg)
+}
+
+// Deliveries returns one page of a webhook's delivery log, newest first.
+func (wu *webhookUseCaseImpl) Deliveries(ctx context.Context, id int64, page, pageSize int) response.Response {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Deliveries")
+	defer span.End()
+
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+
+	if page < 1 || pageSize < 1 || pageSize > MaxPageSize {
+		return response.Error(response.StatusBadRequest, exception.ErrBadRequest)
+	}
+
+	if _, err := wu.repo.FindByID(ctx, id); err != nil {
+		return failure(err)
+	}
+
+	total, err := wu.repo.CountDeliveries(ctx, id)
+	if err != nil {
+		return failure(err)
+	}
+
+	deliveries, err := wu.repo.FindDeliveries(ctx, id, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return failure(err)
+	}
+
+	if deliveries == nil {
+		deliveries = []webhook.Delivery{}
+	}
+
+	return response.Success(response.StatusOK, webhook.DeliveryLog{
+		Deliveries: deliveries,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+	})
+}
+
+// Redeliver queues a delivery again with a fresh set of attempts, whatever
+// its current state.
+func (wu *webhookUseCaseImpl) Redeliver(ctx context.Context, id, deliveryID int64) response.Response {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Redeliver")
+	defer span.End()
+
+	var delivery webhook.Delivery
+
+	err := wu.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		delivery, err = wu.repo.FindDeliveryByID(ctx, id, deliveryID)
+		if err != nil {
+			return err
+		}
+
+		delivery.Status = webhook.DeliveryPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = time.Now()
+		delivery.DeliveredAt = nil
+
+		return wu.repo.UpdateDelivery(ctx, delivery)
+	})
+
+	if err != nil {
+		return failure(err)
+	}
+
+	return response.Success(response.StatusOK, delivery)
+}
+
+// Publish fans an outbox envelope out to every active subscription that
+// wants its type. It lets the webhook registry act as an outbox publisher;
+// the deliveries are queued in the relay's transaction and sent by
+// DeliverDue. Publishing an envelope again queues nothing new, so the relay
+// can retry it after another publisher failed.
+func (wu *webhookUseCaseImpl) Publish(ctx context.Context, envelope event.Envelope) error {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Publish")
+	defer span.End()
+
+	webhooks, err := wu.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := envelopeJSON(envelope)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	var deliveries []webhook.Delivery
+	for _, w := range webhooks {
+		if !w.Active || !w.Subscribed(envelope.Type) {
+			continue
+		}
+
+		deliveries = append(deliveries, webhook.Delivery{
+			WebhookID:     w.ID,
+			EventID:       envelope.ID,
+			EventType:     envelope.Type,
+			Payload:       payload,
+			Status:        webhook.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	return wu.repo.AddDeliveries(ctx, deliveries...)
+}
+
+// DeliverDue sends one batch of due deliveries and reports how many
+// succeeded. A failed attempt is retried with exponential backoff until
+// MaxAttempts, after which the delivery is dead-lettered. The batch is
+// claimed in a short transaction and sent outside of it, each outcome
+// recorded on its own; deliveries whose outcome is lost, e.g. to a crash,
+// are sent again once their lease ends.
+func (wu *webhookUseCaseImpl) DeliverDue(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.DeliverDue")
+	defer span.End()
+
+	deliveries, webhooks, err := wu.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		delivered int
+		errs      []error
+	)
+
+	for _, delivery := range deliveries {
+		delivery = wu.attempt(ctx, webhooks[delivery.WebhookID], delivery)
+		if delivery.Status == webhook.DeliveryDelivered {
+			delivered++
+		}
+
+		if err := wu.repo.UpdateDelivery(ctx, delivery); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return delivered, errors.Join(errs...)
+}
+
+// claim leases a batch of due deliveries to this sender and returns them
+// with their webhooks.
+func (wu *webhookUseCaseImpl) claim(ctx context.Context) ([]webhook.Delivery, map[int64]webhook.Webhook, error) {
+	var deliveries []webhook.Delivery
+	webhooks := map[int64]webhook.Webhook{}
+
+	err := wu.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		deliveries, err = wu.repo.FindDueDeliveries(ctx, time.Now(), wu.opts.BatchSize)
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+
+			if _, ok := webhooks[delivery.WebhookID]; ok {
+				continue
+			}
+
+			w, err := wu.repo.FindByID(ctx, delivery.WebhookID)
+			if err != nil {
+				return err
+			}
+			webhooks[w.ID] = w
+		}
+
+		return wu.repo.LeaseDeliveries(ctx, ids, time.Now().Add(wu.opts.Lease))
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return deliveries, webhooks, nil
+}
+
+// attempt sends delivery once and returns it with its new state.
+func (wu *webhookUseCaseImpl) attempt(ctx context.Context, w webhook.Webhook, delivery webhook.Delivery) webhook.Delivery {
+	now := time.Now()
+	delivery.Attempts++
+
+	if !w.Active {
+		delivery.Status = webhook.DeliveryDead
+		delivery.LastError = "webhook is inactive"
+		return delivery
+	}
+
+	statusCode, err := wu.send(ctx, w, delivery, now)
+	delivery.LastStatusCode = statusCode
+
+	if err == nil {
+		delivery.Status = webhook.DeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return delivery
+	}
+
+	logger.Println(ctx, "webhook", w.ID, "delivery", delivery.ID, err)
+	delivery.LastError = truncate(err.Error(), maxErrorLength)
+
+	if delivery.Attempts >= wu.opts.MaxAttempts {
+		delivery.Status = webhook.DeliveryDead
+		return delivery
+	}
+
+	delivery.NextAttemptAt = now.Add(Backoff(delivery.Attempts, wu.opts.BackoffBase, wu.opts.BackoffMax))
+
+	return delivery
+}
+
+func (wu *webhookUseCaseImpl) send(ctx context.Context, w webhook.Webhook, delivery webhook.Delivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSignature, Sign(w.Secret, now, delivery.Payload))
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+
+	resp, err := wu.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s: %s", resp.Status, body)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff is the wait after the given number of failed attempts: base
+// doubled per attempt, capped at max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= max {
+			return max
+		}
+	}
+
+	return wait
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+
+	return s
+}
+
+// checkTarget refuses a URL whose host is, or resolves to, a loopback,
+// link-local, private or unspecified address, so that a subscription
+// cannot make the server call into its own network.
+func (wu *webhookUseCaseImpl) checkTarget(ctx context.Context, raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil || target.Hostname() == "" {
+		return exception.ErrWebhookTarget
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(target.Hostname()); ip != nil {
+		ips = append(ips, ip)
+	} else {
+		addrs, err := wu.opts.Resolver.LookupIPAddr(ctx, target.Hostname())
+		if err != nil || len(addrs) == 0 {
+			return exception.ErrWebhookTarget
+		}
+
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+			return exception.ErrWebhookTarget
+		}
+	}
+
+	return nil
+}
+
+func failure(err error) response.Response {
+	switch err {
+	case exception.ErrNotFound:
+		return response.Error(response.StatusNotFound, exception.ErrNotFound)
+	case exception.ErrWebhookTarget:
+		return response.Error(response.StatusBadRequest, exception.ErrWebhookTarget)
+	default:
+		return response.Error(response.StatusInternalServerError, exception.ErrInternalServer)
+	}
+}
+
+// withValidators sets the ETag and Last-Modified of the subscriptions res
+// carries, from their ids and update_at.
+func withValidators(res response.Response, data ...webhook.Webhook) response.Response {
+	parts := make([]string, 0, len(data))
+
+	var modified time.Time
+	for _, item := range data {
+		parts = append(parts, fmt.Sprintf("%d:%d", item.ID, item.UpdateAt.UnixNano()))
+		if item.UpdateAt.After(modified) {
+			modified = item.UpdateAt
+		}
+	}
+
+	res.SetHeader("ETag", etag.New(parts...))
+	if !modified.IsZero() {
+		res.SetHeader("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	return res
+}
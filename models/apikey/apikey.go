@@ -0,0 +1,32 @@
+package apikey
+
+import "time"
+
+// Keys look like "hk_<prefix>_<secret>": the prefix finds the key and is
+// shown in lists, only a hash of the secret is stored.
+const KeyPrefix = "hk"
+
+// APIKey is a credential of a server-to-server client.
+type APIKey struct {
+	ID     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Hash   string   `json:"-"`
+	Scopes []string `json:"scopes"`
+	// Key is the whole key, only returned when it is created or rotated.
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdateAt   time.Time  `json:"update_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+type Input struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=cart:read cart:write catalog:admin webhooks:admin keys:admin carts:admin"`
+}
+
+// Revoked reports whether k no longer authenticates.
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
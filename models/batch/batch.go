@@ -0,0 +1,37 @@
+package batch
+
+import "github.com/Risuii/models/product"
+
+const (
+	OpAdd    = "add"
+	OpSet    = "set"
+	OpRemove = "remove"
+
+	// ModeAtomic applies every operation or none of them.
+	ModeAtomic = "atomic"
+	// ModePartial applies the operations that succeed and reports the rest.
+	ModePartial = "partial"
+
+	StatusAborted = "ABORTED"
+)
+
+type Operation struct {
+	Op         string `json:"op" validate:"required,oneof=add set remove"`
+	KodeProduk string `json:"kodeProduk" validate:"required"`
+	Nama       string `json:"nama"`
+	Kuantitas  int64  `json:"kuantitas" validate:"min=0"`
+}
+
+type Batch struct {
+	Mode       string      `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Operations []Operation `json:"operations" validate:"required,min=1,dive"`
+}
+
+type Result struct {
+	Index      int              `json:"index"`
+	Op         string           `json:"op"`
+	KodeProduk string           `json:"kodeProduk"`
+	Status     string           `json:"status"`
+	Item       *product.Product `json:"item,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
@@ -0,0 +1,46 @@
+package cartevent
+
+import "time"
+
+const (
+	ActionAdd             = "ADD"
+	ActionUpdateKuantitas = "UPDATE_KUANTITAS"
+	ActionDelete          = "DELETE"
+	ActionRestore         = "RESTORE"
+	ActionCheckout        = "CHECKOUT"
+	ActionExpire          = "EXPIRE"
+)
+
+// Event is one append-only audit record of a change to a cart line.
+type Event struct {
+	ID              int64     `json:"id"`
+	CartID          int64     `json:"cartId"`
+	KodeProduk      string    `json:"kodeProduk"`
+	Action          string    `json:"action"`
+	Actor           string    `json:"actor"`
+	RequestID       string    `json:"requestId"`
+	SourceIP        string    `json:"sourceIp"`
+	KuantitasBefore int64     `json:"kuantitasBefore"`
+	KuantitasAfter  int64     `json:"kuantitasAfter"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type History struct {
+	Events   []Event `json:"events"`
+	Page     int     `json:"page"`
+	PageSize int     `json:"pageSize"`
+	Total    int64   `json:"total"`
+}
+
+// Access is one append-only record of support or admin reaching a cart
+// other than their own, or listing carts.
+type Access struct {
+	ID        int64     `json:"id"`
+	Actor     string    `json:"actor"`
+	Role      string    `json:"role"`
+	Action    string    `json:"action"`
+	Owner     string    `json:"owner"`
+	RequestID string    `json:"requestId"`
+	SourceIP  string    `json:"sourceIp"`
+	CreatedAt time.Time `json:"created_at"`
+}
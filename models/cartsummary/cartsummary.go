@@ -0,0 +1,26 @@
+package cartsummary
+
+import "time"
+
+// Summary describes one cart by its owner, for support and admin.
+type Summary struct {
+	Owner          string    `json:"owner"`
+	Lines          int64     `json:"lines"`
+	TotalKuantitas int64     `json:"totalKuantitas"`
+	LastActivityAt time.Time `json:"lastActivityAt"`
+}
+
+// Filter selects carts whose owner, or any of whose lines by kodeProduk
+// or nama, contains Q.
+type Filter struct {
+	Q        string
+	Page     int
+	PageSize int
+}
+
+type List struct {
+	Carts    []Summary `json:"carts"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"pageSize"`
+	Total    int64     `json:"total"`
+}
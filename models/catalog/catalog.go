@@ -0,0 +1,8 @@
+package catalog
+
+// Entry is a product the carts have held, by its kodeProduk and nama. The
+// service keeps no catalog of its own; the entries are read from the lines.
+type Entry struct {
+	KodeProduk string `json:"kodeProduk" validate:"required"`
+	Nama       string `json:"nama" validate:"required"`
+}
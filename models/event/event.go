@@ -0,0 +1,113 @@
+package event
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types published to other services. A payload change that breaks
+// consumers gets a new Version and a new schema file; older versions keep
+// their schema.
+const (
+	TypeItemAdded       = "cart.ItemAdded"
+	TypeQuantityChanged = "cart.QuantityChanged"
+	TypeItemRemoved     = "cart.ItemRemoved"
+	TypeCartCheckedOut  = "cart.CartCheckedOut"
+	TypeCartAbandoned   = "cart.CartAbandoned"
+
+	Version = 1
+)
+
+// Types lists every event type, in the order they are documented.
+var Types = []string{
+	TypeItemAdded,
+	TypeQuantityChanged,
+	TypeItemRemoved,
+	TypeCartCheckedOut,
+	TypeCartAbandoned,
+}
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// Envelope is what publishers deliver. Data holds the type specific
+// payload described by the schema for Type and Version; Owner is the owner
+// of the cart the event is about.
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Actor      string          `json:"actor,omitempty"`
+	RequestID  string          `json:"requestId,omitempty"`
+	Owner      string          `json:"owner,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Message is an envelope waiting in the outbox.
+type Message struct {
+	ID       int64
+	Attempts int
+	Envelope Envelope
+}
+
+type ItemAdded struct {
+	CartID     int64  `json:"cartId"`
+	KodeProduk string `json:"kodeProduk"`
+	Kuantitas  int64  `json:"kuantitas"`
+}
+
+type QuantityChanged struct {
+	CartID          int64  `json:"cartId"`
+	KodeProduk      string `json:"kodeProduk"`
+	KuantitasBefore int64  `json:"kuantitasBefore"`
+	KuantitasAfter  int64  `json:"kuantitasAfter"`
+}
+
+type ItemRemoved struct {
+	CartID     int64  `json:"cartId"`
+	KodeProduk string `json:"kodeProduk"`
+	Kuantitas  int64  `json:"kuantitas"`
+}
+
+type Line struct {
+	CartID     int64  `json:"cartId"`
+	KodeProduk string `json:"kodeProduk"`
+	Nama       string `json:"nama"`
+	Kuantitas  int64  `json:"kuantitas"`
+}
+
+type CartCheckedOut struct {
+	Items          []Line `json:"items"`
+	TotalKuantitas int64  `json:"totalKuantitas"`
+}
+
+type CartAbandoned struct {
+	Items          []Line    `json:"items"`
+	LastActivityAt time.Time `json:"lastActivityAt"`
+}
+
+// New wraps data in an envelope with a fresh id.
+func New(eventType string, data interface{}, occurredAt time.Time) (Envelope, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		Version:    Version,
+		OccurredAt: occurredAt,
+		Data:       raw,
+	}, nil
+}
+
+// Schema returns the JSON schema of an event type at a version.
+func Schema(eventType string, version int) ([]byte, error) {
+	return schemas.ReadFile(fmt.Sprintf("schemas/%s.v%d.json", eventType, version))
+}
@@ -1,6 +1,6 @@
 package filter
 
 type Filter struct {
-	Nama      string
-	Kuantitas int64
+	Nama      string `json:"nama"`
+	Kuantitas int64  `json:"kuantitas"`
 }
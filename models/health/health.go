@@ -0,0 +1,18 @@
+package health
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+type Check struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks"`
+}
@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryPending   = "PENDING"
+	DeliveryDelivered = "DELIVERED"
+	// DeliveryDead is a delivery that ran out of attempts; it is only sent
+	// again when redelivered.
+	DeliveryDead = "DEAD"
+)
+
+// Webhook is a subscription. An empty EventTypes receives every event.
+type Webhook struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"eventTypes"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdateAt   time.Time `json:"update_at"`
+}
+
+// Input creates or replaces a subscription. A missing secret is generated
+// on create; a missing active flag means active.
+type Input struct {
+	URL        string   `json:"url" validate:"required,url,startswith=http"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16"`
+	EventTypes []string `json:"eventTypes" validate:"dive,oneof=cart.ItemAdded cart.QuantityChanged cart.ItemRemoved cart.CartCheckedOut cart.CartAbandoned"`
+	Active     *bool    `json:"active"`
+}
+
+type Delivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhookId"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"-"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	LastStatusCode int             `json:"lastStatusCode,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+}
+
+type DeliveryLog struct {
+	Deliveries []Delivery `json:"deliveries"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"pageSize"`
+	Total      int64      `json:"total"`
+}
+
+// Subscribed reports whether w wants events of eventType.
+func (w Webhook) Subscribed(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
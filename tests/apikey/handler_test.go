@@ -0,0 +1,114 @@
+package apikey_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/apikey"
+	models "github.com/Risuii/models/apikey"
+	"github.com/Risuii/tests/mocks"
+)
+
+func serve(handler http.HandlerFunc, r *http.Request) response.ResponseImpl {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, r)
+
+	rb := response.ResponseImpl{}
+	json.NewDecoder(recorder.Body).Decode(&rb)
+
+	return rb
+}
+
+func TestHandler_Create(t *testing.T) {
+	t.Run("Create Success", func(t *testing.T) {
+		resp := response.Success(response.StatusCreated, models.APIKey{ID: 7})
+
+		apiKeyUseCase := new(mocks.APIKeyUseCase)
+		apiKeyUseCase.On("Create", mock.Anything, models.Input{Name: "billing", Scopes: []string{"cart:read"}}).Return(resp)
+
+		apiKeyHandler := apikey.APIKeyHandler{
+			Validate: validator.New(),
+			UseCase:  apiKeyUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/admin/api-keys", strings.NewReader(`{"name":"billing","scopes":["cart:read"]}`))
+
+		rb := serve(apiKeyHandler.Create, r)
+
+		assert.Equal(t, response.StatusCreated, rb.Status)
+		apiKeyUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Create Unknown Scope", func(t *testing.T) {
+		apiKeyUseCase := new(mocks.APIKeyUseCase)
+
+		apiKeyHandler := apikey.APIKeyHandler{
+			Validate: validator.New(),
+			UseCase:  apiKeyUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/admin/api-keys", strings.NewReader(`{"name":"billing","scopes":["cart:nope"]}`))
+
+		rb := serve(apiKeyHandler.Create, r)
+
+		assert.Equal(t, response.StatusBadRequest, rb.Status)
+		apiKeyUseCase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Create Without Scopes", func(t *testing.T) {
+		apiKeyUseCase := new(mocks.APIKeyUseCase)
+
+		apiKeyHandler := apikey.APIKeyHandler{
+			Validate: validator.New(),
+			UseCase:  apiKeyUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/admin/api-keys", strings.NewReader(`{"name":"billing","scopes":[]}`))
+
+		rb := serve(apiKeyHandler.Create, r)
+
+		assert.Equal(t, response.StatusBadRequest, rb.Status)
+	})
+}
+
+func TestHandler_Rotate(t *testing.T) {
+	t.Run("Rotate Success", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, models.APIKey{ID: 7})
+
+		apiKeyUseCase := new(mocks.APIKeyUseCase)
+		apiKeyUseCase.On("Rotate", mock.Anything, int64(7)).Return(resp)
+
+		apiKeyHandler := apikey.APIKeyHandler{UseCase: apiKeyUseCase}
+
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/admin/api-keys/7/rotate", nil), map[string]string{"id": "7"})
+
+		rb := serve(apiKeyHandler.Rotate, r)
+
+		assert.Equal(t, response.StatusOK, rb.Status)
+		apiKeyUseCase.AssertExpectations(t)
+	})
+}
+
+func TestHandler_Revoke(t *testing.T) {
+	t.Run("Revoke Invalid ID", func(t *testing.T) {
+		apiKeyUseCase := new(mocks.APIKeyUseCase)
+
+		apiKeyHandler := apikey.APIKeyHandler{UseCase: apiKeyUseCase}
+
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/admin/api-keys/x", nil), map[string]string{"id": "99999999999999999999"})
+
+		rb := serve(apiKeyHandler.Revoke, r)
+
+		assert.Equal(t, response.StatusBadRequest, rb.Status)
+		apiKeyUseCase.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,113 @@
+package apikey_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/internal/apikey"
+	models "github.com/Risuii/models/apikey"
+	"github.com/Risuii/tests/mock"
+)
+
+var currentTime = time.Date(2021, 12, 12, 0, 0, 0, 0, time.UTC)
+
+var apiKeyColumns = []string{"id", "name", "prefix", "hash", "scopes", "created_at", "update_at", "last_used_at", "revoked_at"}
+
+func newRepository() (apikey.APIKeyRepository, sqlmock.Sqlmock, func() error) {
+	db, mock := mock.NewMock()
+	repo := apikey.NewAPIKeyRepositoryImpl(db, constant.TableAPIKeys, time.Second)
+
+	return repo, mock, db.Close
+}
+
+func TestCreateRepository(t *testing.T) {
+	t.Run("Create Success", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		query := fmt.Sprintf(`INSERT INTO %s \(name, prefix, hash, scopes, created_at, update_at\) VALUES \(\?, \?, \?, \?, \?, \?\)`, constant.TableAPIKeys)
+
+		mock.ExpectExec(query).
+			WithArgs("billing", "3f9c2a7d", "hash", "cart:read,cart:write", currentTime, currentTime).
+			WillReturnResult(sqlmock.NewResult(7, 1))
+
+		ID, err := repo.Create(context.TODO(), models.APIKey{
+			Name:      "billing",
+			Prefix:    "3f9c2a7d",
+			Hash:      "hash",
+			Scopes:    []string{"cart:read", "cart:write"},
+			CreatedAt: currentTime,
+			UpdateAt:  currentTime,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFindByPrefixRepository(t *testing.T) {
+	query := fmt.Sprintf(`SELECT id, name, prefix, hash, scopes, created_at, update_at, last_used_at, revoked_at FROM %s WHERE prefix = \?`, constant.TableAPIKeys)
+
+	t.Run("Find By Prefix Success", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		mock.ExpectQuery(query).WithArgs("3f9c2a7d").WillReturnRows(sqlmock.NewRows(apiKeyColumns).
+			AddRow(7, "billing", "3f9c2a7d", "hash", "cart:read,cart:write", currentTime, currentTime, currentTime, nil))
+
+		data, err := repo.FindByPrefix(context.TODO(), "3f9c2a7d")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"cart:read", "cart:write"}, data.Scopes)
+		assert.Equal(t, currentTime, *data.LastUsedAt)
+		assert.Nil(t, data.RevokedAt)
+	})
+
+	t.Run("Find By Prefix Not Found", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		mock.ExpectQuery(query).WithArgs("3f9c2a7d").WillReturnRows(sqlmock.NewRows(apiKeyColumns))
+
+		_, err := repo.FindByPrefix(context.TODO(), "3f9c2a7d")
+
+		assert.Equal(t, exception.ErrNotFound, err)
+	})
+}
+
+func TestRevokeRepository(t *testing.T) {
+	t.Run("Revoke Not Found", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		query := fmt.Sprintf(`UPDATE %s SET revoked_at = \?, update_at = \? WHERE id = \? AND revoked_at IS NULL`, constant.TableAPIKeys)
+
+		mock.ExpectExec(query).WithArgs(currentTime, currentTime, int64(7)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Revoke(context.TODO(), 7, currentTime)
+
+		assert.Equal(t, exception.ErrNotFound, err)
+	})
+}
+
+func TestTouchRepository(t *testing.T) {
+	t.Run("Touch Success", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		query := fmt.Sprintf(`UPDATE %s SET last_used_at = \? WHERE id = \?`, constant.TableAPIKeys)
+
+		mock.ExpectExec(query).WithArgs(currentTime, int64(7)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.Touch(context.TODO(), 7, currentTime))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
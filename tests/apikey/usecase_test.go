@@ -0,0 +1,192 @@
+package apikey_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/apikey"
+	models "github.com/Risuii/models/apikey"
+	"github.com/Risuii/tests/mocks"
+)
+
+const (
+	testPrefix = "3f9c2a7d"
+	testSecret = "0123456789abcdef0123456789abcdef0123456789abcdef"
+	testKey    = "hk_" + testPrefix + "_" + testSecret
+)
+
+func testHash() string {
+	sum := sha256.Sum256([]byte(testSecret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func newTxManager() *mocks.TxManager {
+	txManager := new(mocks.TxManager)
+	txManager.On("WithinTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+
+	return txManager
+}
+
+func newUseCase(repo apikey.APIKeyRepository) apikey.APIKeyUseCase {
+	return apikey.NewAPIKeyUseCaseImpl(repo, newTxManager(), apikey.Options{UsageInterval: time.Minute})
+}
+
+func TestUseCaseCreate(t *testing.T) {
+	t.Run("Create Returns Whole Key Once", func(t *testing.T) {
+		var stored models.APIKey
+
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("Create", mock.Anything, mock.MatchedBy(func(k models.APIKey) bool {
+			stored = k
+			return true
+		})).Return(int64(7), nil)
+
+		res := newUseCase(apiKeyRepository).Create(context.TODO(), models.Input{Name: "billing", Scopes: []string{auth.ScopeCartRead}})
+
+		data := res.(*response.ResponseImpl).Data.(models.APIKey)
+		assert.Equal(t, response.StatusCreated, res.(*response.ResponseImpl).Status)
+		assert.Equal(t, int64(7), data.ID)
+		assert.True(t, strings.HasPrefix(data.Key, "hk_"+data.Prefix+"_"))
+		assert.Len(t, data.Prefix, 8)
+
+		// only the hash of the secret is stored
+		assert.NotContains(t, stored.Hash, strings.TrimPrefix(data.Key, "hk_"+data.Prefix+"_"))
+		assert.Len(t, stored.Hash, 64)
+	})
+}
+
+func TestUseCaseRotate(t *testing.T) {
+	t.Run("Rotate Keeps Name And Scopes", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByID", mock.Anything, int64(7)).Return(models.APIKey{ID: 7, Name: "billing", Prefix: testPrefix, Hash: testHash(), Scopes: []string{auth.ScopeCartRead}}, nil)
+		apiKeyRepository.On("Rotate", mock.Anything, mock.MatchedBy(func(k models.APIKey) bool {
+			return k.ID == 7 && k.Prefix != testPrefix && k.Hash != testHash()
+		})).Return(nil)
+
+		res := newUseCase(apiKeyRepository).Rotate(context.TODO(), 7)
+
+		data := res.(*response.ResponseImpl).Data.(models.APIKey)
+		assert.Equal(t, response.StatusOK, res.(*response.ResponseImpl).Status)
+		assert.Equal(t, "billing", data.Name)
+		assert.Equal(t, []string{auth.ScopeCartRead}, data.Scopes)
+		assert.NotEmpty(t, data.Key)
+		apiKeyRepository.AssertExpectations(t)
+	})
+
+	t.Run("Rotate Revoked", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByID", mock.Anything, int64(7)).Return(models.APIKey{ID: 7, RevokedAt: &currentTime}, nil)
+
+		res := newUseCase(apiKeyRepository).Rotate(context.TODO(), 7)
+
+		assert.Equal(t, response.StatusConflicted, res.(*response.ResponseImpl).Status)
+		apiKeyRepository.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rotate Not Found", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByID", mock.Anything, int64(7)).Return(models.APIKey{}, exception.ErrNotFound)
+
+		res := newUseCase(apiKeyRepository).Rotate(context.TODO(), 7)
+
+		assert.Equal(t, response.StatusNotFound, res.(*response.ResponseImpl).Status)
+	})
+}
+
+func TestUseCaseRevoke(t *testing.T) {
+	t.Run("Revoke Success", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByID", mock.Anything, int64(7)).Return(models.APIKey{ID: 7}, nil)
+		apiKeyRepository.On("Revoke", mock.Anything, int64(7), mock.Anything).Return(nil)
+
+		res := newUseCase(apiKeyRepository).Revoke(context.TODO(), 7)
+
+		assert.Equal(t, response.StatusOK, res.(*response.ResponseImpl).Status)
+		assert.True(t, res.(*response.ResponseImpl).Data.(models.APIKey).Revoked())
+	})
+
+	t.Run("Revoke Twice", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByID", mock.Anything, int64(7)).Return(models.APIKey{ID: 7, RevokedAt: &currentTime}, nil)
+
+		res := newUseCase(apiKeyRepository).Revoke(context.TODO(), 7)
+
+		assert.Equal(t, response.StatusOK, res.(*response.ResponseImpl).Status)
+		apiKeyRepository.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUseCaseVerifyKey(t *testing.T) {
+	t.Run("Verify Key Records Use", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByPrefix", mock.Anything, testPrefix).Return(models.APIKey{ID: 7, Prefix: testPrefix, Hash: testHash(), Scopes: []string{auth.ScopeCartRead}}, nil)
+		apiKeyRepository.On("Touch", mock.Anything, int64(7), mock.Anything).Return(nil)
+
+		p, err := newUseCase(apiKeyRepository).VerifyKey(context.TODO(), testKey)
+
+		assert.NoError(t, err)
+		assert.Equal(t, auth.Principal{Kind: auth.KindAPIKey, Subject: "7", Scopes: []string{auth.ScopeCartRead}}, p)
+		apiKeyRepository.AssertExpectations(t)
+	})
+
+	t.Run("Verify Key Recently Used", func(t *testing.T) {
+		recently := time.Now().Add(-time.Second)
+
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByPrefix", mock.Anything, testPrefix).Return(models.APIKey{ID: 7, Prefix: testPrefix, Hash: testHash(), LastUsedAt: &recently}, nil)
+
+		_, err := newUseCase(apiKeyRepository).VerifyKey(context.TODO(), testKey)
+
+		assert.NoError(t, err)
+		apiKeyRepository.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Verify Key Wrong Secret", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByPrefix", mock.Anything, testPrefix).Return(models.APIKey{ID: 7, Prefix: testPrefix, Hash: testHash()}, nil)
+
+		_, err := newUseCase(apiKeyRepository).VerifyKey(context.TODO(), "hk_"+testPrefix+"_wrong")
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("Verify Key Revoked", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByPrefix", mock.Anything, testPrefix).Return(models.APIKey{ID: 7, Prefix: testPrefix, Hash: testHash(), RevokedAt: &currentTime}, nil)
+
+		_, err := newUseCase(apiKeyRepository).VerifyKey(context.TODO(), testKey)
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("Verify Key Unknown", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+		apiKeyRepository.On("FindByPrefix", mock.Anything, testPrefix).Return(models.APIKey{}, exception.ErrNotFound)
+
+		_, err := newUseCase(apiKeyRepository).VerifyKey(context.TODO(), testKey)
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("Verify Key Malformed", func(t *testing.T) {
+		apiKeyRepository := new(mocks.APIKeyRepository)
+
+		_, err := newUseCase(apiKeyRepository).VerifyKey(context.TODO(), "not-a-key")
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+		apiKeyRepository.AssertNotCalled(t, "FindByPrefix", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,70 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/audit"
+	"github.com/Risuii/models/cartevent"
+	"github.com/Risuii/tests/mocks"
+)
+
+func TestHandler_History(t *testing.T) {
+	t.Run("History Success", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, cartevent.History{Page: 2, PageSize: 5})
+
+		auditUseCase := new(mocks.AuditUseCase)
+		auditUseCase.On("History", mock.Anything, int64(3), 2, 5).Return(resp)
+
+		auditHandler := audit.AuditHandler{
+			UseCase: auditUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/cart/3/history?page=2&pageSize=5", nil)
+		r = mux.SetURLVars(r, map[string]string{"id": "3"})
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(auditHandler.History)
+		handler.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, response.StatusOK, rb.Status)
+		auditUseCase.AssertExpectations(t)
+	})
+
+	t.Run("History Invalid Page", func(t *testing.T) {
+		auditUseCase := new(mocks.AuditUseCase)
+
+		auditHandler := audit.AuditHandler{
+			UseCase: auditUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/cart/3/history?page=abc", nil)
+		r = mux.SetURLVars(r, map[string]string{"id": "3"})
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(auditHandler.History)
+		handler.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, response.StatusBadRequest, rb.Status)
+		auditUseCase.AssertNotCalled(t, "History")
+	})
+}
@@ -0,0 +1,94 @@
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/internal/audit"
+	"github.com/Risuii/models/cartevent"
+	"github.com/Risuii/tests/mock"
+)
+
+var currentTime = time.Date(2021, 12, 12, 0, 0, 0, 0, time.UTC)
+
+func TestAppendRepository(t *testing.T) {
+	t.Run("Append Single Insert", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := audit.NewAuditRepositoryImpl(db, constant.TableCartEvents, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`INSERT INTO %s \(cart_id, kodeProduk, action, actor, request_id, source_ip, kuantitas_before, kuantitas_after, created_at\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?,\?\),\(\?,\?,\?,\?,\?,\?,\?,\?,\?\)`, constant.TableCartEvents)
+
+		mock.ExpectExec(query).
+			WithArgs(
+				int64(1), "A", cartevent.ActionAdd, "user-1", "req-1", "10.0.0.1", int64(0), int64(2), currentTime,
+				int64(2), "B", cartevent.ActionDelete, "user-1", "req-1", "10.0.0.1", int64(3), int64(0), currentTime,
+			).
+			WillReturnResult(sqlmock.NewResult(1, 2))
+
+		err := repo.Append(context.TODO(),
+			cartevent.Event{CartID: 1, KodeProduk: "A", Action: cartevent.ActionAdd, Actor: "user-1", RequestID: "req-1", SourceIP: "10.0.0.1", KuantitasAfter: 2, CreatedAt: currentTime},
+			cartevent.Event{CartID: 2, KodeProduk: "B", Action: cartevent.ActionDelete, Actor: "user-1", RequestID: "req-1", SourceIP: "10.0.0.1", KuantitasBefore: 3, CreatedAt: currentTime},
+		)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Append Error", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := audit.NewAuditRepositoryImpl(db, constant.TableCartEvents, time.Second)
+
+		defer db.Close()
+
+		mock.ExpectExec(fmt.Sprintf(`INSERT INTO %s`, constant.TableCartEvents)).WillReturnError(fmt.Errorf("boom"))
+
+		err := repo.Append(context.TODO(), cartevent.Event{CartID: 1})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestFindByCartIDRepository(t *testing.T) {
+	t.Run("Find By Cart ID Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := audit.NewAuditRepositoryImpl(db, constant.TableCartEvents, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, cart_id, kodeProduk, action, actor, request_id, source_ip, kuantitas_before, kuantitas_after, created_at FROM %s WHERE cart_id = \? ORDER BY id DESC LIMIT \? OFFSET \?`, constant.TableCartEvents)
+		rows := sqlmock.NewRows([]string{"id", "cart_id", "kodeProduk", "action", "actor", "request_id", "source_ip", "kuantitas_before", "kuantitas_after", "created_at"}).
+			AddRow(2, 1, "A", cartevent.ActionUpdateKuantitas, "user-1", "req-2", "10.0.0.1", 2, 5, currentTime)
+
+		mock.ExpectQuery(query).WithArgs(int64(1), 20, 20).WillReturnRows(rows)
+
+		events, err := repo.FindByCartID(context.TODO(), 1, 20, 20)
+
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, int64(5), events[0].KuantitasAfter)
+	})
+
+	t.Run("Count By Cart ID Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := audit.NewAuditRepositoryImpl(db, constant.TableCartEvents, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT COUNT\(\*\) FROM %s WHERE cart_id = \?`, constant.TableCartEvents)
+
+		mock.ExpectQuery(query).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+		total, err := repo.CountByCartID(context.TODO(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), total)
+	})
+}
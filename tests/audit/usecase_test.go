@@ -0,0 +1,95 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/internal/audit"
+	"github.com/Risuii/models/cartevent"
+	"github.com/Risuii/tests/mocks"
+)
+
+func TestUseCaseHistory(t *testing.T) {
+	t.Run("History Success", func(t *testing.T) {
+		auditRepository := new(mocks.AuditRepository)
+		lines := new(mocks.CartUseCase)
+		lines.On("AuthorizeLine", mock.Anything, int64(1)).Return(nil)
+		auditRepository.On("CountByCartID", mock.Anything, int64(1)).Return(int64(25), nil)
+		auditRepository.On("FindByCartID", mock.Anything, int64(1), 10, 10).Return([]cartevent.Event{{ID: 15, CartID: 1}}, nil)
+
+		auditUseCase := audit.NewAuditUseCaseImpl(auditRepository, lines)
+
+		resp := auditUseCase.History(context.TODO(), 1, 2, 10)
+
+		assert.NoError(t, resp.Err())
+
+		auditRepository.AssertExpectations(t)
+		lines.AssertExpectations(t)
+	})
+
+	t.Run("History Default Page Size", func(t *testing.T) {
+		auditRepository := new(mocks.AuditRepository)
+		lines := new(mocks.CartUseCase)
+		lines.On("AuthorizeLine", mock.Anything, int64(1)).Return(nil)
+		auditRepository.On("CountByCartID", mock.Anything, int64(1)).Return(int64(1), nil)
+		auditRepository.On("FindByCartID", mock.Anything, int64(1), audit.DefaultPageSize, 0).Return(nil, nil)
+
+		auditUseCase := audit.NewAuditUseCaseImpl(auditRepository, lines)
+
+		resp := auditUseCase.History(context.TODO(), 1, 1, 0)
+
+		assert.NoError(t, resp.Err())
+
+		auditRepository.AssertExpectations(t)
+		lines.AssertExpectations(t)
+	})
+
+	t.Run("History Not Found", func(t *testing.T) {
+		auditRepository := new(mocks.AuditRepository)
+		lines := new(mocks.CartUseCase)
+		lines.On("AuthorizeLine", mock.Anything, int64(1)).Return(nil)
+		auditRepository.On("CountByCartID", mock.Anything, int64(1)).Return(int64(0), nil)
+
+		auditUseCase := audit.NewAuditUseCaseImpl(auditRepository, lines)
+
+		resp := auditUseCase.History(context.TODO(), 1, 1, 0)
+
+		assert.Equal(t, exception.ErrNotFound, resp.Err())
+
+		auditRepository.AssertExpectations(t)
+		lines.AssertExpectations(t)
+	})
+
+	t.Run("History Of Another Cart", func(t *testing.T) {
+		auditRepository := new(mocks.AuditRepository)
+		lines := new(mocks.CartUseCase)
+		lines.On("AuthorizeLine", mock.Anything, int64(1)).Return(exception.ErrNotFound)
+
+		auditUseCase := audit.NewAuditUseCaseImpl(auditRepository, lines)
+
+		resp := auditUseCase.History(context.TODO(), 1, 1, 0)
+
+		assert.Equal(t, exception.ErrNotFound, resp.Err())
+
+		auditRepository.AssertExpectations(t)
+		lines.AssertExpectations(t)
+	})
+
+	t.Run("History Page Size Too Large", func(t *testing.T) {
+		auditRepository := new(mocks.AuditRepository)
+		lines := new(mocks.CartUseCase)
+
+		auditUseCase := audit.NewAuditUseCaseImpl(auditRepository, lines)
+
+		resp := auditUseCase.History(context.TODO(), 1, 1, audit.MaxPageSize+1)
+
+		assert.Equal(t, exception.ErrBadRequest, resp.Err())
+
+		auditRepository.AssertExpectations(t)
+		lines.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,265 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/requestinfo"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/tests/mocks"
+)
+
+var secret = []byte("a-test-secret")
+
+func token(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	signed, err := jwt.NewWithClaims(method, claims).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func bearer(value string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/v1/cart/items", nil)
+	r.Header.Set("Authorization", "Bearer "+value)
+
+	return r
+}
+
+func TestJWT(t *testing.T) {
+	authenticator := auth.JWT{Secret: secret, Issuer: "haioo", Audience: "cart"}
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":   "42",
+			"iss":   "haioo",
+			"aud":   "cart",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "cart:read cart:write",
+		}
+	}
+
+	t.Run("JWT Valid", func(t *testing.T) {
+		p, err := authenticator.Authenticate(bearer(token(t, jwt.SigningMethodHS256, valid())))
+
+		assert.NoError(t, err)
+		assert.Equal(t, auth.Principal{Kind: auth.KindUser, Subject: "42", Scopes: []string{auth.ScopeCartRead, auth.ScopeCartWrite}}, p)
+	})
+
+	t.Run("JWT With Role", func(t *testing.T) {
+		claims := valid()
+		claims["role"] = "support"
+
+		p, err := authenticator.Authenticate(bearer(token(t, jwt.SigningMethodHS256, claims)))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "support", p.Role)
+	})
+
+	t.Run("JWT Expired", func(t *testing.T) {
+		claims := valid()
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+
+		_, err := authenticator.Authenticate(bearer(token(t, jwt.SigningMethodHS256, claims)))
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("JWT Without Expiry", func(t *testing.T) {
+		claims := valid()
+		delete(claims, "exp")
+
+		_, err := authenticator.Authenticate(bearer(token(t, jwt.SigningMethodHS256, claims)))
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("JWT Wrong Issuer", func(t *testing.T) {
+		claims := valid()
+		claims["iss"] = "elsewhere"
+
+		_, err := authenticator.Authenticate(bearer(token(t, jwt.SigningMethodHS256, claims)))
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("JWT Wrong Audience", func(t *testing.T) {
+		claims := valid()
+		claims["aud"] = "billing"
+
+		_, err := authenticator.Authenticate(bearer(token(t, jwt.SigningMethodHS256, claims)))
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("JWT Other Algorithm", func(t *testing.T) {
+		_, err := authenticator.Authenticate(bearer(token(t, jwt.SigningMethodHS512, valid())))
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("JWT Without Subject", func(t *testing.T) {
+		claims := valid()
+		delete(claims, "sub")
+
+		_, err := authenticator.Authenticate(bearer(token(t, jwt.SigningMethodHS256, claims)))
+
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+
+	t.Run("JWT Ignores API Keys", func(t *testing.T) {
+		_, err := authenticator.Authenticate(bearer("hk_3f9c2a7d_secret"))
+
+		assert.Equal(t, auth.ErrNoCredentials, err)
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	principal := auth.Principal{Kind: auth.KindAPIKey, Subject: "3f9c2a7d", Scopes: []string{auth.ScopeCartRead}}
+
+	t.Run("API Key Header", func(t *testing.T) {
+		verifier := new(mocks.APIKeyUseCase)
+		verifier.On("VerifyKey", mock.Anything, "hk_3f9c2a7d_secret").Return(principal, nil)
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/cart/items", nil)
+		r.Header.Set(auth.HeaderAPIKey, "hk_3f9c2a7d_secret")
+
+		p, err := auth.APIKeys{Verifier: verifier}.Authenticate(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, principal, p)
+	})
+
+	t.Run("API Key Bearer", func(t *testing.T) {
+		verifier := new(mocks.APIKeyUseCase)
+		verifier.On("VerifyKey", mock.Anything, "hk_3f9c2a7d_secret").Return(principal, nil)
+
+		_, err := auth.APIKeys{Verifier: verifier}.Authenticate(bearer("hk_3f9c2a7d_secret"))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("API Key Ignores JWTs", func(t *testing.T) {
+		verifier := new(mocks.APIKeyUseCase)
+
+		_, err := auth.APIKeys{Verifier: verifier}.Authenticate(bearer("a.b.c"))
+
+		assert.Equal(t, auth.ErrNoCredentials, err)
+		verifier.AssertNotCalled(t, "VerifyKey", mock.Anything, mock.Anything)
+	})
+}
+
+type authenticatorFunc func(r *http.Request) (auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(r *http.Request) (auth.Principal, error) {
+	return f(r)
+}
+
+func returning(p auth.Principal, err error) auth.Authenticator {
+	return authenticatorFunc(func(*http.Request) (auth.Principal, error) { return p, err })
+}
+
+// newRouter serves GET /v1/cart/items and GET /admin/keys behind the
+// middlewares, echoing the actor of the request.
+func newRouter(rules auth.Rules, authenticators ...auth.Authenticator) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(auth.Middleware(authenticators...), auth.Require(rules, "/v1"))
+
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(requestinfo.From(r.Context()).Actor))
+	}
+
+	router.HandleFunc("/v1/cart/items", echo).Methods(http.MethodGet)
+	router.HandleFunc("/cart/items", echo).Methods(http.MethodGet)
+	router.HandleFunc("/admin/keys", echo).Methods(http.MethodGet)
+
+	return router
+}
+
+func get(router http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	user := auth.Principal{Kind: auth.KindUser, Subject: "42", Scopes: []string{auth.ScopeCartRead}}
+
+	t.Run("Middleware Stores Principal", func(t *testing.T) {
+		w := get(newRouter(nil, returning(auth.Principal{}, auth.ErrNoCredentials), returning(user, nil)), "/v1/cart/items")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user:42", w.Body.String())
+	})
+
+	t.Run("Middleware Anonymous", func(t *testing.T) {
+		w := get(newRouter(nil, returning(auth.Principal{}, auth.ErrNoCredentials)), "/v1/cart/items")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Middleware Invalid Credentials", func(t *testing.T) {
+		w := get(newRouter(nil, returning(auth.Principal{}, auth.ErrInvalidCredentials), returning(user, nil)), "/v1/cart/items")
+
+		var rb response.ResponseImpl
+		json.NewDecoder(w.Body).Decode(&rb)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, response.StatusUnauthorized, rb.Status)
+		assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("Middleware Authenticator Failure", func(t *testing.T) {
+		w := get(newRouter(nil, returning(auth.Principal{}, context.DeadlineExceeded)), "/v1/cart/items")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestRequire(t *testing.T) {
+	rules := auth.Rules{
+		"GET /v1/cart/items": {Scope: auth.ScopeCartRead, Anonymous: true},
+		"GET /admin/keys":    {Scope: auth.ScopeKeysAdmin},
+	}
+	reader := returning(auth.Principal{Kind: auth.KindAPIKey, Subject: "3f9c2a7d", Scopes: []string{auth.ScopeCartRead}}, nil)
+
+	t.Run("Require Lets Anonymous Through", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(newRouter(rules), "/v1/cart/items").Code)
+	})
+
+	t.Run("Require Refuses Anonymous", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get(newRouter(rules), "/admin/keys").Code)
+	})
+
+	t.Run("Require Scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(newRouter(rules, reader), "/v1/cart/items").Code)
+	})
+
+	t.Run("Require Missing Scope", func(t *testing.T) {
+		w := get(newRouter(rules, reader), "/admin/keys")
+
+		var rb response.ResponseImpl
+		json.NewDecoder(w.Body).Decode(&rb)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, []interface{}{auth.ScopeKeysAdmin}, rb.Data)
+	})
+
+	t.Run("Require Unversioned Path", func(t *testing.T) {
+		denied := returning(auth.Principal{Kind: auth.KindUser, Subject: "42"}, nil)
+
+		assert.Equal(t, http.StatusForbidden, get(newRouter(rules, denied), "/cart/items").Code)
+	})
+}
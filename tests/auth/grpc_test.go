@@ -0,0 +1,104 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/requestinfo"
+	"github.com/Risuii/internal/cart"
+	cartpb "github.com/Risuii/proto/cart"
+	"github.com/Risuii/tests/mocks"
+)
+
+// call runs the interceptor for method with md as incoming metadata and
+// returns the actor the handler saw.
+func call(interceptor grpc.UnaryServerInterceptor, method string, md metadata.MD) (string, error) {
+	ctx := requestinfo.With(metadata.NewIncomingContext(context.TODO(), md), requestinfo.Info{Actor: requestinfo.ActorAnonymous})
+
+	res, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return requestinfo.From(ctx).Actor, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return res.(string), nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	reader := auth.Principal{Kind: auth.KindAPIKey, Subject: "7", Scopes: []string{auth.ScopeCartRead}}
+
+	t.Run("Interceptor Reads API Key Metadata", func(t *testing.T) {
+		verifier := new(mocks.APIKeyUseCase)
+		verifier.On("VerifyKey", mock.Anything, "hk_3f9c2a7d_secret").Return(reader, nil)
+		interceptor := auth.UnaryServerInterceptor(cart.GRPCRules(true), auth.APIKeys{Verifier: verifier})
+
+		actor, err := call(interceptor, cartpb.CartService_GetItems_FullMethodName, metadata.Pairs("x-api-key", "hk_3f9c2a7d_secret"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "apikey:7", actor)
+	})
+
+	t.Run("Interceptor Reads Authorization Metadata", func(t *testing.T) {
+		var header string
+		authenticator := authenticatorFunc(func(r *http.Request) (auth.Principal, error) {
+			header = r.Header.Get("Authorization")
+			return reader, nil
+		})
+
+		_, err := call(auth.UnaryServerInterceptor(nil, authenticator), cartpb.CartService_GetItems_FullMethodName, metadata.Pairs("authorization", "Bearer a.b.c"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer a.b.c", header)
+	})
+
+	t.Run("Interceptor Lets Anonymous Through", func(t *testing.T) {
+		interceptor := auth.UnaryServerInterceptor(cart.GRPCRules(false), returning(auth.Principal{}, auth.ErrNoCredentials))
+
+		actor, err := call(interceptor, cartpb.CartService_AddItems_FullMethodName, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, requestinfo.ActorAnonymous, actor)
+	})
+
+	t.Run("Interceptor Refuses Anonymous", func(t *testing.T) {
+		interceptor := auth.UnaryServerInterceptor(cart.GRPCRules(true), returning(auth.Principal{}, auth.ErrNoCredentials))
+
+		_, err := call(interceptor, cartpb.CartService_GetItems_FullMethodName, nil)
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Interceptor Invalid Credentials", func(t *testing.T) {
+		interceptor := auth.UnaryServerInterceptor(cart.GRPCRules(false), returning(auth.Principal{}, auth.ErrInvalidCredentials))
+
+		_, err := call(interceptor, cartpb.CartService_GetItems_FullMethodName, metadata.Pairs("x-api-key", "hk_unknown"))
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Interceptor Missing Scope", func(t *testing.T) {
+		interceptor := auth.UnaryServerInterceptor(cart.GRPCRules(true), returning(reader, nil))
+
+		_, err := call(interceptor, cartpb.CartService_AddItems_FullMethodName, metadata.Pairs("x-api-key", "hk_3f9c2a7d_secret"))
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Interceptor Authenticator Failure", func(t *testing.T) {
+		interceptor := auth.UnaryServerInterceptor(cart.GRPCRules(true), returning(auth.Principal{}, context.DeadlineExceeded))
+
+		_, err := call(interceptor, cartpb.CartService_GetItems_FullMethodName, nil)
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
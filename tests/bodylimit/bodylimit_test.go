@@ -0,0 +1,78 @@
+package bodylimit_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/bodylimit"
+	"github.com/Risuii/helpers/response"
+)
+
+// decode answers like the JSON-decoding handlers do.
+var decode = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		_ = bodylimit.DecodeError(err).Write(w, r)
+		return
+	}
+
+	_ = response.Success(response.StatusOK, body).Write(w, r)
+})
+
+func TestHandler(t *testing.T) {
+	handler := bodylimit.Handler(decode, 16)
+
+	status := func(w *httptest.ResponseRecorder) string {
+		var body response.ResponseImpl
+		_ = json.NewDecoder(w.Body).Decode(&body)
+
+		return body.Status
+	}
+
+	t.Run("Within The Limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`)))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Announced Too Large", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":"0123456789abcdef"}`)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, response.StatusEntityTooLarge, status(w))
+	})
+
+	t.Run("Streamed Too Large", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", io.MultiReader(strings.NewReader(`{"a":"0123456789abcdef"}`)))
+		r.ContentLength = -1
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, response.StatusEntityTooLarge, status(w))
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{`)))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestDecodeError(t *testing.T) {
+	wrapped := &http.MaxBytesError{Limit: 1}
+
+	assert.Equal(t, response.StatusEntityTooLarge, bodylimit.DecodeError(errors.Join(errors.New("decode"), wrapped)).(*response.ResponseImpl).Status)
+	assert.Equal(t, response.StatusUnprocessableEntity, bodylimit.DecodeError(io.ErrUnexpectedEOF).(*response.ResponseImpl).Status)
+}
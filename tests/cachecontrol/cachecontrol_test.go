@@ -0,0 +1,90 @@
+package cachecontrol_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/cachecontrol"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		policies, err := cachecontrol.Parse([]string{
+			"GET /v1/cart/items=private no-cache",
+			" get /docs = public  max-age=300 ",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, cachecontrol.Policies{
+			"GET /v1/cart/items": "private, no-cache",
+			"GET /docs":          "public, max-age=300",
+		}, policies)
+	})
+
+	for _, entry := range []string{"GET /docs", "/docs=no-store", "GET docs=no-store", "GET /docs="} {
+		t.Run(entry, func(t *testing.T) {
+			_, err := cachecontrol.Parse([]string{entry})
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	policies := cachecontrol.Policies{"GET /v1/webhooks/{id}": "private, no-cache"}
+
+	handler := func(cacheControl string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if cacheControl != "" {
+				w.Header().Set("Cache-Control", cacheControl)
+			}
+		}
+	}
+
+	newRouter := func(h http.HandlerFunc) *mux.Router {
+		router := mux.NewRouter()
+		router.Use(cachecontrol.Middleware(policies, ""))
+		router.HandleFunc("/v1/webhooks/{id:[0-9]+}", h).Methods(http.MethodGet, http.MethodHead, http.MethodDelete)
+		router.HandleFunc("/v1/webhooks", h).Methods(http.MethodGet)
+
+		return router
+	}
+
+	tests := []struct {
+		name   string
+		router *mux.Router
+		method string
+		path   string
+		want   string
+	}{
+		{name: "Matched Route", router: newRouter(handler("")), method: http.MethodGet, path: "/v1/webhooks/1", want: "private, no-cache"},
+		{name: "HEAD Uses The GET Policy", router: newRouter(handler("")), method: http.MethodHead, path: "/v1/webhooks/1", want: "private, no-cache"},
+		{name: "Other Methods Have None", router: newRouter(handler("")), method: http.MethodDelete, path: "/v1/webhooks/1", want: ""},
+		{name: "Unlisted Route", router: newRouter(handler("")), method: http.MethodGet, path: "/v1/webhooks", want: ""},
+		{name: "Handler Wins", router: newRouter(handler("no-store")), method: http.MethodGet, path: "/v1/webhooks/1", want: "no-store"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			test.router.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+			assert.Equal(t, test.want, w.Header().Get("Cache-Control"))
+		})
+	}
+
+	t.Run("Prefix Is Put In Front Of The Template", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.Use(cachecontrol.Middleware(policies, "/v1"))
+		router.HandleFunc("/webhooks/{id}", handler("")).Methods(http.MethodGet)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/1", nil))
+
+		assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+	})
+}
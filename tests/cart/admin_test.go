@@ -0,0 +1,137 @@
+package cart_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/cart"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/filter"
+	"github.com/Risuii/models/product"
+	"github.com/Risuii/tests/mocks"
+)
+
+// ofCart matches a context acting on the cart of owner.
+func ofCart(owner string) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		return rbac.Cart(ctx) == owner
+	})
+}
+
+func TestAdminHandler_ListCarts(t *testing.T) {
+	t.Run("List Carts Reads Query", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, cartsummary.List{})
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("ListCarts", mock.Anything, cartsummary.Filter{Q: "kopi", Page: 2, PageSize: 5}).Return(resp)
+
+		adminHandler := cart.CartAdminHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/admin/carts?q=kopi&page=2&pageSize=5", nil)
+		recorder := httptest.NewRecorder()
+
+		http.HandlerFunc(adminHandler.ListCarts).ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("List Carts Invalid Page", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		adminHandler := cart.CartAdminHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/admin/carts?page=x", nil)
+		recorder := httptest.NewRecorder()
+
+		http.HandlerFunc(adminHandler.ListCarts).ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		cartUseCase.AssertExpectations(t)
+	})
+}
+
+func TestAdminHandler_Cart(t *testing.T) {
+	t.Run("Get Cart Of Owner", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, []product.Product{{ID: 1, KodeProduk: "A"}})
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("GetItems", ofCart("user:42"), filter.Filter{}).Return(resp)
+
+		adminHandler := cart.CartAdminHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/admin/carts/user:42", nil)
+		r = mux.SetURLVars(r, map[string]string{"owner": "user:42"})
+		recorder := httptest.NewRecorder()
+
+		http.HandlerFunc(adminHandler.GetCart).ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"kodeProduk":"A"`)
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Batch Items Of Owner", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, []batch.Result{})
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("BatchItems", ofCart("user:42"), mock.AnythingOfType("batch.Batch"), `"abc"`).Return(resp)
+
+		adminHandler := cart.CartAdminHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		body := `{"operations":[{"op":"set","nama":"a","kodeProduk":"A","kuantitas":2}]}`
+		r := httptest.NewRequest(http.MethodPost, "/admin/carts/user:42/items:batch", strings.NewReader(body))
+		r.Header.Set("If-Match", `"abc"`)
+		r = mux.SetURLVars(r, map[string]string{"owner": "user:42"})
+		recorder := httptest.NewRecorder()
+
+		http.HandlerFunc(adminHandler.BatchItems).ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Expire Cart Of Owner", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, []product.Product{{ID: 1}})
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("Expire", ofCart("user:42")).Return(resp)
+
+		adminHandler := cart.CartAdminHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/admin/carts/user:42/expire", nil)
+		r = mux.SetURLVars(r, map[string]string{"owner": "user:42"})
+		recorder := httptest.NewRecorder()
+
+		http.HandlerFunc(adminHandler.Expire).ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		cartUseCase.AssertExpectations(t)
+	})
+}
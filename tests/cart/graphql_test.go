@@ -0,0 +1,307 @@
+package cart_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/cart"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/filter"
+	"github.com/Risuii/models/product"
+	"github.com/Risuii/tests/mocks"
+)
+
+type graphQLResult struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Path       []interface{}          `json:"path"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newGraphQLRouter(t *testing.T, usecase cart.CartUseCase) *mux.Router {
+	router := mux.NewRouter()
+	if err := cart.NewCartGraphQLHandler(router, usecase); err != nil {
+		t.Fatal(err)
+	}
+
+	return router
+}
+
+func postGraphQL(t *testing.T, usecase cart.CartUseCase, query string, variables map[string]interface{}) (*httptest.ResponseRecorder, graphQLResult) {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	newGraphQLRouter(t, usecase).ServeHTTP(w, req)
+
+	var result graphQLResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	return w, result
+}
+
+func TestGraphQL_Cart(t *testing.T) {
+	items := []product.Product{
+		{ID: 1, Nama: "a", KodeProduk: "A", Kuantitas: 2, Version: 1},
+		{ID: 2, Nama: "b", KodeProduk: "B", Kuantitas: 3, Version: 1},
+	}
+
+	t.Run("Cart With Lines And Totals", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("GetItems", mock.Anything, filter.Filter{}).
+			Return(response.Success(response.StatusOK, items).SetHeader("ETag", `"abc"`))
+
+		w, result := postGraphQL(t, cartUseCase, `{ cart { etag lineCount totalKuantitas lines { id kuantitas product { kodeProduk nama } } } }`, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, result.Errors)
+
+		data := result.Data["cart"].(map[string]interface{})
+		assert.Equal(t, `"abc"`, data["etag"])
+		assert.Equal(t, float64(2), data["lineCount"])
+		assert.Equal(t, float64(5), data["totalKuantitas"])
+
+		lines := data["lines"].([]interface{})
+		assert.Len(t, lines, 2)
+		assert.Equal(t, "1", lines[0].(map[string]interface{})["id"])
+		assert.Equal(t, map[string]interface{}{"kodeProduk": "B", "nama": "b"}, lines[1].(map[string]interface{})["product"])
+
+		// the lines already carry their products
+		cartUseCase.AssertNotCalled(t, "FindItems", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Cart Empty", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("GetItems", mock.Anything, filter.Filter{}).
+			Return(response.Error(response.StatusNotFound, exception.ErrNotFound))
+
+		_, result := postGraphQL(t, cartUseCase, `{ cart { lineCount lines { id } } }`, nil)
+
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, map[string]interface{}{"lineCount": float64(0), "lines": []interface{}{}}, result.Data["cart"])
+	})
+
+	t.Run("Cart Error Nulls Data", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("GetItems", mock.Anything, filter.Filter{}).
+			Return(response.Error(response.StatusInternalServerError, exception.ErrInternalServer))
+
+		w, result := postGraphQL(t, cartUseCase, `{ cart { lineCount } }`, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, result.Data)
+		assert.Len(t, result.Errors, 1)
+		assert.Equal(t, response.StatusInternalServerError, result.Errors[0].Extensions["code"])
+	})
+
+	t.Run("Products Are Batched", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		// the fields resolve in parallel, so the keys come in any order
+		cartUseCase.On("FindItems", mock.Anything, mock.MatchedBy(func(kodeProduks []string) bool {
+			return assert.ElementsMatch(new(testing.T), []string{"A", "B", "C"}, kodeProduks)
+		})).Return(response.Success(response.StatusOK, items)).Once()
+
+		_, result := postGraphQL(t, cartUseCase, `{
+			a: product(kodeProduk: "A") { nama }
+			b: product(kodeProduk: "B") { nama }
+			c: product(kodeProduk: "C") { nama }
+			again: product(kodeProduk: "A") { kodeProduk }
+		}`, nil)
+
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, map[string]interface{}{"nama": "a"}, result.Data["a"])
+		assert.Equal(t, map[string]interface{}{"nama": "b"}, result.Data["b"])
+		assert.Nil(t, result.Data["c"])
+		assert.Equal(t, map[string]interface{}{"kodeProduk": "A"}, result.Data["again"])
+
+		cartUseCase.AssertNumberOfCalls(t, "FindItems", 1)
+	})
+}
+
+func TestGraphQL_Mutations(t *testing.T) {
+	t.Run("Add Item", func(t *testing.T) {
+		item := product.Product{ID: 1, Nama: "a", KodeProduk: "A", Kuantitas: 2, Version: 1}
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("AddItems", mock.Anything, product.Product{Nama: "a", KodeProduk: "A", Kuantitas: 2}, `"abc"`).
+			Return(response.Success(response.StatusCreated, item))
+		cartUseCase.On("FindItems", mock.Anything, []string{"A"}).
+			Return(response.Success(response.StatusOK, []product.Product{item}))
+
+		_, result := postGraphQL(t, cartUseCase,
+			`mutation Add($kode: String!, $qty: Int!) { addItem(nama: "a", kodeProduk: $kode, kuantitas: $qty, ifMatch: "\"abc\"") { kuantitas product { nama } } }`,
+			map[string]interface{}{"kode": "A", "qty": 2})
+
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, map[string]interface{}{"kuantitas": float64(2), "product": map[string]interface{}{"nama": "a"}}, result.Data["addItem"])
+	})
+
+	t.Run("Add Item Precondition Failed", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("AddItems", mock.Anything, mock.Anything, "stale").
+			Return(response.Error(response.StatusPreconditionFailed, exception.ErrPreconditionFailed))
+
+		_, result := postGraphQL(t, cartUseCase, `mutation { addItem(nama: "a", kodeProduk: "A", kuantitas: 1, ifMatch: "stale") { id } }`, nil)
+
+		assert.Nil(t, result.Data)
+		assert.Len(t, result.Errors, 1)
+		assert.Equal(t, []interface{}{"addItem"}, result.Errors[0].Path)
+		assert.Equal(t, response.StatusPreconditionFailed, result.Errors[0].Extensions["code"])
+	})
+
+	t.Run("Set Quantity", func(t *testing.T) {
+		item := product.Product{ID: 1, Nama: "a", KodeProduk: "A", Kuantitas: 5}
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("BatchItems", mock.Anything, batch.Batch{
+			Mode:       batch.ModeAtomic,
+			Operations: []batch.Operation{{Op: batch.OpSet, KodeProduk: "A", Kuantitas: 5}},
+		}, "").Return(response.Success(response.StatusOK, []batch.Result{{Op: batch.OpSet, KodeProduk: "A", Status: response.StatusOK, Item: &item}}))
+
+		_, result := postGraphQL(t, cartUseCase, `mutation { setQuantity(kodeProduk: "A", kuantitas: 5) { kuantitas } }`, nil)
+
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, map[string]interface{}{"kuantitas": float64(5)}, result.Data["setQuantity"])
+	})
+
+	t.Run("Set Quantity Not Found", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("BatchItems", mock.Anything, mock.Anything, "").
+			Return(response.ErrorWithData(response.StatusBadRequest, exception.ErrBatchFailed, []batch.Result{{Op: batch.OpSet, KodeProduk: "A", Status: response.StatusNotFound, Error: exception.ErrNotFound.Error()}}))
+
+		_, result := postGraphQL(t, cartUseCase, `mutation { setQuantity(kodeProduk: "A", kuantitas: 5) { kuantitas } }`, nil)
+
+		assert.Equal(t, map[string]interface{}{"setQuantity": nil}, result.Data)
+		assert.Equal(t, response.StatusNotFound, result.Errors[0].Extensions["code"])
+	})
+
+	t.Run("Remove Item", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("DeleteItems", mock.Anything, "A", "").Return(response.Success(response.StatusOK, nil))
+
+		_, result := postGraphQL(t, cartUseCase, `mutation { removeItem(kodeProduk: "A") }`, nil)
+
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, true, result.Data["removeItem"])
+	})
+
+	t.Run("Mutations Run In Order", func(t *testing.T) {
+		var calls []string
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("DeleteItems", mock.Anything, mock.Anything, "").
+			Run(func(args mock.Arguments) { calls = append(calls, args.String(1)) }).
+			Return(response.Success(response.StatusOK, nil))
+
+		postGraphQL(t, cartUseCase, `mutation { b: removeItem(kodeProduk: "B") a: removeItem(kodeProduk: "A") }`, nil)
+
+		assert.Equal(t, []string{"B", "A"}, calls)
+	})
+}
+
+func TestGraphQL_Request(t *testing.T) {
+	t.Run("Invalid Query Is Bad Request", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		w, result := postGraphQL(t, cartUseCase, `{ cart { price } }`, nil)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Nil(t, result.Data)
+		assert.Equal(t, `Cannot query field "price" on type "Cart".`, result.Errors[0].Message)
+	})
+
+	t.Run("Missing Variable Is Bad Request", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		w, _ := postGraphQL(t, cartUseCase, `mutation($kode: String!) { removeItem(kodeProduk: $kode) }`, nil)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		cartUseCase.AssertNotCalled(t, "DeleteItems", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query Over GET", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("GetItems", mock.Anything, filter.Filter{}).
+			Return(response.Error(response.StatusNotFound, exception.ErrNotFound))
+
+		req := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`{ cart { lineCount } }`), nil)
+		w := httptest.NewRecorder()
+		newGraphQLRouter(t, cartUseCase).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":{"cart":{"lineCount":0}}}`, w.Body.String())
+	})
+
+	t.Run("Mutation Over GET Not Allowed", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		req := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { removeItem(kodeProduk: "A") }`), nil)
+		w := httptest.NewRecorder()
+		newGraphQLRouter(t, cartUseCase).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+		cartUseCase.AssertNotCalled(t, "DeleteItems", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Mutation Without Write Scope", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"mutation { removeItem(kodeProduk: \"A\") }"}`))
+		req = req.WithContext(auth.With(req.Context(), auth.Principal{Kind: auth.KindAPIKey, Subject: "3f9c2a7d", Scopes: []string{auth.ScopeCartRead}}))
+		w := httptest.NewRecorder()
+		newGraphQLRouter(t, cartUseCase).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		cartUseCase.AssertNotCalled(t, "DeleteItems", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Body Too Large", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ cart { lineCount } }"}`))
+		w := httptest.NewRecorder()
+		req.Body = http.MaxBytesReader(w, req.Body, 8)
+		newGraphQLRouter(t, cartUseCase).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.JSONEq(t, `{"errors":[{"message":"Body is too large."}]}`, w.Body.String())
+		cartUseCase.AssertNotCalled(t, "GetItems", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Introspection", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		w, result := postGraphQL(t, cartUseCase, `{ __type(name: "CartLine") { fields { name } } }`, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, result.Errors)
+		assert.Contains(t, result.Data["__type"].(map[string]interface{})["fields"], map[string]interface{}{"name": "kuantitas"})
+	})
+
+	t.Run("Schema", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/graphql/schema", nil)
+		w := httptest.NewRecorder()
+		newGraphQLRouter(t, new(mocks.CartUseCase)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "type Query {")
+		assert.Contains(t, w.Body.String(), "addItem(nama: String!, kodeProduk: String!, kuantitas: Int!, ifMatch: String): CartLine!")
+	})
+}
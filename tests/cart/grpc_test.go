@@ -0,0 +1,173 @@
+package cart_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/cart"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/filter"
+	"github.com/Risuii/models/product"
+	cartpb "github.com/Risuii/proto/cart"
+	"github.com/Risuii/tests/mocks"
+)
+
+// newGRPCClient serves usecase over an in-memory connection.
+func newGRPCClient(t *testing.T, usecase cart.CartUseCase) cartpb.CartServiceClient {
+	listener := bufconn.Listen(1 << 20)
+
+	server := grpc.NewServer()
+	cart.NewCartGRPCServer(server, usecase)
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return cartpb.NewCartServiceClient(conn)
+}
+
+func TestGRPC_AddItems(t *testing.T) {
+	t.Run("Add Items Created", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("AddItems", mock.Anything, product.Product{Nama: "a", KodeProduk: "A", Kuantitas: 2}, "").
+			Return(response.Success(response.StatusCreated, product.Product{ID: 1, Nama: "a", KodeProduk: "A", Kuantitas: 2, Version: 1}))
+
+		resp, err := newGRPCClient(t, cartUseCase).AddItems(context.TODO(), &cartpb.AddItemsRequest{Nama: "a", KodeProduk: "A", Kuantitas: 2})
+
+		assert.NoError(t, err)
+		assert.True(t, resp.GetCreated())
+		assert.Equal(t, int64(1), resp.GetItem().GetId())
+		assert.Nil(t, resp.GetItem().GetUpdateAt())
+	})
+
+	t.Run("Add Items Nama Required", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		_, err := newGRPCClient(t, cartUseCase).AddItems(context.TODO(), &cartpb.AddItemsRequest{KodeProduk: "A"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		cartUseCase.AssertNotCalled(t, "AddItems", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Add Items Version Conflict", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("AddItems", mock.Anything, mock.Anything, "").
+			Return(response.Error(response.StatusConflicted, exception.ErrVersionConflict))
+
+		_, err := newGRPCClient(t, cartUseCase).AddItems(context.TODO(), &cartpb.AddItemsRequest{Nama: "a", KodeProduk: "A", Version: 3})
+
+		assert.Equal(t, codes.Aborted, status.Code(err))
+	})
+}
+
+func TestGRPC_GetItems(t *testing.T) {
+	items := []product.Product{{ID: 1, KodeProduk: "A"}, {ID: 2, KodeProduk: "B"}, {ID: 3, KodeProduk: "C"}}
+
+	t.Run("Get Items Second Page", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("GetItems", mock.Anything, filter.Filter{Nama: "x"}).
+			Return(response.Success(response.StatusOK, items).SetHeader("ETag", `"abc"`))
+
+		var header metadata.MD
+		resp, err := newGRPCClient(t, cartUseCase).GetItems(context.TODO(), &cartpb.GetItemsRequest{Nama: "x", Page: 2, PageSize: 2}, grpc.Header(&header))
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.GetItems(), 1)
+		assert.Equal(t, "C", resp.GetItems()[0].GetKodeProduk())
+		assert.Equal(t, int64(3), resp.GetTotal())
+		assert.Equal(t, `"abc"`, resp.GetEtag())
+		assert.Equal(t, []string{`"abc"`}, header.Get("etag"))
+	})
+
+	t.Run("Get Items Empty Cart", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("GetItems", mock.Anything, filter.Filter{}).
+			Return(response.Error(response.StatusNotFound, exception.ErrNotFound))
+
+		resp, err := newGRPCClient(t, cartUseCase).GetItems(context.TODO(), &cartpb.GetItemsRequest{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, resp.GetItems())
+		assert.Equal(t, int32(cart.DefaultPageSize), resp.GetPageSize())
+	})
+
+	t.Run("Get Items Page Size Too Large", func(t *testing.T) {
+		_, err := newGRPCClient(t, new(mocks.CartUseCase)).GetItems(context.TODO(), &cartpb.GetItemsRequest{PageSize: cart.MaxPageSize + 1})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestGRPC_DeleteItems(t *testing.T) {
+	t.Run("Delete Items Not Found", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("DeleteItems", mock.Anything, "A", `"abc"`).
+			Return(response.Error(response.StatusNotFound, exception.ErrNotFound))
+
+		_, err := newGRPCClient(t, cartUseCase).DeleteItems(context.TODO(), &cartpb.DeleteItemsRequest{KodeProduk: "A", IfMatch: `"abc"`})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("Delete Items Precondition Failed", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("DeleteItems", mock.Anything, "A", `"old"`).
+			Return(response.Error(response.StatusPreconditionFailed, exception.ErrPreconditionFailed))
+
+		_, err := newGRPCClient(t, cartUseCase).DeleteItems(context.TODO(), &cartpb.DeleteItemsRequest{KodeProduk: "A", IfMatch: `"old"`})
+
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+func TestGRPC_UpdateQuantity(t *testing.T) {
+	set := batch.Batch{
+		Mode:       batch.ModeAtomic,
+		Operations: []batch.Operation{{Op: batch.OpSet, KodeProduk: "A", Kuantitas: 5}},
+	}
+
+	t.Run("Update Quantity Success", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("BatchItems", mock.Anything, set, "").Return(response.Success(response.StatusOK, []batch.Result{
+			{Op: batch.OpSet, KodeProduk: "A", Status: response.StatusOK, Item: &product.Product{ID: 1, KodeProduk: "A", Kuantitas: 5}},
+		}))
+
+		resp, err := newGRPCClient(t, cartUseCase).UpdateQuantity(context.TODO(), &cartpb.UpdateQuantityRequest{KodeProduk: "A", Kuantitas: 5})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), resp.GetItem().GetKuantitas())
+	})
+
+	t.Run("Update Quantity Unknown Line", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("BatchItems", mock.Anything, set, "").Return(response.ErrorWithData(response.StatusUnprocessableEntity, exception.ErrBatchFailed, []batch.Result{
+			{Op: batch.OpSet, KodeProduk: "A", Status: response.StatusNotFound, Error: exception.ErrNotFound.Error()},
+		}))
+
+		_, err := newGRPCClient(t, cartUseCase).UpdateQuantity(context.TODO(), &cartpb.UpdateQuantityRequest{KodeProduk: "A", Kuantitas: 5})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
@@ -8,12 +8,15 @@ import (
 	"testing"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/Risuii/helpers/exception"
 	"github.com/Risuii/helpers/response"
 	"github.com/Risuii/internal/cart"
+	v1 "github.com/Risuii/internal/cart/v1"
+	"github.com/Risuii/models/batch"
 	"github.com/Risuii/models/filter"
 	"github.com/Risuii/models/product"
 	"github.com/Risuii/tests/mocks"
@@ -38,7 +41,7 @@ func TestHandler_AddItems(t *testing.T) {
 
 		validate := validator.New()
 		cartUseCase := new(mocks.CartUseCase)
-		cartUseCase.On("AddItems", mock.Anything, mock.AnythingOfType("product.Product")).Return(resp)
+		cartUseCase.On("AddItems", mock.Anything, mock.AnythingOfType("product.Product"), mock.AnythingOfType("string")).Return(resp)
 
 		cartHandler := cart.CartHandler{
 			Validate: validate,
@@ -105,7 +108,7 @@ func TestHandler_AddItems(t *testing.T) {
 
 		validate := validator.New()
 		cartUseCase := new(mocks.CartUseCase)
-		cartUseCase.On("AddItems", mock.Anything, mock.AnythingOfType("product.Product")).Return(resp)
+		cartUseCase.On("AddItems", mock.Anything, mock.AnythingOfType("product.Product"), mock.AnythingOfType("string")).Return(resp)
 
 		cartHandler := cart.CartHandler{
 			Validate: validate,
@@ -127,6 +130,32 @@ func TestHandler_AddItems(t *testing.T) {
 		assert.Equal(t, response.StatusBadRequest, rb.Status)
 		assert.Nil(t, rb.Data)
 	})
+
+	t.Run("Add Items Error Entity Too Large", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		cartHandler := cart.CartHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader([]byte(`{"nama":"test","kodeProduk":"test-01","kuantitas":1}`)))
+		recorder := httptest.NewRecorder()
+		r.Body = http.MaxBytesReader(recorder, r.Body, 16)
+
+		handler := http.HandlerFunc(cartHandler.AddItems)
+		handler.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+		assert.Equal(t, response.StatusEntityTooLarge, rb.Status)
+		cartUseCase.AssertNotCalled(t, "AddItems", mock.Anything, mock.Anything, mock.Anything)
+	})
 }
 
 func TestHandler_GetItems(t *testing.T) {
@@ -224,10 +253,11 @@ func TestHandler_DeleteItems(t *testing.T) {
 		}
 
 		cartUseCase := new(mocks.CartUseCase)
-		cartUseCase.On("DeleteItems", mock.Anything, mock.AnythingOfType("string")).Return(resp)
+		cartUseCase.On("DeleteItems", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(resp)
 
 		cartHandler := cart.CartHandler{
-			UseCase: cartUseCase,
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
 		}
 
 		r := httptest.NewRequest(http.MethodDelete, "/just/for/testing", bytes.NewReader(newReq))
@@ -251,7 +281,8 @@ func TestHandler_DeleteItems(t *testing.T) {
 		cartUseCase := new(mocks.CartUseCase)
 
 		cartHandler := cart.CartHandler{
-			UseCase: cartUseCase,
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
 		}
 
 		r := httptest.NewRequest(http.MethodDelete, "/just/for/testing", nil)
@@ -269,4 +300,248 @@ func TestHandler_DeleteItems(t *testing.T) {
 		assert.Equal(t, response.StatusUnprocessableEntity, rb.Status)
 		assert.Nil(t, rb.Data)
 	})
+
+	t.Run("Delete Items Error Bad Request", func(t *testing.T) {
+		cartUseCase := new(mocks.CartUseCase)
+
+		cartHandler := cart.CartHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodDelete, "/just/for/testing", bytes.NewReader([]byte(`{"kodeProduk":""}`)))
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(cartHandler.DeleteItems)
+		handler.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, response.StatusBadRequest, rb.Status)
+		cartUseCase.AssertNotCalled(t, "DeleteItems", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandler_IfMatch(t *testing.T) {
+	t.Run("Delete Items Passes If-Match", func(t *testing.T) {
+		mockData := product.Product{
+			KodeProduk: "Test",
+		}
+
+		resp := response.Error(response.StatusPreconditionFailed, exception.ErrPreconditionFailed)
+
+		newReq, err := json.Marshal(mockData)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("DeleteItems", mock.Anything, "Test", `"abc"`).Return(resp)
+
+		cartHandler := cart.CartHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodDelete, "/just/for/testing", bytes.NewReader(newReq))
+		r.Header.Set("If-Match", `"abc"`)
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(cartHandler.DeleteItems)
+		handler.ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+
+		cartUseCase.AssertExpectations(t)
+	})
+}
+
+func TestHandler_BatchItems(t *testing.T) {
+	t.Run("Batch Items Success", func(t *testing.T) {
+		data := batch.Batch{
+			Operations: []batch.Operation{
+				{Op: batch.OpAdd, KodeProduk: "test-01", Nama: "test", Kuantitas: 1},
+				{Op: batch.OpRemove, KodeProduk: "test-02"},
+			},
+		}
+
+		resp := response.Success(response.StatusOK, []batch.Result{})
+
+		newReq, err := json.Marshal(data)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		validate := validator.New()
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("BatchItems", mock.Anything, data, "").Return(resp)
+
+		cartHandler := cart.CartHandler{
+			Validate: validate,
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(newReq))
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(cartHandler.BatchItems)
+		handler.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, response.StatusOK, rb.Status)
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Batch Items Error Bad Request", func(t *testing.T) {
+		data := batch.Batch{
+			Operations: []batch.Operation{
+				{Op: "replace", KodeProduk: "test-01"},
+			},
+		}
+
+		newReq, err := json.Marshal(data)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		validate := validator.New()
+		cartUseCase := new(mocks.CartUseCase)
+
+		cartHandler := cart.CartHandler{
+			Validate: validate,
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader(newReq))
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(cartHandler.BatchItems)
+		handler.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, response.StatusBadRequest, rb.Status)
+		cartUseCase.AssertNotCalled(t, "BatchItems")
+	})
+}
+
+func TestHandler_RestoreItems(t *testing.T) {
+	t.Run("Restore Items Success", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, product.Product{ID: 1, KodeProduk: "test-01"})
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("RestoreItems", mock.Anything, "test-01", "").Return(resp)
+
+		cartHandler := cart.CartHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
+		r = mux.SetURLVars(r, map[string]string{"kodeProduk": "test-01"})
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(cartHandler.RestoreItems)
+		handler.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, response.StatusOK, rb.Status)
+		cartUseCase.AssertExpectations(t)
+	})
+}
+
+func TestHandler_Checkout(t *testing.T) {
+	t.Run("Checkout Passes If-Match", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, nil)
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("Checkout", mock.Anything, `"abc"`).Return(resp)
+
+		cartHandler := cart.CartHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/just/for/testing", nil)
+		r.Header.Set("If-Match", `"abc"`)
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(cartHandler.Checkout)
+		handler.ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		cartUseCase.AssertExpectations(t)
+	})
+}
+
+func TestHandler_V1(t *testing.T) {
+	t.Run("Add Items Maps The Request", func(t *testing.T) {
+		item := product.Product{ID: 7, Nama: "test", KodeProduk: "test-01", Kuantitas: 2, Version: 3}
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("AddItems", mock.Anything, product.Product{Nama: "test", KodeProduk: "test-01", Kuantitas: 2, Version: 3}, "").
+			Return(response.Success(response.StatusOK, item))
+
+		cartHandler := cart.CartHandler{
+			Validate: validator.New(),
+			UseCase:  cartUseCase,
+		}
+
+		body := `{"id":99,"nama":"test","kodeProduk":"test-01","kuantitas":2,"version":3}`
+		r := httptest.NewRequest(http.MethodPost, "/just/for/testing", bytes.NewReader([]byte(body)))
+		recorder := httptest.NewRecorder()
+
+		http.HandlerFunc(cartHandler.AddItems).ServeHTTP(recorder, r)
+
+		var rb struct {
+			Status string  `json:"status"`
+			Data   v1.Item `json:"data"`
+		}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, response.StatusOK, rb.Status)
+		assert.Equal(t, v1.NewItem(item), rb.Data)
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Batch Results Are Presented", func(t *testing.T) {
+		item := product.Product{ID: 1, KodeProduk: "test-01", Kuantitas: 1}
+		results := []batch.Result{{Index: 0, Op: batch.OpAdd, KodeProduk: "test-01", Status: response.StatusOK, Item: &item}}
+
+		res := v1.Present(response.Success(response.StatusOK, results))
+
+		data := res.(*response.ResponseImpl).Data.([]v1.BatchResult)
+		assert.Equal(t, v1.NewItem(item), *data[0].Item)
+	})
+
+	t.Run("Other Data Is Left As It Is", func(t *testing.T) {
+		res := v1.Present(response.Success(response.StatusOK, "test-01"))
+
+		assert.Equal(t, "test-01", res.(*response.ResponseImpl).Data)
+	})
 }
@@ -8,10 +8,15 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/rbac"
 	"github.com/Risuii/internal/cart"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/catalog"
 	"github.com/Risuii/models/filter"
 	"github.com/Risuii/models/product"
 	"github.com/Risuii/tests/mock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,19 +28,20 @@ var productStruct = product.Product{
 	Kuantitas:  1,
 	CreatedAt:  currentTime,
 	UpdateAt:   currentTime,
+	Version:    1,
 }
 
 func TestAddRepository(t *testing.T) {
 	t.Run("Add Product Success", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
 		query := fmt.Sprintf(`INSERT INTO %s`, constant.TableCart)
 		ctx := context.TODO()
 
-		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, rbac.CartAnonymous).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		ID, err := repo.Add(ctx, productStruct)
 
@@ -45,14 +51,14 @@ func TestAddRepository(t *testing.T) {
 
 	t.Run("Add Product Error", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
 		query := fmt.Sprintf(`INSERT INTO %s`, constant.TableCart)
 		ctx := context.TODO()
 
-		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, rbac.CartAnonymous).WillReturnResult(sqlmock.NewResult(0, 0))
 
 		ID, err := repo.Add(ctx, productStruct)
 
@@ -64,7 +70,7 @@ func TestAddRepository(t *testing.T) {
 func TestUpdateKuantitasRepository(t *testing.T) {
 	t.Run("Update Kuantitas Success", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
@@ -72,7 +78,7 @@ func TestUpdateKuantitasRepository(t *testing.T) {
 
 		ctx := context.TODO()
 
-		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Kuantitas).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Kuantitas, productStruct.UpdateAt).WillReturnResult(sqlmock.NewResult(0, 1))
 
 		err := repo.UpdateKuantitas(ctx, productStruct.ID, productStruct)
 
@@ -81,7 +87,7 @@ func TestUpdateKuantitasRepository(t *testing.T) {
 
 	t.Run("Update Kuantitas Error", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
@@ -89,7 +95,7 @@ func TestUpdateKuantitasRepository(t *testing.T) {
 
 		ctx := context.TODO()
 
-		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Kuantitas).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Kuantitas, productStruct.UpdateAt).WillReturnResult(sqlmock.NewResult(0, 0))
 
 		err := repo.UpdateKuantitas(ctx, productStruct.ID, productStruct)
 
@@ -100,16 +106,16 @@ func TestUpdateKuantitasRepository(t *testing.T) {
 func TestFindByKodeProdukRepository(t *testing.T) {
 	t.Run("Find By Kode Produk Success", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE kodeProduk = ?`, constant.TableCart)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt)
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kodeProduk = ?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version)
 
 		ctx := context.TODO()
 
-		mock.ExpectPrepare(query).ExpectQuery().WithArgs(productStruct.KodeProduk).WillReturnRows(rows)
+		mock.ExpectPrepare(query).ExpectQuery().WithArgs(productStruct.KodeProduk, rbac.CartAnonymous).WillReturnRows(rows)
 
 		productStruct, err := repo.FindByKodeProduk(ctx, productStruct.KodeProduk)
 
@@ -119,21 +125,41 @@ func TestFindByKodeProdukRepository(t *testing.T) {
 
 	t.Run("Find By Kode Produk Error", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE kodeProduk = ?`, constant.TableCart)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"})
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kodeProduk = ?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"})
 
 		ctx := context.TODO()
 
-		mock.ExpectPrepare(query).ExpectQuery().WithArgs(productStruct.KodeProduk).WillReturnRows(rows)
+		mock.ExpectPrepare(query).ExpectQuery().WithArgs(productStruct.KodeProduk, rbac.CartAnonymous).WillReturnRows(rows)
 
 		productStruct, err := repo.FindByKodeProduk(ctx, productStruct.KodeProduk)
 
 		assert.Empty(t, productStruct)
-		assert.Error(t, err)
+		assert.Equal(t, exception.ErrNotFound, err)
+	})
+
+	t.Run("Find By Kode Produk Deadlock", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kodeProduk = ?`, constant.TableCart)
+
+		mock.ExpectPrepare(query).ExpectQuery().WithArgs(productStruct.KodeProduk, rbac.CartAnonymous).
+			WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+
+		_, err := repo.FindByKodeProduk(context.TODO(), productStruct.KodeProduk)
+
+		// the transaction manager must see the deadlock to retry it
+		var mysqlErr *mysql.MySQLError
+		assert.ErrorAs(t, err, &mysqlErr)
+		assert.ErrorIs(t, err, exception.ErrInternalServer)
+		assert.NotErrorIs(t, err, exception.ErrNotFound)
 	})
 }
 
@@ -141,16 +167,16 @@ func TestFindAllRepository(t *testing.T) {
 
 	t.Run("Get All Items Success", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s`, constant.TableCart)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt)
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version)
 
 		mock.ExpectQuery(query).WillReturnRows(rows)
 
-		productStruct, err := repo.FindAll()
+		productStruct, err := repo.FindAll(context.TODO())
 
 		assert.NotEmpty(t, productStruct)
 		assert.NoError(t, err)
@@ -158,34 +184,50 @@ func TestFindAllRepository(t *testing.T) {
 
 	t.Run("Get All Items Error", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s`, constant.TableCart)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"})
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"})
 
 		mock.ExpectQuery(query).WillReturnRows(rows)
 
-		productStruct, err := repo.FindAll()
+		productStruct, err := repo.FindAll(context.TODO())
 
 		assert.Empty(t, productStruct)
 		assert.NoError(t, err)
 	})
+
+	t.Run("Get All Items Scan Error", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).AddRow("x", productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version)
+
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		_, err := repo.FindAll(context.TODO())
+
+		assert.ErrorIs(t, err, exception.ErrInternalServer)
+	})
 }
 
 func TestDeleteRepository(t *testing.T) {
 	t.Run("Delete Items Success", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, constant.TableCart)
+		query := fmt.Sprintf(`UPDATE %s SET deleted_at = \?, update_at = \?, version = version \+ 1 WHERE id = 1 AND deleted_at IS NULL`, constant.TableCart)
 
 		ctx := context.TODO()
 
-		mock.ExpectPrepare(query).ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectPrepare(query).ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := repo.Delete(ctx, productStruct.ID)
 
@@ -194,15 +236,15 @@ func TestDeleteRepository(t *testing.T) {
 
 	t.Run("Delete Items Error", func(t *testing.T) {
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, constant.TableCart)
+		query := fmt.Sprintf(`UPDATE %s SET deleted_at = \?, update_at = \?, version = version \+ 1 WHERE id = 1 AND deleted_at IS NULL`, constant.TableCart)
 
 		ctx := context.TODO()
 
-		mock.ExpectPrepare(query).ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPrepare(query).ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
 
 		err := repo.Delete(ctx, productStruct.ID)
 
@@ -220,15 +262,15 @@ func TestFindByFilterRepository(t *testing.T) {
 		}
 
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE nama = '%s' AND kuantitas = '%d'`, constant.TableCart, productStruct.Nama, productStruct.Kuantitas)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt)
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE nama = \? AND kuantitas = \? AND deleted_at IS NULL AND owner = \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version)
 		ctx := context.TODO()
 
-		mock.ExpectQuery(query).WillReturnRows(rows)
+		mock.ExpectQuery(query).WithArgs(filter.Nama, filter.Kuantitas, rbac.CartAnonymous).WillReturnRows(rows)
 
 		productStruct, err := repo.FindByFilter(ctx, filter)
 
@@ -244,15 +286,15 @@ func TestFindByFilterRepository(t *testing.T) {
 		}
 
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE nama = '%s' AND kuantitas = '%d'`, constant.TableCart, productStruct.Nama, productStruct.Kuantitas)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"})
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE nama = \? AND kuantitas = \? AND deleted_at IS NULL AND owner = \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"})
 		ctx := context.TODO()
 
-		mock.ExpectQuery(query).WillReturnRows(rows)
+		mock.ExpectQuery(query).WithArgs(filter.Nama, filter.Kuantitas, rbac.CartAnonymous).WillReturnRows(rows)
 
 		productStruct, err := repo.FindByFilter(ctx, filter)
 		fmt.Println("INI DATA", filter)
@@ -269,15 +311,15 @@ func TestFindByFilterRepository(t *testing.T) {
 		}
 
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE nama = '%s'`, constant.TableCart, productStruct.Nama)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt)
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE nama = \? AND deleted_at IS NULL AND owner = \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version)
 		ctx := context.TODO()
 
-		mock.ExpectQuery(query).WillReturnRows(rows)
+		mock.ExpectQuery(query).WithArgs(filter.Nama, rbac.CartAnonymous).WillReturnRows(rows)
 
 		productStruct, err := repo.FindByFilter(ctx, filter)
 
@@ -292,15 +334,15 @@ func TestFindByFilterRepository(t *testing.T) {
 		}
 
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE nama = '%s'`, constant.TableCart, productStruct.Nama)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"})
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE nama = \? AND deleted_at IS NULL AND owner = \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"})
 		ctx := context.TODO()
 
-		mock.ExpectQuery(query).WillReturnRows(rows)
+		mock.ExpectQuery(query).WithArgs(filter.Nama, rbac.CartAnonymous).WillReturnRows(rows)
 
 		productStruct, err := repo.FindByFilter(ctx, filter)
 
@@ -315,15 +357,15 @@ func TestFindByFilterRepository(t *testing.T) {
 		}
 
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE kuantitas = '%d'`, constant.TableCart, productStruct.Kuantitas)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt)
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kuantitas = \? AND deleted_at IS NULL AND owner = \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version)
 		ctx := context.TODO()
 
-		mock.ExpectQuery(query).WillReturnRows(rows)
+		mock.ExpectQuery(query).WithArgs(filter.Kuantitas, rbac.CartAnonymous).WillReturnRows(rows)
 
 		productStruct, err := repo.FindByFilter(ctx, filter)
 
@@ -338,19 +380,415 @@ func TestFindByFilterRepository(t *testing.T) {
 		}
 
 		db, mock := mock.NewMock()
-		repo := cart.NewCartRepositoryImpl(db, constant.TableCart)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
 
 		defer db.Close()
 
-		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at FROM %s WHERE kuantitas = '%d'`, constant.TableCart, productStruct.Kuantitas)
-		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at"})
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kuantitas = \? AND deleted_at IS NULL AND owner = \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"})
 		ctx := context.TODO()
 
-		mock.ExpectQuery(query).WillReturnRows(rows)
+		mock.ExpectQuery(query).WithArgs(filter.Kuantitas, rbac.CartAnonymous).WillReturnRows(rows)
 
 		productStruct, err := repo.FindByFilter(ctx, filter)
 
 		assert.Empty(t, productStruct)
 		assert.Error(t, err)
 	})
+
+	t.Run("Get Item By Filter Binds Nama", func(t *testing.T) {
+		filter := filter.Filter{
+			Nama: "x' OR '1'='1' -- ",
+		}
+
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE nama = \? AND deleted_at IS NULL AND owner = \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"})
+		ctx := rbac.WithCart(context.TODO(), "user:42")
+
+		mock.ExpectQuery(query).WithArgs(filter.Nama, "user:42").WillReturnRows(rows)
+
+		_, err := repo.FindByFilter(ctx, filter)
+
+		assert.Equal(t, exception.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestQueryTimeoutRepository(t *testing.T) {
+	t.Run("Find All Honors Query Timeout", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, 10*time.Millisecond)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"})
+
+		mock.ExpectQuery(query).WillDelayFor(time.Second).WillReturnRows(rows)
+
+		productStruct, err := repo.FindAll(context.TODO())
+
+		assert.Empty(t, productStruct)
+		assert.Error(t, err)
+	})
+
+	t.Run("Find By Kode Produk Honors Cancelled Request", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kodeProduk = ?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"})
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+
+		mock.ExpectPrepare(query).ExpectQuery().WithArgs(productStruct.KodeProduk, rbac.CartAnonymous).WillDelayFor(time.Second).WillReturnRows(rows)
+
+		_, err := repo.FindByKodeProduk(ctx, productStruct.KodeProduk)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestUpdateKuantitasIfVersionRepository(t *testing.T) {
+	t.Run("Update Kuantitas If Version Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`UPDATE %s SET kuantitas = \?, update_at = \?, version = version \+ 1 WHERE id = \? AND version = \?`, constant.TableCart)
+
+		ctx := context.TODO()
+
+		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Kuantitas, productStruct.UpdateAt, productStruct.ID, productStruct.Version).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.UpdateKuantitasIfVersion(ctx, productStruct.ID, productStruct.Version, productStruct)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Update Kuantitas If Version Conflict", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`UPDATE %s SET kuantitas = \?, update_at = \?, version = version \+ 1 WHERE id = \? AND version = \?`, constant.TableCart)
+
+		ctx := context.TODO()
+
+		mock.ExpectPrepare(query).ExpectExec().WithArgs(productStruct.Kuantitas, productStruct.UpdateAt, productStruct.ID, productStruct.Version).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.UpdateKuantitasIfVersion(ctx, productStruct.ID, productStruct.Version, productStruct)
+
+		assert.Equal(t, exception.ErrVersionConflict, err)
+	})
+}
+
+func TestBatchRepository(t *testing.T) {
+	t.Run("Find By Kode Produks Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kodeProduk IN \(\?,\?\)`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).
+			AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version)
+
+		ctx := context.TODO()
+
+		mock.ExpectQuery(query).WithArgs("test", "other", rbac.CartAnonymous).WillReturnRows(rows)
+
+		data, err := repo.FindByKodeProduks(ctx, []string{"test", "other"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []product.Product{productStruct}, data)
+	})
+
+	t.Run("Find By Kode Produks Row Error", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kodeProduk IN \(\?,\?\)`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).
+			AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version).
+			RowError(0, fmt.Errorf("connection reset"))
+
+		mock.ExpectQuery(query).WithArgs("test", "other", rbac.CartAnonymous).WillReturnRows(rows)
+
+		_, err := repo.FindByKodeProduks(context.TODO(), []string{"test", "other"})
+
+		assert.ErrorIs(t, err, exception.ErrInternalServer)
+	})
+
+	t.Run("Add Batch Single Insert", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`INSERT INTO %s \(nama, kodeProduk, kuantitas, created_at, version, owner\) VALUES \(\?,\?,\?,\?,\?,\?\),\(\?,\?,\?,\?,\?,\?\)`, constant.TableCart)
+
+		ctx := context.TODO()
+
+		mock.ExpectExec(query).
+			WithArgs("a", "A", int64(1), currentTime, 1, rbac.CartAnonymous, "b", "B", int64(2), currentTime, 1, rbac.CartAnonymous).
+			WillReturnResult(sqlmock.NewResult(1, 2))
+
+		err := repo.AddBatch(ctx, []product.Product{
+			{Nama: "a", KodeProduk: "A", Kuantitas: 1, CreatedAt: currentTime},
+			{Nama: "b", KodeProduk: "B", Kuantitas: 2, CreatedAt: currentTime},
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update Kuantitas Batch Single Update", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`UPDATE %s SET kuantitas = CASE id WHEN \? THEN \? WHEN \? THEN \? END, update_at = \?, version = version \+ 1 WHERE id IN \(\?,\?\)`, constant.TableCart)
+
+		ctx := context.TODO()
+
+		mock.ExpectExec(query).
+			WithArgs(int64(1), int64(3), int64(2), int64(4), currentTime, int64(1), int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err := repo.UpdateKuantitasBatch(ctx, []product.Product{
+			{ID: 1, Kuantitas: 3, UpdateAt: currentTime},
+			{ID: 2, Kuantitas: 4, UpdateAt: currentTime},
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Delete Batch Soft Deletes Not Found", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`UPDATE %s SET deleted_at = \?, update_at = \?, version = version \+ 1 WHERE id IN \(\?,\?\) AND deleted_at IS NULL`, constant.TableCart)
+
+		ctx := context.TODO()
+
+		mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.DeleteBatch(ctx, []int64{1, 2})
+
+		assert.Equal(t, exception.ErrNotFound, err)
+	})
+}
+
+func TestSoftDeleteRepository(t *testing.T) {
+	t.Run("Find Deleted By Kode Produk Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version, deleted_at FROM %s WHERE kodeProduk = \? AND deleted_at >= \? AND owner = \? ORDER BY deleted_at DESC LIMIT 1`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version", "deleted_at"}).
+			AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version, currentTime)
+
+		ctx := context.TODO()
+
+		mock.ExpectPrepare(query).ExpectQuery().WithArgs(productStruct.KodeProduk, currentTime, rbac.CartAnonymous).WillReturnRows(rows)
+
+		data, err := repo.FindDeletedByKodeProduk(ctx, productStruct.KodeProduk, currentTime)
+
+		assert.NoError(t, err)
+		assert.Equal(t, productStruct.ID, data.ID)
+		assert.Equal(t, currentTime, *data.DeletedAt)
+	})
+
+	t.Run("Restore Not Deleted", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL, update_at = \?, version = version \+ 1 WHERE id = \? AND deleted_at IS NOT NULL`, constant.TableCart)
+
+		ctx := context.TODO()
+
+		mock.ExpectPrepare(query).ExpectExec().WithArgs(sqlmock.AnyArg(), productStruct.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Restore(ctx, productStruct.ID)
+
+		assert.Equal(t, exception.ErrNotFound, err)
+	})
+
+	t.Run("Purge Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`DELETE FROM %s WHERE deleted_at IS NOT NULL AND deleted_at < \? LIMIT \?`, constant.TableCart)
+
+		ctx := context.TODO()
+
+		mock.ExpectExec(query).WithArgs(currentTime, 10).WillReturnResult(sqlmock.NewResult(0, 3))
+
+		purged, err := repo.Purge(ctx, currentTime, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), purged)
+	})
+}
+
+func TestOwnerRepository(t *testing.T) {
+	t.Run("Find All Of Selected Cart", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE deleted_at IS NULL AND owner = \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).AddRow(productStruct.ID, productStruct.Nama, productStruct.KodeProduk, productStruct.Kuantitas, productStruct.CreatedAt, productStruct.UpdateAt, productStruct.Version)
+
+		ctx := rbac.WithCart(context.TODO(), "user:42")
+
+		mock.ExpectQuery(query).WithArgs("user:42").WillReturnRows(rows)
+
+		data, err := repo.FindAll(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []product.Product{productStruct}, data)
+	})
+
+	t.Run("Find Owner Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT owner FROM %s WHERE id = \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"owner"}).AddRow("user:42")
+
+		mock.ExpectQuery(query).WithArgs(productStruct.ID).WillReturnRows(rows)
+
+		owner, err := repo.FindOwner(context.TODO(), productStruct.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user:42", owner)
+	})
+
+	t.Run("Find Owner Not Found", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT owner FROM %s WHERE id = \?`, constant.TableCart)
+
+		mock.ExpectQuery(query).WithArgs(productStruct.ID).WillReturnRows(sqlmock.NewRows([]string{"owner"}))
+
+		_, err := repo.FindOwner(context.TODO(), productStruct.ID)
+
+		assert.Equal(t, exception.ErrNotFound, err)
+	})
+
+	t.Run("Find Carts Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT owner, COUNT\(\*\), COALESCE\(SUM\(kuantitas\), 0\), MAX\(COALESCE\(update_at, created_at\)\) FROM %s WHERE deleted_at IS NULL AND owner IN \(SELECT owner FROM %s WHERE deleted_at IS NULL AND \(owner LIKE \? OR kodeProduk LIKE \? OR nama LIKE \?\)\) GROUP BY owner ORDER BY owner LIMIT \? OFFSET \?`, constant.TableCart, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"owner", "lines", "kuantitas", "last_activity"}).AddRow("user:42", 2, 5, currentTime)
+
+		mock.ExpectQuery(query).WithArgs(`%te\_st%`, `%te\_st%`, `%te\_st%`, 10, 10).WillReturnRows(rows)
+
+		carts, err := repo.FindCarts(context.TODO(), cartsummary.Filter{Q: "te_st", Page: 2, PageSize: 10})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []cartsummary.Summary{{Owner: "user:42", Lines: 2, TotalKuantitas: 5, LastActivityAt: currentTime}}, carts)
+	})
+
+	t.Run("Count Carts Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT COUNT\(DISTINCT owner\) FROM %s WHERE deleted_at IS NULL`, constant.TableCart)
+
+		mock.ExpectQuery(query).WithArgs().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		total, err := repo.CountCarts(context.TODO(), "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+	})
+
+	t.Run("Find Catalog Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT DISTINCT kodeProduk, COALESCE\(nama, ''\) FROM %s WHERE deleted_at IS NULL ORDER BY kodeProduk, 2`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"kodeProduk", "nama"}).AddRow("KOPI-01", "Kopi Susu").AddRow("TEH-01", "Teh Manis")
+
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		entries, err := repo.FindCatalog(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []catalog.Entry{{KodeProduk: "KOPI-01", Nama: "Kopi Susu"}, {KodeProduk: "TEH-01", Nama: "Teh Manis"}}, entries)
+	})
+
+	t.Run("Find Carts Row Error", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT owner, COUNT\(\*\), COALESCE\(SUM\(kuantitas\), 0\), MAX\(COALESCE\(update_at, created_at\)\) FROM %s WHERE deleted_at IS NULL GROUP BY owner ORDER BY owner LIMIT \? OFFSET \?`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"owner", "lines", "kuantitas", "last_activity"}).
+			AddRow("user:42", 2, 5, currentTime).
+			RowError(0, fmt.Errorf("connection reset"))
+
+		mock.ExpectQuery(query).WithArgs(10, 0).WillReturnRows(rows)
+
+		_, err := repo.FindCarts(context.TODO(), cartsummary.Filter{Page: 1, PageSize: 10})
+
+		assert.ErrorIs(t, err, exception.ErrInternalServer)
+	})
+
+	t.Run("Find Catalog Row Error", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT DISTINCT kodeProduk, COALESCE\(nama, ''\) FROM %s WHERE deleted_at IS NULL ORDER BY kodeProduk, 2`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"kodeProduk", "nama"}).
+			AddRow("KOPI-01", "Kopi Susu").
+			RowError(0, fmt.Errorf("connection reset"))
+
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		_, err := repo.FindCatalog(context.TODO())
+
+		assert.ErrorIs(t, err, exception.ErrInternalServer)
+	})
 }
@@ -2,19 +2,62 @@ package cart_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/Risuii/helpers/auth"
 	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/helpers/requestinfo"
+	"github.com/Risuii/helpers/response"
 	"github.com/Risuii/internal/cart"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/cartevent"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/catalog"
+	"github.com/Risuii/models/event"
 	"github.com/Risuii/models/filter"
 	"github.com/Risuii/models/product"
 	"github.com/Risuii/tests/mocks"
 )
 
+// newAuditRepository accepts every audit event.
+func newAuditRepository() *mocks.AuditRepository {
+	auditRepository := new(mocks.AuditRepository)
+	auditRepository.On("Append", mock.Anything, mock.Anything).Return(nil).Maybe()
+	auditRepository.On("Append", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	auditRepository.On("Append", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	return auditRepository
+}
+
+// newOutboxRepository accepts every domain event.
+func newOutboxRepository() *mocks.OutboxRepository {
+	outboxRepository := new(mocks.OutboxRepository)
+	outboxRepository.On("Append", mock.Anything).Return(nil).Maybe()
+	outboxRepository.On("Append", mock.Anything, mock.Anything).Return(nil).Maybe()
+	outboxRepository.On("Append", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	outboxRepository.On("Append", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	return outboxRepository
+}
+
+func newTxManager() *mocks.TxManager {
+	txManager := new(mocks.TxManager)
+	txManager.On("WithinTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+
+	return txManager
+}
+
 func TestUseCaseAddItems(t *testing.T) {
 	t.Run("Add Items Success", func(t *testing.T) {
 
@@ -33,9 +76,13 @@ func TestUseCaseAddItems(t *testing.T) {
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
-		resp := cartUseCase.AddItems(ctx, mockData)
+		resp := cartUseCase.AddItems(ctx, mockData, "")
 
 		assert.NoError(t, resp.Err())
 
@@ -58,9 +105,13 @@ func TestUseCaseAddItems(t *testing.T) {
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
-		resp := cartUseCase.AddItems(ctx, mockData)
+		resp := cartUseCase.AddItems(ctx, mockData, "")
 
 		assert.Error(t, resp.Err())
 
@@ -79,13 +130,17 @@ func TestUseCaseAddItems(t *testing.T) {
 
 		cartRepository := new(mocks.CartRepository)
 		cartRepository.On("FindByKodeProduk", mock.Anything, mock.AnythingOfType("string")).Return(mockData, nil)
-		cartRepository.On("UpdateKuantitas", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("product.Product")).Return(nil)
+		cartRepository.On("UpdateKuantitasIfVersion", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), mock.AnythingOfType("product.Product")).Return(nil)
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
-		resp := cartUseCase.AddItems(ctx, mockData)
+		resp := cartUseCase.AddItems(ctx, mockData, "")
 
 		assert.NoError(t, resp.Err())
 
@@ -104,18 +159,34 @@ func TestUseCaseAddItems(t *testing.T) {
 
 		cartRepository := new(mocks.CartRepository)
 		cartRepository.On("FindByKodeProduk", mock.Anything, mock.AnythingOfType("string")).Return(mockData, nil)
-		cartRepository.On("UpdateKuantitas", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("product.Product")).Return(exception.ErrInternalServer)
+		cartRepository.On("UpdateKuantitasIfVersion", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("int64"), mock.AnythingOfType("product.Product")).Return(exception.ErrInternalServer)
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
-		resp := cartUseCase.AddItems(ctx, mockData)
+		resp := cartUseCase.AddItems(ctx, mockData, "")
 
 		assert.Error(t, resp.Err())
 
 		cartRepository.AssertExpectations(t)
 	})
+
+	t.Run("Add Items Lookup Error", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(product.Product{}, exception.ErrInternalServer)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		resp := cartUseCase.AddItems(context.TODO(), product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 1}, "")
+
+		assert.Equal(t, exception.ErrInternalServer, resp.Err())
+		cartRepository.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
 }
 
 func TestUseCaseGetItems(t *testing.T) {
@@ -129,10 +200,14 @@ func TestUseCaseGetItems(t *testing.T) {
 		}
 
 		cartRepository := new(mocks.CartRepository)
-		cartRepository.On("FindAll").Return(data, nil)
+		cartRepository.On("FindAll", mock.Anything).Return(data, nil)
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
 		resp := cartUseCase.GetItems(ctx, mockData)
@@ -151,10 +226,14 @@ func TestUseCaseGetItems(t *testing.T) {
 		}
 
 		cartRepository := new(mocks.CartRepository)
-		cartRepository.On("FindAll").Return(nil, exception.ErrNotFound)
+		cartRepository.On("FindAll", mock.Anything).Return(nil, exception.ErrNotFound)
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
 		resp := cartUseCase.GetItems(ctx, mockData)
@@ -173,10 +252,14 @@ func TestUseCaseGetItems(t *testing.T) {
 		}
 
 		cartRepository := new(mocks.CartRepository)
-		cartRepository.On("FindAll").Return(nil, exception.ErrInternalServer)
+		cartRepository.On("FindAll", mock.Anything).Return(nil, exception.ErrInternalServer)
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
 		resp := cartUseCase.GetItems(ctx, mockData)
@@ -196,9 +279,14 @@ func TestUseCaseGetItems(t *testing.T) {
 
 		cartRepository := new(mocks.CartRepository)
 		cartRepository.On("FindByFilter", mock.Anything, mock.AnythingOfType("filter.Filter")).Return(data, nil)
+		cartRepository.On("FindAll", mock.Anything).Return(data, nil)
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
 		resp := cartUseCase.GetItems(ctx, mockData)
@@ -221,6 +309,10 @@ func TestUseCaseGetItems(t *testing.T) {
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
 		resp := cartUseCase.GetItems(ctx, mockData)
@@ -242,6 +334,10 @@ func TestUseCaseGetItems(t *testing.T) {
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
 		resp := cartUseCase.GetItems(ctx, mockData)
@@ -252,6 +348,47 @@ func TestUseCaseGetItems(t *testing.T) {
 	})
 }
 
+func TestUseCaseFindItems(t *testing.T) {
+	t.Run("Find Items Success", func(t *testing.T) {
+		data := []product.Product{{ID: 1, KodeProduk: "A"}, {ID: 2, KodeProduk: "B"}}
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduks", mock.Anything, []string{"A", "B", "C"}).Return(data, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		resp := cartUseCase.FindItems(context.TODO(), []string{"A", "B", "C"})
+
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, data, resp.(*response.ResponseImpl).Data)
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Find Items None Found", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduks", mock.Anything, []string{"A"}).Return(nil, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		resp := cartUseCase.FindItems(context.TODO(), []string{"A"})
+
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, []product.Product{}, resp.(*response.ResponseImpl).Data)
+	})
+
+	t.Run("Find Items Error Internal Server", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduks", mock.Anything, []string{"A"}).Return(nil, errors.New("boom"))
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		resp := cartUseCase.FindItems(context.TODO(), []string{"A"})
+
+		assert.Equal(t, exception.ErrInternalServer, resp.Err())
+	})
+}
+
 func TestUseCaseDeleteItems(t *testing.T) {
 	t.Run("Delete Items Success", func(t *testing.T) {
 		type req struct {
@@ -270,9 +407,13 @@ func TestUseCaseDeleteItems(t *testing.T) {
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
-		resp := cartUseCase.DeleteItems(ctx, newReq.Data)
+		resp := cartUseCase.DeleteItems(ctx, newReq.Data, "")
 
 		assert.NoError(t, resp.Err())
 
@@ -296,9 +437,13 @@ func TestUseCaseDeleteItems(t *testing.T) {
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
-		resp := cartUseCase.DeleteItems(ctx, newReq.Data)
+		resp := cartUseCase.DeleteItems(ctx, newReq.Data, "")
 
 		assert.Error(t, resp.Err())
 
@@ -321,9 +466,13 @@ func TestUseCaseDeleteItems(t *testing.T) {
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
-		resp := cartUseCase.DeleteItems(ctx, newReq.Data)
+		resp := cartUseCase.DeleteItems(ctx, newReq.Data, "")
 
 		assert.Error(t, resp.Err())
 
@@ -346,12 +495,1034 @@ func TestUseCaseDeleteItems(t *testing.T) {
 
 		cartUseCase := cart.NewCartUseCaseImpl(
 			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
 		)
 
-		resp := cartUseCase.DeleteItems(ctx, newReq.Data)
+		resp := cartUseCase.DeleteItems(ctx, newReq.Data, "")
 
 		assert.Error(t, resp.Err())
 
 		cartRepository.AssertExpectations(t)
 	})
 }
+
+func TestUseCaseOptimisticConcurrency(t *testing.T) {
+	mockData := product.Product{
+		ID:         1,
+		Nama:       "test",
+		KodeProduk: "test",
+		Kuantitas:  1,
+		Version:    2,
+	}
+
+	t.Run("Get Items Sets ETag", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{mockData}, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.GetItems(ctx, filter.Filter{})
+		recorder := httptest.NewRecorder()
+		resp.JSON(recorder)
+
+		assert.NoError(t, resp.Err())
+		assert.NotEmpty(t, recorder.Header().Get("ETag"))
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Get Items Sets Last-Modified", func(t *testing.T) {
+		ctx := context.TODO()
+		latest := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{
+			{ID: 1, Version: 1, UpdateAt: latest.Add(-time.Hour)},
+			{ID: 2, Version: 1, UpdateAt: latest},
+		}, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.GetItems(ctx, filter.Filter{})
+		recorder := httptest.NewRecorder()
+		resp.JSON(recorder)
+
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, "Mon, 19 Oct 2026 08:30:00 GMT", recorder.Header().Get("Last-Modified"))
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Add Items If-Match Matches", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{mockData}, nil)
+		cartRepository.On("FindByKodeProduk", mock.Anything, mock.AnythingOfType("string")).Return(mockData, nil)
+		cartRepository.On("UpdateKuantitasIfVersion", mock.Anything, int64(1), int64(2), mock.AnythingOfType("product.Product")).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		recorder := httptest.NewRecorder()
+		cartUseCase.GetItems(ctx, filter.Filter{}).JSON(recorder)
+
+		resp := cartUseCase.AddItems(ctx, product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 1}, recorder.Header().Get("ETag"))
+
+		assert.NoError(t, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Add Items If-Match From Filtered Get Items", func(t *testing.T) {
+		ctx := context.TODO()
+		other := product.Product{ID: 2, Nama: "other", KodeProduk: "other", Kuantitas: 1, Version: 5}
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByFilter", mock.Anything, filter.Filter{Nama: "test"}).Return([]product.Product{mockData}, nil)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{mockData, other}, nil)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(mockData, nil)
+		cartRepository.On("UpdateKuantitasIfVersion", mock.Anything, int64(1), int64(2), mock.AnythingOfType("product.Product")).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		recorder := httptest.NewRecorder()
+		cartUseCase.GetItems(ctx, filter.Filter{Nama: "test"}).JSON(recorder)
+
+		resp := cartUseCase.AddItems(ctx, product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 1}, recorder.Header().Get("ETag"))
+
+		assert.NoError(t, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Add Items If-Match Stale", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{mockData}, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.AddItems(ctx, product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 1}, `"stale"`)
+		recorder := httptest.NewRecorder()
+		resp.JSON(recorder)
+
+		assert.Equal(t, exception.ErrPreconditionFailed, resp.Err())
+		assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Add Items Version Conflict", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, mock.AnythingOfType("string")).Return(mockData, nil)
+		cartRepository.On("UpdateKuantitasIfVersion", mock.Anything, int64(1), int64(1), mock.AnythingOfType("product.Product")).Return(exception.ErrVersionConflict)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.AddItems(ctx, product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 1, Version: 1}, "")
+		recorder := httptest.NewRecorder()
+		resp.JSON(recorder)
+
+		assert.Equal(t, exception.ErrVersionConflict, resp.Err())
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Delete Items If-Match Stale", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{mockData}, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.DeleteItems(ctx, "test", `"stale"`)
+
+		assert.Equal(t, exception.ErrPreconditionFailed, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseBatchItems(t *testing.T) {
+	existing := product.Product{
+		ID:         1,
+		Nama:       "test",
+		KodeProduk: "test",
+		Kuantitas:  2,
+		Version:    1,
+	}
+
+	params := batch.Batch{
+		Operations: []batch.Operation{
+			{Op: batch.OpAdd, KodeProduk: "test", Kuantitas: 3},
+			{Op: batch.OpAdd, KodeProduk: "new", Nama: "baru", Kuantitas: 1},
+			{Op: batch.OpRemove, KodeProduk: "missing"},
+		},
+	}
+
+	t.Run("Batch Items Atomic Rejects All", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduks", mock.Anything, []string{"test", "new", "missing"}).Return([]product.Product{existing}, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.BatchItems(ctx, params, "")
+		recorder := httptest.NewRecorder()
+		resp.JSON(recorder)
+
+		rb := response.ResponseImpl{Data: &[]batch.Result{}}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		results := *rb.Data.(*[]batch.Result)
+
+		assert.Equal(t, exception.ErrBatchFailed, resp.Err())
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		assert.Equal(t, batch.StatusAborted, results[0].Status)
+		assert.Equal(t, batch.StatusAborted, results[1].Status)
+		assert.Equal(t, response.StatusNotFound, results[2].Status)
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Batch Items Partial Applies Successes", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduks", mock.Anything, []string{"test", "new", "missing"}).Return([]product.Product{existing}, nil)
+		cartRepository.On("DeleteBatch", mock.Anything, []int64(nil)).Return(nil)
+		cartRepository.On("UpdateKuantitasBatch", mock.Anything, mock.MatchedBy(func(items []product.Product) bool {
+			return len(items) == 1 && items[0].ID == 1 && items[0].Kuantitas == 5
+		})).Return(nil)
+		cartRepository.On("AddBatch", mock.Anything, mock.MatchedBy(func(items []product.Product) bool {
+			return len(items) == 1 && items[0].KodeProduk == "new" && items[0].Kuantitas == 1
+		})).Return(nil)
+		cartRepository.On("FindByKodeProduks", mock.Anything, []string{"test", "new"}).Return([]product.Product{
+			{ID: 1, Nama: "test", KodeProduk: "test", Kuantitas: 5, Version: 2},
+			{ID: 2, Nama: "baru", KodeProduk: "new", Kuantitas: 1, Version: 1},
+		}, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{BatchMode: batch.ModePartial},
+		)
+
+		resp := cartUseCase.BatchItems(ctx, params, "")
+		recorder := httptest.NewRecorder()
+		resp.JSON(recorder)
+
+		rb := response.ResponseImpl{Data: &[]batch.Result{}}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		results := *rb.Data.(*[]batch.Result)
+
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, response.StatusOK, results[0].Status)
+		assert.Equal(t, int64(5), results[0].Item.Kuantitas)
+		assert.Equal(t, int64(2), results[1].Item.ID)
+		assert.Equal(t, response.StatusNotFound, results[2].Status)
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Batch Items Set Zero Removes", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduks", mock.Anything, []string{"test"}).Return([]product.Product{existing}, nil)
+		cartRepository.On("DeleteBatch", mock.Anything, []int64{1}).Return(nil)
+		cartRepository.On("UpdateKuantitasBatch", mock.Anything, []product.Product(nil)).Return(nil)
+		cartRepository.On("AddBatch", mock.Anything, []product.Product(nil)).Return(nil)
+		cartRepository.On("FindByKodeProduks", mock.Anything, []string(nil)).Return(nil, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.BatchItems(ctx, batch.Batch{Operations: []batch.Operation{{Op: batch.OpSet, KodeProduk: "test"}}}, "")
+
+		assert.NoError(t, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Batch Items Too Large", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{BatchMaxOperations: 2},
+		)
+
+		resp := cartUseCase.BatchItems(ctx, params, "")
+
+		assert.Equal(t, exception.ErrBatchTooLarge, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Batch Items Repository Error", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduks", mock.Anything, mock.Anything).Return(nil, exception.ErrInternalServer)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.BatchItems(ctx, params, "")
+
+		assert.Equal(t, exception.ErrInternalServer, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseRestoreItems(t *testing.T) {
+	deletedAt := time.Now()
+	deleted := product.Product{
+		ID:         1,
+		Nama:       "test",
+		KodeProduk: "test",
+		Kuantitas:  2,
+		Version:    2,
+		DeletedAt:  &deletedAt,
+	}
+
+	t.Run("Restore Items Success", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(product.Product{}, exception.ErrNotFound)
+		cartRepository.On("FindDeletedByKodeProduk", mock.Anything, "test", mock.MatchedBy(func(since time.Time) bool {
+			return time.Since(since) >= time.Hour && time.Since(since) < time.Hour+time.Minute
+		})).Return(deleted, nil)
+		cartRepository.On("Restore", mock.Anything, int64(1)).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{RestoreWindow: time.Hour},
+		)
+
+		resp := cartUseCase.RestoreItems(ctx, "test", "")
+		recorder := httptest.NewRecorder()
+		resp.JSON(recorder)
+
+		rb := response.ResponseImpl{Data: &product.Product{}}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		data := rb.Data.(*product.Product)
+
+		assert.NoError(t, resp.Err())
+		assert.Nil(t, data.DeletedAt)
+		assert.Equal(t, int64(3), data.Version)
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Restore Items Already In Cart", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(product.Product{ID: 2, KodeProduk: "test"}, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.RestoreItems(ctx, "test", "")
+
+		assert.Equal(t, exception.ErrConflicted, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Restore Items Outside Window", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(product.Product{}, exception.ErrNotFound)
+		cartRepository.On("FindDeletedByKodeProduk", mock.Anything, "test", mock.AnythingOfType("time.Time")).Return(product.Product{}, exception.ErrNotFound)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.RestoreItems(ctx, "test", "")
+
+		assert.Equal(t, exception.ErrNotFound, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Restore Items Lookup Error", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(product.Product{}, exception.ErrInternalServer)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{RestoreWindow: time.Hour})
+
+		resp := cartUseCase.RestoreItems(context.TODO(), "test", "")
+
+		assert.Equal(t, exception.ErrInternalServer, resp.Err())
+		cartRepository.AssertNotCalled(t, "FindDeletedByKodeProduk", mock.Anything, mock.Anything, mock.Anything)
+		cartRepository.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUseCasePurgeDeleted(t *testing.T) {
+	t.Run("Purge Deleted Repeats Until Drained", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("Purge", mock.Anything, mock.AnythingOfType("time.Time"), 500).Return(int64(500), nil).Once()
+		cartRepository.On("Purge", mock.Anything, mock.AnythingOfType("time.Time"), 500).Return(int64(20), nil).Once()
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		purged, err := cartUseCase.PurgeDeleted(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(520), purged)
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Purge Deleted Error", func(t *testing.T) {
+		ctx := context.TODO()
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("Purge", mock.Anything, mock.AnythingOfType("time.Time"), 500).Return(int64(0), exception.ErrInternalServer)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		_, err := cartUseCase.PurgeDeleted(ctx)
+
+		assert.Equal(t, exception.ErrInternalServer, err)
+
+		cartRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseAuditTrail(t *testing.T) {
+	ctx := requestinfo.With(context.TODO(), requestinfo.Info{
+		RequestID: "req-1",
+		Actor:     "user-1",
+		SourceIP:  "10.0.0.1",
+	})
+
+	mockData := product.Product{
+		ID:         7,
+		Nama:       "test",
+		KodeProduk: "test",
+		Kuantitas:  2,
+		Version:    1,
+	}
+
+	t.Run("Add Items Records Quantity Change", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(mockData, nil)
+		cartRepository.On("UpdateKuantitasIfVersion", mock.Anything, int64(7), int64(1), mock.AnythingOfType("product.Product")).Return(nil)
+
+		auditRepository := new(mocks.AuditRepository)
+		auditRepository.On("Append", mock.Anything, mock.MatchedBy(func(e cartevent.Event) bool {
+			return e.CartID == 7 &&
+				e.Action == cartevent.ActionUpdateKuantitas &&
+				e.KuantitasBefore == 2 &&
+				e.KuantitasAfter == 5 &&
+				e.Actor == "user-1" &&
+				e.RequestID == "req-1" &&
+				e.SourceIP == "10.0.0.1"
+		})).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			auditRepository,
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.AddItems(ctx, product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 3}, "")
+
+		assert.NoError(t, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+		auditRepository.AssertExpectations(t)
+	})
+
+	t.Run("Delete Items Records Removal", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(mockData, nil)
+		cartRepository.On("Delete", mock.Anything, int64(7)).Return(nil)
+
+		auditRepository := new(mocks.AuditRepository)
+		auditRepository.On("Append", mock.Anything, mock.MatchedBy(func(e cartevent.Event) bool {
+			return e.Action == cartevent.ActionDelete && e.KuantitasBefore == 2 && e.KuantitasAfter == 0
+		})).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			auditRepository,
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.DeleteItems(ctx, "test", "")
+
+		assert.NoError(t, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+		auditRepository.AssertExpectations(t)
+	})
+
+	t.Run("Audit Failure Fails Mutation", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(product.Product{}, exception.ErrNotFound)
+		cartRepository.On("Add", mock.Anything, mock.AnythingOfType("product.Product")).Return(int64(8), nil)
+
+		auditRepository := new(mocks.AuditRepository)
+		auditRepository.On("Append", mock.Anything, mock.AnythingOfType("cartevent.Event")).Return(exception.ErrInternalServer)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			auditRepository,
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.AddItems(ctx, product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 1}, "")
+
+		assert.Equal(t, exception.ErrInternalServer, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+		auditRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseDomainEvents(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Add Items Publishes Item Added", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(product.Product{}, exception.ErrNotFound)
+		cartRepository.On("Add", mock.Anything, mock.AnythingOfType("product.Product")).Return(int64(9), nil)
+
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("Append", mock.Anything, mock.MatchedBy(func(e event.Envelope) bool {
+			var data event.ItemAdded
+			json.Unmarshal(e.Data, &data)
+
+			return e.Type == event.TypeItemAdded && e.Version == event.Version && data.CartID == 9 && data.Kuantitas == 2
+		})).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			outboxRepository,
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.AddItems(ctx, product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 2}, "")
+
+		assert.NoError(t, resp.Err())
+
+		outboxRepository.AssertExpectations(t)
+	})
+
+	t.Run("Checkout Publishes Cart Checked Out", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{
+			{ID: 1, Nama: "a", KodeProduk: "A", Kuantitas: 2},
+			{ID: 2, Nama: "b", KodeProduk: "B", Kuantitas: 3},
+		}, nil)
+		cartRepository.On("DeleteBatch", mock.Anything, []int64{1, 2}).Return(nil)
+
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("Append", mock.Anything).Return(nil)
+		outboxRepository.On("Append", mock.Anything, mock.MatchedBy(func(e event.Envelope) bool {
+			var data event.CartCheckedOut
+			json.Unmarshal(e.Data, &data)
+
+			return e.Type == event.TypeCartCheckedOut && len(data.Items) == 2 && data.TotalKuantitas == 5
+		})).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			outboxRepository,
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.Checkout(ctx, "")
+
+		assert.NoError(t, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+		outboxRepository.AssertExpectations(t)
+	})
+
+	t.Run("Checkout Empty Cart", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return(nil, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{},
+		)
+
+		resp := cartUseCase.Checkout(ctx, "")
+		recorder := httptest.NewRecorder()
+		resp.JSON(recorder)
+
+		assert.Equal(t, exception.ErrCartEmpty, resp.Err())
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	})
+
+	t.Run("Detect Abandoned Publishes Once Per Cart", func(t *testing.T) {
+		lastActivity := time.Now().Add(-2 * time.Hour)
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindCarts", mock.Anything, cartsummary.Filter{Page: 1, PageSize: 100}).Return([]cartsummary.Summary{
+			{Owner: "user:1", Lines: 1, LastActivityAt: lastActivity},
+			{Owner: "user:2", Lines: 1, LastActivityAt: lastActivity},
+		}, nil)
+		for _, owner := range []string{"user:1", "user:2"} {
+			cartRepository.On("FindAll", ofCart(owner)).Return([]product.Product{
+				{ID: 1, KodeProduk: "A", Kuantitas: 1, CreatedAt: lastActivity.Add(-time.Hour), UpdateAt: lastActivity},
+			}, nil)
+		}
+
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("LastOccurredAt", mock.Anything, event.TypeCartAbandoned, "user:1").Return(lastActivity.Add(-time.Minute), nil).Once()
+		outboxRepository.On("LastOccurredAt", mock.Anything, event.TypeCartAbandoned, "user:2").Return(time.Time{}, nil).Once()
+		for _, owner := range []string{"user:1", "user:2"} {
+			owner := owner
+			outboxRepository.On("Append", mock.Anything, mock.MatchedBy(func(e event.Envelope) bool {
+				return e.Type == event.TypeCartAbandoned && e.Owner == owner
+			})).Return(nil).Once()
+		}
+		outboxRepository.On("LastOccurredAt", mock.Anything, event.TypeCartAbandoned, mock.Anything).Return(time.Now(), nil).Twice()
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			outboxRepository,
+			newTxManager(),
+			cart.Options{AbandonAfter: time.Hour},
+		)
+
+		first, err := cartUseCase.DetectAbandoned(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, first)
+
+		second, err := cartUseCase.DetectAbandoned(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, second)
+
+		outboxRepository.AssertExpectations(t)
+	})
+
+	t.Run("Detect Abandoned Recent Activity", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindCarts", mock.Anything, cartsummary.Filter{Page: 1, PageSize: 100}).Return([]cartsummary.Summary{
+			{Owner: "user:1", Lines: 1, LastActivityAt: time.Now()},
+		}, nil)
+
+		outboxRepository := new(mocks.OutboxRepository)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			outboxRepository,
+			newTxManager(),
+			cart.Options{AbandonAfter: time.Hour},
+		)
+
+		abandoned, err := cartUseCase.DetectAbandoned(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, abandoned)
+
+		cartRepository.AssertExpectations(t)
+		outboxRepository.AssertExpectations(t)
+	})
+
+	t.Run("Detect Abandoned Goes On After A Failing Cart", func(t *testing.T) {
+		lastActivity := time.Now().Add(-2 * time.Hour)
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindCarts", mock.Anything, cartsummary.Filter{Page: 1, PageSize: 100}).Return([]cartsummary.Summary{
+			{Owner: "user:1", Lines: 1, LastActivityAt: lastActivity},
+			{Owner: "user:2", Lines: 1, LastActivityAt: lastActivity},
+		}, nil)
+		cartRepository.On("FindAll", ofCart("user:1")).Return(nil, exception.ErrInternalServer)
+		cartRepository.On("FindAll", ofCart("user:2")).Return([]product.Product{
+			{ID: 2, KodeProduk: "B", Kuantitas: 1, CreatedAt: lastActivity},
+		}, nil)
+
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("LastOccurredAt", mock.Anything, event.TypeCartAbandoned, "user:2").Return(time.Time{}, nil)
+		outboxRepository.On("Append", mock.Anything, mock.Anything).Return(nil).Once()
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			outboxRepository,
+			newTxManager(),
+			cart.Options{AbandonAfter: time.Hour},
+		)
+
+		abandoned, err := cartUseCase.DetectAbandoned(ctx)
+
+		assert.ErrorIs(t, err, exception.ErrInternalServer)
+		assert.Equal(t, 1, abandoned)
+
+		outboxRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseNotifier(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("Notifies After Commit", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(product.Product{}, exception.ErrNotFound)
+		cartRepository.On("Add", mock.Anything, mock.AnythingOfType("product.Product")).Return(int64(9), nil)
+
+		notifier := new(mocks.Notifier)
+		notifier.On("Notify", mock.MatchedBy(func(e event.Envelope) bool {
+			return e.Type == event.TypeItemAdded
+		})).Return()
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			newOutboxRepository(),
+			newTxManager(),
+			cart.Options{Notifier: notifier},
+		)
+
+		resp := cartUseCase.AddItems(ctx, product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 2}, "")
+
+		assert.NoError(t, resp.Err())
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("Rolled Back Change Is Not Notified", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindByKodeProduk", mock.Anything, "test").Return(product.Product{}, exception.ErrNotFound)
+		cartRepository.On("Add", mock.Anything, mock.AnythingOfType("product.Product")).Return(int64(9), nil)
+
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("Append", mock.Anything, mock.Anything).Return(nil)
+
+		// the commit fails after the unit of work wrote its events
+		txManager := new(mocks.TxManager)
+		txManager.On("WithinTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+
+			return errors.New("commit failed")
+		})
+
+		notifier := new(mocks.Notifier)
+
+		cartUseCase := cart.NewCartUseCaseImpl(
+			cartRepository,
+			newAuditRepository(),
+			outboxRepository,
+			txManager,
+			cart.Options{Notifier: notifier},
+		)
+
+		resp := cartUseCase.AddItems(ctx, product.Product{Nama: "test", KodeProduk: "test", Kuantitas: 2}, "")
+
+		assert.Error(t, resp.Err())
+		notifier.AssertNotCalled(t, "Notify", mock.Anything)
+	})
+}
+
+func TestUseCaseRoles(t *testing.T) {
+	customer := auth.With(context.TODO(), auth.Principal{Kind: auth.KindUser, Subject: "42"})
+	support := auth.With(context.TODO(), auth.Principal{Kind: auth.KindUser, Subject: "7", Role: string(rbac.RoleSupport)})
+	admin := auth.With(context.TODO(), auth.Principal{Kind: auth.KindUser, Subject: "1", Role: string(rbac.RoleAdmin)})
+
+	t.Run("Customer Cannot Reach Another Cart", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		resp := cartUseCase.GetItems(rbac.WithCart(customer, "user:9"), filter.Filter{})
+		recorder := httptest.NewRecorder()
+		resp.JSON(recorder)
+
+		assert.Equal(t, exception.ErrForbidden, resp.Err())
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Support Access Is Recorded", func(t *testing.T) {
+		ctx := requestinfo.With(rbac.WithCart(support, "user:42"), requestinfo.Info{RequestID: "req-1", Actor: "user:7", SourceIP: "10.0.0.1"})
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{{ID: 1, KodeProduk: "A", Kuantitas: 1}}, nil)
+
+		accessRepository := new(mocks.AccessRepository)
+		accessRepository.On("Append", mock.Anything, mock.MatchedBy(func(a cartevent.Access) bool {
+			return a.Actor == "user:7" && a.Role == "support" && a.Action == string(rbac.ActionViewCart) && a.Owner == "user:42" && a.RequestID == "req-1" && a.SourceIP == "10.0.0.1"
+		})).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{Access: accessRepository})
+
+		resp := cartUseCase.GetItems(ctx, filter.Filter{})
+
+		assert.NoError(t, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+		accessRepository.AssertExpectations(t)
+	})
+
+	t.Run("Own Cart Access Is Not Recorded", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{{ID: 1, KodeProduk: "A", Kuantitas: 1}}, nil)
+
+		accessRepository := new(mocks.AccessRepository)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{Access: accessRepository})
+
+		resp := cartUseCase.GetItems(customer, filter.Filter{})
+
+		assert.NoError(t, resp.Err())
+		accessRepository.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+	})
+
+	t.Run("List Carts", func(t *testing.T) {
+		params := cartsummary.Filter{Q: "A", Page: 1, PageSize: cart.DefaultPageSize}
+
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("CountCarts", mock.Anything, "A").Return(int64(1), nil)
+		cartRepository.On("FindCarts", mock.Anything, params).Return([]cartsummary.Summary{{Owner: "user:42", Lines: 1, TotalKuantitas: 1}}, nil)
+
+		accessRepository := new(mocks.AccessRepository)
+		accessRepository.On("Append", mock.Anything, mock.MatchedBy(func(a cartevent.Access) bool {
+			return a.Action == string(rbac.ActionListCarts) && a.Owner == ""
+		})).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{Access: accessRepository})
+
+		resp := cartUseCase.ListCarts(support, cartsummary.Filter{Q: "A", Page: 1})
+
+		assert.NoError(t, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+		accessRepository.AssertExpectations(t)
+	})
+
+	t.Run("Customer Cannot List Carts", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		resp := cartUseCase.ListCarts(customer, cartsummary.Filter{Page: 1})
+
+		assert.Equal(t, exception.ErrForbidden, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Catalog", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindCatalog", mock.Anything).Return(nil, nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		resp := cartUseCase.Catalog(support)
+
+		assert.NoError(t, resp.Err())
+		assert.Equal(t, []catalog.Entry{}, resp.(*response.ResponseImpl).Data)
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Customer Cannot Read Catalog", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		resp := cartUseCase.Catalog(customer)
+
+		assert.Equal(t, exception.ErrForbidden, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Admin Expires Cart", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindAll", mock.Anything).Return([]product.Product{
+			{ID: 1, KodeProduk: "A", Kuantitas: 2},
+			{ID: 2, KodeProduk: "B", Kuantitas: 3},
+		}, nil)
+		cartRepository.On("DeleteBatch", mock.Anything, []int64{1, 2}).Return(nil)
+
+		auditRepository := new(mocks.AuditRepository)
+		auditRepository.On("Append", mock.Anything, mock.MatchedBy(func(e cartevent.Event) bool {
+			return e.Action == cartevent.ActionExpire && e.Actor == "user:1"
+		}), mock.Anything).Return(nil)
+
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("Append", mock.Anything, mock.MatchedBy(func(e event.Envelope) bool {
+			return e.Type == event.TypeItemRemoved
+		}), mock.Anything).Return(nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, auditRepository, outboxRepository, newTxManager(), cart.Options{})
+
+		resp := cartUseCase.Expire(rbac.WithCart(admin, "user:42"))
+
+		assert.NoError(t, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+		auditRepository.AssertExpectations(t)
+		outboxRepository.AssertExpectations(t)
+	})
+
+	t.Run("Support Cannot Expire Cart", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		resp := cartUseCase.Expire(rbac.WithCart(support, "user:42"))
+
+		assert.Equal(t, exception.ErrForbidden, resp.Err())
+
+		cartRepository.AssertExpectations(t)
+	})
+
+	t.Run("Authorize Line Of Another Cart", func(t *testing.T) {
+		cartRepository := new(mocks.CartRepository)
+		cartRepository.On("FindOwner", mock.Anything, int64(1)).Return("user:9", nil)
+
+		cartUseCase := cart.NewCartUseCaseImpl(cartRepository, newAuditRepository(), newOutboxRepository(), newTxManager(), cart.Options{})
+
+		assert.Equal(t, exception.ErrNotFound, cartUseCase.AuthorizeLine(customer, 1))
+		assert.NoError(t, cartUseCase.AuthorizeLine(support, 1))
+
+		cartRepository.AssertExpectations(t)
+	})
+}
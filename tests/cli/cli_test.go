@@ -0,0 +1,359 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/cli"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/catalog"
+	"github.com/Risuii/models/filter"
+	"github.com/Risuii/models/product"
+	"github.com/Risuii/tests/mocks"
+)
+
+// asOperator matches a context run by the operator, on the cart of owner
+// unless it is empty.
+func asOperator(owner string) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		p, ok := auth.From(ctx)
+
+		return ok && p.Kind == auth.KindOperator && p.Subject == "budi" && (owner == "" || rbac.Cart(ctx) == owner)
+	})
+}
+
+func dependencies(cartUseCase *mocks.CartUseCase, migrator *mocks.Migrator, out *bytes.Buffer) cli.Dependencies {
+	return cli.Dependencies{
+		Validate: validator.New(),
+		Cart:     cartUseCase,
+		Migrator: migrator,
+		Operator: "budi",
+		Out:      out,
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Run("Run Without Command", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := cli.Run(context.TODO(), dependencies(new(mocks.CartUseCase), new(mocks.Migrator), &out), nil)
+
+		assert.ErrorIs(t, err, cli.ErrUsage)
+	})
+
+	t.Run("Run Unknown Command", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := cli.Run(context.TODO(), dependencies(new(mocks.CartUseCase), new(mocks.Migrator), &out), []string{"catalog", "sync"})
+
+		assert.ErrorIs(t, err, cli.ErrUsage)
+	})
+
+	t.Run("Run Unknown Output", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := cli.Run(context.TODO(), dependencies(new(mocks.CartUseCase), new(mocks.Migrator), &out), []string{"purge-expired", "-o", "yaml"})
+
+		assert.ErrorIs(t, err, cli.ErrUsage)
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("Migrate Up Prints Applied Versions", func(t *testing.T) {
+		var out bytes.Buffer
+		migrator := new(mocks.Migrator)
+		migrator.On("Up", mock.Anything).Return([]int64{7, 8}, nil)
+
+		err := cli.Run(context.TODO(), dependencies(new(mocks.CartUseCase), migrator, &out), []string{"migrate"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "version  direction\n7        up\n8        up\n", out.String())
+		migrator.AssertExpectations(t)
+	})
+
+	t.Run("Migrate Down Steps", func(t *testing.T) {
+		var out bytes.Buffer
+		migrator := new(mocks.Migrator)
+		migrator.On("Down", mock.Anything, 2).Return([]int64{8, 7}, nil)
+
+		err := cli.Run(context.TODO(), dependencies(new(mocks.CartUseCase), migrator, &out), []string{"migrate", "down", "-steps", "2"})
+
+		assert.NoError(t, err)
+		migrator.AssertExpectations(t)
+	})
+
+	t.Run("Migrate Version As JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		migrator := new(mocks.Migrator)
+		migrator.On("Version", mock.Anything).Return(int64(8), true, nil)
+
+		err := cli.Run(context.TODO(), dependencies(new(mocks.CartUseCase), migrator, &out), []string{"migrate", "version", "-o", "json"})
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"version": 8, "dirty": true}`, out.String())
+		migrator.AssertExpectations(t)
+	})
+}
+
+func TestCarts(t *testing.T) {
+	t.Run("Carts List As JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		list := cartsummary.List{Carts: []cartsummary.Summary{{Owner: "user:42", Lines: 2}}, Page: 2, PageSize: 5, Total: 6}
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("ListCarts", asOperator(""), cartsummary.Filter{Q: "kopi", Page: 2, PageSize: 5}).
+			Return(response.Success(response.StatusOK, list))
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out),
+			[]string{"carts", "list", "-q", "kopi", "-page", "2", "-page-size", "5", "-o", "json"})
+
+		assert.NoError(t, err)
+
+		var printed cartsummary.List
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &printed))
+		assert.Equal(t, list.Total, printed.Total)
+		assert.Equal(t, "user:42", printed.Carts[0].Owner)
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Carts Show Of Owner", func(t *testing.T) {
+		var out bytes.Buffer
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("GetItems", asOperator("user:42"), filter.Filter{}).
+			Return(response.Success(response.StatusOK, []product.Product{{ID: 1, KodeProduk: "KOPI-01", Nama: "Kopi Susu", Kuantitas: 2}}))
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"carts", "show", "user:42"})
+
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), "kodeProduk")
+		assert.Contains(t, out.String(), "KOPI-01")
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Carts Show Empty Cart", func(t *testing.T) {
+		var out bytes.Buffer
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("GetItems", asOperator("user:42"), filter.Filter{}).
+			Return(response.Error(response.StatusNotFound, exception.ErrNotFound))
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"carts", "show", "user:42", "-o", "json"})
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `[]`, out.String())
+	})
+
+	t.Run("Carts Show Without Owner", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := cli.Run(context.TODO(), dependencies(new(mocks.CartUseCase), new(mocks.Migrator), &out), []string{"carts", "show"})
+
+		assert.ErrorIs(t, err, cli.ErrUsage)
+	})
+
+	t.Run("Carts Clear Error", func(t *testing.T) {
+		var out bytes.Buffer
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("Expire", asOperator("user:42")).
+			Return(response.Error(response.StatusUnprocessableEntity, exception.ErrCartEmpty))
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"carts", "clear", "user:42"})
+
+		assert.Equal(t, exception.ErrCartEmpty, err)
+		cartUseCase.AssertExpectations(t)
+	})
+}
+
+func TestSeed(t *testing.T) {
+	t.Run("Seed Sample Lines", func(t *testing.T) {
+		var out bytes.Buffer
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("BatchItems", asOperator("user:42"), cli.SampleLines.Batch(), "").
+			Return(response.Success(response.StatusOK, []batch.Result{{Op: batch.OpSet, KodeProduk: "KOPI-01", Status: "applied"}}))
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"seed", "-owner", "user:42"})
+
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), "KOPI-01")
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Seed From File", func(t *testing.T) {
+		var out bytes.Buffer
+		path := filepath.Join(t.TempDir(), "seed.json")
+		raw := `{"operations": [{"op": "add", "kodeProduk": "SUSU-01", "nama": "Susu", "kuantitas": 1}]}`
+		if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("BatchItems", asOperator(rbac.CartAnonymous), batch.Batch{
+			Operations: []batch.Operation{{Op: batch.OpAdd, KodeProduk: "SUSU-01", Nama: "Susu", Kuantitas: 1}},
+		}, "").Return(response.Success(response.StatusOK, []batch.Result{}))
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"seed", "-file", path})
+
+		assert.NoError(t, err)
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Seed Invalid File", func(t *testing.T) {
+		var out bytes.Buffer
+		path := filepath.Join(t.TempDir(), "seed.json")
+		if err := os.WriteFile(path, []byte(`{"operations": []}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		cartUseCase := new(mocks.CartUseCase)
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"seed", "-file", path})
+
+		assert.ErrorIs(t, err, cli.ErrUsage)
+		cartUseCase.AssertExpectations(t)
+	})
+}
+
+func TestPurgeExpired(t *testing.T) {
+	t.Run("Purge Expired Prints Count", func(t *testing.T) {
+		var out bytes.Buffer
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("PurgeDeleted", mock.Anything).Return(int64(12), nil)
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"purge-expired", "-o", "json"})
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"purged": 12}`, out.String())
+		cartUseCase.AssertExpectations(t)
+	})
+}
+
+func TestCatalog(t *testing.T) {
+	entries := []catalog.Entry{
+		{KodeProduk: "KOPI-01", Nama: "Kopi Susu"},
+		{KodeProduk: "TEH-01", Nama: "Teh Manis"},
+		{KodeProduk: "ROTI-01", Nama: "Roti, Bakar"},
+	}
+
+	t.Run("Export CSV", func(t *testing.T) {
+		var out bytes.Buffer
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("Catalog", asOperator("")).Return(response.Success(response.StatusOK, entries))
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"catalog", "export", "-o", "csv"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "kodeProduk,nama\nKOPI-01,Kopi Susu\nTEH-01,Teh Manis\nROTI-01,\"Roti, Bakar\"\n", out.String())
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Only Export Prints CSV", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := cli.Run(context.TODO(), dependencies(new(mocks.CartUseCase), new(mocks.Migrator), &out), []string{"purge-expired", "-o", "csv"})
+
+		assert.ErrorIs(t, err, cli.ErrUsage)
+	})
+
+	t.Run("Import What Export Wrote", func(t *testing.T) {
+		var exported bytes.Buffer
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("Catalog", asOperator("")).Return(response.Success(response.StatusOK, entries))
+
+		if err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &exported), []string{"catalog", "export", "-o", "csv"}); err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(t.TempDir(), "catalog.csv")
+		if err := os.WriteFile(path, exported.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		// two batches: the indexes of the second continue from the first
+		cartUseCase.On("BatchItems", asOperator("user:42"), batch.Batch{
+			Mode: batch.ModeAtomic,
+			Operations: []batch.Operation{
+				{Op: batch.OpSet, KodeProduk: "KOPI-01", Nama: "Kopi Susu", Kuantitas: 2},
+				{Op: batch.OpSet, KodeProduk: "TEH-01", Nama: "Teh Manis", Kuantitas: 2},
+			},
+		}, "").Return(response.Success(response.StatusOK, []batch.Result{
+			{Index: 0, Op: batch.OpSet, KodeProduk: "KOPI-01", Status: "applied"},
+			{Index: 1, Op: batch.OpSet, KodeProduk: "TEH-01", Status: "applied"},
+		}))
+		cartUseCase.On("BatchItems", asOperator("user:42"), batch.Batch{
+			Mode:       batch.ModeAtomic,
+			Operations: []batch.Operation{{Op: batch.OpSet, KodeProduk: "ROTI-01", Nama: "Roti, Bakar", Kuantitas: 2}},
+		}, "").Return(response.Success(response.StatusOK, []batch.Result{
+			{Index: 0, Op: batch.OpSet, KodeProduk: "ROTI-01", Status: "applied"},
+		}))
+
+		var out bytes.Buffer
+		deps := dependencies(cartUseCase, new(mocks.Migrator), &out)
+		deps.BatchSize = 2
+
+		err := cli.Run(context.TODO(), deps, []string{"catalog", "import", "-file", path, "-owner", "user:42", "-kuantitas", "2", "-o", "json"})
+
+		assert.NoError(t, err)
+
+		var results []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &results))
+		assert.Len(t, results, 3)
+		assert.Equal(t, float64(2), results[2]["index"])
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Import JSON Stops At Failed Batch", func(t *testing.T) {
+		var out bytes.Buffer
+		path := filepath.Join(t.TempDir(), "catalog.json")
+		if err := os.WriteFile(path, []byte(`[{"kodeProduk": "SUSU-01", "nama": "Susu"}]`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		cartUseCase := new(mocks.CartUseCase)
+		cartUseCase.On("BatchItems", asOperator(rbac.CartAnonymous), batch.Batch{
+			Mode:       batch.ModeAtomic,
+			Operations: []batch.Operation{{Op: batch.OpSet, KodeProduk: "SUSU-01", Nama: "Susu", Kuantitas: 1}},
+		}, "").Return(response.ErrorWithData(response.StatusUnprocessableEntity, exception.ErrBatchFailed, []batch.Result{
+			{Op: batch.OpSet, KodeProduk: "SUSU-01", Status: "failed", Error: "conflict"},
+		}))
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"catalog", "import", "-file", path})
+
+		assert.ErrorIs(t, err, exception.ErrBatchFailed)
+		assert.Contains(t, out.String(), "SUSU-01")
+		cartUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Import Without Nama", func(t *testing.T) {
+		var out bytes.Buffer
+		path := filepath.Join(t.TempDir(), "catalog.csv")
+		if err := os.WriteFile(path, []byte("kodeProduk,nama\nSUSU-01,\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		cartUseCase := new(mocks.CartUseCase)
+
+		err := cli.Run(context.TODO(), dependencies(cartUseCase, new(mocks.Migrator), &out), []string{"catalog", "import", "-file", path})
+
+		assert.ErrorIs(t, err, cli.ErrUsage)
+		cartUseCase.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,156 @@
+package compress_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/compress"
+)
+
+func serve(t *testing.T, handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	compress.Middleware(compress.Options{MinBytes: 64, Level: -1})(handler).ServeHTTP(w, r)
+
+	return w
+}
+
+func get(acceptEncoding string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	return r
+}
+
+func body(text string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, text)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("a", 200) + `"}`
+
+	t.Run("Compresses Above The Threshold", func(t *testing.T) {
+		w := serve(t, body(large), get("gzip"))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Equal(t, `W/"v1"`, w.Header().Get("ETag"))
+
+		reader, err := gzip.NewReader(w.Body)
+		assert.NoError(t, err)
+		plain, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, large, string(plain))
+	})
+
+	t.Run("Prefers Brotli", func(t *testing.T) {
+		w := serve(t, body(large), get("gzip, deflate, br"))
+
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+
+		decoded, err := io.ReadAll(brotli.NewReader(w.Body))
+		assert.NoError(t, err)
+		assert.Equal(t, large, string(decoded))
+	})
+
+	t.Run("Leaves Small Bodies Alone", func(t *testing.T) {
+		w := serve(t, body(`{}`), get("gzip"))
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, `"v1"`, w.Header().Get("ETag"))
+		assert.Equal(t, `{}`, w.Body.String())
+	})
+
+	t.Run("Without Accept-Encoding", func(t *testing.T) {
+		w := serve(t, body(large), get(""))
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("Not Event Streams", func(t *testing.T) {
+		w := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: "+large+"\n\n")
+			w.(http.Flusher).Flush()
+		}, get("gzip"))
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.True(t, w.Flushed)
+		assert.Contains(t, w.Body.String(), large)
+	})
+
+	t.Run("Not Already Encoded Bodies", func(t *testing.T) {
+		w := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "identity")
+			_, _ = io.WriteString(w, large)
+		}, get("gzip"))
+
+		assert.Equal(t, "identity", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("Not Upgrades", func(t *testing.T) {
+		r := get("gzip")
+		r.Header.Set("Upgrade", "websocket")
+
+		w := serve(t, body(large), r)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Header().Get("Vary"))
+	})
+
+	t.Run("Not Modified", func(t *testing.T) {
+		w := serve(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotModified)
+		}, get("gzip"))
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+}
+
+func TestNegotiate(t *testing.T) {
+	codecs := []compress.Codec{compress.Gzip, compress.Deflate}
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "gzip", want: "gzip"},
+		{header: "deflate", want: "deflate"},
+		{header: "gzip, deflate", want: "gzip"},
+		{header: "gzip;q=0.5, deflate", want: "deflate"},
+		{header: "br, *;q=0.1", want: "gzip"},
+		{header: "*, gzip;q=0", want: "deflate"},
+		{header: "identity", want: ""},
+		{header: "gzip;q=0, deflate;q=0", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.header, func(t *testing.T) {
+			got := ""
+			if codec := compress.Negotiate(test.header, codecs); codec != nil {
+				got = codec.Name()
+			}
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/config"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_USERNAME", "root")
+	t.Setenv("DB_DATABASE_NAME", "Haioo")
+}
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Load Defaults", func(t *testing.T) {
+		setRequiredEnv(t)
+
+		cfg, err := config.Load(nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 8080, cfg.App.Port)
+		assert.Equal(t, 10*time.Second, cfg.Server.ReadTimeout)
+		assert.Contains(t, cfg.Database.DSN, "loc=Asia%2FJakarta")
+		assert.Contains(t, cfg.Database.DSN, "parseTime=true")
+		assert.Equal(t, "none", cfg.Outbox.Publisher)
+	})
+
+	t.Run("Load Layers In Order", func(t *testing.T) {
+		setRequiredEnv(t)
+		path := writeFile(t, "app:\n  port: 7000\nserver:\n  readTimeout: 3s\n  writeTimeout: 4s\n")
+		t.Setenv("SERVER_READ_TIMEOUT", "30s")
+
+		cfg, err := config.Load([]string{"-config", path, "-port", "9000"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 9000, cfg.App.Port)
+		assert.Equal(t, 30*time.Second, cfg.Server.ReadTimeout)
+		assert.Equal(t, 4*time.Second, cfg.Server.WriteTimeout)
+	})
+
+	t.Run("Load Config File From Env", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv(config.EnvConfigFile, writeFile(t, "features:\n  requestTracing: false\n"))
+
+		cfg, err := config.Load(nil)
+
+		assert.NoError(t, err)
+		assert.False(t, cfg.Features.RequestTracing)
+	})
+
+	t.Run("Load Unknown File Key Error", func(t *testing.T) {
+		setRequiredEnv(t)
+		path := writeFile(t, "app:\n  prot: 7000\n")
+
+		_, err := config.Load([]string{"-config", path})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Load Reports Every Problem", func(t *testing.T) {
+		t.Setenv("DB_HOST", "")
+		t.Setenv("DB_USERNAME", "")
+		t.Setenv("DB_DATABASE_NAME", "")
+
+		_, err := config.Load([]string{"-tls", "-tracing-exporter", "zipkin", "-db-max-open-conns", "5", "-db-max-idle-conns", "10"})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "database.host is required")
+		assert.Contains(t, err.Error(), "database.name is required")
+		assert.Contains(t, err.Error(), "tls.certFile is required")
+		assert.Contains(t, err.Error(), "tracing.exporter must be one of")
+		assert.Contains(t, err.Error(), "database.maxIdleConns must not exceed")
+	})
+
+	t.Run("Load Reports Problems Of Every Layer", func(t *testing.T) {
+		t.Setenv("DB_HOST", "")
+		t.Setenv("DB_USERNAME", "root")
+		t.Setenv("DB_DATABASE_NAME", "Haioo")
+		t.Setenv("DB_PORT", "abc")
+		path := writeFile(t, "app:\n  prot: 7000\n")
+
+		_, err := config.Load([]string{"-config", path, "-grpc-port", "x", "-nope"})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "flag provided but not defined: -nope")
+		assert.Contains(t, err.Error(), "prot")
+		assert.Contains(t, err.Error(), "DB_PORT")
+		assert.Contains(t, err.Error(), "flag -grpc-port")
+		assert.Contains(t, err.Error(), "database.host is required")
+	})
+
+	t.Run("Load Retention Shorter Than Restore Window", func(t *testing.T) {
+		setRequiredEnv(t)
+
+		_, err := config.Load([]string{"-cart-restore-window", "48h", "-cart-deleted-retention", "24h"})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "cart.deletedRetention must not be shorter than cart.restoreWindow")
+	})
+
+	t.Run("Load Invalid Env Value", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("DB_PORT", "abc")
+
+		_, err := config.Load(nil)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_PORT")
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Parse Returns The Command", func(t *testing.T) {
+		setRequiredEnv(t)
+
+		cfg, rest, err := config.Parse([]string{"-port", "9000", "carts", "list", "-q", "kopi"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 9000, cfg.App.Port)
+		assert.Equal(t, []string{"carts", "list", "-q", "kopi"}, rest)
+	})
+
+	t.Run("Parse Without Command", func(t *testing.T) {
+		setRequiredEnv(t)
+
+		_, rest, err := config.Parse([]string{"-port", "9000"})
+
+		assert.NoError(t, err)
+		assert.Empty(t, rest)
+	})
+}
+
+func TestPrint(t *testing.T) {
+	t.Run("Print Redacts Secrets", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("DB_PASSWORD", "hunter2")
+
+		cfg, err := config.Load(nil)
+		assert.NoError(t, err)
+
+		var out bytes.Buffer
+		err = config.Print(&out, cfg)
+
+		assert.NoError(t, err)
+		assert.NotContains(t, out.String(), "hunter2")
+		assert.Contains(t, out.String(), "password: '******'")
+		assert.Equal(t, "hunter2", cfg.Database.Password)
+	})
+}
@@ -0,0 +1,142 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/cors"
+)
+
+var options = cors.Options{
+	AllowedOrigins:   []string{"https://shop.example.com", "https://*.preview.example.com"},
+	AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+	AllowedHeaders:   []string{"Content-Type", "If-Match"},
+	ExposedHeaders:   []string{"ETag", "X-Request-Id"},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
+func serve(t *testing.T, opts cors.Options, r *http.Request) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+
+	reached := false
+	handler, err := cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return w, reached
+}
+
+func preflight(origin, method, headers string) *http.Request {
+	r := httptest.NewRequest(http.MethodOptions, "/v1/cart/items", nil)
+	r.Header.Set("Origin", origin)
+	r.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		r.Header.Set("Access-Control-Request-Headers", headers)
+	}
+
+	return r
+}
+
+func TestPreflight(t *testing.T) {
+	t.Run("Allowed", func(t *testing.T) {
+		w, reached := serve(t, options, preflight("https://shop.example.com", http.MethodDelete, "content-type, if-match"))
+
+		assert.False(t, reached)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://shop.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "GET, POST, DELETE", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "content-type, if-match", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Contains(t, w.Header().Get("Vary"), "Origin")
+	})
+
+	tests := []struct {
+		name    string
+		request *http.Request
+	}{
+		{name: "Unknown Origin", request: preflight("https://evil.example", http.MethodGet, "")},
+		{name: "Method Not Allowed", request: preflight("https://shop.example.com", http.MethodPut, "")},
+		{name: "Header Not Allowed", request: preflight("https://shop.example.com", http.MethodPost, "Content-Type, X-Secret")},
+		{name: "Wildcard Needs A Label", request: preflight("https://preview.example.com", http.MethodGet, "")},
+		{name: "Wildcard Is One Label", request: preflight("https://a.b/.preview.example.com", http.MethodGet, "")},
+	}
+
+	for _, test := range tests {
+		t.Run("Refused "+test.name, func(t *testing.T) {
+			w, reached := serve(t, options, test.request)
+
+			assert.False(t, reached)
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+		})
+	}
+}
+
+func TestRequest(t *testing.T) {
+	get := func(origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/v1/cart/items", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+
+		return r
+	}
+
+	t.Run("Allowed Subdomain", func(t *testing.T) {
+		w, reached := serve(t, options, get("https://pr-12.preview.example.com"))
+
+		assert.True(t, reached)
+		assert.Equal(t, "https://pr-12.preview.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "ETag, X-Request-Id", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+
+	t.Run("Unknown Origin Still Reaches The Handler", func(t *testing.T) {
+		w, reached := serve(t, options, get("https://evil.example"))
+
+		assert.True(t, reached)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Same Origin", func(t *testing.T) {
+		w, reached := serve(t, options, get(""))
+
+		assert.True(t, reached)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Any Origin", func(t *testing.T) {
+		w, _ := serve(t, cors.Options{AllowedOrigins: []string{"*"}}, get("https://anywhere.example"))
+
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("Plain OPTIONS Reaches The Handler", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodOptions, "/v1/cart/items", nil)
+		r.Header.Set("Origin", "https://shop.example.com")
+
+		_, reached := serve(t, options, r)
+
+		assert.True(t, reached)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	_, err := cors.Handler(http.NotFoundHandler(), cors.Options{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/database"
+)
+
+func TestWithTimeout(t *testing.T) {
+	t.Run("Applies Ceiling Without Request Deadline", func(t *testing.T) {
+		ctx, cancel := database.WithTimeout(context.TODO(), time.Second)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("Keeps Sooner Request Deadline", func(t *testing.T) {
+		parent, cancelParent := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+		defer cancelParent()
+
+		ctx, cancel := database.WithTimeout(parent, time.Minute)
+		defer cancel()
+
+		parentDeadline, _ := parent.Deadline()
+		deadline, ok := ctx.Deadline()
+
+		assert.True(t, ok)
+		assert.Equal(t, parentDeadline, deadline)
+	})
+
+	t.Run("Zero Ceiling Leaves Context Open", func(t *testing.T) {
+		ctx, cancel := database.WithTimeout(context.TODO(), 0)
+		defer cancel()
+
+		_, ok := ctx.Deadline()
+
+		assert.False(t, ok)
+	})
+}
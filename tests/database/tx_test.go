@@ -0,0 +1,150 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/helpers/database"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/internal/cart"
+	"github.com/Risuii/tests/mock"
+)
+
+func TestWithinTransaction(t *testing.T) {
+	t.Run("Commit On Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		txManager := database.NewTxManagerImpl(db, sql.LevelDefault, 0, 0)
+
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE cart`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := txManager.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+			_, err := database.Conn(ctx, db).ExecContext(ctx, `UPDATE cart SET kuantitas = 1`)
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback On Error", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		txManager := database.NewTxManagerImpl(db, sql.LevelDefault, 3, 0)
+
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := txManager.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+			return exception.ErrNotFound
+		})
+
+		assert.Equal(t, exception.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retry On Deadlock", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		txManager := database.NewTxManagerImpl(db, sql.LevelDefault, 3, time.Millisecond)
+
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE cart`).WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"})
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE cart`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		attempts := 0
+		err := txManager.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+			attempts++
+			if _, err := database.Conn(ctx, db).ExecContext(ctx, `UPDATE cart SET kuantitas = 1`); err != nil {
+				return database.Error(err)
+			}
+
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Give Up After Max Retries", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		txManager := database.NewTxManagerImpl(db, sql.LevelDefault, 1, 0)
+
+		defer db.Close()
+
+		deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+		for i := 0; i < 2; i++ {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+
+		err := txManager.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+			return database.Error(deadlock)
+		})
+
+		assert.True(t, errors.Is(err, exception.ErrInternalServer))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Nested Call Joins Transaction", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		txManager := database.NewTxManagerImpl(db, sql.LevelDefault, 0, 0)
+
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err := txManager.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+			return txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				assert.True(t, database.InTransaction(ctx))
+				return nil
+			})
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Repository Uses Transaction From Context", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		txManager := database.NewTxManagerImpl(db, sql.LevelDefault, 0, 0)
+		repo := cart.NewCartRepositoryImpl(db, constant.TableCart, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, nama, kodeProduk, kuantitas, created_at, update_at, version FROM %s WHERE kodeProduk = \? AND deleted_at IS NULL AND owner = \? FOR UPDATE`, constant.TableCart)
+		rows := sqlmock.NewRows([]string{"id", "nama", "kodeProduk", "kuantitas", "created_at", "update_at", "version"}).AddRow(1, "test", "test", 1, time.Now(), time.Now(), 1)
+
+		mock.ExpectBegin()
+		mock.ExpectPrepare(query).ExpectQuery().WithArgs("test", rbac.CartAnonymous).WillReturnRows(rows)
+		mock.ExpectCommit()
+
+		err := txManager.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+			_, err := repo.FindByKodeProduk(ctx, "test")
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
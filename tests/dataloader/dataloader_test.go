@@ -0,0 +1,111 @@
+package dataloader_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/dataloader"
+)
+
+func TestLoader(t *testing.T) {
+	t.Run("Load Batches Pending Keys", func(t *testing.T) {
+		var calls [][]int
+		loader := dataloader.New(func(ctx context.Context, keys []int) (map[int]string, error) {
+			calls = append(calls, keys)
+			return map[int]string{1: "one", 2: "two"}, nil
+		})
+
+		one := loader.Load(context.TODO(), 1)
+		two := loader.Load(context.TODO(), 2)
+		three := loader.Load(context.TODO(), 3)
+		again := loader.Load(context.TODO(), 1)
+
+		value, err := one()
+		assert.NoError(t, err)
+		assert.Equal(t, "one", value)
+
+		value, _ = two()
+		assert.Equal(t, "two", value)
+
+		value, _ = three()
+		assert.Equal(t, "", value)
+
+		value, _ = again()
+		assert.Equal(t, "one", value)
+
+		assert.Equal(t, [][]int{{1, 2, 3}}, calls)
+		assert.Equal(t, 1, loader.Batches())
+	})
+
+	t.Run("Wait Batches Concurrent Loads", func(t *testing.T) {
+		var calls [][]int
+		loader := dataloader.NewWithWait(func(ctx context.Context, keys []int) (map[int]string, error) {
+			calls = append(calls, keys)
+			return map[int]string{}, nil
+		}, 50*time.Millisecond)
+
+		// each goroutine runs its thunk right away, as a resolver would
+		var wg sync.WaitGroup
+		for key := 1; key <= 3; key++ {
+			wg.Add(1)
+			go func(key int) {
+				defer wg.Done()
+				loader.Load(context.TODO(), key)()
+			}(key)
+		}
+		wg.Wait()
+
+		assert.Len(t, calls, 1)
+		sort.Ints(calls[0])
+		assert.Equal(t, []int{1, 2, 3}, calls[0])
+	})
+
+	t.Run("Load Cached Key", func(t *testing.T) {
+		loader := dataloader.New(func(ctx context.Context, keys []int) (map[int]string, error) {
+			return map[int]string{1: "one"}, nil
+		})
+
+		loader.Load(context.TODO(), 1)()
+		loader.Load(context.TODO(), 1)()
+
+		assert.Equal(t, 1, loader.Batches())
+	})
+
+	t.Run("Prime Skips The Batch", func(t *testing.T) {
+		loader := dataloader.New(func(ctx context.Context, keys []int) (map[int]string, error) {
+			t.Fatal("batch called")
+			return nil, nil
+		})
+
+		loader.Prime(1, "one")
+
+		value, err := loader.Load(context.TODO(), 1)()
+		assert.NoError(t, err)
+		assert.Equal(t, "one", value)
+	})
+
+	t.Run("Failed Batch Is Retried", func(t *testing.T) {
+		fail := true
+		loader := dataloader.New(func(ctx context.Context, keys []int) (map[int]string, error) {
+			if fail {
+				return nil, errors.New("boom")
+			}
+			return map[int]string{1: "one"}, nil
+		})
+
+		_, err := loader.Load(context.TODO(), 1)()
+		assert.EqualError(t, err, "boom")
+
+		fail = false
+		value, err := loader.Load(context.TODO(), 1)()
+		assert.NoError(t, err)
+		assert.Equal(t, "one", value)
+		assert.Equal(t, 2, loader.Batches())
+	})
+}
@@ -0,0 +1,68 @@
+package event_test
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/models/event"
+)
+
+type schemaDoc struct {
+	Required   []string `json:"required"`
+	Properties struct {
+		Type struct {
+			Const string `json:"const"`
+		} `json:"type"`
+		Data struct {
+			Required []string `json:"required"`
+		} `json:"data"`
+	} `json:"properties"`
+}
+
+func TestSchemas(t *testing.T) {
+	payloads := map[string]interface{}{
+		event.TypeItemAdded:       event.ItemAdded{},
+		event.TypeQuantityChanged: event.QuantityChanged{},
+		event.TypeItemRemoved:     event.ItemRemoved{},
+		event.TypeCartCheckedOut:  event.CartCheckedOut{},
+		event.TypeCartAbandoned:   event.CartAbandoned{},
+	}
+
+	for _, eventType := range event.Types {
+		t.Run(eventType, func(t *testing.T) {
+			raw, err := event.Schema(eventType, event.Version)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var schema schemaDoc
+			if !assert.NoError(t, json.Unmarshal(raw, &schema)) {
+				return
+			}
+
+			envelope, err := event.New(eventType, payloads[eventType], time.Now())
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var data map[string]interface{}
+			assert.NoError(t, json.Unmarshal(envelope.Data, &data))
+
+			keys := make([]string, 0, len(data))
+			for key := range data {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+			sort.Strings(schema.Properties.Data.Required)
+
+			assert.Equal(t, eventType, schema.Properties.Type.Const)
+			assert.Equal(t, schema.Properties.Data.Required, keys)
+			assert.NotEmpty(t, envelope.ID)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package health_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/health"
+	models "github.com/Risuii/models/health"
+	"github.com/Risuii/tests/mocks"
+)
+
+func TestHandler_Readiness(t *testing.T) {
+	t.Run("Readiness Success", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, models.Report{Status: models.StatusUp})
+
+		healthUseCase := new(mocks.HealthUseCase)
+		healthUseCase.On("Readiness", mock.Anything).Return(resp)
+
+		healthHandler := health.HealthHandler{
+			UseCase: healthUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(healthHandler.Readiness)
+		handler.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, response.StatusOK, rb.Status)
+	})
+
+	t.Run("Readiness Unavailable Keeps Breakdown", func(t *testing.T) {
+		report := models.Report{
+			Status: models.StatusDown,
+			Checks: []models.Check{{Name: "database", Status: models.StatusDown}},
+		}
+		resp := response.ErrorWithData(response.StatusServiceUnavailable, exception.ErrServiceUnavailable, report)
+
+		healthUseCase := new(mocks.HealthUseCase)
+		healthUseCase.On("Readiness", mock.Anything).Return(resp)
+
+		healthHandler := health.HealthHandler{
+			UseCase: healthUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(healthHandler.Readiness)
+		handler.ServeHTTP(recorder, r)
+
+		rb := struct {
+			Status string        `json:"status"`
+			Data   models.Report `json:"data"`
+		}{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Equal(t, response.StatusServiceUnavailable, rb.Status)
+		assert.Equal(t, "database", rb.Data.Checks[0].Name)
+	})
+}
+
+func TestHandler_Liveness(t *testing.T) {
+	t.Run("Liveness Success", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, models.Report{Status: models.StatusUp})
+
+		healthUseCase := new(mocks.HealthUseCase)
+		healthUseCase.On("Liveness", mock.Anything).Return(resp)
+
+		healthHandler := health.HealthHandler{
+			UseCase: healthUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		recorder := httptest.NewRecorder()
+
+		handler := http.HandlerFunc(healthHandler.Liveness)
+		handler.ServeHTTP(recorder, r)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
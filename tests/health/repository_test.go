@@ -0,0 +1,50 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/internal/health"
+	"github.com/Risuii/tests/mock"
+)
+
+func TestMigrationVersionRepository(t *testing.T) {
+	t.Run("Migration Version Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := health.NewHealthRepositoryImpl(db, constant.TableSchemaMigrations)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT version, dirty FROM %s`, constant.TableSchemaMigrations)
+		rows := sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false)
+
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		version, dirty, err := repo.MigrationVersion(context.TODO())
+
+		assert.Equal(t, int64(1), version)
+		assert.False(t, dirty)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Migration Version Error", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := health.NewHealthRepositoryImpl(db, constant.TableSchemaMigrations)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT version, dirty FROM %s`, constant.TableSchemaMigrations)
+
+		mock.ExpectQuery(query).WillReturnError(errors.New("table doesn't exist"))
+
+		_, _, err := repo.MigrationVersion(context.TODO())
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,115 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/health"
+	models "github.com/Risuii/models/health"
+	"github.com/Risuii/tests/mocks"
+)
+
+func TestUseCaseLiveness(t *testing.T) {
+	t.Run("Liveness Does Not Touch Dependencies", func(t *testing.T) {
+		ctx := context.TODO()
+
+		healthRepository := new(mocks.HealthRepository)
+
+		healthUseCase := health.NewHealthUseCaseImpl(healthRepository, 1, time.Second)
+
+		resp := healthUseCase.Liveness(ctx)
+
+		assert.NoError(t, resp.Err())
+
+		healthRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseReadiness(t *testing.T) {
+	t.Run("Readiness Success", func(t *testing.T) {
+		ctx := context.TODO()
+
+		healthRepository := new(mocks.HealthRepository)
+		healthRepository.On("Ping", mock.Anything).Return(nil)
+		healthRepository.On("MigrationVersion", mock.Anything).Return(int64(1), false, nil)
+
+		healthUseCase := health.NewHealthUseCaseImpl(healthRepository, 1, time.Second)
+
+		resp := healthUseCase.Readiness(ctx)
+
+		assert.NoError(t, resp.Err())
+
+		report := resp.(*response.ResponseImpl).Data.(models.Report)
+		assert.Equal(t, models.StatusUp, report.Status)
+		assert.Len(t, report.Checks, 2)
+
+		healthRepository.AssertExpectations(t)
+	})
+
+	t.Run("Readiness Database Down", func(t *testing.T) {
+		ctx := context.TODO()
+
+		healthRepository := new(mocks.HealthRepository)
+		healthRepository.On("Ping", mock.Anything).Return(errors.New("connection refused"))
+		healthRepository.On("MigrationVersion", mock.Anything).Return(int64(1), false, nil)
+
+		healthUseCase := health.NewHealthUseCaseImpl(healthRepository, 1, time.Second)
+
+		resp := healthUseCase.Readiness(ctx)
+
+		assert.Error(t, resp.Err())
+
+		report := resp.(*response.ResponseImpl).Data.(models.Report)
+		assert.Equal(t, models.StatusDown, report.Status)
+		assert.Equal(t, models.StatusDown, report.Checks[0].Status)
+		assert.Equal(t, "connection refused", report.Checks[0].Error)
+
+		healthRepository.AssertExpectations(t)
+	})
+
+	t.Run("Readiness Migration Mismatch", func(t *testing.T) {
+		ctx := context.TODO()
+
+		healthRepository := new(mocks.HealthRepository)
+		healthRepository.On("Ping", mock.Anything).Return(nil)
+		healthRepository.On("MigrationVersion", mock.Anything).Return(int64(1), false, nil)
+
+		healthUseCase := health.NewHealthUseCaseImpl(healthRepository, 2, time.Second)
+
+		resp := healthUseCase.Readiness(ctx)
+
+		assert.Error(t, resp.Err())
+
+		healthRepository.AssertExpectations(t)
+	})
+
+	t.Run("Readiness Registered Check Timeout", func(t *testing.T) {
+		ctx := context.TODO()
+
+		healthRepository := new(mocks.HealthRepository)
+		healthRepository.On("Ping", mock.Anything).Return(nil)
+		healthRepository.On("MigrationVersion", mock.Anything).Return(int64(1), false, nil)
+
+		healthUseCase := health.NewHealthUseCaseImpl(healthRepository, 1, 10*time.Millisecond)
+		healthUseCase.Register("slow", func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		})
+
+		resp := healthUseCase.Readiness(ctx)
+
+		assert.Error(t, resp.Err())
+
+		report := resp.(*response.ResponseImpl).Data.(models.Report)
+		assert.Equal(t, "slow", report.Checks[2].Name)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[2].Error)
+
+		healthRepository.AssertExpectations(t)
+	})
+}
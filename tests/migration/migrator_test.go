@@ -0,0 +1,199 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/db/migration"
+	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/tests/mock"
+)
+
+func expectVersion(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectExec(regexp.QuoteMeta(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s`, constant.TableSchemaMigrations))).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(fmt.Sprintf(`SELECT version, dirty FROM %s`, constant.TableSchemaMigrations)).
+		WillReturnRows(rows)
+}
+
+func expectSetVersion(mock sqlmock.Sqlmock, version int64, dirty bool) {
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`DELETE FROM %s`, constant.TableSchemaMigrations)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if version > 0 {
+		mock.ExpectExec(regexp.QuoteMeta(fmt.Sprintf(`INSERT INTO %s (version, dirty)`, constant.TableSchemaMigrations))).
+			WithArgs(version, dirty).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+// readFile returns the direction file of version.
+func readFile(t *testing.T, version int64, direction string) string {
+	files, err := fs.Glob(migration.Files, fmt.Sprintf("%06d_*.%s.sql", version, direction))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("no %s file for version %d", direction, version)
+	}
+
+	raw, err := fs.ReadFile(migration.Files, files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(raw)
+}
+
+// expectFile expects the statements of the direction file of version,
+// run between marking it dirty and setting target.
+func expectFile(t *testing.T, mock sqlmock.Sqlmock, version int64, direction string, target int64) {
+	expectSetVersion(mock, version, true)
+	for _, statement := range strings.Split(readFile(t, version, direction), ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			mock.ExpectExec(regexp.QuoteMeta(statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+	}
+	expectSetVersion(mock, target, false)
+}
+
+func TestMigrator(t *testing.T) {
+	latest, err := migration.LatestVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Version Before Any Migration", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		migrator := migration.NewMigrator(db, constant.TableSchemaMigrations)
+
+		defer db.Close()
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}))
+
+		version, dirty, err := migrator.Version(context.TODO())
+
+		assert.Equal(t, int64(0), version)
+		assert.False(t, dirty)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Up Applies Newer Migrations", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		migrator := migration.NewMigrator(db, constant.TableSchemaMigrations)
+
+		defer db.Close()
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(7, false))
+		var want []int64
+		for version := int64(8); version <= latest; version++ {
+			expectFile(t, mock, version, "up", version)
+			want = append(want, version)
+		}
+
+		applied, err := migrator.Up(context.TODO())
+
+		assert.Equal(t, want, applied)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Up Leaves A Failed Migration Dirty", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		migrator := migration.NewMigrator(db, constant.TableSchemaMigrations)
+
+		defer db.Close()
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(7, false))
+		expectSetVersion(mock, 8, true)
+		mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `cart` ADD COLUMN `owner`")).WillReturnError(errors.New("duplicate column"))
+
+		applied, err := migrator.Up(context.TODO())
+
+		assert.Empty(t, applied)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Up Refuses A Dirty Database", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		migrator := migration.NewMigrator(db, constant.TableSchemaMigrations)
+
+		defer db.Close()
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest, true))
+
+		applied, err := migrator.Up(context.TODO())
+
+		assert.Empty(t, applied)
+		assert.ErrorIs(t, err, migration.ErrDirty)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Down Reverts The Latest Migration", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		migrator := migration.NewMigrator(db, constant.TableSchemaMigrations)
+
+		defer db.Close()
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest, false))
+		expectFile(t, mock, latest, "down", latest-1)
+
+		reverted, err := migrator.Down(context.TODO(), 1)
+
+		assert.Equal(t, []int64{latest}, reverted)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("Up And Down Round Trip", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		migrator := migration.NewMigrator(db, constant.TableSchemaMigrations)
+
+		defer db.Close()
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}))
+		var want []int64
+		for version := int64(1); version <= latest; version++ {
+			expectFile(t, mock, version, "up", version)
+			want = append(want, version)
+		}
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest, false))
+		var wantReverted []int64
+		for version := latest; version >= 1; version-- {
+			expectFile(t, mock, version, "down", version-1)
+			wantReverted = append(wantReverted, version)
+		}
+
+		applied, err := migrator.Up(context.TODO())
+
+		assert.Equal(t, want, applied)
+		assert.NoError(t, err)
+
+		reverted, err := migrator.Down(context.TODO(), len(want))
+
+		assert.Equal(t, wantReverted, reverted)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Down Drops The Tables Up Creates", func(t *testing.T) {
+		created := regexp.MustCompile("(?i)CREATE TABLE ((?:`\\w+`\\.)?`\\w+`)")
+
+		for version := int64(1); version <= latest; version++ {
+			up := readFile(t, version, "up")
+			down := readFile(t, version, "down")
+
+			for _, match := range created.FindAllStringSubmatch(up, -1) {
+				assert.Contains(t, down, "DROP TABLE "+match[1], "version %d", version)
+			}
+		}
+	})
+}
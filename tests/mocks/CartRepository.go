@@ -4,11 +4,12 @@ package mocks
 
 import (
 	context "context"
-
+	cartsummary "github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/catalog"
 	filter "github.com/Risuii/models/filter"
-	mock "github.com/stretchr/testify/mock"
-
 	product "github.com/Risuii/models/product"
+	mock "github.com/stretchr/testify/mock"
+	time "time"
 )
 
 // CartRepository is an autogenerated mock type for the CartRepository type
@@ -37,6 +38,41 @@ func (_m *CartRepository) Add(ctx context.Context, params product.Product) (int6
 	return r0, r1
 }
 
+// AddBatch provides a mock function with given fields: ctx, items
+func (_m *CartRepository) AddBatch(ctx context.Context, items []product.Product) error {
+	ret := _m.Called(ctx, items)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []product.Product) error); ok {
+		r0 = rf(ctx, items)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CountCarts provides a mock function with given fields: ctx, q
+func (_m *CartRepository) CountCarts(ctx context.Context, q string) (int64, error) {
+	ret := _m.Called(ctx, q)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, q)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Delete provides a mock function with given fields: ctx, id
 func (_m *CartRepository) Delete(ctx context.Context, id int64) error {
 	ret := _m.Called(ctx, id)
@@ -51,13 +87,27 @@ func (_m *CartRepository) Delete(ctx context.Context, id int64) error {
 	return r0
 }
 
-// FindAll provides a mock function with given fields:
-func (_m *CartRepository) FindAll() ([]product.Product, error) {
-	ret := _m.Called()
+// DeleteBatch provides a mock function with given fields: ctx, ids
+func (_m *CartRepository) DeleteBatch(ctx context.Context, ids []int64) error {
+	ret := _m.Called(ctx, ids)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) error); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindAll provides a mock function with given fields: ctx
+func (_m *CartRepository) FindAll(ctx context.Context) ([]product.Product, error) {
+	ret := _m.Called(ctx)
 
 	var r0 []product.Product
-	if rf, ok := ret.Get(0).(func() []product.Product); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []product.Product); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]product.Product)
@@ -65,8 +115,8 @@ func (_m *CartRepository) FindAll() ([]product.Product, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -118,6 +168,152 @@ func (_m *CartRepository) FindByKodeProduk(ctx context.Context, kodeProduk strin
 	return r0, r1
 }
 
+// FindByKodeProduks provides a mock function with given fields: ctx, kodeProduks
+func (_m *CartRepository) FindByKodeProduks(ctx context.Context, kodeProduks []string) ([]product.Product, error) {
+	ret := _m.Called(ctx, kodeProduks)
+
+	var r0 []product.Product
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []product.Product); ok {
+		r0 = rf(ctx, kodeProduks)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]product.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, kodeProduks)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindCarts provides a mock function with given fields: ctx, params
+func (_m *CartRepository) FindCarts(ctx context.Context, params cartsummary.Filter) ([]cartsummary.Summary, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 []cartsummary.Summary
+	if rf, ok := ret.Get(0).(func(context.Context, cartsummary.Filter) []cartsummary.Summary); ok {
+		r0 = rf(ctx, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cartsummary.Summary)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, cartsummary.Filter) error); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindCatalog provides a mock function with given fields: ctx
+func (_m *CartRepository) FindCatalog(ctx context.Context) ([]catalog.Entry, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []catalog.Entry
+	if rf, ok := ret.Get(0).(func(context.Context) []catalog.Entry); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]catalog.Entry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindDeletedByKodeProduk provides a mock function with given fields: ctx, kodeProduk, since
+func (_m *CartRepository) FindDeletedByKodeProduk(ctx context.Context, kodeProduk string, since time.Time) (product.Product, error) {
+	ret := _m.Called(ctx, kodeProduk, since)
+
+	var r0 product.Product
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) product.Product); ok {
+		r0 = rf(ctx, kodeProduk, since)
+	} else {
+		r0 = ret.Get(0).(product.Product)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, kodeProduk, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindOwner provides a mock function with given fields: ctx, id
+func (_m *CartRepository) FindOwner(ctx context.Context, id int64) (string, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, int64) string); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, before, limit
+func (_m *CartRepository) Purge(ctx context.Context, before time.Time, limit int) (int64, error) {
+	ret := _m.Called(ctx, before, limit)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int64); ok {
+		r0 = rf(ctx, before, limit)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *CartRepository) Restore(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateKuantitas provides a mock function with given fields: ctx, id, params
 func (_m *CartRepository) UpdateKuantitas(ctx context.Context, id int64, params product.Product) error {
 	ret := _m.Called(ctx, id, params)
@@ -132,6 +328,34 @@ func (_m *CartRepository) UpdateKuantitas(ctx context.Context, id int64, params
 	return r0
 }
 
+// UpdateKuantitasBatch provides a mock function with given fields: ctx, items
+func (_m *CartRepository) UpdateKuantitasBatch(ctx context.Context, items []product.Product) error {
+	ret := _m.Called(ctx, items)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []product.Product) error); ok {
+		r0 = rf(ctx, items)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateKuantitasIfVersion provides a mock function with given fields: ctx, id, version, params
+func (_m *CartRepository) UpdateKuantitasIfVersion(ctx context.Context, id int64, version int64, params product.Product) error {
+	ret := _m.Called(ctx, id, version, params)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, product.Product) error); ok {
+		r0 = rf(ctx, id, version, params)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewCartRepository interface {
 	mock.TestingT
 	Cleanup(func())
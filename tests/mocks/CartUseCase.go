@@ -4,13 +4,12 @@ package mocks
 
 import (
 	context "context"
-
+	response "github.com/Risuii/helpers/response"
+	batch "github.com/Risuii/models/batch"
+	cartsummary "github.com/Risuii/models/cartsummary"
 	filter "github.com/Risuii/models/filter"
-	mock "github.com/stretchr/testify/mock"
-
 	product "github.com/Risuii/models/product"
-
-	response "github.com/Risuii/helpers/response"
+	mock "github.com/stretchr/testify/mock"
 )
 
 // CartUseCase is an autogenerated mock type for the CartUseCase type
@@ -18,33 +17,134 @@ type CartUseCase struct {
 	mock.Mock
 }
 
-// AddItems provides a mock function with given fields: ctx, params
-func (_m *CartUseCase) AddItems(ctx context.Context, params product.Product) response.Response {
-	ret := _m.Called(ctx, params)
+// AddItems provides a mock function with given fields: ctx, params, ifMatch
+func (_m *CartUseCase) AddItems(ctx context.Context, params product.Product, ifMatch string) response.Response {
+	ret := _m.Called(ctx, params, ifMatch)
 
 	var r0 response.Response
-	if rf, ok := ret.Get(0).(func(context.Context, product.Product) response.Response); ok {
-		r0 = rf(ctx, params)
+	if rf, ok := ret.Get(0).(func(context.Context, product.Product, string) response.Response); ok {
+		r0 = rf(ctx, params, ifMatch)
+	} else {
+		r0 = ret.Get(0).(response.Response)
+	}
+
+	return r0
+}
+
+// AuthorizeLine provides a mock function with given fields: ctx, cartID
+func (_m *CartUseCase) AuthorizeLine(ctx context.Context, cartID int64) error {
+	ret := _m.Called(ctx, cartID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, cartID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BatchItems provides a mock function with given fields: ctx, params, ifMatch
+func (_m *CartUseCase) BatchItems(ctx context.Context, params batch.Batch, ifMatch string) response.Response {
+	ret := _m.Called(ctx, params, ifMatch)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, batch.Batch, string) response.Response); ok {
+		r0 = rf(ctx, params, ifMatch)
+	} else {
+		r0 = ret.Get(0).(response.Response)
+	}
+
+	return r0
+}
+
+// Catalog provides a mock function with given fields: ctx
+func (_m *CartUseCase) Catalog(ctx context.Context) response.Response {
+	ret := _m.Called(ctx)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context) response.Response); ok {
+		r0 = rf(ctx)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(response.Response)
-		}
+		r0 = ret.Get(0).(response.Response)
 	}
 
 	return r0
 }
 
-// DeleteItems provides a mock function with given fields: ctx, kodeProduk
-func (_m *CartUseCase) DeleteItems(ctx context.Context, kodeProduk string) response.Response {
-	ret := _m.Called(ctx, kodeProduk)
+// Checkout provides a mock function with given fields: ctx, ifMatch
+func (_m *CartUseCase) Checkout(ctx context.Context, ifMatch string) response.Response {
+	ret := _m.Called(ctx, ifMatch)
 
 	var r0 response.Response
 	if rf, ok := ret.Get(0).(func(context.Context, string) response.Response); ok {
-		r0 = rf(ctx, kodeProduk)
+		r0 = rf(ctx, ifMatch)
+	} else {
+		r0 = ret.Get(0).(response.Response)
+	}
+
+	return r0
+}
+
+// DeleteItems provides a mock function with given fields: ctx, kodeProduk, ifMatch
+func (_m *CartUseCase) DeleteItems(ctx context.Context, kodeProduk string, ifMatch string) response.Response {
+	ret := _m.Called(ctx, kodeProduk, ifMatch)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.Response); ok {
+		r0 = rf(ctx, kodeProduk, ifMatch)
+	} else {
+		r0 = ret.Get(0).(response.Response)
+	}
+
+	return r0
+}
+
+// DetectAbandoned provides a mock function with given fields: ctx
+func (_m *CartUseCase) DetectAbandoned(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Expire provides a mock function with given fields: ctx
+func (_m *CartUseCase) Expire(ctx context.Context) response.Response {
+	ret := _m.Called(ctx)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context) response.Response); ok {
+		r0 = rf(ctx)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(response.Response)
-		}
+		r0 = ret.Get(0).(response.Response)
+	}
+
+	return r0
+}
+
+// FindItems provides a mock function with given fields: ctx, kodeProduks
+func (_m *CartUseCase) FindItems(ctx context.Context, kodeProduks []string) response.Response {
+	ret := _m.Called(ctx, kodeProduks)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, []string) response.Response); ok {
+		r0 = rf(ctx, kodeProduks)
+	} else {
+		r0 = ret.Get(0).(response.Response)
 	}
 
 	return r0
@@ -58,9 +158,56 @@ func (_m *CartUseCase) GetItems(ctx context.Context, params filter.Filter) respo
 	if rf, ok := ret.Get(0).(func(context.Context, filter.Filter) response.Response); ok {
 		r0 = rf(ctx, params)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(response.Response)
-		}
+		r0 = ret.Get(0).(response.Response)
+	}
+
+	return r0
+}
+
+// ListCarts provides a mock function with given fields: ctx, params
+func (_m *CartUseCase) ListCarts(ctx context.Context, params cartsummary.Filter) response.Response {
+	ret := _m.Called(ctx, params)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, cartsummary.Filter) response.Response); ok {
+		r0 = rf(ctx, params)
+	} else {
+		r0 = ret.Get(0).(response.Response)
+	}
+
+	return r0
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx
+func (_m *CartUseCase) PurgeDeleted(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreItems provides a mock function with given fields: ctx, kodeProduk, ifMatch
+func (_m *CartUseCase) RestoreItems(ctx context.Context, kodeProduk string, ifMatch string) response.Response {
+	ret := _m.Called(ctx, kodeProduk, ifMatch)
+
+	var r0 response.Response
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) response.Response); ok {
+		r0 = rf(ctx, kodeProduk, ifMatch)
+	} else {
+		r0 = ret.Get(0).(response.Response)
 	}
 
 	return r0
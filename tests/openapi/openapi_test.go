@@ -0,0 +1,99 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/models/batch"
+	"github.com/Risuii/models/product"
+)
+
+type embedded struct {
+	Shared string `json:"shared"`
+}
+
+type Sample struct {
+	embedded
+	Name     string            `json:"name" validate:"required,min=3"`
+	Kind     string            `json:"kind" validate:"omitempty,oneof=a b"`
+	Count    int64             `json:"count,omitempty" validate:"min=0"`
+	Tags     []string          `json:"tags" validate:"required,dive,oneof=x y"`
+	Labels   map[string]string `json:"labels"`
+	At       time.Time         `json:"at"`
+	Optional *bool             `json:"optional"`
+	Hidden   string            `json:"-"`
+	NoTag    string
+	private  string
+}
+
+func TestSchema(t *testing.T) {
+	t.Run("Struct Becomes Component", func(t *testing.T) {
+		doc := openapi.New(openapi.Info{Title: "t", Version: "1"})
+
+		assert.Equal(t, "#/components/schemas/Product", doc.Schema(product.Product{}).Ref)
+		assert.Equal(t, "#/components/schemas/BatchResult", doc.Schema(batch.Result{}).Ref)
+		assert.Equal(t, "#/components/schemas/Batch", doc.Schema(&batch.Batch{}).Ref)
+
+		assert.Equal(t, []string{"nama"}, doc.Components.Schemas["Product"].Required)
+		assert.Equal(t, "date-time", doc.Components.Schemas["Product"].Properties["created_at"].Format)
+	})
+
+	t.Run("Tags Map To Constraints", func(t *testing.T) {
+		doc := openapi.New(openapi.Info{Title: "t", Version: "1"})
+		doc.Schema(Sample{})
+
+		s := doc.Components.Schemas["Openapi_testSample"]
+
+		assert.ElementsMatch(t, []string{"shared", "name", "kind", "count", "tags", "labels", "at", "optional", "NoTag"}, keys(s.Properties))
+		assert.Equal(t, []string{"name", "tags"}, s.Required)
+
+		assert.Equal(t, 3, *s.Properties["name"].MinLength)
+		assert.Equal(t, []interface{}{"a", "b"}, s.Properties["kind"].Enum)
+		assert.Equal(t, float64(0), *s.Properties["count"].Minimum)
+		assert.Equal(t, 1, *s.Properties["tags"].MinItems)
+		assert.Equal(t, []interface{}{"x", "y"}, s.Properties["tags"].Items.Enum)
+		assert.Equal(t, "string", s.Properties["labels"].AdditionalProperties.Type)
+		assert.Equal(t, []string{"boolean", "null"}, s.Properties["optional"].Type)
+	})
+
+	t.Run("Envelope", func(t *testing.T) {
+		doc := openapi.New(openapi.Info{Title: "t", Version: "1"})
+
+		body, _ := json.Marshal(doc.Envelope([]product.Product{}))
+
+		assert.JSONEq(t, `{"allOf":[
+			{"$ref":"#/components/schemas/Response"},
+			{"type":"object","properties":{"data":{"type":"array","items":{"$ref":"#/components/schemas/Product"}}}}
+		]}`, string(body))
+
+		body, _ = json.Marshal(doc.Error("boom").Content["application/json"].Schema)
+		assert.Contains(t, string(body), `"data":{"type":"null"}`)
+	})
+}
+
+func keys(m map[string]*openapi.Schema) []string {
+	var out []string
+	for key := range m {
+		out = append(out, key)
+	}
+
+	return out
+}
+
+func TestPrefixed(t *testing.T) {
+	doc := openapi.New(openapi.Info{Title: "t", Version: "1"})
+	v1 := doc.Prefixed("/v1")
+
+	v1.Add("GET", "/items", &openapi.Operation{OperationID: "items"})
+
+	assert.NotNil(t, doc.Operation("GET", "/v1/items"))
+	assert.NotNil(t, v1.Operation("GET", "/items"))
+	assert.Nil(t, doc.Operation("GET", "/items"))
+
+	v1.Schema(product.Product{})
+	assert.Contains(t, doc.Components.Schemas, "Product")
+}
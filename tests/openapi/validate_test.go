@@ -0,0 +1,177 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/models/webhook"
+)
+
+func newValidated(t *testing.T) (*mux.Router, *string) {
+	doc := openapi.New(openapi.Info{Title: "t", Version: "1"})
+
+	doc.Add(http.MethodPut, "/things/{id}", &openapi.Operation{
+		OperationID: "putThing",
+		Parameters: []openapi.Parameter{
+			openapi.PathParam("id", "", openapi.Integer(1)),
+			openapi.QueryParam("dryRun", "", &openapi.Schema{Type: "boolean"}),
+		},
+		RequestBody: doc.JSONBody(webhook.Input{}),
+	})
+
+	var received string
+
+	router := mux.NewRouter()
+	router.Use(openapi.Middleware(doc, 1024))
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	router.HandleFunc("/things/{id:[0-9a-z]+}", handler).Methods(http.MethodPut)
+	router.HandleFunc("/undocumented", handler).Methods(http.MethodPost)
+
+	return router, &received
+}
+
+func validate(router *mux.Router, method, target, body string) (int, []openapi.Violation) {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	var res struct {
+		Data []openapi.Violation `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+
+	return w.Code, res.Data
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("Valid Request Keeps Its Body", func(t *testing.T) {
+		router, received := newValidated(t)
+
+		body := `{"url":"https://example.com/hook","eventTypes":["cart.ItemAdded"],"active":null}`
+		code, _ := validate(router, http.MethodPut, "/things/7?dryRun=true", body)
+
+		assert.Equal(t, http.StatusNoContent, code)
+		assert.Equal(t, body, *received)
+	})
+
+	t.Run("Parameters", func(t *testing.T) {
+		router, _ := newValidated(t)
+
+		code, violations := validate(router, http.MethodPut, "/things/abc?dryRun=maybe", `{"url":"https://example.com"}`)
+
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []openapi.Violation{
+			{In: "path", Field: "id", Message: "must be an integer"},
+			{In: "query", Field: "dryRun", Message: "must be a boolean"},
+		}, violations)
+
+		_, violations = validate(router, http.MethodPut, "/things/0", `{"url":"https://example.com"}`)
+		assert.Equal(t, []openapi.Violation{{In: "path", Field: "id", Message: "must be at least 1"}}, violations)
+	})
+
+	t.Run("Body", func(t *testing.T) {
+		router, _ := newValidated(t)
+
+		tests := []struct {
+			name       string
+			body       string
+			violations []openapi.Violation
+		}{
+			{
+				name:       "Missing",
+				body:       "",
+				violations: []openapi.Violation{{In: "body", Message: "is required"}},
+			},
+			{
+				name:       "Required Field",
+				body:       `{}`,
+				violations: []openapi.Violation{{In: "body", Field: "/url", Message: "is required"}},
+			},
+			{
+				name: "Format And Pattern",
+				body: `{"url":"ftp://example.com"}`,
+				violations: []openapi.Violation{
+					{In: "body", Field: "/url", Message: "must match ^http"},
+				},
+			},
+			{
+				name: "Enum In Array",
+				body: `{"url":"http://example.com","eventTypes":["cart.Unknown"]}`,
+				violations: []openapi.Violation{
+					{In: "body", Field: "/eventTypes/0", Message: "must be one of cart.ItemAdded, cart.QuantityChanged, cart.ItemRemoved, cart.CartCheckedOut, cart.CartAbandoned"},
+				},
+			},
+			{
+				name:       "Nullable",
+				body:       `{"url":"http://example.com","active":"yes"}`,
+				violations: []openapi.Violation{{In: "body", Field: "/active", Message: "must be a boolean or null"}},
+			},
+			{
+				name:       "Trailing Data",
+				body:       `{"url":"http://example.com"} {}`,
+				violations: []openapi.Violation{{In: "body", Message: "has data after the JSON value"}},
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				code, violations := validate(router, http.MethodPut, "/things/1", test.body)
+
+				assert.Equal(t, http.StatusBadRequest, code)
+				assert.Equal(t, test.violations, violations)
+			})
+		}
+	})
+
+	t.Run("Malformed JSON", func(t *testing.T) {
+		router, _ := newValidated(t)
+
+		code, violations := validate(router, http.MethodPut, "/things/1", `{"url":`)
+
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Len(t, violations, 1)
+		assert.Contains(t, violations[0].Message, "is not valid JSON")
+	})
+
+	t.Run("Body Larger Than Its Content-Length Allows", func(t *testing.T) {
+		router, _ := newValidated(t)
+
+		r := httptest.NewRequest(http.MethodPut, "/things/1", strings.NewReader(`{"url":"http://example.com/`+strings.Repeat("a", 2048)+`"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.ContentLength = -1
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("Undocumented Route Passes Through", func(t *testing.T) {
+		router, _ := newValidated(t)
+
+		code, _ := validate(router, http.MethodPost, "/undocumented", "not json")
+
+		assert.Equal(t, http.StatusNoContent, code)
+	})
+}
+
+func TestPathTemplate(t *testing.T) {
+	assert.Equal(t, "/cart/items", openapi.PathTemplate("/cart/items"))
+	assert.Equal(t, "/webhooks/{id}", openapi.PathTemplate("/webhooks/{id:[0-9]+}"))
+	assert.Equal(t, "/a/{x}/b/{y}", openapi.PathTemplate("/a/{x:[a-z]{1,3}}/b/{y}"))
+}
@@ -0,0 +1,133 @@
+package outbox_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/internal/outbox"
+	"github.com/Risuii/models/event"
+	"github.com/Risuii/tests/mocks"
+)
+
+func newEnvelope(t *testing.T) event.Envelope {
+	envelope, err := event.New(event.TypeItemAdded, event.ItemAdded{CartID: 1, KodeProduk: "A", Kuantitas: 2}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return envelope
+}
+
+func TestWriterPublisher(t *testing.T) {
+	t.Run("Writer Publisher Writes JSON Lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		publisher := outbox.NewWriterPublisher(&buf)
+
+		assert.NoError(t, publisher.Publish(context.TODO(), newEnvelope(t)))
+		assert.NoError(t, publisher.Publish(context.TODO(), newEnvelope(t)))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(t, lines, 2)
+
+		var got event.Envelope
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &got))
+		assert.Equal(t, event.TypeItemAdded, got.Type)
+	})
+
+	t.Run("File Publisher Appends", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.jsonl")
+
+		for i := 0; i < 2; i++ {
+			publisher, err := outbox.OpenFilePublisher(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.NoError(t, publisher.Publish(context.TODO(), newEnvelope(t)))
+			assert.NoError(t, publisher.Close())
+		}
+
+		raw, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(string(raw), "\n"))
+	})
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	t.Run("Webhook Publisher Posts Envelope", func(t *testing.T) {
+		envelope := newEnvelope(t)
+
+		var received event.Envelope
+		var header http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		publisher := outbox.NewWebhookPublisher(server.URL, time.Second)
+
+		err := publisher.Publish(context.TODO(), envelope)
+
+		assert.NoError(t, err)
+		assert.Equal(t, envelope.ID, received.ID)
+		assert.Equal(t, envelope.ID, header.Get(outbox.HeaderEventID))
+		assert.Equal(t, event.TypeItemAdded, header.Get(outbox.HeaderEventType))
+	})
+
+	t.Run("Webhook Publisher Non 2xx Error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		publisher := outbox.NewWebhookPublisher(server.URL, time.Second)
+
+		err := publisher.Publish(context.TODO(), newEnvelope(t))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestMultiPublisher(t *testing.T) {
+	t.Run("Multi Publisher Stops At First Error", func(t *testing.T) {
+		envelope := newEnvelope(t)
+
+		first := new(mocks.Publisher)
+		first.On("Publish", mock.Anything, envelope).Return(errors.New("unreachable"))
+		second := new(mocks.Publisher)
+
+		err := outbox.MultiPublisher{first, second}.Publish(context.TODO(), envelope)
+
+		assert.Error(t, err)
+		second.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Multi Publisher Publishes To All", func(t *testing.T) {
+		envelope := newEnvelope(t)
+
+		first := new(mocks.Publisher)
+		first.On("Publish", mock.Anything, envelope).Return(nil)
+		second := new(mocks.Publisher)
+		second.On("Publish", mock.Anything, envelope).Return(nil)
+
+		err := outbox.MultiPublisher{first, second}.Publish(context.TODO(), envelope)
+
+		assert.NoError(t, err)
+		first.AssertExpectations(t)
+		second.AssertExpectations(t)
+	})
+}
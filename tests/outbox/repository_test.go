@@ -0,0 +1,116 @@
+package outbox_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/internal/outbox"
+	"github.com/Risuii/models/event"
+	"github.com/Risuii/tests/mock"
+)
+
+var currentTime = time.Date(2021, 12, 12, 0, 0, 0, 0, time.UTC)
+
+func TestAppendRepository(t *testing.T) {
+	t.Run("Append Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := outbox.NewOutboxRepositoryImpl(db, constant.TableOutbox, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`INSERT INTO %s \(event_id, event_type, version, actor, request_id, owner, payload, occurred_at\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?\)`, constant.TableOutbox)
+
+		mock.ExpectExec(query).
+			WithArgs("a", event.TypeItemAdded, 1, "user-1", "req-1", "user:1", `{"cartId":1}`, currentTime).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.Append(context.TODO(), event.Envelope{
+			ID:         "a",
+			Type:       event.TypeItemAdded,
+			Version:    1,
+			Actor:      "user-1",
+			RequestID:  "req-1",
+			Owner:      "user:1",
+			Data:       []byte(`{"cartId":1}`),
+			OccurredAt: currentTime,
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFindUnpublishedRepository(t *testing.T) {
+	t.Run("Find Unpublished Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := outbox.NewOutboxRepositoryImpl(db, constant.TableOutbox, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT id, event_id, event_type, version, actor, request_id, owner, payload, occurred_at, attempts FROM %s WHERE published_at IS NULL AND \(leased_until IS NULL OR leased_until <= \?\) ORDER BY id LIMIT \?$`, constant.TableOutbox)
+		rows := sqlmock.NewRows([]string{"id", "event_id", "event_type", "version", "actor", "request_id", "owner", "payload", "occurred_at", "attempts"}).
+			AddRow(1, "a", event.TypeItemAdded, 1, "user-1", "req-1", "user:1", []byte(`{"cartId":1}`), currentTime, 0)
+
+		mock.ExpectQuery(query).WithArgs(currentTime, 10).WillReturnRows(rows)
+
+		messages, err := repo.FindUnpublished(context.TODO(), currentTime, 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, messages, 1)
+		assert.Equal(t, "a", messages[0].Envelope.ID)
+		assert.Equal(t, "user:1", messages[0].Envelope.Owner)
+		assert.JSONEq(t, `{"cartId":1}`, string(messages[0].Envelope.Data))
+	})
+
+	t.Run("Last Occurred At None", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := outbox.NewOutboxRepositoryImpl(db, constant.TableOutbox, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`SELECT MAX\(occurred_at\) FROM %s WHERE event_type = \? AND owner = \?`, constant.TableOutbox)
+
+		mock.ExpectQuery(query).WithArgs(event.TypeCartAbandoned, "user:1").WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+
+		last, err := repo.LastOccurredAt(context.TODO(), event.TypeCartAbandoned, "user:1")
+
+		assert.NoError(t, err)
+		assert.True(t, last.IsZero())
+	})
+}
+
+func TestLeaseMessagesRepository(t *testing.T) {
+	t.Run("Lease Messages Success", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := outbox.NewOutboxRepositoryImpl(db, constant.TableOutbox, time.Second)
+
+		defer db.Close()
+
+		query := fmt.Sprintf(`UPDATE %s SET leased_until = \? WHERE id IN \(\?,\?\)`, constant.TableOutbox)
+
+		mock.ExpectExec(query).WithArgs(currentTime, int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err := repo.LeaseMessages(context.TODO(), []int64{1, 2}, currentTime)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Lease No Messages", func(t *testing.T) {
+		db, mock := mock.NewMock()
+		repo := outbox.NewOutboxRepositoryImpl(db, constant.TableOutbox, time.Second)
+
+		defer db.Close()
+
+		err := repo.LeaseMessages(context.TODO(), nil, currentTime)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
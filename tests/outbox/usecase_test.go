@@ -0,0 +1,139 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/internal/outbox"
+	"github.com/Risuii/models/event"
+	"github.com/Risuii/tests/mocks"
+)
+
+func newTxManager() *mocks.TxManager {
+	txManager := new(mocks.TxManager)
+	txManager.On("WithinTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+
+	return txManager
+}
+
+func TestUseCaseRelay(t *testing.T) {
+	messages := []event.Message{
+		{ID: 1, Envelope: event.Envelope{ID: "a"}},
+		{ID: 2, Envelope: event.Envelope{ID: "b"}},
+		{ID: 3, Envelope: event.Envelope{ID: "c"}},
+	}
+
+	t.Run("Relay Publishes In Order", func(t *testing.T) {
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("FindUnpublished", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return(messages, nil)
+		outboxRepository.On("LeaseMessages", mock.Anything, []int64{1, 2, 3}, mock.AnythingOfType("time.Time")).Return(nil)
+		outboxRepository.On("MarkPublished", mock.Anything, []int64{1, 2, 3}, mock.AnythingOfType("time.Time")).Return(nil)
+
+		var order []string
+		publisher := new(mocks.Publisher)
+		publisher.On("Publish", mock.Anything, mock.AnythingOfType("event.Envelope")).Run(func(args mock.Arguments) {
+			order = append(order, args.Get(1).(event.Envelope).ID)
+		}).Return(nil)
+
+		outboxUseCase := outbox.NewOutboxUseCaseImpl(outboxRepository, newTxManager(), publisher, 10, time.Minute)
+
+		published, err := outboxUseCase.Relay(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, published)
+		assert.Equal(t, []string{"a", "b", "c"}, order)
+
+		outboxRepository.AssertExpectations(t)
+	})
+
+	t.Run("Relay Stops At First Failure", func(t *testing.T) {
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("FindUnpublished", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return(messages, nil)
+		outboxRepository.On("LeaseMessages", mock.Anything, []int64{1, 2, 3}, mock.AnythingOfType("time.Time")).Return(nil)
+		outboxRepository.On("MarkFailed", mock.Anything, int64(2), "unreachable").Return(nil)
+		outboxRepository.On("MarkPublished", mock.Anything, []int64{1}, mock.AnythingOfType("time.Time")).Return(nil)
+		outboxRepository.On("LeaseMessages", mock.Anything, []int64{3}, mock.AnythingOfType("time.Time")).Return(nil)
+
+		publisher := new(mocks.Publisher)
+		publisher.On("Publish", mock.Anything, messages[0].Envelope).Return(nil)
+		publisher.On("Publish", mock.Anything, messages[1].Envelope).Return(errors.New("unreachable"))
+
+		outboxUseCase := outbox.NewOutboxUseCaseImpl(outboxRepository, newTxManager(), publisher, 10, time.Minute)
+
+		published, err := outboxUseCase.Relay(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, published)
+
+		outboxRepository.AssertExpectations(t)
+		publisher.AssertNotCalled(t, "Publish", mock.Anything, messages[2].Envelope)
+	})
+
+	t.Run("Relay Publishes Outside Transactions", func(t *testing.T) {
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("FindUnpublished", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return(messages[:1], nil)
+		outboxRepository.On("LeaseMessages", mock.Anything, []int64{1}, mock.AnythingOfType("time.Time")).Return(nil)
+		outboxRepository.On("MarkPublished", mock.Anything, []int64{1}, mock.AnythingOfType("time.Time")).Return(nil)
+
+		// every transaction is retried once, as after a deadlock
+		var inTransaction bool
+		txManager := new(mocks.TxManager)
+		txManager.On("WithinTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+			inTransaction = true
+			defer func() { inTransaction = false }()
+
+			if err := fn(ctx); err != nil {
+				return err
+			}
+
+			return fn(ctx)
+		})
+
+		publisher := new(mocks.Publisher)
+		publisher.On("Publish", mock.Anything, messages[0].Envelope).Run(func(args mock.Arguments) {
+			assert.False(t, inTransaction)
+		}).Return(nil)
+
+		outboxUseCase := outbox.NewOutboxUseCaseImpl(outboxRepository, txManager, publisher, 10, time.Minute)
+
+		published, err := outboxUseCase.Relay(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, published)
+
+		publisher.AssertNumberOfCalls(t, "Publish", 1)
+	})
+
+	t.Run("Relay Nothing Pending", func(t *testing.T) {
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("FindUnpublished", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return(nil, nil)
+
+		outboxUseCase := outbox.NewOutboxUseCaseImpl(outboxRepository, newTxManager(), new(mocks.Publisher), 10, time.Minute)
+
+		published, err := outboxUseCase.Relay(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, published)
+
+		outboxRepository.AssertNotCalled(t, "MarkPublished", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Relay Repository Error", func(t *testing.T) {
+		outboxRepository := new(mocks.OutboxRepository)
+		outboxRepository.On("FindUnpublished", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return(nil, exception.ErrInternalServer)
+
+		outboxUseCase := outbox.NewOutboxUseCaseImpl(outboxRepository, newTxManager(), new(mocks.Publisher), 10, time.Minute)
+
+		_, err := outboxUseCase.Relay(context.TODO())
+
+		assert.Equal(t, exception.ErrInternalServer, err)
+	})
+}
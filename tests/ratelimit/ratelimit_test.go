@@ -0,0 +1,176 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/ratelimit"
+	"github.com/Risuii/helpers/requestinfo"
+	"github.com/Risuii/tests/mocks"
+)
+
+func TestMemory(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	limiter := ratelimit.NewMemory()
+	limiter.Now = func() time.Time { return now }
+
+	quota := ratelimit.Quota{Limit: 3, Period: time.Minute}
+	take := func(key string) ratelimit.Decision {
+		decision, err := limiter.Take(context.TODO(), key, quota)
+		assert.NoError(t, err)
+
+		return decision
+	}
+
+	t.Run("Burst Up To The Limit", func(t *testing.T) {
+		for remaining := 2; remaining >= 0; remaining-- {
+			decision := take("a")
+
+			assert.True(t, decision.Allowed)
+			assert.Equal(t, remaining, decision.Remaining)
+		}
+
+		decision := take("a")
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, 20*time.Second, decision.RetryAfter)
+		assert.Equal(t, time.Minute, decision.Reset)
+	})
+
+	t.Run("Buckets Are Per Key", func(t *testing.T) {
+		assert.True(t, take("b").Allowed)
+	})
+
+	t.Run("Refills Over Time", func(t *testing.T) {
+		now = now.Add(20 * time.Second)
+
+		assert.True(t, take("a").Allowed)
+		assert.False(t, take("a").Allowed)
+
+		now = now.Add(time.Hour)
+
+		decision := take("a")
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 2, decision.Remaining)
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		quotas, err := ratelimit.Parse([]string{"post /v1/cart/items = 30/1m", "GET /v1/webhooks=5/1s"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, ratelimit.Quotas{
+			"POST /v1/cart/items": {Limit: 30, Period: time.Minute},
+			"GET /v1/webhooks":    {Limit: 5, Period: time.Second},
+		}, quotas)
+	})
+
+	for _, entry := range []string{"POST /v1/cart/items", "/v1/cart/items=30/1m", "POST /v1/cart/items=30", "POST /v1/cart/items=0/1m", "POST /v1/cart/items=30/0s"} {
+		t.Run(entry, func(t *testing.T) {
+			_, err := ratelimit.Parse([]string{entry})
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	newRouter := func(limiter ratelimit.Limiter, opts ratelimit.Options) *mux.Router {
+		router := mux.NewRouter()
+		router.Use(requestinfo.Middleware(false), ratelimit.Middleware(limiter, opts))
+
+		ok := func(w http.ResponseWriter, r *http.Request) {}
+		router.HandleFunc("/v1/cart/items", ok).Methods(http.MethodPost, http.MethodGet)
+		router.HandleFunc("/cart/items", ok).Methods(http.MethodPost)
+
+		return router
+	}
+
+	send := func(router *mux.Router, method, path, ip string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.RemoteAddr = ip + ":1234"
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w
+	}
+
+	opts := ratelimit.Options{
+		Default: ratelimit.Quota{Limit: 100, Period: time.Minute},
+		Routes:  ratelimit.Quotas{"POST /v1/cart/items": {Limit: 2, Period: time.Minute}},
+		Prefix:  "/v1",
+	}
+
+	t.Run("Refuses Over The Route Quota", func(t *testing.T) {
+		router := newRouter(ratelimit.NewMemory(), opts)
+
+		w := send(router, http.MethodPost, "/v1/cart/items", "10.0.0.1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+		// the unversioned path shares the bucket
+		send(router, http.MethodPost, "/cart/items", "10.0.0.1")
+
+		w = send(router, http.MethodPost, "/v1/cart/items", "10.0.0.1")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+		assert.JSONEq(t, `{"status":"TOO_MANY_REQUESTS","data":null}`, w.Body.String())
+
+		// other clients and routes are not affected
+		assert.Equal(t, http.StatusOK, send(router, http.MethodPost, "/v1/cart/items", "10.0.0.2").Code)
+		assert.Equal(t, "100", send(router, http.MethodGet, "/v1/cart/items", "10.0.0.1").Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("Keys By Actor", func(t *testing.T) {
+		limiter := new(mocks.Limiter)
+		limiter.On("Take", mock.Anything, "actor:user-1|POST /v1/cart/items", opts.Routes["POST /v1/cart/items"]).
+			Return(ratelimit.Decision{Allowed: true, Remaining: 1}, nil)
+
+		router := mux.NewRouter()
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(requestinfo.WithActor(r.Context(), "user-1")))
+			})
+		}, ratelimit.Middleware(limiter, opts))
+		router.HandleFunc("/v1/cart/items", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodPost)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/cart/items", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		limiter.AssertExpectations(t)
+	})
+
+	t.Run("Lets Requests Through When The Limiter Fails", func(t *testing.T) {
+		limiter := new(mocks.Limiter)
+		limiter.On("Take", mock.Anything, mock.Anything, mock.Anything).Return(ratelimit.Decision{}, errors.New("store down"))
+
+		w := send(newRouter(limiter, opts), http.MethodPost, "/v1/cart/items", "10.0.0.1")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("Unlimited Without A Default", func(t *testing.T) {
+		limiter := new(mocks.Limiter)
+
+		w := send(newRouter(limiter, ratelimit.Options{Routes: opts.Routes}), http.MethodGet, "/v1/cart/items", "10.0.0.1")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		limiter.AssertNotCalled(t, "Take", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
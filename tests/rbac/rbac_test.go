@@ -0,0 +1,92 @@
+package rbac_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/rbac"
+)
+
+func user(role string) context.Context {
+	return auth.With(context.TODO(), auth.Principal{Kind: auth.KindUser, Subject: "42", Role: role})
+}
+
+func TestRoleOf(t *testing.T) {
+	t.Run("Anonymous Is Customer", func(t *testing.T) {
+		assert.Equal(t, rbac.RoleCustomer, rbac.RoleOf(context.TODO()))
+	})
+
+	t.Run("User Takes Role Of Token", func(t *testing.T) {
+		assert.Equal(t, rbac.RoleSupport, rbac.RoleOf(user("support")))
+		assert.Equal(t, rbac.RoleAdmin, rbac.RoleOf(user("admin")))
+	})
+
+	t.Run("Unknown Role Is Customer", func(t *testing.T) {
+		assert.Equal(t, rbac.RoleCustomer, rbac.RoleOf(user("root")))
+		assert.Equal(t, rbac.RoleCustomer, rbac.RoleOf(user("")))
+	})
+
+	t.Run("API Key Is Admin", func(t *testing.T) {
+		ctx := auth.With(context.TODO(), auth.Principal{Kind: auth.KindAPIKey, Subject: "3f9c2a7d"})
+
+		assert.Equal(t, rbac.RoleAdmin, rbac.RoleOf(ctx))
+	})
+
+	t.Run("Operator Is Admin", func(t *testing.T) {
+		ctx := auth.With(context.TODO(), auth.Principal{Kind: auth.KindOperator, Subject: "budi"})
+
+		assert.Equal(t, rbac.RoleAdmin, rbac.RoleOf(ctx))
+	})
+}
+
+func TestCart(t *testing.T) {
+	t.Run("Own Cart By Default", func(t *testing.T) {
+		assert.Equal(t, rbac.CartAnonymous, rbac.Cart(context.TODO()))
+		assert.Equal(t, "user:42", rbac.Cart(user("")))
+		assert.False(t, rbac.Foreign(user("")))
+	})
+
+	t.Run("Selected Cart", func(t *testing.T) {
+		ctx := rbac.WithCart(user("support"), "user:7")
+
+		assert.Equal(t, "user:7", rbac.Cart(ctx))
+		assert.True(t, rbac.Foreign(ctx))
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Run("Customer Edits Own Cart", func(t *testing.T) {
+		assert.NoError(t, rbac.Authorize(user(""), rbac.ActionEditCart))
+		assert.NoError(t, rbac.Authorize(context.TODO(), rbac.ActionEditCart))
+	})
+
+	t.Run("Customer Cannot Reach Another Cart", func(t *testing.T) {
+		ctx := rbac.WithCart(user(""), "user:7")
+
+		assert.Equal(t, exception.ErrForbidden, rbac.Authorize(ctx, rbac.ActionViewCart))
+		assert.Equal(t, exception.ErrForbidden, rbac.Authorize(user(""), rbac.ActionListCarts))
+	})
+
+	t.Run("Support Reaches Any Cart", func(t *testing.T) {
+		ctx := rbac.WithCart(user("support"), "user:7")
+
+		assert.NoError(t, rbac.Authorize(ctx, rbac.ActionViewCart))
+		assert.NoError(t, rbac.Authorize(ctx, rbac.ActionEditCart))
+		assert.NoError(t, rbac.Authorize(ctx, rbac.ActionListCarts))
+	})
+
+	t.Run("Only Admin Expires", func(t *testing.T) {
+		assert.Equal(t, exception.ErrForbidden, rbac.Authorize(rbac.WithCart(user("support"), "user:7"), rbac.ActionExpireCart))
+		assert.Equal(t, exception.ErrForbidden, rbac.Authorize(user(""), rbac.ActionExpireCart))
+		assert.NoError(t, rbac.Authorize(rbac.WithCart(user("admin"), "user:7"), rbac.ActionExpireCart))
+	})
+
+	t.Run("Authorize Cart Of Owner", func(t *testing.T) {
+		assert.NoError(t, rbac.AuthorizeCart(user(""), rbac.ActionViewCart, "user:42"))
+		assert.Equal(t, exception.ErrForbidden, rbac.AuthorizeCart(user(""), rbac.ActionViewCart, rbac.CartAnonymous))
+	})
+}
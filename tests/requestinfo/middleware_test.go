@@ -0,0 +1,86 @@
+package requestinfo_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/Risuii/helpers/requestinfo"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Run("Middleware Keeps Request ID And Source IP", func(t *testing.T) {
+		var info requestinfo.Info
+		handler := requestinfo.Middleware(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info = requestinfo.From(r.Context())
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/cart/items", nil)
+		r.RemoteAddr = "10.0.0.1:5555"
+		r.Header.Set(requestinfo.HeaderRequestID, "req-1")
+		r.Header.Set("X-Forwarded-For", "1.2.3.4")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+
+		assert.Equal(t, "req-1", info.RequestID)
+		assert.Equal(t, "10.0.0.1", info.SourceIP)
+		assert.Equal(t, requestinfo.ActorAnonymous, info.Actor)
+		assert.Equal(t, "req-1", recorder.Header().Get(requestinfo.HeaderRequestID))
+	})
+
+	t.Run("Middleware Generates Request ID And Trusts Proxy", func(t *testing.T) {
+		var info requestinfo.Info
+		handler := requestinfo.Middleware(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info = requestinfo.From(r.Context())
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/cart/items", nil)
+		r.Header.Set(requestinfo.HeaderRequestID, "has spaces")
+		r.Header.Set("X-Forwarded-For", "1.2.3.4, 10.0.0.1")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, r)
+
+		assert.Len(t, info.RequestID, 32)
+		assert.Equal(t, "1.2.3.4", info.SourceIP)
+		assert.Equal(t, info.RequestID, recorder.Header().Get(requestinfo.HeaderRequestID))
+	})
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Run("Keeps Incoming Request ID", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-1"))
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 5000}})
+
+		var info requestinfo.Info
+		interceptor := requestinfo.UnaryServerInterceptor()
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			info = requestinfo.From(ctx)
+			return nil, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "req-1", info.RequestID)
+		assert.Equal(t, "10.0.0.7", info.SourceIP)
+		assert.Equal(t, requestinfo.ActorAnonymous, info.Actor)
+	})
+
+	t.Run("Generates Missing Request ID", func(t *testing.T) {
+		var info requestinfo.Info
+		interceptor := requestinfo.UnaryServerInterceptor()
+		interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			info = requestinfo.From(ctx)
+			return nil, nil
+		})
+
+		assert.Len(t, info.RequestID, 32)
+	})
+}
@@ -0,0 +1,238 @@
+package response_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+)
+
+type line struct {
+	ID        int64      `json:"id"`
+	Nama      string     `json:"nama"`
+	Tags      []string   `json:"tags"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Hidden    string     `json:"-"`
+}
+
+func write(res response.Response, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+
+	w := httptest.NewRecorder()
+	_ = res.Write(w, r)
+
+	return w
+}
+
+func TestWrite(t *testing.T) {
+	at := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+	lines := []line{
+		{ID: 1, Nama: "buku, tulis", Tags: []string{"a"}},
+		{ID: 2, Nama: "pensil", DeletedAt: &at},
+	}
+
+	t.Run("JSON By Default", func(t *testing.T) {
+		w := write(response.Success(response.StatusOK, lines), "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		assert.True(t, json.Valid(w.Body.Bytes()))
+	})
+
+	t.Run("MessagePack", func(t *testing.T) {
+		w := write(response.Success(response.StatusCreated, lines[0]).SetHeader("ETag", `"1"`), "application/msgpack")
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+		assert.Equal(t, `"1-msgpack"`, w.Header().Get("ETag"))
+
+		var value map[string]interface{}
+		assert.NoError(t, msgpack.Unmarshal(w.Body.Bytes(), &value))
+		assert.Equal(t, response.StatusCreated, value["status"])
+		assert.Equal(t, "buku, tulis", value["data"].(map[string]interface{})["nama"])
+		assert.Equal(t, int8(1), value["data"].(map[string]interface{})["id"])
+		assert.NotContains(t, value["data"], "Hidden")
+		assert.NotContains(t, value["data"], "deleted_at")
+	})
+
+	t.Run("ETag Differs By Media Type", func(t *testing.T) {
+		tagged := func() response.Response {
+			return response.Success(response.StatusOK, lines).SetHeader("ETag", `"v1"`)
+		}
+
+		asJSON := write(tagged(), "application/json").Header().Get("ETag")
+		asCSV := write(tagged(), "text/csv").Header().Get("ETag")
+
+		assert.Equal(t, `"v1-json"`, asJSON)
+		assert.Equal(t, `"v1-csv"`, asCSV)
+	})
+
+	t.Run("CSV For A List", func(t *testing.T) {
+		w := write(response.Success(response.StatusOK, lines), "text/csv")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "id,nama,tags,deleted_at\n"+
+			"1,\"buku, tulis\",\"[\"\"a\"\"]\",\n"+
+			"2,pensil,null,2026-10-19T08:30:00Z\n", w.Body.String())
+	})
+
+	t.Run("CSV Of An Empty List Has A Header", func(t *testing.T) {
+		w := write(response.Success(response.StatusOK, []line{}), "text/csv")
+
+		assert.Equal(t, "id,nama,tags,deleted_at\n", w.Body.String())
+	})
+
+	t.Run("CSV Of An Object Is Not Acceptable", func(t *testing.T) {
+		w := write(response.Success(response.StatusOK, lines[0]), "text/csv")
+
+		var body struct {
+			Status string   `json:"status"`
+			Data   []string `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Equal(t, response.StatusNotAcceptable, body.Status)
+		assert.Equal(t, []string{"application/json", "application/msgpack", "text/csv"}, body.Data)
+	})
+
+	t.Run("Falls Back To A Lower Quality", func(t *testing.T) {
+		w := write(response.Success(response.StatusOK, lines[0]), "text/csv, application/msgpack;q=0.5, */*;q=0.1")
+
+		assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Errors Fall Back To JSON", func(t *testing.T) {
+		w := write(response.Error(response.StatusNotFound, exception.ErrNotFound), "text/csv")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Unknown Type Is Not Acceptable", func(t *testing.T) {
+		w := write(response.Success(response.StatusOK, lines), "application/xml")
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+}
+
+func TestNegotiate(t *testing.T) {
+	registry := response.NewRegistry(response.JSONEncoder{}, response.MessagePackEncoder{}, response.CSVEncoder{})
+
+	tests := []struct {
+		accept string
+		want   []string
+	}{
+		{accept: "", want: []string{"application/json", "application/msgpack", "text/csv"}},
+		{accept: "*/*", want: []string{"application/json", "application/msgpack", "text/csv"}},
+		{accept: "text/*", want: []string{"text/csv"}},
+		{accept: "text/csv;q=0.2, application/*;q=0.5", want: []string{"application/json", "application/msgpack", "text/csv"}},
+		{accept: "application/*, application/json;q=0", want: []string{"application/msgpack"}},
+		{accept: "application/msgpack, application/json;q=0.9", want: []string{"application/msgpack", "application/json"}},
+		{accept: "not a type, text/csv", want: []string{"text/csv"}},
+		{accept: "image/png", want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			got := []string{}
+			for _, encoder := range registry.Negotiate(test.accept) {
+				got = append(got, strings.Split(encoder.ContentType(), ";")[0])
+			}
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+type upper struct{}
+
+func (upper) ContentType() string { return "text/plain" }
+
+func (upper) Encode(w io.Writer, res *response.ResponseImpl) error {
+	_, err := io.WriteString(w, strings.ToUpper(res.Status))
+	return err
+}
+
+func TestRegister(t *testing.T) {
+	registry := response.NewRegistry(response.JSONEncoder{})
+	registry.Register(upper{})
+	registry.Register(upper{})
+
+	assert.Equal(t, []string{"application/json", "text/plain"}, registry.MediaTypes())
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+
+	assert.NoError(t, registry.Write(w, r, response.Success(response.StatusOK, nil).(*response.ResponseImpl)))
+	assert.Equal(t, "OK", w.Body.String())
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+}
+
+func TestConditionalGet(t *testing.T) {
+	modified := "Mon, 19 Oct 2026 08:30:00 GMT"
+
+	send := func(res response.Response, method string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/", nil)
+		for key, value := range header {
+			r.Header.Set(key, value)
+		}
+
+		w := httptest.NewRecorder()
+		_ = res.Write(w, r)
+
+		return w
+	}
+
+	ok := func() response.Response {
+		return response.Success(response.StatusOK, []line{{ID: 1}}).
+			SetHeader("ETag", `"v1"`).
+			SetHeader("Last-Modified", modified)
+	}
+
+	tests := []struct {
+		name   string
+		res    response.Response
+		method string
+		header map[string]string
+		want   int
+	}{
+		{name: "If-None-Match Matches", res: ok(), method: http.MethodGet, header: map[string]string{"If-None-Match": `"v0", "v1-json"`}, want: http.StatusNotModified},
+		{name: "If-None-Match Matches A Weak Tag", res: ok(), method: http.MethodGet, header: map[string]string{"If-None-Match": `W/"v1-json"`}, want: http.StatusNotModified},
+		{name: "If-None-Match Of Another Media Type", res: ok(), method: http.MethodGet, header: map[string]string{"If-None-Match": `"v1-msgpack"`}, want: http.StatusOK},
+		{name: "If-None-Match Differs", res: ok(), method: http.MethodGet, header: map[string]string{"If-None-Match": `"v0"`}, want: http.StatusOK},
+		{name: "If-None-Match Wins Over If-Modified-Since", res: ok(), method: http.MethodGet, header: map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": modified}, want: http.StatusOK},
+		{name: "Not Modified Since", res: ok(), method: http.MethodGet, header: map[string]string{"If-Modified-Since": modified}, want: http.StatusNotModified},
+		{name: "Modified Since", res: ok(), method: http.MethodGet, header: map[string]string{"If-Modified-Since": "Mon, 19 Oct 2026 08:29:59 GMT"}, want: http.StatusOK},
+		{name: "Malformed If-Modified-Since", res: ok(), method: http.MethodGet, header: map[string]string{"If-Modified-Since": "yesterday"}, want: http.StatusOK},
+		{name: "Only For GET And HEAD", res: ok(), method: http.MethodPost, header: map[string]string{"If-None-Match": `"v1"`}, want: http.StatusOK},
+		{name: "Not For Errors", res: response.Error(response.StatusNotFound, exception.ErrNotFound).SetHeader("ETag", `"v1"`), method: http.MethodGet, header: map[string]string{"If-None-Match": `"v1"`}, want: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := send(test.res, test.method, test.header)
+
+			assert.Equal(t, test.want, w.Code)
+			if test.want == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+				assert.Equal(t, `"v1-json"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
@@ -0,0 +1,560 @@
+package routes_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/cachecontrol"
+	"github.com/Risuii/helpers/compress"
+	"github.com/Risuii/helpers/openapi"
+	"github.com/Risuii/helpers/ratelimit"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/routes"
+	"github.com/Risuii/internal/stream"
+	"github.com/Risuii/models/cartsummary"
+	"github.com/Risuii/models/product"
+	"github.com/Risuii/tests/mocks"
+)
+
+func newRouter(t *testing.T) *mux.Router {
+	return newRouterWith(t, new(mocks.CartUseCase), true)
+}
+
+func newRouterWith(t *testing.T, cart *mocks.CartUseCase, legacy bool) *mux.Router {
+	router := mux.NewRouter()
+
+	err := routes.Register(router, routes.Dependencies{
+		Validate:     validator.New(),
+		Cart:         cart,
+		Audit:        new(mocks.AuditUseCase),
+		Hub:          stream.NewHub(stream.HubOptions{}),
+		Webhook:      new(mocks.WebhookUseCase),
+		Health:       new(mocks.HealthUseCase),
+		MaxBodyBytes: 64,
+		Legacy:       legacy,
+		LegacySunset: time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return router
+}
+
+// registered lists "METHOD /path" for every route on router.
+func registered(t *testing.T, router *mux.Router) []string {
+	var out []string
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			out = append(out, method+" "+openapi.PathTemplate(template))
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+func TestSpec(t *testing.T) {
+	t.Run("Every Route Is Documented", func(t *testing.T) {
+		doc := routes.Spec(true)
+
+		routes := registered(t, newRouter(t))
+		assert.NotEmpty(t, routes)
+
+		for _, route := range routes {
+			method, path, _ := strings.Cut(route, " ")
+			assert.NotNil(t, doc.Operation(method, path), "%s is not in the OpenAPI document", route)
+		}
+	})
+
+	t.Run("Every Documented Route Exists", func(t *testing.T) {
+		doc := routes.Spec(true)
+
+		known := map[string]bool{}
+		for _, route := range registered(t, newRouter(t)) {
+			known[route] = true
+		}
+
+		for path, item := range doc.Paths {
+			for method := range *item {
+				route := strings.ToUpper(method) + " " + path
+				assert.True(t, known[route], "%s is documented but not registered", route)
+			}
+		}
+	})
+
+	t.Run("Operation IDs Are Unique", func(t *testing.T) {
+		seen := map[string]string{}
+
+		for path, item := range routes.Spec(true).Paths {
+			for method, op := range *item {
+				route := strings.ToUpper(method) + " " + path
+				if other, ok := seen[op.OperationID]; ok {
+					t.Errorf("%s and %s share operationId %q", other, route, op.OperationID)
+				}
+				seen[op.OperationID] = route
+			}
+		}
+	})
+
+	t.Run("Every Reference Resolves", func(t *testing.T) {
+		body, err := json.Marshal(routes.Spec(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var doc struct {
+			Components struct {
+				Schemas map[string]json.RawMessage `json:"schemas"`
+			} `json:"components"`
+		}
+		assert.NoError(t, json.Unmarshal(body, &doc))
+
+		for _, match := range regexp.MustCompile(`"\$ref":"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(string(body), -1) {
+			assert.Contains(t, doc.Components.Schemas, match[1])
+		}
+	})
+}
+
+func TestServe(t *testing.T) {
+	router := newRouter(t)
+
+	t.Run("Serve Spec", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, routes.PathSpec, nil))
+
+		var doc map[string]interface{}
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+		assert.Equal(t, "3.1.0", doc["openapi"])
+	})
+
+	t.Run("Serve Docs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, routes.PathDocs, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "/openapi.json")
+	})
+}
+
+func TestValidation(t *testing.T) {
+	send := func(router *mux.Router, method, path, contentType, body string) (*httptest.ResponseRecorder, []openapi.Violation) {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		var res struct {
+			Status string              `json:"status"`
+			Data   []openapi.Violation `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &res)
+
+		return w, res.Data
+	}
+
+	t.Run("Delete Without KodeProduk", func(t *testing.T) {
+		w, violations := send(newRouter(t), http.MethodDelete, "/v1/cart/items", "application/json", `{"kodeProduk":""}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []openapi.Violation{{In: "body", Field: "/kodeProduk", Message: "must not be empty"}}, violations)
+	})
+
+	t.Run("Get With Wrong Types And Unknown Fields", func(t *testing.T) {
+		w, violations := send(newRouter(t), http.MethodGet, "/v1/cart/items", "application/json", `{"nama":1,"warna":"x"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []openapi.Violation{
+			{In: "body", Field: "/nama", Message: "must be a string"},
+			{In: "body", Field: "/warna", Message: "is not a known field"},
+		}, violations)
+	})
+
+	t.Run("Wrong Content Type", func(t *testing.T) {
+		w, violations := send(newRouter(t), http.MethodPost, "/v1/cart/items", "text/plain", `{"nama":"a"}`)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Equal(t, "Content-Type", violations[0].Field)
+	})
+
+	t.Run("Body Too Large", func(t *testing.T) {
+		w, _ := send(newRouter(t), http.MethodPost, "/v1/cart/items", "application/json", `{"nama":"`+strings.Repeat("a", 100)+`"}`)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("Valid Request Reaches The Handler", func(t *testing.T) {
+		cart := new(mocks.CartUseCase)
+		cart.On("DeleteItems", mock.Anything, "A1", "").Return(response.Success(response.StatusOK, "A1"))
+
+		w, _ := send(newRouterWith(t, cart, false), http.MethodDelete, "/v1/cart/items", "application/json; charset=utf-8", `{"kodeProduk":"A1"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		cart.AssertExpectations(t)
+	})
+}
+
+func TestLegacy(t *testing.T) {
+	t.Run("Unversioned Path Is Deprecated", func(t *testing.T) {
+		cart := new(mocks.CartUseCase)
+		cart.On("Checkout", mock.Anything, "").Return(response.Success(response.StatusOK, nil))
+
+		w := httptest.NewRecorder()
+		newRouterWith(t, cart, true).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/cart/checkout", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Wed, 30 Jun 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, `</v1/cart/checkout>; rel="successor-version"`, w.Header().Get("Link"))
+	})
+
+	t.Run("Versioned Path Is Not Deprecated", func(t *testing.T) {
+		cart := new(mocks.CartUseCase)
+		cart.On("Checkout", mock.Anything, "").Return(response.Success(response.StatusOK, nil))
+
+		w := httptest.NewRecorder()
+		newRouterWith(t, cart, true).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/cart/checkout", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Deprecation"))
+	})
+
+	t.Run("Unversioned Paths Can Be Turned Off", func(t *testing.T) {
+		router := newRouterWith(t, new(mocks.CartUseCase), false)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/cart/checkout", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		for _, route := range registered(t, router) {
+			_, path, _ := strings.Cut(route, " ")
+			assert.False(t, strings.HasPrefix(path, "/cart") || strings.HasPrefix(path, "/webhooks"), "%s is served without legacy routes", route)
+		}
+
+		assert.Nil(t, routes.Spec(false).Operation(http.MethodPost, "/cart/checkout"))
+	})
+
+	t.Run("Unversioned Operations Are Documented As Deprecated", func(t *testing.T) {
+		op := routes.Spec(true).Operation(http.MethodPost, "/cart/checkout")
+
+		assert.True(t, op.Deprecated)
+		assert.Equal(t, "legacyCheckout", op.OperationID)
+		assert.Contains(t, op.Responses["200"].Headers, "Sunset")
+		assert.False(t, routes.Spec(true).Operation(http.MethodPost, routes.PrefixV1+"/cart/checkout").Deprecated)
+	})
+}
+
+func TestNegotiation(t *testing.T) {
+	t.Run("Cart As CSV", func(t *testing.T) {
+		cart := new(mocks.CartUseCase)
+		cart.On("GetItems", mock.Anything, mock.Anything).Return(response.Success(response.StatusOK, []product.Product{
+			{ID: 1, Nama: "buku", KodeProduk: "BK-01", Kuantitas: 2, Version: 1},
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/cart/items", strings.NewReader(`{}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept", "text/csv")
+
+		w := httptest.NewRecorder()
+		newRouterWith(t, cart, false).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "id,nama,kodeProduk,kuantitas,created_at,update_at,version,deleted_at\n"+
+			"1,buku,BK-01,2,0001-01-01T00:00:00Z,0001-01-01T00:00:00Z,1,\n", w.Body.String())
+	})
+
+	t.Run("Checkout As CSV Is Not Acceptable", func(t *testing.T) {
+		cart := new(mocks.CartUseCase)
+		cart.On("Checkout", mock.Anything, "").Return(response.Success(response.StatusOK, map[string]int{"totalKuantitas": 1}))
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/cart/checkout", nil)
+		r.Header.Set("Accept", "text/csv")
+
+		w := httptest.NewRecorder()
+		newRouterWith(t, cart, false).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+}
+
+func TestCaching(t *testing.T) {
+	newCachingRouter := func(t *testing.T, cart *mocks.CartUseCase) *mux.Router {
+		router := mux.NewRouter()
+
+		err := routes.Register(router, routes.Dependencies{
+			Validate:      validator.New(),
+			Cart:          cart,
+			Audit:         new(mocks.AuditUseCase),
+			Hub:           stream.NewHub(stream.HubOptions{}),
+			Webhook:       new(mocks.WebhookUseCase),
+			Health:        new(mocks.HealthUseCase),
+			MaxBodyBytes:  64,
+			Legacy:        true,
+			Compression:   &compress.Options{MinBytes: 1, Level: -1},
+			CachePolicies: cachecontrol.Policies{"GET /v1/cart/items": "private, no-cache"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return router
+	}
+
+	items := func() *mocks.CartUseCase {
+		cart := new(mocks.CartUseCase)
+		cart.On("GetItems", mock.Anything, mock.Anything).Return(response.Success(response.StatusOK, []product.Product{
+			{ID: 1, Nama: "buku", KodeProduk: "BK-01", Kuantitas: 2, Version: 1},
+		}).SetHeader("ETag", `"v1"`))
+
+		return cart
+	}
+
+	get := func(path string, header map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, path, strings.NewReader(`{}`))
+		r.Header.Set("Content-Type", "application/json")
+		for key, value := range header {
+			r.Header.Set(key, value)
+		}
+
+		return r
+	}
+
+	for _, path := range []string{"/v1/cart/items", "/cart/items"} {
+		t.Run("Policy And Gzip On "+path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newCachingRouter(t, items()).ServeHTTP(w, get(path, map[string]string{"Accept-Encoding": "gzip"}))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+			assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+			assert.Equal(t, `W/"v1-json"`, w.Header().Get("ETag"))
+		})
+	}
+
+	t.Run("Revalidates A Compressed Copy", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newCachingRouter(t, items()).ServeHTTP(w, get("/v1/cart/items", map[string]string{
+			"Accept-Encoding": "gzip",
+			"If-None-Match":   `W/"v1-json"`,
+		}))
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Spec Documents Validators", func(t *testing.T) {
+		op := routes.Spec(false).Operation(http.MethodGet, "/v1/cart/items")
+
+		assert.Contains(t, op.Responses, "304")
+		assert.Contains(t, op.Responses["200"].Headers, "Last-Modified")
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	cart := new(mocks.CartUseCase)
+	cart.On("Checkout", mock.Anything, "").Return(response.Success(response.StatusOK, map[string]int{"totalKuantitas": 1}))
+
+	router := mux.NewRouter()
+	err := routes.Register(router, routes.Dependencies{
+		Validate:    validator.New(),
+		Cart:        cart,
+		Audit:       new(mocks.AuditUseCase),
+		Hub:         stream.NewHub(stream.HubOptions{}),
+		Webhook:     new(mocks.WebhookUseCase),
+		Health:      new(mocks.HealthUseCase),
+		Legacy:      true,
+		RateLimiter: ratelimit.NewMemory(),
+		RateLimit: ratelimit.Options{
+			Routes: ratelimit.Quotas{"POST /v1/cart/checkout": {Limit: 1, Period: time.Minute}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkout := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, checkout("/v1/cart/checkout").Code)
+
+	w := checkout("/cart/checkout")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	op := routes.Spec(true).Operation(http.MethodPost, "/cart/checkout")
+	assert.Contains(t, op.Responses["429"].Headers, "Retry-After")
+}
+
+func TestAuth(t *testing.T) {
+	keys := map[string]auth.Principal{
+		"hk_00000001_reader": {Kind: auth.KindAPIKey, Subject: "00000001", Scopes: []string{auth.ScopeCartRead}},
+		"hk_00000002_admin":  {Kind: auth.KindAPIKey, Subject: "00000002", Scopes: []string{auth.ScopeKeysAdmin}},
+		"hk_00000004_carts":  {Kind: auth.KindAPIKey, Subject: "00000004", Scopes: []string{auth.ScopeCartsAdmin}},
+		"hk_00000005_hooks":  {Kind: auth.KindAPIKey, Subject: "00000005", Scopes: []string{auth.ScopeWebhooksAdmin}},
+	}
+
+	newAuthRouter := func(t *testing.T, required bool) *mux.Router {
+		cart := new(mocks.CartUseCase)
+		cart.On("GetItems", mock.Anything, mock.Anything).Return(response.Success(response.StatusOK, []product.Product{}))
+		cart.On("ListCarts", mock.Anything, mock.Anything).Return(response.Success(response.StatusOK, cartsummary.List{}))
+
+		apiKey := new(mocks.APIKeyUseCase)
+		apiKey.On("List", mock.Anything).Return(response.Success(response.StatusOK, []interface{}{}))
+		apiKey.On("VerifyKey", mock.Anything, mock.Anything).Return(func(_ context.Context, key string) auth.Principal {
+			return keys[key]
+		}, func(_ context.Context, key string) error {
+			if _, ok := keys[key]; !ok {
+				return auth.ErrInvalidCredentials
+			}
+			return nil
+		})
+
+		webhook := new(mocks.WebhookUseCase)
+		webhook.On("List", mock.Anything).Return(response.Success(response.StatusOK, []interface{}{}))
+
+		router := mux.NewRouter()
+		err := routes.Register(router, routes.Dependencies{
+			Validate:       validator.New(),
+			Cart:           cart,
+			Audit:          new(mocks.AuditUseCase),
+			Hub:            stream.NewHub(stream.HubOptions{}),
+			Webhook:        webhook,
+			Health:         new(mocks.HealthUseCase),
+			APIKey:         apiKey,
+			Authenticators: []auth.Authenticator{auth.APIKeys{Verifier: apiKey}},
+			RequireAuth:    required,
+			Legacy:         true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return router
+	}
+
+	get := func(router http.Handler, path, key string) int {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if strings.HasSuffix(path, "/cart/items") {
+			// the items are filtered by the body
+			r = httptest.NewRequest(http.MethodGet, path, strings.NewReader(`{}`))
+			r.Header.Set("Content-Type", "application/json")
+		}
+		if key != "" {
+			r.Header.Set(auth.HeaderAPIKey, key)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	t.Run("Anonymous API Allowed By Default", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(newAuthRouter(t, false), "/v1/cart/items", ""))
+	})
+
+	t.Run("Anonymous API Refused When Required", func(t *testing.T) {
+		router := newAuthRouter(t, true)
+
+		assert.Equal(t, http.StatusUnauthorized, get(router, "/v1/cart/items", ""))
+		assert.Equal(t, http.StatusUnauthorized, get(router, "/cart/items", ""))
+		assert.Equal(t, http.StatusOK, get(router, "/v1/cart/items", "hk_00000001_reader"))
+	})
+
+	t.Run("Unknown Key Refused", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get(newAuthRouter(t, false), "/v1/cart/items", "hk_00000003_nope"))
+	})
+
+	t.Run("Admin Needs Keys Scope", func(t *testing.T) {
+		router := newAuthRouter(t, false)
+
+		assert.Equal(t, http.StatusUnauthorized, get(router, routes.PrefixAdmin+"/api-keys", ""))
+		assert.Equal(t, http.StatusForbidden, get(router, routes.PrefixAdmin+"/api-keys", "hk_00000001_reader"))
+		assert.Equal(t, http.StatusOK, get(router, routes.PrefixAdmin+"/api-keys", "hk_00000002_admin"))
+	})
+
+	t.Run("Webhooks Always Need Credentials", func(t *testing.T) {
+		router := newAuthRouter(t, false)
+
+		assert.Equal(t, http.StatusUnauthorized, get(router, "/v1/webhooks", ""))
+		assert.Equal(t, http.StatusUnauthorized, get(router, "/webhooks", ""))
+		assert.Equal(t, http.StatusForbidden, get(router, "/v1/webhooks", "hk_00000001_reader"))
+		assert.Equal(t, http.StatusOK, get(router, "/v1/webhooks", "hk_00000005_hooks"))
+	})
+
+	t.Run("Admin Carts Need Carts Scope", func(t *testing.T) {
+		router := newAuthRouter(t, false)
+
+		assert.Equal(t, http.StatusUnauthorized, get(router, routes.PrefixAdmin+"/carts?q=kopi", ""))
+		assert.Equal(t, http.StatusForbidden, get(router, routes.PrefixAdmin+"/carts", "hk_00000002_admin"))
+		assert.Equal(t, http.StatusOK, get(router, routes.PrefixAdmin+"/carts?q=kopi&page=1", "hk_00000004_carts"))
+		assert.Equal(t, http.StatusOK, get(router, routes.PrefixAdmin+"/carts/user:42", "hk_00000004_carts"))
+	})
+
+	t.Run("Scope Missing From Key", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, get(newAuthRouter(t, false), "/v1/cart/items", "hk_00000002_admin"))
+	})
+
+	t.Run("Every API Route Has A Rule", func(t *testing.T) {
+		rules := routes.Rules(false)
+
+		for _, route := range registered(t, newRouter(t)) {
+			method, path, _ := strings.Cut(route, " ")
+			if !strings.HasPrefix(path, routes.PrefixV1+"/") && !strings.HasPrefix(path, routes.PrefixAdmin+"/") {
+				continue
+			}
+
+			assert.Contains(t, rules, method+" "+path)
+		}
+	})
+
+	t.Run("Spec Documents Security", func(t *testing.T) {
+		op := routes.Spec(false).Operation(http.MethodPost, routes.PrefixAdmin+"/api-keys")
+
+		assert.Equal(t, []string{auth.ScopeKeysAdmin}, op.Security[0]["bearer"])
+		assert.Contains(t, op.Responses, "401")
+		assert.Contains(t, op.Responses, "403")
+	})
+}
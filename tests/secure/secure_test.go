@@ -0,0 +1,70 @@
+package secure_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/secure"
+)
+
+func TestHandler(t *testing.T) {
+	opts := secure.Options{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+	}
+
+	serve := func(opts secure.Options, r *http.Request) http.Header {
+		w := httptest.NewRecorder()
+		secure.Handler(http.NotFoundHandler(), opts).ServeHTTP(w, r)
+
+		return w.Header()
+	}
+
+	t.Run("Plain HTTP", func(t *testing.T) {
+		header := serve(opts, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", header.Get("X-Frame-Options"))
+		assert.Equal(t, "no-referrer", header.Get("Referrer-Policy"))
+		assert.Empty(t, header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("HTTPS", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.TLS = &tls.ConnectionState{}
+
+		header := serve(opts, r)
+
+		assert.Equal(t, "max-age=31536000; includeSubDomains", header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("Forwarded HTTPS", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Forwarded-Proto", "https")
+
+		assert.Empty(t, serve(opts, r).Get("Strict-Transport-Security"))
+
+		trusted := opts
+		trusted.TrustProxyHeaders = true
+		assert.Equal(t, "max-age=31536000; includeSubDomains", serve(trusted, r).Get("Strict-Transport-Security"))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.TLS = &tls.ConnectionState{}
+
+		header := serve(secure.Options{}, r)
+
+		assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+		assert.Empty(t, header.Get("X-Frame-Options"))
+		assert.Empty(t, header.Get("Referrer-Policy"))
+		assert.Empty(t, header.Get("Strict-Transport-Security"))
+	})
+}
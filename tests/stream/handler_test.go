@@ -0,0 +1,241 @@
+package stream_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+
+	"github.com/Risuii/helpers/auth"
+	"github.com/Risuii/helpers/rbac"
+	"github.com/Risuii/internal/stream"
+	"github.com/Risuii/models/event"
+)
+
+// headerUser authenticates requests as the user named by the X-User
+// header.
+type headerUser struct{}
+
+func (headerUser) Authenticate(r *http.Request) (auth.Principal, error) {
+	if r.Header.Get("X-User") == "" {
+		return auth.Principal{}, auth.ErrNoCredentials
+	}
+
+	return auth.Principal{Kind: auth.KindUser, Subject: r.Header.Get("X-User")}, nil
+}
+
+func newServer(hub *stream.Hub) *httptest.Server {
+	router := mux.NewRouter()
+	router.Use(auth.Middleware(headerUser{}))
+	stream.NewStreamHandler(router, hub, time.Hour)
+
+	return httptest.NewServer(router)
+}
+
+// waitSubscribers waits until the handler subscribed, so notifications are
+// not published before anyone listens.
+func waitSubscribers(t *testing.T, hub *stream.Hub, owner string) {
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Subscribers(owner) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no subscriber")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// readEvent reads one server-sent event, skipping comments and retry hints.
+func readEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	fields := map[string]string{}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if fields["event"] != "" {
+				return fields
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, ": ")
+		fields[key] = value
+	}
+}
+
+func TestHandler_Stream(t *testing.T) {
+	t.Run("Stream Sends Cart Events", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+		server := newServer(hub)
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/cart/stream", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(resp.Body)
+
+		waitSubscribers(t, hub, rbac.CartAnonymous)
+
+		added := newEnvelope(t, rbac.CartAnonymous, event.TypeItemAdded, event.ItemAdded{CartID: 1, KodeProduk: "A", Kuantitas: 1})
+		hub.Notify(added)
+
+		fields := readEvent(t, reader)
+		assert.Equal(t, added.ID, fields["id"])
+		assert.Equal(t, event.TypeItemAdded, fields["event"])
+		assert.Contains(t, fields["data"], `"kodeProduk":"A"`)
+	})
+
+	t.Run("Stream Replays From Last Event ID", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+		server := newServer(hub)
+		defer server.Close()
+
+		first := newEnvelope(t, rbac.CartAnonymous, event.TypeItemAdded, event.ItemAdded{CartID: 1})
+		second := newEnvelope(t, rbac.CartAnonymous, event.TypeItemRemoved, event.ItemRemoved{CartID: 1})
+		hub.Notify(first, second)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/cart/stream", nil)
+		req.Header.Set(stream.HeaderLastEventID, first.ID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		fields := readEvent(t, bufio.NewReader(resp.Body))
+		assert.Equal(t, second.ID, fields["id"])
+	})
+
+	t.Run("Stream Resets Unknown Last Event ID", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+		server := newServer(hub)
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/cart/stream?lastEventId=gone", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		assert.Equal(t, stream.EventReset, readEvent(t, bufio.NewReader(resp.Body))["event"])
+	})
+
+	t.Run("Stream Over WebSocket", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+		server := newServer(hub)
+		defer server.Close()
+
+		ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/cart/stream", "", server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ws.Close()
+
+		var heartbeat map[string]string
+		assert.NoError(t, websocket.JSON.Receive(ws, &heartbeat))
+		assert.Equal(t, stream.EventHeartbeat, heartbeat["type"])
+
+		waitSubscribers(t, hub, rbac.CartAnonymous)
+
+		added := newEnvelope(t, rbac.CartAnonymous, event.TypeItemAdded, event.ItemAdded{CartID: 1})
+		hub.Notify(added)
+
+		var received event.Envelope
+		assert.NoError(t, websocket.JSON.Receive(ws, &received))
+		assert.Equal(t, added.ID, received.ID)
+	})
+
+	t.Run("Stream Follows The Cart Of The Caller", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+		server := newServer(hub)
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/cart/stream", nil)
+		req.Header.Set("X-User", "42")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		reader := bufio.NewReader(resp.Body)
+
+		waitSubscribers(t, hub, "user:42")
+		assert.Equal(t, 0, hub.Subscribers(rbac.CartAnonymous))
+
+		other := newEnvelope(t, "user:7", event.TypeItemAdded, event.ItemAdded{CartID: 1})
+		own := newEnvelope(t, "user:42", event.TypeItemAdded, event.ItemAdded{CartID: 2})
+		hub.Notify(other, own)
+
+		assert.Equal(t, own.ID, readEvent(t, reader)["id"])
+	})
+	t.Run("Shutdown Ends Open Streams", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+		server := newServer(hub)
+		defer server.Close()
+
+		server.Config.RegisterOnShutdown(hub.Close)
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/cart/stream", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/cart/stream", "", server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ws.Close()
+
+		deadline := time.Now().Add(2 * time.Second)
+		for hub.Subscribers(rbac.CartAnonymous) < 2 {
+			if time.Now().After(deadline) {
+				t.Fatal("no subscriber")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		start := time.Now()
+		assert.NoError(t, server.Config.Shutdown(ctx))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
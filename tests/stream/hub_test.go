@@ -0,0 +1,123 @@
+package stream_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/internal/stream"
+	"github.com/Risuii/models/event"
+)
+
+func newEnvelope(t *testing.T, owner, eventType string, data interface{}) event.Envelope {
+	envelope, err := event.New(eventType, data, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope.Owner = owner
+
+	return envelope
+}
+
+func TestHub(t *testing.T) {
+	t.Run("Notify Reaches Subscribers Of The Owner Only", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+
+		sub, _, _ := hub.Subscribe("user:1", "")
+		other, _, _ := hub.Subscribe("user:2", "")
+		defer hub.Unsubscribe(sub)
+		defer hub.Unsubscribe(other)
+
+		added := newEnvelope(t, "user:1", event.TypeItemAdded, event.ItemAdded{CartID: 1, KodeProduk: "A", Kuantitas: 1})
+		hub.Notify(added)
+
+		assert.Equal(t, added.ID, (<-sub.C).ID)
+		assert.Len(t, other.C, 0)
+	})
+
+	t.Run("Notify Reaches Every Connection Of The Cart", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+
+		first, _, _ := hub.Subscribe("user:1", "")
+		second, _, _ := hub.Subscribe("user:1", "")
+
+		hub.Notify(newEnvelope(t, "user:1", event.TypeCartCheckedOut, event.CartCheckedOut{Items: []event.Line{{CartID: 1}, {CartID: 2}}}))
+
+		assert.Len(t, first.C, 1)
+		assert.Len(t, second.C, 1)
+	})
+
+	t.Run("Slow Subscriber Is Dropped", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{BufferSize: 1})
+
+		sub, _, _ := hub.Subscribe("user:1", "")
+
+		hub.Notify(
+			newEnvelope(t, "user:1", event.TypeItemAdded, event.ItemAdded{CartID: 1}),
+			newEnvelope(t, "user:1", event.TypeItemRemoved, event.ItemRemoved{CartID: 1}),
+		)
+
+		<-sub.C
+		_, ok := <-sub.C
+
+		assert.False(t, ok)
+		assert.True(t, sub.Overflowed())
+		assert.Equal(t, 0, hub.Subscribers("user:1"))
+	})
+
+	t.Run("Subscribe Resumes After Last Event", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+
+		first := newEnvelope(t, "user:1", event.TypeItemAdded, event.ItemAdded{CartID: 1})
+		unrelated := newEnvelope(t, "user:2", event.TypeItemAdded, event.ItemAdded{CartID: 2})
+		second := newEnvelope(t, "user:1", event.TypeItemRemoved, event.ItemRemoved{CartID: 1})
+		hub.Notify(first, unrelated, second)
+
+		sub, replay, resumed := hub.Subscribe("user:1", first.ID)
+		defer hub.Unsubscribe(sub)
+
+		assert.True(t, resumed)
+		assert.Len(t, replay, 1)
+		assert.Equal(t, second.ID, replay[0].ID)
+	})
+
+	t.Run("Subscribe Unknown Last Event", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{HistorySize: 1})
+
+		first := newEnvelope(t, "user:1", event.TypeItemAdded, event.ItemAdded{CartID: 1})
+		hub.Notify(first, newEnvelope(t, "user:1", event.TypeItemRemoved, event.ItemRemoved{CartID: 1}))
+
+		_, replay, resumed := hub.Subscribe("user:1", first.ID)
+
+		assert.False(t, resumed)
+		assert.Empty(t, replay)
+	})
+
+	t.Run("Unsubscribe Twice", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+
+		sub, _, _ := hub.Subscribe("user:1", "")
+		hub.Unsubscribe(sub)
+		hub.Unsubscribe(sub)
+
+		assert.Equal(t, 0, hub.Subscribers("user:1"))
+	})
+	t.Run("Close Ends Every Subscription", func(t *testing.T) {
+		hub := stream.NewHub(stream.HubOptions{})
+
+		sub, _, _ := hub.Subscribe("user:1", "")
+		hub.Close()
+
+		_, ok := <-sub.C
+		assert.False(t, ok)
+		assert.False(t, sub.Overflowed())
+		assert.Equal(t, 0, hub.Subscribers("user:1"))
+
+		later, _, _ := hub.Subscribe("user:1", "")
+		_, ok = <-later.C
+		assert.False(t, ok)
+
+		hub.Close()
+	})
+}
@@ -0,0 +1,104 @@
+package tracing_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/helpers/tracing"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent   = "00-" + parentTraceID + "-00f067aa0ba902b7-01"
+)
+
+func newRecorder() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("Continue Incoming Trace", func(t *testing.T) {
+		spans := newRecorder()
+
+		router := mux.NewRouter()
+		router.Use(tracing.Middleware)
+		router.HandleFunc("/cart/items", func(w http.ResponseWriter, r *http.Request) {
+			response.Success(response.StatusOK, "ok").JSON(w)
+		}).Methods(http.MethodGet)
+
+		r := httptest.NewRequest(http.MethodGet, "/cart/items", nil)
+		r.Header.Set("traceparent", traceparent)
+		recorder := httptest.NewRecorder()
+
+		router.ServeHTTP(recorder, r)
+
+		assert.Equal(t, parentTraceID, recorder.Header().Get(tracing.HeaderTraceID))
+		assert.Len(t, spans.Ended(), 1)
+		assert.Equal(t, "GET /cart/items", spans.Ended()[0].Name())
+		assert.Equal(t, parentTraceID, spans.Ended()[0].SpanContext().TraceID().String())
+	})
+
+	t.Run("Error Body Carries Trace ID", func(t *testing.T) {
+		newRecorder()
+
+		router := mux.NewRouter()
+		router.Use(tracing.Middleware)
+		router.HandleFunc("/cart/items", func(w http.ResponseWriter, r *http.Request) {
+			response.Error(response.StatusInternalServerError, exception.ErrInternalServer).JSON(w)
+		}).Methods(http.MethodGet)
+
+		r := httptest.NewRequest(http.MethodGet, "/cart/items", nil)
+		r.Header.Set("traceparent", traceparent)
+		recorder := httptest.NewRecorder()
+
+		router.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, response.StatusInternalServerError, rb.Status)
+		assert.Equal(t, parentTraceID, rb.TraceID)
+	})
+
+	t.Run("Success Body Omits Trace ID", func(t *testing.T) {
+		newRecorder()
+
+		router := mux.NewRouter()
+		router.Use(tracing.Middleware)
+		router.HandleFunc("/cart/items", func(w http.ResponseWriter, r *http.Request) {
+			response.Success(response.StatusOK, "ok").JSON(w)
+		}).Methods(http.MethodGet)
+
+		r := httptest.NewRequest(http.MethodGet, "/cart/items", nil)
+		recorder := httptest.NewRecorder()
+
+		router.ServeHTTP(recorder, r)
+
+		rb := response.ResponseImpl{}
+		if err := json.NewDecoder(recorder.Body).Decode(&rb); err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Empty(t, rb.TraceID)
+		assert.NotEmpty(t, recorder.Header().Get(tracing.HeaderTraceID))
+	})
+}
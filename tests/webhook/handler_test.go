@@ -0,0 +1,119 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/webhook"
+	models "github.com/Risuii/models/webhook"
+	"github.com/Risuii/tests/mocks"
+)
+
+func serve(handler http.HandlerFunc, r *http.Request) response.ResponseImpl {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, r)
+
+	rb := response.ResponseImpl{}
+	json.NewDecoder(recorder.Body).Decode(&rb)
+
+	return rb
+}
+
+func TestHandler_Create(t *testing.T) {
+	t.Run("Create Success", func(t *testing.T) {
+		resp := response.Success(response.StatusCreated, models.Webhook{ID: 7})
+
+		webhookUseCase := new(mocks.WebhookUseCase)
+		webhookUseCase.On("Create", mock.Anything, models.Input{URL: "https://example.com/hook", EventTypes: []string{"cart.ItemAdded"}}).Return(resp)
+
+		webhookHandler := webhook.WebhookHandler{
+			Validate: validator.New(),
+			UseCase:  webhookUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url":"https://example.com/hook","eventTypes":["cart.ItemAdded"]}`))
+
+		rb := serve(webhookHandler.Create, r)
+
+		assert.Equal(t, response.StatusCreated, rb.Status)
+		webhookUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Create Unknown Event Type", func(t *testing.T) {
+		webhookUseCase := new(mocks.WebhookUseCase)
+
+		webhookHandler := webhook.WebhookHandler{
+			Validate: validator.New(),
+			UseCase:  webhookUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url":"https://example.com/hook","eventTypes":["cart.Nope"]}`))
+
+		rb := serve(webhookHandler.Create, r)
+
+		assert.Equal(t, response.StatusBadRequest, rb.Status)
+		webhookUseCase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Create Invalid URL", func(t *testing.T) {
+		webhookUseCase := new(mocks.WebhookUseCase)
+
+		webhookHandler := webhook.WebhookHandler{
+			Validate: validator.New(),
+			UseCase:  webhookUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"url":"ftp://example.com"}`))
+
+		rb := serve(webhookHandler.Create, r)
+
+		assert.Equal(t, response.StatusBadRequest, rb.Status)
+	})
+}
+
+func TestHandler_Redeliver(t *testing.T) {
+	t.Run("Redeliver Success", func(t *testing.T) {
+		resp := response.Success(response.StatusOK, models.Delivery{ID: 3})
+
+		webhookUseCase := new(mocks.WebhookUseCase)
+		webhookUseCase.On("Redeliver", mock.Anything, int64(7), int64(3)).Return(resp)
+
+		webhookHandler := webhook.WebhookHandler{
+			UseCase: webhookUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/webhooks/7/deliveries/3/redeliver", nil)
+		r = mux.SetURLVars(r, map[string]string{"id": "7", "deliveryId": "3"})
+
+		rb := serve(webhookHandler.Redeliver, r)
+
+		assert.Equal(t, response.StatusOK, rb.Status)
+		webhookUseCase.AssertExpectations(t)
+	})
+}
+
+func TestHandler_Deliveries(t *testing.T) {
+	t.Run("Deliveries Invalid Page Size", func(t *testing.T) {
+		webhookUseCase := new(mocks.WebhookUseCase)
+
+		webhookHandler := webhook.WebhookHandler{
+			UseCase: webhookUseCase,
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/webhooks/7/deliveries?pageSize=abc", nil)
+		r = mux.SetURLVars(r, map[string]string{"id": "7"})
+
+		rb := serve(webhookHandler.Deliveries, r)
+
+		assert.Equal(t, response.StatusBadRequest, rb.Status)
+	})
+}
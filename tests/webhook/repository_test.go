@@ -0,0 +1,220 @@
+package webhook_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/constant"
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/internal/webhook"
+	"github.com/Risuii/models/event"
+	models "github.com/Risuii/models/webhook"
+	"github.com/Risuii/tests/mock"
+)
+
+var currentTime = time.Date(2021, 12, 12, 0, 0, 0, 0, time.UTC)
+
+var deliveryColumns = []string{"id", "webhook_id", "event_id", "event_type", "payload", "status", "attempts", "next_attempt_at", "last_status_code", "last_error", "created_at", "delivered_at"}
+
+func newRepository() (webhook.WebhookRepository, sqlmock.Sqlmock, func() error) {
+	db, mock := mock.NewMock()
+	repo := webhook.NewWebhookRepositoryImpl(db, constant.TableWebhooks, constant.TableWebhookDeliveries, time.Second)
+
+	return repo, mock, db.Close
+}
+
+func TestCreateRepository(t *testing.T) {
+	t.Run("Create Success", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		query := fmt.Sprintf(`INSERT INTO %s \(url, secret, event_types, active, created_at, update_at\) VALUES \(\?, \?, \?, \?, \?, \?\)`, constant.TableWebhooks)
+
+		mock.ExpectExec(query).
+			WithArgs("https://example.com/hook", "whsec_secret", "cart.ItemAdded,cart.ItemRemoved", true, currentTime, currentTime).
+			WillReturnResult(sqlmock.NewResult(7, 1))
+
+		ID, err := repo.Create(context.TODO(), models.Webhook{
+			URL:        "https://example.com/hook",
+			Secret:     "whsec_secret",
+			EventTypes: []string{event.TypeItemAdded, event.TypeItemRemoved},
+			Active:     true,
+			CreatedAt:  currentTime,
+			UpdateAt:   currentTime,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFindByIDRepository(t *testing.T) {
+	query := fmt.Sprintf(`SELECT id, url, secret, event_types, active, created_at, update_at FROM %s WHERE id = \?`, constant.TableWebhooks)
+
+	t.Run("Find By ID Success", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		rows := sqlmock.NewRows([]string{"id", "url", "secret", "event_types", "active", "created_at", "update_at"}).
+			AddRow(7, "https://example.com/hook", "whsec_secret", "cart.ItemAdded", true, currentTime, currentTime)
+
+		mock.ExpectQuery(query).WithArgs(7).WillReturnRows(rows)
+
+		data, err := repo.FindByID(context.TODO(), 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{event.TypeItemAdded}, data.EventTypes)
+		assert.Equal(t, "whsec_secret", data.Secret)
+	})
+
+	t.Run("Find By ID All Events", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		rows := sqlmock.NewRows([]string{"id", "url", "secret", "event_types", "active", "created_at", "update_at"}).
+			AddRow(7, "https://example.com/hook", "whsec_secret", "", true, currentTime, currentTime)
+
+		mock.ExpectQuery(query).WithArgs(7).WillReturnRows(rows)
+
+		data, err := repo.FindByID(context.TODO(), 7)
+
+		assert.NoError(t, err)
+		assert.Empty(t, data.EventTypes)
+		assert.True(t, data.Subscribed(event.TypeCartCheckedOut))
+	})
+
+	t.Run("Find By ID Not Found", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		mock.ExpectQuery(query).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.FindByID(context.TODO(), 7)
+
+		assert.Equal(t, exception.ErrNotFound, err)
+	})
+}
+
+func TestDeleteRepository(t *testing.T) {
+	t.Run("Delete Not Found", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		query := fmt.Sprintf(`DELETE FROM %s WHERE id = \?`, constant.TableWebhooks)
+		mock.ExpectExec(query).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Delete(context.TODO(), 7)
+
+		assert.Equal(t, exception.ErrNotFound, err)
+	})
+}
+
+func TestAddDeliveriesRepository(t *testing.T) {
+	t.Run("Add Deliveries Success", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		query := fmt.Sprintf(`INSERT INTO %s \(webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at\) VALUES \(\?,\?,\?,\?,\?,\?,\?,\?\),\(\?,\?,\?,\?,\?,\?,\?,\?\) ON DUPLICATE KEY UPDATE id = id$`, constant.TableWebhookDeliveries)
+
+		mock.ExpectExec(query).
+			WithArgs(
+				1, "a", event.TypeItemAdded, `{"id":"a"}`, models.DeliveryPending, 0, currentTime, currentTime,
+				2, "a", event.TypeItemAdded, `{"id":"a"}`, models.DeliveryPending, 0, currentTime, currentTime,
+			).
+			WillReturnResult(sqlmock.NewResult(1, 2))
+
+		delivery := models.Delivery{
+			EventID:       "a",
+			EventType:     event.TypeItemAdded,
+			Payload:       []byte(`{"id":"a"}`),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: currentTime,
+			CreatedAt:     currentTime,
+		}
+		first, second := delivery, delivery
+		first.WebhookID, second.WebhookID = 1, 2
+
+		err := repo.AddDeliveries(context.TODO(), first, second)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Add Deliveries Nothing", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		err := repo.AddDeliveries(context.TODO())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFindDueDeliveriesRepository(t *testing.T) {
+	t.Run("Find Due Deliveries Success", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		query := fmt.Sprintf(`SELECT id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at FROM %s WHERE status = \? AND next_attempt_at <= \? ORDER BY next_attempt_at, id LIMIT \?$`, constant.TableWebhookDeliveries)
+		rows := sqlmock.NewRows(deliveryColumns).
+			AddRow(3, 1, "a", event.TypeItemAdded, []byte(`{"id":"a"}`), models.DeliveryPending, 2, currentTime, 500, "boom", currentTime, nil)
+
+		mock.ExpectQuery(query).WithArgs(models.DeliveryPending, currentTime, 10).WillReturnRows(rows)
+
+		deliveries, err := repo.FindDueDeliveries(context.TODO(), currentTime, 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 1)
+		assert.Equal(t, 2, deliveries[0].Attempts)
+		assert.Equal(t, "boom", deliveries[0].LastError)
+		assert.Nil(t, deliveries[0].DeliveredAt)
+	})
+}
+
+func TestLeaseDeliveriesRepository(t *testing.T) {
+	t.Run("Lease Deliveries Success", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		query := fmt.Sprintf(`UPDATE %s SET next_attempt_at = \? WHERE id IN \(\?,\?\)`, constant.TableWebhookDeliveries)
+
+		mock.ExpectExec(query).WithArgs(currentTime, 3, 4).WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err := repo.LeaseDeliveries(context.TODO(), []int64{3, 4}, currentTime)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUpdateDeliveryRepository(t *testing.T) {
+	t.Run("Update Delivery Success", func(t *testing.T) {
+		repo, mock, close := newRepository()
+		defer close()
+
+		query := fmt.Sprintf(`UPDATE %s SET status = \?, attempts = \?, next_attempt_at = \?, last_status_code = \?, last_error = \?, delivered_at = \? WHERE id = \?`, constant.TableWebhookDeliveries)
+
+		mock.ExpectExec(query).
+			WithArgs(models.DeliveryDelivered, 1, currentTime, 200, "", &currentTime, 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.UpdateDelivery(context.TODO(), models.Delivery{
+			ID:             3,
+			Status:         models.DeliveryDelivered,
+			Attempts:       1,
+			NextAttemptAt:  currentTime,
+			LastStatusCode: 200,
+			DeliveredAt:    &currentTime,
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,364 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/Risuii/helpers/exception"
+	"github.com/Risuii/helpers/response"
+	"github.com/Risuii/internal/webhook"
+	"github.com/Risuii/models/event"
+	models "github.com/Risuii/models/webhook"
+	"github.com/Risuii/tests/mocks"
+)
+
+func newTxManager() *mocks.TxManager {
+	txManager := new(mocks.TxManager)
+	txManager.On("WithinTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+
+	return txManager
+}
+
+// resolver answers with the addresses in hosts, and fails for any other.
+type resolver map[string]string
+
+func (r resolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	addr, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return []net.IPAddr{{IP: net.ParseIP(addr)}}, nil
+}
+
+func newUseCase(repo webhook.WebhookRepository) webhook.WebhookUseCase {
+	return webhook.NewWebhookUseCaseImpl(repo, newTxManager(), webhook.Options{
+		MaxAttempts: 3,
+		BackoffBase: time.Second,
+		BackoffMax:  time.Minute,
+		BatchSize:   10,
+		Resolver: resolver{
+			"example.com":       "93.184.215.14",
+			"intranet.example":  "10.0.0.12",
+			"metadata.internal": "169.254.169.254",
+		},
+	})
+}
+
+func TestUseCaseCreate(t *testing.T) {
+	t.Run("Create Generates Secret", func(t *testing.T) {
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("Create", mock.Anything, mock.MatchedBy(func(w models.Webhook) bool {
+			return strings.HasPrefix(w.Secret, "whsec_") && w.Active
+		})).Return(int64(7), nil)
+
+		res := newUseCase(webhookRepository).Create(context.TODO(), models.Input{URL: "https://example.com/hook"})
+
+		assert.Equal(t, response.StatusCreated, res.(*response.ResponseImpl).Status)
+		assert.Equal(t, int64(7), res.(*response.ResponseImpl).Data.(models.Webhook).ID)
+		assert.NotEmpty(t, res.(*response.ResponseImpl).Data.(models.Webhook).Secret)
+		webhookRepository.AssertExpectations(t)
+	})
+
+	t.Run("Create Inactive", func(t *testing.T) {
+		active := false
+
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("Create", mock.Anything, mock.MatchedBy(func(w models.Webhook) bool {
+			return w.Secret == "a-very-long-secret" && !w.Active
+		})).Return(int64(7), nil)
+
+		res := newUseCase(webhookRepository).Create(context.TODO(), models.Input{
+			URL:    "https://example.com/hook",
+			Secret: "a-very-long-secret",
+			Active: &active,
+		})
+
+		assert.Equal(t, response.StatusCreated, res.(*response.ResponseImpl).Status)
+		webhookRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseTarget(t *testing.T) {
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://192.168.1.10/hook",
+		"http://0.0.0.0/hook",
+		"https://intranet.example/hook",
+		"http://metadata.internal/latest",
+		"https://unknown.example/hook",
+	} {
+		t.Run("Create Refuses "+url, func(t *testing.T) {
+			webhookRepository := new(mocks.WebhookRepository)
+
+			res := newUseCase(webhookRepository).Create(context.TODO(), models.Input{URL: url})
+
+			assert.Equal(t, exception.ErrWebhookTarget, res.Err())
+			assert.Equal(t, response.StatusBadRequest, res.(*response.ResponseImpl).Status)
+			webhookRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Update Refuses Private Address", func(t *testing.T) {
+		webhookRepository := new(mocks.WebhookRepository)
+
+		res := newUseCase(webhookRepository).Update(context.TODO(), 7, models.Input{URL: "http://10.1.2.3/hook"})
+
+		assert.Equal(t, exception.ErrWebhookTarget, res.Err())
+		webhookRepository.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUseCaseGet(t *testing.T) {
+	t.Run("Get Hides Secret", func(t *testing.T) {
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindByID", mock.Anything, int64(7)).Return(models.Webhook{ID: 7, Secret: "whsec_secret"}, nil)
+
+		res := newUseCase(webhookRepository).Get(context.TODO(), 7)
+
+		assert.Equal(t, response.StatusOK, res.(*response.ResponseImpl).Status)
+		assert.Empty(t, res.(*response.ResponseImpl).Data.(models.Webhook).Secret)
+	})
+
+	t.Run("Get Not Found", func(t *testing.T) {
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindByID", mock.Anything, int64(7)).Return(models.Webhook{}, exception.ErrNotFound)
+
+		res := newUseCase(webhookRepository).Get(context.TODO(), 7)
+
+		assert.Equal(t, response.StatusNotFound, res.(*response.ResponseImpl).Status)
+	})
+}
+
+func TestUseCasePublish(t *testing.T) {
+	t.Run("Publish Fans Out To Subscribers", func(t *testing.T) {
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindAll", mock.Anything).Return([]models.Webhook{
+			{ID: 1, Active: true},
+			{ID: 2, Active: true, EventTypes: []string{event.TypeItemAdded}},
+			{ID: 3, Active: true, EventTypes: []string{event.TypeItemRemoved}},
+			{ID: 4, Active: false},
+		}, nil)
+		webhookRepository.On("AddDeliveries", mock.Anything,
+			mock.MatchedBy(func(d models.Delivery) bool { return d.WebhookID == 1 && d.Status == models.DeliveryPending }),
+			mock.MatchedBy(func(d models.Delivery) bool { return d.WebhookID == 2 && d.EventID == "a" }),
+		).Return(nil)
+
+		err := newUseCase(webhookRepository).Publish(context.TODO(), event.Envelope{ID: "a", Type: event.TypeItemAdded})
+
+		assert.NoError(t, err)
+		webhookRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseDeliverDue(t *testing.T) {
+	payload := []byte(`{"id":"a","type":"cart.ItemAdded"}`)
+	due := models.Delivery{ID: 3, WebhookID: 1, EventID: "a", EventType: event.TypeItemAdded, Payload: payload, Status: models.DeliveryPending}
+
+	t.Run("Deliver Signs Request", func(t *testing.T) {
+		var verifyErr error
+		var headers http.Header
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			headers = r.Header
+			verifyErr = webhook.Verify("whsec_secret", r.Header.Get(webhook.HeaderSignature), body, time.Minute, time.Now())
+		}))
+		defer receiver.Close()
+
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]models.Delivery{due}, nil)
+		webhookRepository.On("LeaseDeliveries", mock.Anything, []int64{3}, mock.AnythingOfType("time.Time")).Return(nil)
+		webhookRepository.On("FindByID", mock.Anything, int64(1)).Return(models.Webhook{ID: 1, URL: receiver.URL, Secret: "whsec_secret", Active: true}, nil)
+		webhookRepository.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(d models.Delivery) bool {
+			return d.Status == models.DeliveryDelivered && d.Attempts == 1 && d.DeliveredAt != nil && d.LastStatusCode == http.StatusOK
+		})).Return(nil)
+
+		delivered, err := newUseCase(webhookRepository).DeliverDue(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		assert.NoError(t, verifyErr)
+		assert.Equal(t, "3", headers.Get(webhook.HeaderDeliveryID))
+		assert.Equal(t, "a", headers.Get(webhook.HeaderEventID))
+		assert.Equal(t, event.TypeItemAdded, headers.Get(webhook.HeaderEventType))
+		webhookRepository.AssertExpectations(t)
+	})
+
+	t.Run("Deliver Failure Backs Off", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer receiver.Close()
+
+		failed := due
+		failed.Attempts = 1
+		before := time.Now()
+
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]models.Delivery{failed}, nil)
+		webhookRepository.On("LeaseDeliveries", mock.Anything, []int64{3}, mock.AnythingOfType("time.Time")).Return(nil)
+		webhookRepository.On("FindByID", mock.Anything, int64(1)).Return(models.Webhook{ID: 1, URL: receiver.URL, Active: true}, nil)
+		webhookRepository.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(d models.Delivery) bool {
+			wait := d.NextAttemptAt.Sub(before)
+			return d.Status == models.DeliveryPending && d.Attempts == 2 && d.LastStatusCode == http.StatusServiceUnavailable &&
+				d.LastError != "" && wait >= 2*time.Second && wait < 3*time.Second
+		})).Return(nil)
+
+		delivered, err := newUseCase(webhookRepository).DeliverDue(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		webhookRepository.AssertExpectations(t)
+	})
+
+	t.Run("Deliver Dead Letters After Max Attempts", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+
+		failed := due
+		failed.Attempts = 2
+
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]models.Delivery{failed}, nil)
+		webhookRepository.On("LeaseDeliveries", mock.Anything, []int64{3}, mock.AnythingOfType("time.Time")).Return(nil)
+		webhookRepository.On("FindByID", mock.Anything, int64(1)).Return(models.Webhook{ID: 1, URL: receiver.URL, Active: true}, nil)
+		webhookRepository.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(d models.Delivery) bool {
+			return d.Status == models.DeliveryDead && d.Attempts == 3
+		})).Return(nil)
+
+		_, err := newUseCase(webhookRepository).DeliverDue(context.TODO())
+
+		assert.NoError(t, err)
+		webhookRepository.AssertExpectations(t)
+	})
+
+	t.Run("Deliver Inactive Webhook", func(t *testing.T) {
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]models.Delivery{due}, nil)
+		webhookRepository.On("LeaseDeliveries", mock.Anything, []int64{3}, mock.AnythingOfType("time.Time")).Return(nil)
+		webhookRepository.On("FindByID", mock.Anything, int64(1)).Return(models.Webhook{ID: 1, Active: false}, nil)
+		webhookRepository.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(d models.Delivery) bool {
+			return d.Status == models.DeliveryDead
+		})).Return(nil)
+
+		_, err := newUseCase(webhookRepository).DeliverDue(context.TODO())
+
+		assert.NoError(t, err)
+		webhookRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseDeliverDueClaims(t *testing.T) {
+	payload := []byte(`{"id":"a","type":"cart.ItemAdded"}`)
+
+	t.Run("Deliver Sends Outside The Transaction", func(t *testing.T) {
+		var inTransaction, sentInTransaction bool
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sentInTransaction = sentInTransaction || inTransaction
+		}))
+		defer receiver.Close()
+
+		txManager := new(mocks.TxManager)
+		txManager.On("WithinTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(context.Context) error) error {
+			inTransaction = true
+			defer func() { inTransaction = false }()
+
+			return fn(ctx)
+		})
+
+		before := time.Now()
+
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]models.Delivery{
+			{ID: 3, WebhookID: 1, EventID: "a", Payload: payload, Status: models.DeliveryPending},
+			{ID: 4, WebhookID: 1, EventID: "b", Payload: payload, Status: models.DeliveryPending},
+		}, nil)
+		webhookRepository.On("FindByID", mock.Anything, int64(1)).Return(models.Webhook{ID: 1, URL: receiver.URL, Active: true}, nil).Once()
+		webhookRepository.On("LeaseDeliveries", mock.Anything, []int64{3, 4}, mock.MatchedBy(func(until time.Time) bool {
+			return !until.Before(before.Add(time.Minute))
+		})).Return(nil)
+		// one lost outcome does not keep the others from being recorded
+		webhookRepository.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(d models.Delivery) bool { return d.ID == 3 })).Return(exception.ErrInternalServer)
+		webhookRepository.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(d models.Delivery) bool { return d.ID == 4 })).Return(nil)
+
+		delivered, err := webhook.NewWebhookUseCaseImpl(webhookRepository, txManager, webhook.Options{
+			BatchSize: 10,
+			Lease:     time.Minute,
+		}).DeliverDue(context.TODO())
+
+		assert.ErrorIs(t, err, exception.ErrInternalServer)
+		assert.Equal(t, 2, delivered)
+		assert.False(t, sentInTransaction)
+		webhookRepository.AssertExpectations(t)
+	})
+
+	t.Run("Deliver Nothing Due", func(t *testing.T) {
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindDueDeliveries", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return(nil, nil)
+
+		delivered, err := newUseCase(webhookRepository).DeliverDue(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		webhookRepository.AssertExpectations(t)
+	})
+}
+
+func TestUseCaseRedeliver(t *testing.T) {
+	t.Run("Redeliver Resets Delivery", func(t *testing.T) {
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindDeliveryByID", mock.Anything, int64(1), int64(3)).Return(models.Delivery{ID: 3, Status: models.DeliveryDead, Attempts: 8}, nil)
+		webhookRepository.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(d models.Delivery) bool {
+			return d.Status == models.DeliveryPending && d.Attempts == 0
+		})).Return(nil)
+
+		res := newUseCase(webhookRepository).Redeliver(context.TODO(), 1, 3)
+
+		assert.Equal(t, response.StatusOK, res.(*response.ResponseImpl).Status)
+		webhookRepository.AssertExpectations(t)
+	})
+
+	t.Run("Redeliver Not Found", func(t *testing.T) {
+		webhookRepository := new(mocks.WebhookRepository)
+		webhookRepository.On("FindDeliveryByID", mock.Anything, int64(1), int64(3)).Return(models.Delivery{}, exception.ErrNotFound)
+
+		res := newUseCase(webhookRepository).Redeliver(context.TODO(), 1, 3)
+
+		assert.Equal(t, response.StatusNotFound, res.(*response.ResponseImpl).Status)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, webhook.Backoff(1, 10*time.Second, time.Hour))
+	assert.Equal(t, 40*time.Second, webhook.Backoff(3, 10*time.Second, time.Hour))
+	assert.Equal(t, time.Hour, webhook.Backoff(20, 10*time.Second, time.Hour))
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"a"}`)
+	now := time.Now()
+	header := webhook.Sign("whsec_secret", now, body)
+
+	assert.NoError(t, webhook.Verify("whsec_secret", header, body, time.Minute, now))
+	assert.Error(t, webhook.Verify("other", header, body, time.Minute, now))
+	assert.Error(t, webhook.Verify("whsec_secret", header, []byte(`{}`), time.Minute, now))
+	assert.Error(t, webhook.Verify("whsec_secret", header, body, time.Minute, now.Add(time.Hour)))
+	assert.Error(t, webhook.Verify("whsec_secret", "garbage", body, 0, now))
+}
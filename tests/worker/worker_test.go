@@ -0,0 +1,79 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Risuii/helpers/worker"
+)
+
+func TestGroupShutdown(t *testing.T) {
+	t.Run("Shutdown Waits For Workers", func(t *testing.T) {
+		stopped := make(chan struct{})
+
+		group := worker.NewGroup(context.TODO())
+		group.Go("test", func(ctx context.Context) error {
+			<-ctx.Done()
+			close(stopped)
+			return ctx.Err()
+		})
+
+		err := group.Shutdown(context.TODO())
+
+		assert.NoError(t, err)
+		select {
+		case <-stopped:
+		default:
+			t.Error("worker did not stop before Shutdown returned")
+		}
+	})
+
+	t.Run("Shutdown Gives Up At Deadline", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		group := worker.NewGroup(context.TODO())
+		group.Go("stuck", func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+		defer cancel()
+
+		err := group.Shutdown(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestEvery(t *testing.T) {
+	t.Run("Every Runs Until Cancelled", func(t *testing.T) {
+		ticks := make(chan struct{}, 10)
+
+		group := worker.NewGroup(context.TODO())
+		group.Go("ticker", worker.Every("ticker", time.Millisecond, func(ctx context.Context) error {
+			select {
+			case ticks <- struct{}{}:
+			default:
+			}
+			return errors.New("keeps going")
+		}))
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-ticks:
+			case <-time.After(time.Second):
+				t.Fatal("worker did not tick")
+			}
+		}
+
+		err := group.Shutdown(context.TODO())
+
+		assert.NoError(t, err)
+	})
+}